@@ -0,0 +1,47 @@
+// npm-crawler 是基于本仓库的命令行工具
+//
+// 用法:
+//
+//	npm-crawler <command> [flags]
+//
+// 命令:
+//
+//	serve    启动 npm Registry 拉取式缓存服务器
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// commands 是所有子命令，值为命令的入口函数，参数是去掉命令名之后的命令行参数
+var commands = map[string]func(args []string) error{
+	"serve": serve,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := command(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "npm-crawler:", err)
+		os.Exit(1)
+	}
+}
+
+// usage 输出命令行用法
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage: npm-crawler <command> [flags]
+
+Commands:
+  serve    run a pull-through caching npm registry
+
+Run "npm-crawler <command> -h" for the flags of a command.`)
+}
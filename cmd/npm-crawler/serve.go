@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/scagogogo/npm-crawler/pkg/server"
+	"github.com/scagogogo/npm-crawler/pkg/store"
+)
+
+// serve 启动缓存服务器，收到 SIGINT 或 SIGTERM 后优雅退出
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := flags.String("listen", ":4873", "address to listen on")
+	upstream := flags.String("upstream", registry.DefaultRegistryURL, "upstream registry URL")
+	mirror := flags.String("mirror", "", "use a known mirror as upstream: "+strings.Join(mirrorNames(), ", "))
+	proxy := flags.String("proxy", "", "HTTP proxy used to reach the upstream")
+	token := flags.String("token", "", "auth token sent to the upstream registry")
+	storage := flags.String("store", "storage", "cache location: a directory, a *.db bolt file or a *.jsonl[.gz] archive")
+	publicURL := flags.String("public-url", "", "URL clients use to reach this server, used to rewrite dist.tarball (default: derived from the request)")
+	maxAge := flags.Duration("max-age", 5*time.Minute, "how long cached metadata is served without revalidating upstream")
+	timeout := flags.Duration("timeout", time.Minute, "timeout of upstream requests")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	registryURL := *upstream
+	if *mirror != "" {
+		url, ok := findMirror(*mirror)
+		if !ok {
+			return fmt.Errorf("unknown mirror %q, available: %s", *mirror, strings.Join(mirrorNames(), ", "))
+		}
+		registryURL = url
+	}
+	s, err := openStore(*storage)
+	if err != nil {
+		return fmt.Errorf("open store %s: %w", *storage, err)
+	}
+	defer s.Close()
+
+	r := registry.NewRegistry(registry.NewOptions().SetRegistryURL(registryURL).SetProxy(*proxy).SetAuthToken(*token))
+	options := server.NewOptions().SetPublicURL(*publicURL).SetMaxAge(*maxAge).SetTimeout(*timeout)
+	httpServer := &http.Server{
+		Addr:              *listen,
+		Handler:           logRequests(server.NewServer(r, s, options)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("serving %s on %s, cache in %s", registryURL, *listen, *storage)
+		errs <- httpServer.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// openStore 按路径打开存储: ".db" 结尾使用 BoltStore，".jsonl" 或 ".jsonl.gz" 结尾使用 ArchiveStore，否则使用 FileStore
+func openStore(path string) (store.Store, error) {
+	switch {
+	case strings.HasSuffix(path, ".db"):
+		return store.OpenBoltStore(path)
+	case strings.HasSuffix(path, ".jsonl"), strings.HasSuffix(path, ".jsonl.gz"):
+		return store.OpenArchiveStore(path)
+	default:
+		return store.NewFileStore(path)
+	}
+}
+
+// findMirror 按名称查找 registry.Mirrors 中的镜像
+func findMirror(name string) (string, bool) {
+	for _, mirror := range registry.Mirrors {
+		if mirror.Name == name {
+			return mirror.URL, true
+		}
+	}
+	return "", false
+}
+
+// mirrorNames 返回 registry.Mirrors 中所有镜像的名称
+func mirrorNames() []string {
+	names := make([]string, 0, len(registry.Mirrors))
+	for _, mirror := range registry.Mirrors {
+		names = append(names, mirror.Name)
+	}
+	return names
+}
+
+// statusRecorder 记录响应的状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader 记录状态码
+func (x *statusRecorder) WriteHeader(status int) {
+	x.status = status
+	x.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests 输出每个请求的访问日志
+func logRequests(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(recorder, r)
+		log.Printf("%s %s %d %s %s", r.Method, r.URL.Path, recorder.status, w.Header().Get("X-Cache"), time.Since(start).Round(time.Millisecond))
+	})
+}
@@ -0,0 +1,260 @@
+package audit
+
+import (
+	"context"
+	"sort"
+
+	"github.com/scagogogo/npm-crawler/pkg/lockfile"
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// Vulnerability 表示一个受公告影响的已安装版本
+type Vulnerability struct {
+	Name     string           `json:"name"`
+	Version  string           `json:"version"`
+	Advisory *models.Advisory `json:"advisory"`
+}
+
+// Report 表示审计结果
+//
+// 主要字段说明:
+//   - Vulnerabilities: 受影响的版本，按包名、版本、严重程度（从高到低）和公告 ID 排序
+type Report struct {
+	Vulnerabilities []*Vulnerability `json:"vulnerabilities"`
+}
+
+// Summary 按严重程度统计受影响的版本数量
+//
+// 返回值:
+//   - map[models.Severity]int: 键为严重程度，值为对应的公告命中次数
+func (x *Report) Summary() map[models.Severity]int {
+	summary := make(map[models.Severity]int)
+	for _, vulnerability := range x.Vulnerabilities {
+		summary[vulnerability.Advisory.Severity]++
+	}
+	return summary
+}
+
+// Affected 返回影响指定版本的所有公告
+//
+// 参数:
+//   - name: 包名称
+//   - version: 版本号
+//
+// 返回值:
+//   - []*models.Advisory: 影响该版本的公告，没有时返回 nil
+func (x *Report) Affected(name, version string) []*models.Advisory {
+	var advisories []*models.Advisory
+	for _, vulnerability := range x.Vulnerabilities {
+		if vulnerability.Name == name && vulnerability.Version == version {
+			advisories = append(advisories, vulnerability.Advisory)
+		}
+	}
+	return advisories
+}
+
+// Match 根据公告的受影响范围计算真正受影响的版本
+//
+// bulk 接口按包名返回公告，不区分版本，这里使用与 npm audit 相同的规则
+// （预发布版本与正式版本同等对待）判断每个已安装版本是否落在 VulnerableVersions 范围内，
+// 范围无法解析的公告会被忽略
+//
+// 参数:
+//   - versions: 包名到已安装版本列表的映射
+//   - advisories: 包名到公告列表的映射，通常来自 Registry.GetBulkAdvisories
+//
+// 返回值:
+//   - []*Vulnerability: 受影响的版本，排序规则与 Report.Vulnerabilities 相同
+func Match(versions map[string][]string, advisories models.BulkAdvisories) []*Vulnerability {
+	var vulnerabilities []*Vulnerability
+	for name, list := range advisories {
+		for _, advisory := range list {
+			if advisory == nil {
+				continue
+			}
+			r, err := semver.ParseRange(advisory.VulnerableVersions)
+			if err != nil {
+				continue
+			}
+			for _, version := range versions[name] {
+				v, err := semver.Parse(version)
+				if err != nil || !r.SatisfiesIncludingPrerelease(v) {
+					continue
+				}
+				vulnerabilities = append(vulnerabilities, &Vulnerability{Name: name, Version: version, Advisory: advisory})
+			}
+		}
+	}
+	sort.Slice(vulnerabilities, func(i, j int) bool {
+		a, b := vulnerabilities[i], vulnerabilities[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if cmp := semver.Compare(a.Version, b.Version); cmp != 0 {
+			return cmp < 0
+		}
+		if a.Advisory.Severity.Level() != b.Advisory.Severity.Level() {
+			return a.Advisory.Severity.Level() > b.Advisory.Severity.Level()
+		}
+		return a.Advisory.ID < b.Advisory.ID
+	})
+	return vulnerabilities
+}
+
+// Auditor 依赖审计器，使用 npm 的 bulk 公告接口审计锁文件或依赖图，不需要运行 npm audit
+type Auditor struct {
+	registry *registry.Registry
+	options  *Options
+}
+
+// NewAuditor 创建一个新的依赖审计器
+//
+// 参数:
+//   - reg: 用于查询公告的 Registry 客户端
+//   - options: 可选的配置选项，如未提供则使用 NewOptions() 的默认配置
+//
+// 返回值:
+//   - *Auditor: 新创建的审计器
+//
+// 使用示例:
+//
+//	project, _ := lockfile.ParseFile("package-lock.json")
+//	auditor := audit.NewAuditor(registry.NewRegistry())
+//	report, err := auditor.AuditProject(ctx, project)
+//	if err != nil {
+//		// 处理错误
+//	}
+//	for _, v := range report.Vulnerabilities {
+//		fmt.Println(v.Name, v.Version, v.Advisory.Severity, v.Advisory.URL)
+//	}
+func NewAuditor(reg *registry.Registry, options ...*Options) *Auditor {
+	if len(options) == 0 {
+		options = append(options, NewOptions())
+	}
+	return &Auditor{
+		registry: reg,
+		options:  options[0],
+	}
+}
+
+// GetOptions 获取当前审计器的配置选项
+func (x *Auditor) GetOptions() *Options {
+	return x.options
+}
+
+// Audit 审计一组已安装的版本
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - versions: 包名到已安装版本列表的映射
+//
+// 返回值:
+//   - *Report: 审计结果
+//   - error: 查询公告失败时返回错误
+func (x *Auditor) Audit(ctx context.Context, versions map[string][]string) (*Report, error) {
+	report := &Report{}
+	if len(versions) == 0 {
+		return report, nil
+	}
+	advisories, err := x.registry.GetBulkAdvisories(ctx, versions)
+	if err != nil {
+		return nil, err
+	}
+	minLevel := x.options.MinSeverity.Level()
+	for _, vulnerability := range Match(versions, advisories) {
+		if vulnerability.Advisory.Severity.Level() >= minLevel {
+			report.Vulnerabilities = append(report.Vulnerabilities, vulnerability)
+		}
+	}
+	return report, nil
+}
+
+// AuditProject 审计锁文件中锁定的所有包
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - project: 解析后的锁文件
+//
+// 返回值:
+//   - *Report: 审计结果
+//   - error: 查询公告失败时返回错误
+func (x *Auditor) AuditProject(ctx context.Context, project *lockfile.Project) (*Report, error) {
+	return x.Audit(ctx, ProjectVersions(project, x.options.OmitDev))
+}
+
+// AuditGraph 审计依赖图中的所有节点
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - graph: 解析得到的依赖图
+//
+// 返回值:
+//   - *Report: 审计结果
+//   - error: 查询公告失败时返回错误
+func (x *Auditor) AuditGraph(ctx context.Context, graph *resolver.Graph) (*Report, error) {
+	return x.Audit(ctx, GraphVersions(graph, x.options.OmitDev))
+}
+
+// ProjectVersions 返回锁文件中每个包名对应的所有版本
+//
+// 参数:
+//   - project: 解析后的锁文件
+//   - omitDev: 是否忽略只被开发依赖引用的包
+//
+// 返回值:
+//   - map[string][]string: 键为包名，值为锁定的版本，从小到大排序
+func ProjectVersions(project *lockfile.Project, omitDev bool) map[string][]string {
+	versions := make(map[string][]string)
+	for _, pkg := range project.Packages {
+		if pkg.Version == "" || (omitDev && pkg.Dev) {
+			continue
+		}
+		versions[pkg.Name] = append(versions[pkg.Name], pkg.Version)
+	}
+	for _, list := range versions {
+		semver.Sort(list)
+	}
+	return versions
+}
+
+// GraphVersions 返回依赖图中每个包名对应的所有版本
+//
+// 参数:
+//   - graph: 解析得到的依赖图
+//   - omitDev: 是否忽略只能通过根开发依赖到达的节点
+//
+// 返回值:
+//   - map[string][]string: 键为包名，值为版本，从小到大排序
+func GraphVersions(graph *resolver.Graph, omitDev bool) map[string][]string {
+	if !omitDev {
+		return graph.Versions()
+	}
+	visited := make(map[*resolver.Node]bool)
+	var queue []*resolver.Node
+	for _, edge := range graph.Roots {
+		if edge.Type != resolver.DependencyTypeDev && edge.To != nil && !visited[edge.To] {
+			visited[edge.To] = true
+			queue = append(queue, edge.To)
+		}
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, edge := range node.Dependencies {
+			if edge.To != nil && !visited[edge.To] {
+				visited[edge.To] = true
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+	versions := make(map[string][]string)
+	for _, node := range graph.SortedNodes() {
+		if visited[node] {
+			versions[node.Name] = append(versions[node.Name], node.Version)
+		}
+	}
+	return versions
+}
@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/lockfile"
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+var testAdvisories = models.BulkAdvisories{
+	"lodash": {
+		{ID: 2, Severity: models.SeverityHigh, VulnerableVersions: "<4.17.21"},
+		{ID: 1, Severity: models.SeverityCritical, VulnerableVersions: "<4.17.12"},
+		{ID: 3, Severity: models.SeverityLow, VulnerableVersions: "not a range"},
+	},
+	"minimist": {
+		{ID: 4, Severity: models.SeverityModerate, VulnerableVersions: ">=1.0.0 <1.2.6"},
+	},
+}
+
+func TestMatch(t *testing.T) {
+	vulnerabilities := Match(map[string][]string{
+		"lodash":   {"4.17.11", "4.17.21-beta.1", "4.17.21"},
+		"minimist": {"0.0.8", "1.2.5"},
+	}, testAdvisories)
+
+	var got []string
+	for _, v := range vulnerabilities {
+		got = append(got, v.Name+"@"+v.Version+"#"+string(v.Advisory.Severity))
+	}
+	assert.Equal(t, []string{
+		"lodash@4.17.11#critical",
+		"lodash@4.17.11#high",
+		"lodash@4.17.21-beta.1#high",
+		"minimist@1.2.5#moderate",
+	}, got)
+}
+
+// setupAdvisoryServer 创建返回 testAdvisories 的 bulk 接口，并记录请求体
+func setupAdvisoryServer(requested *map[string][]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		*requested = nil
+		json.Unmarshal(data, requested)
+		json.NewEncoder(w).Encode(testAdvisories)
+	}))
+}
+
+func TestAuditProject(t *testing.T) {
+	var requested map[string][]string
+	server := setupAdvisoryServer(&requested)
+	defer server.Close()
+	reg := registry.NewRegistry(registry.NewOptions().SetRegistryURL(server.URL))
+
+	project := &lockfile.Project{Packages: []*lockfile.Package{
+		{Name: "lodash", Version: "4.17.20"},
+		{Name: "lodash", Version: "4.17.11"},
+		{Name: "minimist", Version: "1.2.5", Dev: true},
+	}}
+	report, err := NewAuditor(reg).AuditProject(context.Background(), project)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{"lodash": {"4.17.11", "4.17.20"}, "minimist": {"1.2.5"}}, requested)
+	assert.Len(t, report.Vulnerabilities, 4)
+	assert.Equal(t, map[models.Severity]int{
+		models.SeverityCritical: 1,
+		models.SeverityHigh:     2,
+		models.SeverityModerate: 1,
+	}, report.Summary())
+	assert.Len(t, report.Affected("lodash", "4.17.11"), 2)
+	assert.Nil(t, report.Affected("lodash", "4.17.21"))
+
+	options := NewOptions().SetOmitDev(true).SetMinSeverity(models.SeverityHigh)
+	report, err = NewAuditor(reg, options).AuditProject(context.Background(), project)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{"lodash": {"4.17.11", "4.17.20"}}, requested)
+	assert.Equal(t, map[models.Severity]int{models.SeverityCritical: 1, models.SeverityHigh: 2}, report.Summary())
+
+	// 没有依赖时不发起请求
+	requested = nil
+	report, err = NewAuditor(reg).AuditProject(context.Background(), &lockfile.Project{})
+	assert.Nil(t, err)
+	assert.Nil(t, requested)
+	assert.Empty(t, report.Vulnerabilities)
+}
+
+func TestAuditGraph(t *testing.T) {
+	var requested map[string][]string
+	server := setupAdvisoryServer(&requested)
+	defer server.Close()
+	reg := registry.NewRegistry(registry.NewOptions().SetRegistryURL(server.URL))
+
+	graph := resolver.NewGraph()
+	express := &resolver.Node{Name: "express", Version: "4.18.2"}
+	lodash := &resolver.Node{Name: "lodash", Version: "4.17.20"}
+	minimist := &resolver.Node{Name: "minimist", Version: "1.2.5"}
+	express.Dependencies = []*resolver.Edge{{From: express, Name: "lodash", Type: resolver.DependencyTypeProd, To: lodash}}
+	for _, node := range []*resolver.Node{express, lodash, minimist} {
+		graph.Nodes[node.ID()] = node
+	}
+	graph.Roots = []*resolver.Edge{
+		{Name: "express", Type: resolver.DependencyTypeProd, To: express},
+		{Name: "minimist", Type: resolver.DependencyTypeDev, To: minimist},
+	}
+
+	report, err := NewAuditor(reg).AuditGraph(context.Background(), graph)
+	assert.Nil(t, err)
+	assert.Len(t, requested, 3)
+	assert.Len(t, report.Vulnerabilities, 2)
+
+	report, err = NewAuditor(reg, NewOptions().SetOmitDev(true)).AuditGraph(context.Background(), graph)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{"express": {"4.18.2"}, "lodash": {"4.17.20"}}, requested)
+	assert.Len(t, report.Vulnerabilities, 1)
+	assert.Equal(t, int64(2), report.Vulnerabilities[0].Advisory.ID)
+}
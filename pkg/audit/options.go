@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// Options 表示审计器的配置选项
+//
+// 包含字段:
+//   - MinSeverity: 只报告不低于该严重程度的公告，为空时报告所有公告
+//   - OmitDev: 是否忽略只被开发依赖引用的包，相当于 npm audit --omit=dev
+//
+// 使用示例:
+//
+//	options := NewOptions().SetMinSeverity(models.SeverityHigh).SetOmitDev(true)
+//	auditor := NewAuditor(registry.NewRegistry(), options)
+type Options struct {
+	MinSeverity models.Severity
+	OmitDev     bool
+}
+
+// NewOptions 创建并返回默认的配置选项
+//
+// 默认配置:
+//   - MinSeverity: 空，报告所有公告
+//   - OmitDev: false
+func NewOptions() *Options {
+	return &Options{}
+}
+
+// SetMinSeverity 设置需要报告的最低严重程度
+func (o *Options) SetMinSeverity(severity models.Severity) *Options {
+	o.MinSeverity = severity
+	return o
+}
+
+// SetOmitDev 设置是否忽略只被开发依赖引用的包
+func (o *Options) SetOmitDev(omitDev bool) *Options {
+	o.OmitDev = omitDev
+	return o
+}
@@ -0,0 +1,163 @@
+package confusion
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/scagogogo/npm-crawler/pkg/tarball"
+)
+
+// Report 表示一个版本的检查结果
+//
+// 主要字段说明:
+//   - Name: 包名称
+//   - Version: 版本号
+//   - Tarball: 检查的 tarball 地址
+//   - Differences: Registry 元数据与 tarball 中 package.json 的差异
+//   - Error: 检查失败的原因（例如下载失败或完整性校验失败），只在 CheckAll 的结果中使用
+type Report struct {
+	Name        string        `json:"name"`
+	Version     string        `json:"version"`
+	Tarball     string        `json:"tarball,omitempty"`
+	Differences []*Difference `json:"differences,omitempty"`
+	Error       error         `json:"-"`
+}
+
+// Confused 判断是否存在清单混淆，即 Registry 元数据与 tarball 中的 package.json 不一致
+func (x *Report) Confused() bool {
+	return len(x.Differences) > 0
+}
+
+// Target 表示一个待检查的版本
+type Target struct {
+	Name    string
+	Version string
+}
+
+// Checker 清单混淆检查器
+//
+// 攻击者可以发布一个 package.json 与 Registry 元数据不一致的 tarball，例如在元数据中隐藏
+// postinstall 脚本或依赖。npm 安装时执行的是 tarball 中的 package.json，而大多数审计工具
+// 只检查 Registry 的元数据，检查器会下载 tarball 并逐字段比较两者
+type Checker struct {
+	registry *registry.Registry
+	options  *Options
+}
+
+// NewChecker 创建一个新的清单混淆检查器
+//
+// 参数:
+//   - reg: 用于获取元数据和下载 tarball 的 Registry 客户端
+//   - options: 可选的配置选项，如未提供则使用 NewOptions() 的默认配置
+//
+// 返回值:
+//   - *Checker: 新创建的检查器
+//
+// 使用示例:
+//
+//	checker := confusion.NewChecker(registry.NewRegistry())
+//	report, err := checker.Check(ctx, "left-pad", "1.3.0")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	for _, diff := range report.Differences {
+//		fmt.Println(diff.Field, diff.Kind, diff.Registry, "->", diff.Tarball)
+//	}
+func NewChecker(reg *registry.Registry, options ...*Options) *Checker {
+	if len(options) == 0 {
+		options = append(options, NewOptions())
+	}
+	return &Checker{
+		registry: reg,
+		options:  options[0],
+	}
+}
+
+// GetOptions 获取当前检查器的配置选项
+func (x *Checker) GetOptions() *Options {
+	return x.options
+}
+
+// Check 检查一个版本是否存在清单混淆
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - name: 包名称
+//   - version: 版本号
+//
+// 返回值:
+//   - *Report: 检查结果，没有差异时 Differences 为空
+//   - error: 获取元数据、下载或读取 tarball 失败时返回错误，tarball 完整性校验失败时返回 *registry.IntegrityError
+func (x *Checker) Check(ctx context.Context, name, version string) (*Report, error) {
+	manifest, err := x.registry.GetPackageVersion(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil || manifest.Name == "" {
+		return nil, fmt.Errorf("%s@%s not found", name, version)
+	}
+	report := &Report{Name: name, Version: version}
+	if manifest.Dist != nil {
+		report.Tarball = manifest.Dist.Tarball
+	}
+
+	var buf bytes.Buffer
+	if err := x.registry.DownloadTarball(ctx, manifest, &buf); err != nil {
+		return nil, err
+	}
+	limits := x.options.Tarball
+	if limits == nil {
+		limits = tarball.NewOptions()
+	}
+	tb, err := tarball.Read(&buf, limits)
+	if err != nil {
+		return nil, fmt.Errorf("read tarball of %s@%s: %w", name, version, err)
+	}
+	packed, err := tb.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("read package.json of %s@%s: %w", name, version, err)
+	}
+
+	var files []string
+	for _, entry := range tb.Entries() {
+		files = append(files, entry.Path)
+	}
+	report.Differences = Compare(manifest, packed, files)
+	return report, nil
+}
+
+// CheckAll 并发检查多个版本，适合对整个依赖集合做定期检查
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - targets: 待检查的版本
+//
+// 返回值:
+//   - []*Report: 与 targets 顺序一致的检查结果，检查失败的版本的错误记录在 Report.Error 中
+func (x *Checker) CheckAll(ctx context.Context, targets []Target) []*Report {
+	concurrency := x.options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	reports := make([]*Report, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			report, err := x.Check(ctx, target.Name, target.Version)
+			if err != nil {
+				report = &Report{Name: target.Name, Version: target.Version, Error: err}
+			}
+			reports[i] = report
+		}(i, target)
+	}
+	wg.Wait()
+	return reports
+}
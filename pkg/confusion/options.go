@@ -0,0 +1,47 @@
+package confusion
+
+import (
+	"github.com/scagogogo/npm-crawler/pkg/tarball"
+)
+
+// DefaultConcurrency 是 CheckAll 默认同时检查的包数量
+const DefaultConcurrency = 4
+
+// Options 表示检查器的配置选项
+//
+// 包含字段:
+//   - Concurrency: CheckAll 同时检查的包数量
+//   - Tarball: 读取 tarball 时的安全限制
+//
+// 使用示例:
+//
+//	options := NewOptions().SetConcurrency(16)
+//	checker := NewChecker(registry.NewRegistry(), options)
+type Options struct {
+	Concurrency int
+	Tarball     *tarball.Options
+}
+
+// NewOptions 创建并返回默认的配置选项
+//
+// 默认配置:
+//   - Concurrency: 4
+//   - Tarball: tarball.NewOptions() 的默认限制
+func NewOptions() *Options {
+	return &Options{
+		Concurrency: DefaultConcurrency,
+		Tarball:     tarball.NewOptions(),
+	}
+}
+
+// SetConcurrency 设置 CheckAll 同时检查的包数量，小于等于 0 时使用默认值
+func (o *Options) SetConcurrency(concurrency int) *Options {
+	o.Concurrency = concurrency
+	return o
+}
+
+// SetTarball 设置读取 tarball 时的安全限制
+func (o *Options) SetTarball(options *tarball.Options) *Options {
+	o.Tarball = options
+	return o
+}
@@ -0,0 +1,313 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// ErrNotFound 表示 Registry 中不存在该包，这种错误不会重试
+var ErrNotFound = errors.New("package not found")
+
+// Stats 表示爬虫运行以来的统计信息
+//
+// 主要字段说明:
+//   - Fetched: 成功抓取并写入全部 Sink 的包数量
+//   - Failed: 重试后仍然失败的包数量
+//   - Retries: 重试次数
+//   - Tarballs: 下载的 tarball 数量
+type Stats struct {
+	Fetched  int64 `json:"fetched"`
+	Failed   int64 `json:"failed"`
+	Retries  int64 `json:"retries"`
+	Tarballs int64 `json:"tarballs"`
+}
+
+// Crawler 是并发爬虫引擎，从 Queue 中取出包名，抓取元数据以及可选的 tarball 和下载统计，交给 Sink 处理
+//
+// 运行流程:
+//   - 每个 Seeder 在独立的 goroutine 中向队列提供包名
+//   - Options.Workers 个 worker 并发从队列中取出包名抓取，失败时按指数退避重试
+//   - 抓取结果依次写入所有 Sink，全部成功后在队列中标记为完成，否则标记为失败
+//   - 所有 Seeder 结束并且队列为空时 Run 返回
+//
+// 配合 OpenQueue 使用时队列状态会持久化到磁盘，进程崩溃或重启后再次 Run 会从上次的位置继续，
+// 已经完成的包不会重复抓取，崩溃时正在处理的包会重新抓取
+//
+// 使用示例:
+//
+//	queue, err := OpenQueue("crawl.queue")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	defer queue.Close()
+//	crawler := NewCrawler(registry.NewRegistry(), queue, NewOptions().SetWorkers(16)).
+//		AddSeeder(SeedNames("react", "vue", "@angular/core")).
+//		AddSink(NewJSONLinesSink(os.Stdout))
+//	if err := crawler.Run(ctx); err != nil {
+//		// 处理错误
+//	}
+type Crawler struct {
+	registry *registry.Registry
+	queue    *Queue
+	options  *Options
+
+	seeders      []Seeder
+	sinks        []Sink
+	frontier     *Frontier
+	errorHandler func(name string, err error)
+
+	stats Stats
+}
+
+// NewCrawler 创建一个爬虫
+//
+// 参数:
+//   - r: 用于抓取的 Registry 客户端
+//   - queue: 任务队列，使用 NewQueue 创建内存队列或者 OpenQueue 打开持久化队列
+//   - options: 配置选项，为 nil 时使用默认选项
+//
+// 返回值:
+//   - *Crawler: 新创建的爬虫
+func NewCrawler(r *registry.Registry, queue *Queue, options *Options) *Crawler {
+	if options == nil {
+		options = NewOptions()
+	}
+	return &Crawler{
+		registry: r,
+		queue:    queue,
+		options:  options,
+	}
+}
+
+// AddSeeder 添加提供包名的 Seeder
+func (x *Crawler) AddSeeder(seeders ...Seeder) *Crawler {
+	x.seeders = append(x.seeders, seeders...)
+	return x
+}
+
+// AddSink 添加接收抓取结果的 Sink
+func (x *Crawler) AddSink(sinks ...Sink) *Crawler {
+	x.sinks = append(x.sinks, sinks...)
+	return x
+}
+
+// SetFrontier 设置依赖图爬取的抓取范围，Run 时会先把种子加入队列，每抓取一个包就沿依赖关系扩展队列
+func (x *Crawler) SetFrontier(frontier *Frontier) *Crawler {
+	x.frontier = frontier
+	return x
+}
+
+// SetErrorHandler 设置单个包抓取或写入失败时的回调，可用于记录日志
+func (x *Crawler) SetErrorHandler(handler func(name string, err error)) *Crawler {
+	x.errorHandler = handler
+	return x
+}
+
+// Queue 返回爬虫使用的任务队列
+func (x *Crawler) Queue() *Queue {
+	return x.queue
+}
+
+// Stats 返回爬虫运行以来的统计信息
+func (x *Crawler) Stats() Stats {
+	return Stats{
+		Fetched:  atomic.LoadInt64(&x.stats.Fetched),
+		Failed:   atomic.LoadInt64(&x.stats.Failed),
+		Retries:  atomic.LoadInt64(&x.stats.Retries),
+		Tarballs: atomic.LoadInt64(&x.stats.Tarballs),
+	}
+}
+
+// Run 运行爬虫，直到所有 Seeder 结束并且队列为空，或者 ctx 被取消
+//
+// 单个包的失败不会导致 Run 返回错误，而是通过 ErrorHandler 报告并在队列中标记为失败
+//
+// 返回值:
+//   - error: ctx 被取消时返回 ctx.Err()，否则返回所有 Seeder 的错误，都没有出错时返回 nil
+func (x *Crawler) Run(ctx context.Context) error {
+	seedCtx, cancelSeeders := context.WithCancel(ctx)
+	defer cancelSeeders()
+
+	all := x.seeders
+	if x.frontier != nil {
+		all = append([]Seeder{x.frontier}, all...)
+	}
+	var seeders sync.WaitGroup
+	seedErrors := make([]error, len(all))
+	for i, seeder := range all {
+		x.queue.AddProducer()
+		seeders.Add(1)
+		go func(i int, seeder Seeder) {
+			defer seeders.Done()
+			defer x.queue.DoneProducer()
+			seedErrors[i] = seeder.Seed(seedCtx, x.queue)
+		}(i, seeder)
+	}
+
+	workers := x.options.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				name, ok := x.queue.Pop(ctx)
+				if !ok {
+					return
+				}
+				x.process(ctx, name)
+			}
+		}()
+	}
+	wg.Wait()
+	cancelSeeders()
+	seeders.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.Join(seedErrors...)
+}
+
+// process 抓取一个包并写入所有 Sink，然后在队列中标记结果
+//
+// ctx 被取消时直接返回，包保持正在处理的状态，持久化队列重启后会重新抓取
+func (x *Crawler) process(ctx context.Context, name string) {
+	result, err := x.fetchWithRetry(ctx, name)
+	if ctx.Err() != nil {
+		return
+	}
+	if err == nil && x.frontier != nil {
+		err = x.frontier.expand(result, x.queue)
+	}
+	if err == nil {
+		for _, sink := range x.sinks {
+			if err = sink.Write(ctx, result); err != nil {
+				err = fmt.Errorf("write %s: %w", name, err)
+				break
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		atomic.AddInt64(&x.stats.Failed, 1)
+		x.reportError(name, err)
+		if err := x.queue.Fail(name); err != nil {
+			x.reportError(name, err)
+		}
+		return
+	}
+	atomic.AddInt64(&x.stats.Fetched, 1)
+	if err := x.queue.Done(name); err != nil {
+		x.reportError(name, err)
+	}
+}
+
+// fetchWithRetry 抓取一个包，失败时按指数退避重试，包不存在时不重试
+func (x *Crawler) fetchWithRetry(ctx context.Context, name string) (*Result, error) {
+	backoff := x.options.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		result, err := x.fetch(ctx, name)
+		if err == nil || errors.Is(err, ErrNotFound) || attempt >= x.options.MaxRetries || ctx.Err() != nil {
+			return result, err
+		}
+		atomic.AddInt64(&x.stats.Retries, 1)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// fetch 抓取一个包的元数据、tarball 和下载统计
+func (x *Crawler) fetch(ctx context.Context, name string) (*Result, error) {
+	var pkg *models.Package
+	var err error
+	if x.options.Abbreviated {
+		pkg, err = x.registry.GetAbbreviatedPackageInformation(ctx, name)
+	} else {
+		pkg, err = x.registry.GetPackageInformation(ctx, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", name, err)
+	}
+	// Registry 对不存在的包返回 404 和 {"error": "Not found"}，解析出来的 Package 没有名称
+	if pkg == nil || (pkg.Name == "" && pkg.ID == "") {
+		return nil, fmt.Errorf("fetch %s: %w", name, ErrNotFound)
+	}
+	result := &Result{
+		Name:    name,
+		Package: pkg,
+	}
+
+	for _, version := range x.tarballVersions(pkg) {
+		v := pkg.Versions[version]
+		var buf bytes.Buffer
+		if err := x.registry.DownloadTarball(ctx, &v, &buf); err != nil {
+			return nil, err
+		}
+		atomic.AddInt64(&x.stats.Tarballs, 1)
+		result.Tarballs = append(result.Tarballs, &Tarball{
+			Version: version,
+			Size:    buf.Len(),
+			Data:    buf.Bytes(),
+		})
+	}
+
+	if x.options.DownloadStats {
+		stats, err := x.registry.GetDownloadStats(ctx, name, x.options.DownloadPeriod)
+		if err != nil {
+			return nil, fmt.Errorf("fetch download stats of %s: %w", name, err)
+		}
+		result.DownloadStats = stats
+		result.DownloadPeriod = x.options.DownloadPeriod
+	}
+
+	result.FetchedAt = time.Now()
+	return result, nil
+}
+
+// tarballVersions 按 Options.Tarballs 返回需要下载 tarball 的版本，按版本号从低到高排序
+func (x *Crawler) tarballVersions(pkg *models.Package) []string {
+	switch x.options.Tarballs {
+	case TarballLatest:
+		if latest, ok := pkg.DistTags["latest"]; ok {
+			if _, exists := pkg.Versions[latest]; exists {
+				return []string{latest}
+			}
+		}
+	case TarballAll:
+		versions := make([]string, 0, len(pkg.Versions))
+		for version := range pkg.Versions {
+			versions = append(versions, version)
+		}
+		semver.Sort(versions)
+		return versions
+	}
+	return nil
+}
+
+// reportError 调用 ErrorHandler
+func (x *Crawler) reportError(name string, err error) {
+	if x.errorHandler != nil {
+		x.errorHandler(name, err)
+	}
+}
@@ -0,0 +1,213 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/scagogogo/npm-crawler/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupCrawlerServer 创建提供 react、vue、flaky 三个包的模拟 Registry
+//
+// go-requests 对每个请求会尝试 3 次，flaky 的前 3 次请求返回 500，因此爬虫需要重试一次才能成功
+func setupCrawlerServer() *httptest.Server {
+	var flaky int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		if strings.HasSuffix(name, ".tgz") {
+			w.Write([]byte("tarball of " + name))
+			return
+		}
+		if name == "flaky" && atomic.AddInt32(&flaky, 1) <= 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if name != "react" && name != "vue" && name != "flaky" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "Not found"}`))
+			return
+		}
+		fmt.Fprintf(w, `{
+			"_id": %[1]q,
+			"name": %[1]q,
+			"dist-tags": {"latest": "1.1.0"},
+			"versions": {
+				"1.0.0": {"name": %[1]q, "version": "1.0.0", "dist": {"tarball": "%[2]s/%[1]s/-/%[1]s-1.0.0.tgz"}},
+				"1.1.0": {"name": %[1]q, "version": "1.1.0", "dist": {"tarball": "%[2]s/%[1]s/-/%[1]s-1.1.0.tgz"}}
+			}
+		}`, name, server.URL)
+	}))
+	return server
+}
+
+// memorySink 把抓取结果保存在内存中
+type memorySink struct {
+	lock    sync.Mutex
+	results map[string]*Result
+}
+
+func (x *memorySink) Write(ctx context.Context, result *Result) error {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.results[result.Name] = result
+	return nil
+}
+
+func TestCrawler(t *testing.T) {
+	server := setupCrawlerServer()
+	defer server.Close()
+	r := registry.NewRegistry(registry.NewOptions().SetRegistryURL(server.URL))
+	sink := &memorySink{results: make(map[string]*Result)}
+	var buf bytes.Buffer
+	var lock sync.Mutex
+	var failed []string
+	crawler := NewCrawler(r, NewQueue(), NewOptions().SetWorkers(2).SetTarballs(TarballLatest).SetRetryBackoff(time.Millisecond)).
+		AddSeeder(SeedNames("react", "vue"), SeedNames("vue", "flaky", "missing")).
+		AddSink(sink, NewJSONLinesSink(&buf)).
+		SetErrorHandler(func(name string, err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			failed = append(failed, name)
+			assert.True(t, errors.Is(err, ErrNotFound))
+		})
+
+	assert.Nil(t, crawler.Run(context.Background()))
+	assert.Equal(t, Stats{Fetched: 3, Failed: 1, Retries: 1, Tarballs: 3}, crawler.Stats())
+	assert.Equal(t, QueueStats{Done: 3, Failed: 1}, crawler.Queue().Stats())
+	assert.Equal(t, []string{"missing"}, failed)
+
+	assert.Len(t, sink.results, 3)
+	react := sink.results["react"]
+	assert.Equal(t, "react", react.Package.Name)
+	assert.Len(t, react.Tarballs, 1)
+	assert.Equal(t, "1.1.0", react.Tarballs[0].Version)
+	assert.Equal(t, "tarball of react/-/react-1.1.0.tgz", string(react.Tarballs[0].Data))
+	assert.False(t, react.FetchedAt.IsZero())
+
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var result map[string]any
+		assert.Nil(t, json.Unmarshal([]byte(line), &result))
+		names = append(names, result["name"].(string))
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"flaky", "react", "vue"}, names)
+}
+
+func TestCrawlerResume(t *testing.T) {
+	server := setupCrawlerServer()
+	defer server.Close()
+	r := registry.NewRegistry(registry.NewOptions().SetRegistryURL(server.URL))
+	filename := filepath.Join(t.TempDir(), "crawl.queue")
+
+	queue, err := OpenQueue(filename)
+	assert.Nil(t, err)
+	crawler := NewCrawler(r, queue, NewOptions().SetTarballs(TarballAll)).AddSeeder(SeedNames("react"))
+	assert.Nil(t, crawler.Run(context.Background()))
+	assert.Equal(t, Stats{Fetched: 1, Tarballs: 2}, crawler.Stats())
+	assert.Nil(t, queue.Close())
+
+	// 重启后已经完成的包不会重复抓取
+	queue, err = OpenQueue(filename)
+	assert.Nil(t, err)
+	defer queue.Close()
+	crawler = NewCrawler(r, queue, nil).AddSeeder(SeedNames("react", "vue"))
+	assert.Nil(t, crawler.Run(context.Background()))
+	assert.Equal(t, int64(1), crawler.Stats().Fetched)
+	assert.Equal(t, QueueStats{Done: 2}, queue.Stats())
+}
+
+func TestCrawlerErrors(t *testing.T) {
+	server := setupCrawlerServer()
+	defer server.Close()
+	r := registry.NewRegistry(registry.NewOptions().SetRegistryURL(server.URL))
+
+	// Sink 失败时包被标记为失败，Seeder 的错误由 Run 返回
+	crawler := NewCrawler(r, NewQueue(), nil).
+		AddSeeder(SeedNames("react"), SeederFunc(func(ctx context.Context, queue *Queue) error {
+			return errors.New("seed failed")
+		})).
+		AddSink(SinkFunc(func(ctx context.Context, result *Result) error {
+			return errors.New("disk full")
+		}))
+	err := crawler.Run(context.Background())
+	assert.Equal(t, "seed failed", err.Error())
+	assert.Equal(t, QueueStats{Failed: 1}, crawler.Queue().Stats())
+
+	// ctx 被取消时 Run 返回 ctx.Err()
+	ctx, cancel := context.WithCancel(context.Background())
+	crawler = NewCrawler(r, NewQueue(), nil).AddSeeder(SeederFunc(func(ctx context.Context, queue *Queue) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	assert.True(t, errors.Is(crawler.Run(ctx), context.Canceled))
+}
+
+func TestStoreSink(t *testing.T) {
+	server := setupCrawlerServer()
+	defer server.Close()
+	r := registry.NewRegistry(registry.NewOptions().SetRegistryURL(server.URL))
+	s, err := store.OpenBoltStore(filepath.Join(t.TempDir(), "packages.db"))
+	assert.Nil(t, err)
+	defer s.Close()
+
+	crawler := NewCrawler(r, NewQueue(), NewOptions().SetTarballs(TarballLatest)).
+		AddSeeder(SeedNames("react", "vue")).
+		AddSink(NewStoreSink(s))
+	assert.Nil(t, crawler.Run(context.Background()))
+
+	pkg, err := s.GetPackage(context.Background(), "react")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.1.0", pkg.DistTags["latest"])
+	tarball, err := s.OpenTarball(context.Background(), "vue", "1.1.0")
+	assert.Nil(t, err)
+	defer tarball.Close()
+	data, err := io.ReadAll(tarball)
+	assert.Nil(t, err)
+	assert.Equal(t, "tarball of vue/-/vue-1.1.0.tgz", string(data))
+}
+
+func TestSnapshotSink(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	sink, err := NewSnapshotSink(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, sink.Write(ctx, &Result{
+		Name:           "react",
+		Package:        &models.Package{Name: "react", DistTags: map[string]string{"latest": "18.2.0"}},
+		DownloadStats:  &models.DownloadStats{Downloads: 100},
+		DownloadPeriod: "last-week",
+	}))
+	assert.Nil(t, registry.BuildSnapshotIndex(ctx, dir))
+
+	// 快照可以直接离线读取
+	r := registry.NewRegistry(registry.NewOptions().SetSnapshot(dir))
+	pkg, err := r.GetPackageInformation(ctx, "react")
+	assert.Nil(t, err)
+	assert.Equal(t, "18.2.0", pkg.DistTags["latest"])
+	stats, err := r.GetDownloadStats(ctx, "react", "last-week")
+	assert.Nil(t, err)
+	assert.Equal(t, 100, stats.Downloads)
+	assert.Equal(t, "react", stats.Package)
+	result, err := r.SearchPackages(ctx, "react", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Total)
+}
@@ -0,0 +1,421 @@
+package crawler
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// VersionPolicy 表示依赖图爬取时展开包的哪些版本
+type VersionPolicy string
+
+const (
+	// VersionsLatest 只展开 dist-tags.latest 指向的版本
+	VersionsLatest VersionPolicy = "latest"
+
+	// VersionsAll 展开全部版本，图的规模会迅速膨胀，通常需要配合 MaxDepth 使用
+	VersionsAll VersionPolicy = "all"
+
+	// VersionsSatisfying 对每个指向该包的依赖声明，展开 npm install 会选中的版本（满足范围的最高版本）
+	VersionsSatisfying VersionPolicy = "satisfying"
+)
+
+// FrontierOptions 表示依赖图爬取的配置选项
+//
+// 包含字段:
+//   - MaxDepth: 最大深度，种子的深度为 0，深度达到 MaxDepth 的包会被抓取但不再展开依赖，为 0 时不限制
+//   - Versions: 展开哪些版本，默认为 VersionsSatisfying
+//   - IncludePeer: 是否展开 peerDependencies，默认为 true，peerDependenciesMeta 中标记为可选的除外
+//   - IncludeOptional: 是否展开 optionalDependencies，默认为 true
+//   - IncludeDev: 是否展开 devDependencies，默认为 false
+//
+// 使用示例:
+//
+//	options := NewFrontierOptions().SetMaxDepth(3).SetVersions(VersionsLatest)
+type FrontierOptions struct {
+	MaxDepth        int
+	Versions        VersionPolicy
+	IncludePeer     bool
+	IncludeOptional bool
+	IncludeDev      bool
+}
+
+// NewFrontierOptions 创建并返回默认的配置选项
+func NewFrontierOptions() *FrontierOptions {
+	return &FrontierOptions{
+		Versions:        VersionsSatisfying,
+		IncludePeer:     true,
+		IncludeOptional: true,
+	}
+}
+
+// SetMaxDepth 设置最大深度
+func (o *FrontierOptions) SetMaxDepth(maxDepth int) *FrontierOptions {
+	o.MaxDepth = maxDepth
+	return o
+}
+
+// SetVersions 设置展开哪些版本
+func (o *FrontierOptions) SetVersions(policy VersionPolicy) *FrontierOptions {
+	o.Versions = policy
+	return o
+}
+
+// SetIncludePeer 设置是否展开 peerDependencies
+func (o *FrontierOptions) SetIncludePeer(includePeer bool) *FrontierOptions {
+	o.IncludePeer = includePeer
+	return o
+}
+
+// SetIncludeOptional 设置是否展开 optionalDependencies
+func (o *FrontierOptions) SetIncludeOptional(includeOptional bool) *FrontierOptions {
+	o.IncludeOptional = includeOptional
+	return o
+}
+
+// SetIncludeDev 设置是否展开 devDependencies
+func (o *FrontierOptions) SetIncludeDev(includeDev bool) *FrontierOptions {
+	o.IncludeDev = includeDev
+	return o
+}
+
+// Edge 表示依赖图中的一条依赖声明
+//
+// 主要字段说明:
+//   - From: 声明依赖的包名
+//   - FromVersion: 声明依赖的版本
+//   - Name: 依赖名称，别名依赖时为别名
+//   - To: 依赖在 Registry 中真实的包名
+//   - Spec: 原始的版本声明，例如 "^4.17.0"、"npm:bar@^1"
+//   - Type: 依赖类型
+//   - ToVersion: 按 npm 规则为该声明选中的版本，只有在 Frontier.Graph 中且目标包已经抓取时才有
+type Edge struct {
+	From        string                  `json:"from"`
+	FromVersion string                  `json:"fromVersion"`
+	Name        string                  `json:"name"`
+	To          string                  `json:"to"`
+	Spec        string                  `json:"spec"`
+	Type        resolver.DependencyType `json:"type"`
+	ToVersion   string                  `json:"toVersion,omitempty"`
+
+	// 去掉别名前缀之后的版本选择器
+	selector string
+}
+
+// GraphNode 表示依赖图中的一个包
+//
+// 主要字段说明:
+//   - Name: 包名
+//   - Depth: 距离种子的最短深度
+//   - Versions: 按 VersionPolicy 选中的版本，按版本号排序，包还没有抓取或抓取失败时为空
+type GraphNode struct {
+	Name     string   `json:"name"`
+	Depth    int      `json:"depth"`
+	Versions []string `json:"versions,omitempty"`
+}
+
+// Graph 表示依赖图爬取发现的完整依赖图
+type Graph struct {
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*Edge      `json:"edges"`
+}
+
+// frontierNode 保存一个包在依赖图中的状态
+type frontierNode struct {
+	depth int
+
+	// 指向该包的依赖声明中的版本选择器
+	selectors map[string]bool
+
+	// 已经抓取过的包才会有以下字段，pkg 只保留选择版本和展开依赖需要的字段
+	pkg      *models.Package
+	resolved map[string]string
+	versions map[string]bool
+
+	// 已经展开过依赖的版本，以及展开得到的依赖声明
+	expanded map[string]bool
+	edges    []*Edge
+}
+
+// Frontier 从一组种子出发，沿着依赖关系扩展爬虫的抓取范围，只抓取从种子可达的包
+//
+// 与 Crawler 配合使用: Crawler 每抓取一个包，Frontier 按 VersionPolicy 选出需要展开的版本，
+// 把这些版本的依赖加入队列，并把发现的依赖声明写入 Result.Edges。同一个包只会抓取一次，
+// Frontier 保存抓取到的版本列表和依赖，之后出现新的版本范围或者更短的路径时在本地重新选择版本并展开，
+// 更短的路径会传递给已经发现的所有下游包，因此结果与抓取顺序无关
+//
+// 注意: Frontier 的状态只保存在内存中，应当与 NewQueue 创建的内存队列一起使用
+//
+// 使用示例:
+//
+//	frontier, err := NewFrontier(NewFrontierOptions().SetMaxDepth(5), "react@^18", "express")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	crawler := NewCrawler(registry.NewRegistry(), NewQueue(), nil).SetFrontier(frontier)
+//	if err := crawler.Run(ctx); err != nil {
+//		// 处理错误
+//	}
+//	graph := frontier.Graph()
+type Frontier struct {
+	options *FrontierOptions
+	seeds   []*resolver.Spec
+
+	lock  sync.Mutex
+	nodes map[string]*frontierNode
+	edges []*Edge
+}
+
+// NewFrontier 创建一个依赖图爬取的抓取范围
+//
+// 参数:
+//   - options: 配置选项，为 nil 时使用默认选项
+//   - specs: 种子包声明，格式与 npm install 相同，例如 "react"、"react@^18"、"@types/node@next"
+//
+// 返回值:
+//   - *Frontier: 新创建的抓取范围
+//   - error: 种子声明不合法时返回错误
+func NewFrontier(options *FrontierOptions, specs ...string) (*Frontier, error) {
+	if options == nil {
+		options = NewFrontierOptions()
+	}
+	frontier := &Frontier{
+		options: options,
+		nodes:   make(map[string]*frontierNode),
+	}
+	for _, raw := range specs {
+		spec, err := resolver.ParseSpec(raw)
+		if err != nil {
+			return nil, err
+		}
+		frontier.seeds = append(frontier.seeds, spec)
+	}
+	return frontier, nil
+}
+
+var _ Seeder = &Frontier{}
+
+// Seed 把种子包加入队列
+func (x *Frontier) Seed(ctx context.Context, queue *Queue) error {
+	x.lock.Lock()
+	var names []string
+	for _, spec := range x.seeds {
+		// 种子在抓取开始之前加入，只有新发现的包需要抓取
+		if fetch, _ := x.discover(spec.PackageName, spec.Selector, 0); fetch {
+			names = append(names, spec.PackageName)
+		}
+	}
+	x.lock.Unlock()
+	_, err := queue.Push(names...)
+	return err
+}
+
+// Graph 返回到目前为止发现的依赖图，节点按包名排序，边按发现的顺序排列
+func (x *Frontier) Graph() *Graph {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	graph := &Graph{
+		Nodes: make([]*GraphNode, 0, len(x.nodes)),
+		Edges: make([]*Edge, 0, len(x.edges)),
+	}
+	for name, node := range x.nodes {
+		graph.Nodes = append(graph.Nodes, &GraphNode{Name: name, Depth: node.depth, Versions: sortedVersions(node.versions)})
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		return graph.Nodes[i].Name < graph.Nodes[j].Name
+	})
+	for _, edge := range x.edges {
+		edge := *edge
+		if target := x.nodes[edge.To]; target != nil {
+			edge.ToVersion = target.resolved[edge.selector]
+		}
+		graph.Edges = append(graph.Edges, &edge)
+	}
+	return graph
+}
+
+// expand 处理一个抓取结果: 选出需要展开的版本，记录依赖声明，并把新发现的包加入队列
+//
+// 已经抓取过的包因为这次抓取出现了新的版本范围或者更短的路径时，会在本地重新展开，
+// 这些包新展开的依赖声明同样写入 result.Edges
+func (x *Frontier) expand(result *Result, queue *Queue) error {
+	x.lock.Lock()
+	node := x.nodes[result.Name]
+	if node == nil {
+		// 来自其他 Seeder 的包按种子处理
+		node = x.newNode(0)
+		node.selectors["latest"] = true
+		x.nodes[result.Name] = node
+	}
+	node.pkg = trimPackage(result.Package)
+
+	var pushes []string
+	pending := []string{result.Name}
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		pushes, pending = x.update(name, result, pushes, pending)
+	}
+	result.Versions = sortedVersions(node.versions)
+	x.lock.Unlock()
+
+	_, err := queue.Push(pushes...)
+	return err
+}
+
+// update 重新选择已抓取的包 name 需要展开的版本，把深度传递给已经发现的依赖，并展开新选中的版本，调用方需要持有锁
+//
+// 新发现的包追加到 pushes，需要在本地重新展开的已抓取的包追加到 pending
+func (x *Frontier) update(name string, result *Result, pushes, pending []string) ([]string, []string) {
+	node := x.nodes[name]
+	node.resolved = make(map[string]string, len(node.selectors))
+	for selector := range node.selectors {
+		if manifest, err := resolver.PickManifest(node.pkg, selector); err == nil {
+			node.resolved[selector] = manifest.Version
+		}
+	}
+	for version := range x.selectVersions(node.pkg, node) {
+		node.versions[version] = true
+	}
+
+	// 节点的深度可能变小了，已经展开的依赖需要同步更新深度
+	for _, edge := range node.edges {
+		if _, reprocess := x.discover(edge.To, edge.selector, node.depth+1); reprocess {
+			pending = append(pending, edge.To)
+		}
+	}
+
+	if x.options.MaxDepth > 0 && node.depth >= x.options.MaxDepth {
+		return pushes, pending
+	}
+	for _, version := range sortedVersions(node.versions) {
+		if node.expanded[version] {
+			continue
+		}
+		node.expanded[version] = true
+		manifest := node.pkg.Versions[version]
+		for _, dependency := range resolver.Dependencies(&manifest, x.options.IncludeOptional, x.options.IncludeDev, x.options.IncludePeer) {
+			spec, err := resolver.ParseDependency(dependency.Name, dependency.Spec)
+			if err != nil {
+				// git、本地路径等不是来自 Registry 的依赖无法继续爬取
+				continue
+			}
+			edge := &Edge{
+				From:        name,
+				FromVersion: version,
+				Name:        dependency.Name,
+				To:          spec.PackageName,
+				Spec:        dependency.Spec,
+				Type:        dependency.Type,
+				selector:    spec.Selector,
+			}
+			node.edges = append(node.edges, edge)
+			x.edges = append(x.edges, edge)
+			result.Edges = append(result.Edges, edge)
+
+			fetch, reprocess := x.discover(spec.PackageName, spec.Selector, node.depth+1)
+			if fetch {
+				pushes = append(pushes, spec.PackageName)
+			}
+			if reprocess {
+				pending = append(pending, spec.PackageName)
+			}
+		}
+	}
+	return pushes, pending
+}
+
+// discover 记录指向 name 的一条依赖声明，调用方需要持有锁
+//
+// 返回值:
+//   - fetch: 新发现的包，需要加入队列抓取
+//   - reprocess: 已经抓取的包出现了更短的路径，或者出现了新的版本选择器，需要调用 update 在本地重新展开
+func (x *Frontier) discover(name, selector string, depth int) (fetch, reprocess bool) {
+	node, exists := x.nodes[name]
+	if !exists {
+		node = x.newNode(depth)
+		node.selectors[selector] = true
+		x.nodes[name] = node
+		return true, false
+	}
+	changed := false
+	if depth < node.depth {
+		node.depth = depth
+		changed = true
+	}
+	if !node.selectors[selector] {
+		node.selectors[selector] = true
+		changed = changed || x.options.Versions == VersionsSatisfying
+	}
+	// 还没有抓取的包在抓取完成后会按最新的深度和版本选择器展开
+	return false, changed && node.pkg != nil
+}
+
+// newNode 创建一个深度为 depth 的节点
+func (x *Frontier) newNode(depth int) *frontierNode {
+	return &frontierNode{
+		depth:     depth,
+		selectors: make(map[string]bool),
+		versions:  make(map[string]bool),
+		expanded:  make(map[string]bool),
+	}
+}
+
+// trimPackage 复制包元数据中选择版本和展开依赖需要的字段，避免在内存中保存完整的元数据
+func trimPackage(pkg *models.Package) *models.Package {
+	trimmed := &models.Package{
+		Name:     pkg.Name,
+		DistTags: pkg.DistTags,
+		Versions: make(map[string]models.Version, len(pkg.Versions)),
+	}
+	for version, manifest := range pkg.Versions {
+		trimmed.Versions[version] = models.Version{
+			Name:                 manifest.Name,
+			Version:              manifest.Version,
+			Deprecated:           manifest.Deprecated,
+			Dependencies:         manifest.Dependencies,
+			DevDependencies:      manifest.DevDependencies,
+			PeerDependencies:     manifest.PeerDependencies,
+			PeerDependenciesMeta: manifest.PeerDependenciesMeta,
+			OptionalDependencies: manifest.OptionalDependencies,
+		}
+	}
+	return trimmed
+}
+
+// selectVersions 按 VersionPolicy 选出需要展开的版本
+func (x *Frontier) selectVersions(pkg *models.Package, node *frontierNode) map[string]bool {
+	versions := make(map[string]bool)
+	switch x.options.Versions {
+	case VersionsAll:
+		for version := range pkg.Versions {
+			versions[version] = true
+		}
+	case VersionsLatest:
+		if latest := pkg.DistTags["latest"]; latest != "" {
+			if _, ok := pkg.Versions[latest]; ok {
+				versions[latest] = true
+			}
+		}
+	default:
+		for _, version := range node.resolved {
+			versions[version] = true
+		}
+	}
+	return versions
+}
+
+// sortedVersions 返回按版本号排序的版本列表
+func sortedVersions(versions map[string]bool) []string {
+	result := make([]string, 0, len(versions))
+	for version := range versions {
+		result = append(result, version)
+	}
+	semver.Sort(result)
+	return result
+}
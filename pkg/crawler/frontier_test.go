@@ -0,0 +1,160 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+var frontierPackages = map[string]string{
+	"app": `{"name": "app", "dist-tags": {"latest": "1.0.0"}, "versions": {
+		"1.0.0": {"name": "app", "version": "1.0.0",
+			"dependencies": {"lib": "^1.0.0", "from-git": "github:user/repo", "alias": "npm:leaf@^1"},
+			"peerDependencies": {"react": "^18", "optional-peer": "*"},
+			"peerDependenciesMeta": {"optional-peer": {"optional": true}},
+			"devDependencies": {"dev-tool": "^1"}}}}`,
+	"lib": `{"name": "lib", "dist-tags": {"latest": "2.0.0"}, "versions": {
+		"1.0.0": {"name": "lib", "version": "1.0.0"},
+		"1.2.0": {"name": "lib", "version": "1.2.0", "dependencies": {"leaf": "1.0.0"}},
+		"2.0.0": {"name": "lib", "version": "2.0.0", "dependencies": {"other": "^1"}}}}`,
+	"leaf":     `{"name": "leaf", "dist-tags": {"latest": "1.0.0"}, "versions": {"1.0.0": {"name": "leaf", "version": "1.0.0"}}}`,
+	"react":    `{"name": "react", "dist-tags": {"latest": "18.2.0"}, "versions": {"18.2.0": {"name": "react", "version": "18.2.0"}}}`,
+	"other":    `{"name": "other", "dist-tags": {"latest": "1.0.0"}, "versions": {"1.0.0": {"name": "other", "version": "1.0.0"}}}`,
+	"dev-tool": `{"name": "dev-tool", "dist-tags": {"latest": "1.0.0"}, "versions": {"1.0.0": {"name": "dev-tool", "version": "1.0.0"}}}`,
+}
+
+func setupFrontierServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := frontierPackages[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "Not found"}`))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+// crawlFrontier 使用 frontier 爬取模拟 Registry，返回依赖图和成功抓取的包数量
+func crawlFrontier(t *testing.T, server *httptest.Server, frontier *Frontier) (*Graph, int64) {
+	r := registry.NewRegistry(registry.NewOptions().SetRegistryURL(server.URL))
+	crawler := NewCrawler(r, NewQueue(), NewOptions().SetWorkers(4)).SetFrontier(frontier)
+	assert.Nil(t, crawler.Run(context.Background()))
+	return frontier.Graph(), crawler.Stats().Fetched
+}
+
+func nodeVersions(graph *Graph) map[string][]string {
+	versions := make(map[string][]string)
+	for _, node := range graph.Nodes {
+		versions[node.Name] = node.Versions
+	}
+	return versions
+}
+
+func TestFrontierSatisfying(t *testing.T) {
+	server := setupFrontierServer()
+	defer server.Close()
+
+	frontier, err := NewFrontier(nil, "app", "lib@2")
+	assert.Nil(t, err)
+	graph, fetched := crawlFrontier(t, server, frontier)
+	assert.Equal(t, int64(5), fetched)
+	assert.Equal(t, map[string][]string{
+		"app":   {"1.0.0"},
+		"lib":   {"1.2.0", "2.0.0"},
+		"leaf":  {"1.0.0"},
+		"other": {"1.0.0"},
+		"react": {"18.2.0"},
+	}, nodeVersions(graph))
+
+	edges := make(map[string]*Edge)
+	for _, edge := range graph.Edges {
+		edges[edge.From+"@"+edge.FromVersion+">"+edge.Name] = edge
+	}
+	assert.Len(t, edges, 5)
+	assert.Equal(t, &Edge{From: "app", FromVersion: "1.0.0", Name: "lib", To: "lib", Spec: "^1.0.0", Type: resolver.DependencyTypeProd, ToVersion: "1.2.0", selector: "^1.0.0"}, edges["app@1.0.0>lib"])
+	assert.Equal(t, "leaf", edges["app@1.0.0>alias"].To)
+	assert.Equal(t, "1.0.0", edges["app@1.0.0>alias"].ToVersion)
+	assert.Equal(t, resolver.DependencyTypePeer, edges["app@1.0.0>react"].Type)
+	assert.NotNil(t, edges["lib@1.2.0>leaf"])
+	assert.NotNil(t, edges["lib@2.0.0>other"])
+}
+
+func TestFrontierOptions(t *testing.T) {
+	server := setupFrontierServer()
+	defer server.Close()
+
+	// 只展开最新版本，包含开发依赖，不包含同级依赖
+	frontier, err := NewFrontier(NewFrontierOptions().SetVersions(VersionsLatest).SetIncludeDev(true).SetIncludePeer(false), "app")
+	assert.Nil(t, err)
+	graph, _ := crawlFrontier(t, server, frontier)
+	assert.Equal(t, map[string][]string{
+		"app":      {"1.0.0"},
+		"lib":      {"2.0.0"},
+		"leaf":     {"1.0.0"},
+		"other":    {"1.0.0"},
+		"dev-tool": {"1.0.0"},
+	}, nodeVersions(graph))
+
+	// 限制深度，深度为 1 的包会被抓取但不再展开
+	frontier, err = NewFrontier(NewFrontierOptions().SetMaxDepth(1).SetVersions(VersionsAll), "app")
+	assert.Nil(t, err)
+	graph, fetched := crawlFrontier(t, server, frontier)
+	assert.Equal(t, int64(4), fetched)
+	assert.Equal(t, []string{"1.0.0", "1.2.0", "2.0.0"}, nodeVersions(graph)["lib"])
+	for _, node := range graph.Nodes {
+		assert.LessOrEqual(t, node.Depth, 1)
+	}
+	assert.Len(t, graph.Edges, 3)
+
+	_, err = NewFrontier(nil, "not a spec")
+	assert.NotNil(t, err)
+}
+
+func TestFrontierShorterPath(t *testing.T) {
+	// a -> m1 -> c -> d 和 b -> c -> d -> e，经过 a 时 d 的深度为 3，经过 b 时为 2
+	packages := map[string]string{
+		"a":  `{"name": "a", "dist-tags": {"latest": "1.0.0"}, "versions": {"1.0.0": {"name": "a", "version": "1.0.0", "dependencies": {"m1": "^1"}}}}`,
+		"b":  `{"name": "b", "dist-tags": {"latest": "1.0.0"}, "versions": {"1.0.0": {"name": "b", "version": "1.0.0", "dependencies": {"c": "^1"}}}}`,
+		"m1": `{"name": "m1", "dist-tags": {"latest": "1.0.0"}, "versions": {"1.0.0": {"name": "m1", "version": "1.0.0", "dependencies": {"c": "^1"}}}}`,
+		"c":  `{"name": "c", "dist-tags": {"latest": "1.0.0"}, "versions": {"1.0.0": {"name": "c", "version": "1.0.0", "dependencies": {"d": "^1"}}}}`,
+		"d":  `{"name": "d", "dist-tags": {"latest": "1.0.0"}, "versions": {"1.0.0": {"name": "d", "version": "1.0.0", "dependencies": {"e": "^1"}}}}`,
+		"e":  `{"name": "e", "dist-tags": {"latest": "1.0.0"}, "versions": {"1.0.0": {"name": "e", "version": "1.0.0"}}}`,
+	}
+	// b 在 d 作为叶子节点展开之后才返回，这是最短路径最晚出现的抓取顺序
+	leafExpanded := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		if name == "b" {
+			<-leafExpanded
+		}
+		w.Write([]byte(packages[name]))
+	}))
+	defer server.Close()
+
+	frontier, err := NewFrontier(NewFrontierOptions().SetMaxDepth(3), "a", "b")
+	assert.Nil(t, err)
+	r := registry.NewRegistry(registry.NewOptions().SetRegistryURL(server.URL))
+	crawler := NewCrawler(r, NewQueue(), NewOptions().SetWorkers(4)).SetFrontier(frontier).
+		AddSink(SinkFunc(func(ctx context.Context, result *Result) error {
+			if result.Name == "d" {
+				close(leafExpanded)
+			}
+			return nil
+		}))
+	assert.Nil(t, crawler.Run(context.Background()))
+	assert.Equal(t, int64(6), crawler.Stats().Fetched)
+
+	depths := make(map[string]int)
+	for _, node := range frontier.Graph().Nodes {
+		depths[node.Name] = node.Depth
+	}
+	assert.Equal(t, map[string]int{"a": 0, "b": 0, "m1": 1, "c": 1, "d": 2, "e": 3}, depths)
+	assert.Equal(t, []string{"1.0.0"}, nodeVersions(frontier.Graph())["e"])
+}
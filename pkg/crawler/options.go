@@ -0,0 +1,103 @@
+package crawler
+
+import (
+	"time"
+)
+
+// TarballPolicy 表示抓取包时下载哪些版本的 tarball
+type TarballPolicy string
+
+const (
+	// TarballNone 不下载 tarball
+	TarballNone TarballPolicy = ""
+
+	// TarballLatest 只下载 dist-tags.latest 指向的版本
+	TarballLatest TarballPolicy = "latest"
+
+	// TarballAll 下载全部版本
+	TarballAll TarballPolicy = "all"
+)
+
+// Options 表示爬虫的配置选项
+//
+// 包含字段:
+//   - Workers: 并发抓取的 worker 数量，默认为 8
+//   - Abbreviated: 是否只抓取精简版元数据（corgi 文档），体积更小但不包含 readme、time 等字段
+//   - Tarballs: 下载哪些版本的 tarball，默认不下载
+//   - DownloadStats: 是否同时抓取下载统计
+//   - DownloadPeriod: 下载统计的周期，默认为 "last-week"
+//   - MaxRetries: 单个包抓取失败后的最大重试次数，默认为 3，包不存在时不会重试
+//   - RetryBackoff: 第一次重试前的等待时间，之后每次翻倍，默认为 1 秒
+//
+// 使用示例:
+//
+//	options := NewOptions().SetWorkers(32).SetTarballs(TarballLatest).SetDownloadStats(true)
+//	crawler := NewCrawler(registry.NewRegistry(), NewQueue(), options)
+type Options struct {
+	Workers        int
+	Abbreviated    bool
+	Tarballs       TarballPolicy
+	DownloadStats  bool
+	DownloadPeriod string
+	MaxRetries     int
+	RetryBackoff   time.Duration
+}
+
+// NewOptions 创建并返回默认的配置选项
+//
+// 默认配置:
+//   - Workers: 8
+//   - 抓取完整元数据，不下载 tarball，不抓取下载统计
+//   - DownloadPeriod: "last-week"
+//   - MaxRetries: 3
+//   - RetryBackoff: 1 秒
+func NewOptions() *Options {
+	return &Options{
+		Workers:        8,
+		DownloadPeriod: "last-week",
+		MaxRetries:     3,
+		RetryBackoff:   time.Second,
+	}
+}
+
+// SetWorkers 设置并发抓取的 worker 数量
+func (o *Options) SetWorkers(workers int) *Options {
+	o.Workers = workers
+	return o
+}
+
+// SetAbbreviated 设置是否只抓取精简版元数据
+func (o *Options) SetAbbreviated(abbreviated bool) *Options {
+	o.Abbreviated = abbreviated
+	return o
+}
+
+// SetTarballs 设置下载哪些版本的 tarball
+func (o *Options) SetTarballs(policy TarballPolicy) *Options {
+	o.Tarballs = policy
+	return o
+}
+
+// SetDownloadStats 设置是否同时抓取下载统计
+func (o *Options) SetDownloadStats(downloadStats bool) *Options {
+	o.DownloadStats = downloadStats
+	return o
+}
+
+// SetDownloadPeriod 设置下载统计的周期，例如 "last-day"、"last-week"、"last-month"
+func (o *Options) SetDownloadPeriod(period string) *Options {
+	o.DownloadPeriod = period
+	return o
+}
+
+// SetMaxRetries 设置单个包抓取失败后的最大重试次数
+func (o *Options) SetMaxRetries(maxRetries int) *Options {
+	o.MaxRetries = maxRetries
+	return o
+}
+
+// SetRetryBackoff 设置第一次重试前的等待时间
+func (o *Options) SetRetryBackoff(backoff time.Duration) *Options {
+	o.RetryBackoff = backoff
+	return o
+}
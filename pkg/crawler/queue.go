@@ -0,0 +1,421 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// itemState 表示队列中一个包的状态
+type itemState int
+
+const (
+	stateQueued itemState = iota
+	stateInFlight
+	stateDone
+	stateFailed
+)
+
+// 日志中的操作类型，每行的格式为 "<操作>\t<包名>"
+const (
+	opPush    = "push"
+	opRefresh = "refresh"
+	opDone    = "done"
+	opFail    = "fail"
+)
+
+// QueueStats 表示队列中各个状态的包数量
+type QueueStats struct {
+	Pending  int `json:"pending"`
+	InFlight int `json:"inFlight"`
+	Done     int `json:"done"`
+	Failed   int `json:"failed"`
+}
+
+// Queue 是爬虫使用的去重任务队列，可以选择把状态持久化到磁盘
+//
+// 每个包名在队列中只会出现一次，已经完成或失败的包不会被 Push 再次加入，需要重新抓取时使用 Refresh。
+//
+// 持久化使用追加写入的日志文件: Push、Refresh、Done、Fail 都会立即写入一行记录，
+// 被 Pop 取出但还没有 Done 的包不会记录，因此进程崩溃或重启后这些包会重新排队，
+// 已经完成的包不会重复抓取。OpenQueue 打开时会重放日志并压缩成只包含当前状态的新日志
+type Queue struct {
+	lock    sync.Mutex
+	pending []string
+	head    int
+	states  map[string]itemState
+	refresh map[string]bool
+	stats   QueueStats
+
+	// 生产者数量，大于 0 时队列为空也不会结束
+	producers int
+	closed    bool
+	signal    chan struct{}
+
+	filename string
+	journal  *os.File
+}
+
+// NewQueue 创建一个只保存在内存中的队列
+func NewQueue() *Queue {
+	return &Queue{
+		states:  make(map[string]itemState),
+		refresh: make(map[string]bool),
+		signal:  make(chan struct{}),
+	}
+}
+
+// OpenQueue 打开保存在 filename 的持久化队列，文件不存在时创建新队列
+//
+// 参数:
+//   - filename: 日志文件路径，所在目录必须已经存在
+//
+// 返回值:
+//   - *Queue: 恢复了上次状态的队列，使用完毕后需要调用 Close
+//   - error: 读取或压缩日志失败时返回错误
+//
+// 使用示例:
+//
+//	queue, err := OpenQueue("crawl.queue")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	defer queue.Close()
+func OpenQueue(filename string) (*Queue, error) {
+	queue := NewQueue()
+	queue.filename = filename
+	if err := queue.replay(); err != nil {
+		return nil, err
+	}
+	if err := queue.compact(); err != nil {
+		return nil, err
+	}
+	return queue, nil
+}
+
+// Push 把包名加入队列，已经在队列中、正在处理、已经完成或失败的包名会被忽略
+//
+// 返回值:
+//   - int: 实际加入的包数量
+//   - error: 写入日志失败时返回错误
+func (x *Queue) Push(names ...string) (int, error) {
+	return x.add(opPush, names)
+}
+
+// Refresh 把包名加入队列，与 Push 不同的是已经完成或失败的包会重新排队，
+// 正在处理的包会在处理完成后重新排队，适合处理 _changes 中的更新
+//
+// 返回值:
+//   - int: 实际加入（或标记为重新排队）的包数量
+//   - error: 写入日志失败时返回错误
+func (x *Queue) Refresh(names ...string) (int, error) {
+	return x.add(opRefresh, names)
+}
+
+// Pop 取出下一个待处理的包名，处理完成后必须调用 Done 或 Fail
+//
+// 队列为空时会阻塞，直到有新的包名加入，或者队列已经没有待处理和正在处理的包、也没有生产者，
+// 或者 ctx 被取消、队列被关闭
+//
+// 返回值:
+//   - string: 包名
+//   - bool: 队列已经结束或者 ctx 被取消时返回 false
+func (x *Queue) Pop(ctx context.Context) (string, bool) {
+	for {
+		x.lock.Lock()
+		if x.closed {
+			x.lock.Unlock()
+			return "", false
+		}
+		if x.head < len(x.pending) {
+			name := x.pending[x.head]
+			x.pending[x.head] = ""
+			x.head++
+			if x.head > 1024 && x.head*2 > len(x.pending) {
+				x.pending = append([]string(nil), x.pending[x.head:]...)
+				x.head = 0
+			}
+			x.states[name] = stateInFlight
+			x.stats.Pending--
+			x.stats.InFlight++
+			x.lock.Unlock()
+			return name, true
+		}
+		if x.stats.InFlight == 0 && x.producers == 0 {
+			x.lock.Unlock()
+			return "", false
+		}
+		signal := x.signal
+		x.lock.Unlock()
+
+		select {
+		case <-signal:
+		case <-ctx.Done():
+			return "", false
+		}
+	}
+}
+
+// Done 标记包已经处理完成
+func (x *Queue) Done(name string) error {
+	return x.finish(name, opDone, stateDone)
+}
+
+// Fail 标记包处理失败，失败的包不会被 Push 再次加入，可以通过 Refresh 重试
+func (x *Queue) Fail(name string) error {
+	return x.finish(name, opFail, stateFailed)
+}
+
+// AddProducer 登记一个生产者，生产者全部结束之前即使队列为空 Pop 也会继续等待
+func (x *Queue) AddProducer() {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.producers++
+}
+
+// DoneProducer 注销一个生产者
+func (x *Queue) DoneProducer() {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.producers--
+	x.notify()
+}
+
+// Stats 返回队列中各个状态的包数量
+func (x *Queue) Stats() QueueStats {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	return x.stats
+}
+
+// Close 关闭队列，阻塞中的 Pop 会返回 false；持久化队列会同步并关闭日志文件
+func (x *Queue) Close() error {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	if x.closed {
+		return nil
+	}
+	x.closed = true
+	x.notify()
+	if x.journal == nil {
+		return nil
+	}
+	if err := x.journal.Sync(); err != nil {
+		_ = x.journal.Close()
+		return err
+	}
+	return x.journal.Close()
+}
+
+// add 按 op 的规则把包名加入队列
+func (x *Queue) add(op string, names []string) (int, error) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	if x.closed {
+		return 0, errors.New("queue is closed")
+	}
+	var added []string
+	for _, name := range names {
+		if name != "" && x.apply(op, name) {
+			added = append(added, name)
+		}
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+	x.notify()
+	return len(added), x.write(op, added...)
+}
+
+// finish 把正在处理的包标记为 state，需要重新排队的包会重新加入队列
+func (x *Queue) finish(name, op string, state itemState) error {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	if x.states[name] != stateInFlight {
+		return fmt.Errorf("%s is not in flight", name)
+	}
+	x.stats.InFlight--
+	x.setState(name, state)
+	if x.refresh[name] {
+		delete(x.refresh, name)
+		x.apply(opRefresh, name)
+	}
+	x.notify()
+	if err := x.write(op, name); err != nil {
+		return err
+	}
+	if x.states[name] == stateQueued {
+		return x.write(opRefresh, name)
+	}
+	return nil
+}
+
+// apply 在内存中执行一条操作，返回操作是否改变了队列
+func (x *Queue) apply(op, name string) bool {
+	state, exists := x.states[name]
+	switch op {
+	case opPush:
+		if exists {
+			return false
+		}
+	case opRefresh:
+		if exists && state == stateQueued {
+			return false
+		}
+		if exists && state == stateInFlight {
+			if x.refresh[name] {
+				return false
+			}
+			x.refresh[name] = true
+			return true
+		}
+	case opDone:
+		x.setState(name, stateDone)
+		return true
+	case opFail:
+		x.setState(name, stateFailed)
+		return true
+	default:
+		return false
+	}
+	x.setState(name, stateQueued)
+	x.pending = append(x.pending, name)
+	return true
+}
+
+// setState 设置包的状态并更新统计，不处理 InFlight 计数
+func (x *Queue) setState(name string, state itemState) {
+	if old, exists := x.states[name]; exists {
+		switch old {
+		case stateQueued:
+			x.stats.Pending--
+		case stateDone:
+			x.stats.Done--
+		case stateFailed:
+			x.stats.Failed--
+		}
+	}
+	x.states[name] = state
+	switch state {
+	case stateQueued:
+		x.stats.Pending++
+	case stateDone:
+		x.stats.Done++
+	case stateFailed:
+		x.stats.Failed++
+	}
+}
+
+// notify 唤醒所有阻塞在 Pop 中的调用，调用方需要持有锁
+func (x *Queue) notify() {
+	close(x.signal)
+	x.signal = make(chan struct{})
+}
+
+// write 向日志追加记录，内存队列什么也不做
+func (x *Queue) write(op string, names ...string) error {
+	if x.journal == nil {
+		return nil
+	}
+	var builder strings.Builder
+	for _, name := range names {
+		builder.WriteString(op)
+		builder.WriteByte('\t')
+		builder.WriteString(name)
+		builder.WriteByte('\n')
+	}
+	_, err := x.journal.WriteString(builder.String())
+	return err
+}
+
+// replay 重放日志恢复队列状态
+//
+// 重放时没有正在处理的包，被 Pop 取出但没有完成的包仍然是排队状态；
+// 同一个包可能多次入队，因此先重放全部记录，再按首次入队的顺序重建待处理列表
+func (x *Queue) replay() error {
+	file, err := os.Open(x.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			// 进程崩溃时最后一行可能只写入了一半，没有换行符的行直接丢弃
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("replay %s: %w", x.filename, err)
+		}
+		if op, name, ok := strings.Cut(strings.TrimSuffix(line, "\n"), "\t"); ok && name != "" {
+			x.apply(op, name)
+		}
+	}
+
+	seen := make(map[string]bool, len(x.pending))
+	pending := x.pending[:0]
+	for _, name := range x.pending {
+		if x.states[name] == stateQueued && !seen[name] {
+			seen[name] = true
+			pending = append(pending, name)
+		}
+	}
+	x.pending = pending
+	return nil
+}
+
+// compact 把当前状态写入新的日志文件并替换旧文件，然后以追加模式打开
+func (x *Queue) compact() (err error) {
+	file, err := os.CreateTemp(filepath.Dir(x.filename), ".queue-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+	writer := bufio.NewWriter(file)
+	for name, state := range x.states {
+		switch state {
+		case stateDone:
+			_, err = fmt.Fprintf(writer, "%s\t%s\n", opDone, name)
+		case stateFailed:
+			_, err = fmt.Fprintf(writer, "%s\t%s\n", opFail, name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range x.pending[x.head:] {
+		if _, err = fmt.Fprintf(writer, "%s\t%s\n", opPush, name); err != nil {
+			return err
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(file.Name(), x.filename); err != nil {
+		return err
+	}
+	x.journal, err = os.OpenFile(x.filename, os.O_WRONLY|os.O_APPEND, 0644)
+	return err
+}
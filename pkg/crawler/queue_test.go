@@ -0,0 +1,106 @@
+package crawler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	queue := NewQueue()
+	n, err := queue.Push("a", "b", "a", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	name, ok := queue.Pop(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "a", name)
+	assert.Equal(t, QueueStats{Pending: 1, InFlight: 1}, queue.Stats())
+
+	// 正在处理的包不会重复加入，Refresh 会在完成后重新排队
+	n, _ = queue.Push("a")
+	assert.Equal(t, 0, n)
+	n, _ = queue.Refresh("a")
+	assert.Equal(t, 1, n)
+	assert.Nil(t, queue.Done("a"))
+	assert.NotNil(t, queue.Done("a"))
+	assert.Equal(t, QueueStats{Pending: 2}, queue.Stats())
+
+	name, _ = queue.Pop(ctx)
+	assert.Equal(t, "b", name)
+	assert.Nil(t, queue.Fail("b"))
+	name, _ = queue.Pop(ctx)
+	assert.Equal(t, "a", name)
+	assert.Nil(t, queue.Done("a"))
+
+	// 已经完成或失败的包只能通过 Refresh 重新加入
+	n, _ = queue.Push("a", "b")
+	assert.Equal(t, 0, n)
+	assert.Equal(t, QueueStats{Done: 1, Failed: 1}, queue.Stats())
+
+	// 队列为空且没有正在处理的包时结束
+	_, ok = queue.Pop(ctx)
+	assert.False(t, ok)
+}
+
+func TestQueueProducer(t *testing.T) {
+	queue := NewQueue()
+	queue.AddProducer()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		queue.Push("a")
+		queue.DoneProducer()
+	}()
+	name, ok := queue.Pop(context.Background())
+	assert.True(t, ok)
+	assert.Equal(t, "a", name)
+	assert.Nil(t, queue.Done("a"))
+	_, ok = queue.Pop(context.Background())
+	assert.False(t, ok)
+
+	// 生产者存在时 Pop 会一直等待，直到 ctx 被取消
+	queue.AddProducer()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, ok = queue.Pop(ctx)
+	assert.False(t, ok)
+}
+
+func TestOpenQueue(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "crawl.queue")
+	queue, err := OpenQueue(filename)
+	assert.Nil(t, err)
+	queue.Push("a", "b", "c", "d")
+	name, _ := queue.Pop(ctx)
+	assert.Nil(t, queue.Done(name))
+	name, _ = queue.Pop(ctx)
+	assert.Nil(t, queue.Fail(name))
+	// c 被取出但没有完成，模拟进程崩溃
+	queue.Pop(ctx)
+	assert.Nil(t, queue.Close())
+
+	// 追加一行不完整的记录，模拟写入过程中崩溃
+	file, _ := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0644)
+	file.WriteString("done\td")
+	file.Close()
+
+	queue, err = OpenQueue(filename)
+	assert.Nil(t, err)
+	defer queue.Close()
+	assert.Equal(t, QueueStats{Pending: 2, Done: 1, Failed: 1}, queue.Stats())
+	name, _ = queue.Pop(ctx)
+	assert.Equal(t, "c", name)
+	name, _ = queue.Pop(ctx)
+	assert.Equal(t, "d", name)
+
+	n, _ := queue.Push("a", "b")
+	assert.Equal(t, 0, n)
+	n, _ = queue.Refresh("a")
+	assert.Equal(t, 1, n)
+}
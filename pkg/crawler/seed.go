@@ -0,0 +1,94 @@
+package crawler
+
+import (
+	"context"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+)
+
+// Seeder 向队列中提供待抓取的包名
+//
+// 爬虫会在独立的 goroutine 中运行每个 Seeder，Seeder 运行期间即使队列暂时为空，worker 也会继续等待
+type Seeder interface {
+
+	// Seed 把包名加入队列，返回时表示不会再有新的包名
+	Seed(ctx context.Context, queue *Queue) error
+}
+
+// SeederFunc 把普通函数适配为 Seeder
+type SeederFunc func(ctx context.Context, queue *Queue) error
+
+var _ Seeder = SeederFunc(nil)
+
+// Seed 调用函数本身
+func (x SeederFunc) Seed(ctx context.Context, queue *Queue) error {
+	return x(ctx, queue)
+}
+
+// SeedNames 返回把固定的包名列表加入队列的 Seeder
+func SeedNames(names ...string) Seeder {
+	return SeederFunc(func(ctx context.Context, queue *Queue) error {
+		_, err := queue.Push(names...)
+		return err
+	})
+}
+
+// SeedSearch 返回把搜索结果加入队列的 Seeder
+//
+// 参数:
+//   - r: 用于搜索的 Registry 客户端
+//   - query: 搜索关键字
+//   - limit: 搜索结果数量，为 0 时使用 SearchPackages 的默认值
+func SeedSearch(r *registry.Registry, query string, limit int) Seeder {
+	return SeederFunc(func(ctx context.Context, queue *Queue) error {
+		result, err := r.SearchPackages(ctx, query, limit)
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(result.Objects))
+		for _, object := range result.Objects {
+			names = append(names, object.Package.Name)
+		}
+		_, err = queue.Push(names...)
+		return err
+	})
+}
+
+// SeedAllPackages 返回通过 _all_docs 把 Registry 中全部包名加入队列的 Seeder
+//
+// 参数:
+//   - r: 提供 _all_docs 接口的 Registry 客户端，例如 registry.NewNpmjsComRegistry()
+//   - options: 遍历选项，为 nil 时使用默认选项
+//
+// 注意: 包名是边遍历边加入队列的，队列本身会去重，因此重启后从头遍历也不会重复抓取已经完成的包
+func SeedAllPackages(r *registry.Registry, options *registry.AllPackagesOptions) Seeder {
+	return SeederFunc(func(ctx context.Context, queue *Queue) error {
+		it := r.AllPackages(ctx, options)
+		for it.Next() {
+			if _, err := queue.Push(it.Name()); err != nil {
+				return err
+			}
+		}
+		return it.Err()
+	})
+}
+
+// SeedChanges 返回跟踪 _changes 接口并把发生变更的包重新加入队列的 Seeder
+//
+// 发生变更的包使用 Queue.Refresh 加入队列，已经抓取过的包也会重新抓取；被删除的包会被忽略。
+// 该 Seeder 会一直运行直到 ctx 被取消，因此爬虫也会一直运行，序列号由 follower 的 CheckpointStore 保存
+//
+// 参数:
+//   - follower: 变更跟踪器，例如 registry.NewChangesFollower(registry.NewReplicateRegistry(), options)
+func SeedChanges(follower *registry.ChangesFollower) Seeder {
+	return SeederFunc(func(ctx context.Context, queue *Queue) error {
+		return follower.Run(ctx, func(change *models.Change) error {
+			if change.Deleted {
+				return nil
+			}
+			_, err := queue.Refresh(change.ID)
+			return err
+		})
+	})
+}
@@ -0,0 +1,176 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/scagogogo/npm-crawler/pkg/store"
+)
+
+// Result 表示抓取一个包的结果
+//
+// 主要字段说明:
+//   - Name: 包名
+//   - Package: 包的元数据，Options.Abbreviated 为 true 时是精简版元数据
+//   - Tarballs: 按 Options.Tarballs 下载的 tarball，已经通过完整性校验
+//   - DownloadStats: 下载统计，只有 Options.DownloadStats 为 true 时才有
+//   - DownloadPeriod: 下载统计的周期
+//   - Versions: 使用 Frontier 时按 VersionPolicy 选中的版本
+//   - Edges: 使用 Frontier 时这次抓取新展开的依赖声明，包括因为这次抓取而在本地重新展开的其他包的依赖声明（Edge.From 为实际声明依赖的包），
+//     汇总所有结果的 Edges 即可重建依赖图
+//   - FetchedAt: 抓取完成的时间
+type Result struct {
+	Name           string                `json:"name"`
+	Package        *models.Package       `json:"package"`
+	Tarballs       []*Tarball            `json:"tarballs,omitempty"`
+	DownloadStats  *models.DownloadStats `json:"downloadStats,omitempty"`
+	DownloadPeriod string                `json:"downloadPeriod,omitempty"`
+	Versions       []string              `json:"versions,omitempty"`
+	Edges          []*Edge               `json:"edges,omitempty"`
+	FetchedAt      time.Time             `json:"fetchedAt"`
+}
+
+// Tarball 表示抓取到的一个版本的 tarball，序列化为 JSON 时不包含数据本身
+type Tarball struct {
+	Version string `json:"version"`
+	Size    int    `json:"size"`
+	Data    []byte `json:"-"`
+}
+
+// Sink 接收爬虫的抓取结果，多个 worker 会并发调用 Write，实现需要保证并发安全
+//
+// Write 返回错误时该包会被标记为失败
+type Sink interface {
+	Write(ctx context.Context, result *Result) error
+}
+
+// SinkFunc 把普通函数适配为 Sink
+type SinkFunc func(ctx context.Context, result *Result) error
+
+var _ Sink = SinkFunc(nil)
+
+// Write 调用函数本身
+func (x SinkFunc) Write(ctx context.Context, result *Result) error {
+	return x(ctx, result)
+}
+
+// JSONLinesSink 把抓取结果以 JSON Lines 格式写入 io.Writer，每个包一行，不包含 tarball 数据
+type JSONLinesSink struct {
+	lock    sync.Mutex
+	encoder *json.Encoder
+}
+
+var _ Sink = &JSONLinesSink{}
+
+// NewJSONLinesSink 创建写入 w 的 JSONLinesSink
+//
+// 使用示例:
+//
+//	file, _ := os.Create("packages.jsonl")
+//	defer file.Close()
+//	crawler.AddSink(NewJSONLinesSink(file))
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{encoder: json.NewEncoder(w)}
+}
+
+// Write 写入一行抓取结果
+func (x *JSONLinesSink) Write(ctx context.Context, result *Result) error {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	return x.encoder.Encode(result)
+}
+
+// StoreSink 把抓取结果写入 store.Store: 包元数据和下载的 tarball
+//
+// 存储中已有相同修订版本（Package.Rev）的包元数据时不会重复写入，tarball 总是写入
+type StoreSink struct {
+	store store.Store
+}
+
+var _ Sink = &StoreSink{}
+
+// NewStoreSink 创建写入 s 的 StoreSink
+//
+// 使用示例:
+//
+//	s, _ := store.OpenBoltStore("packages.db")
+//	defer s.Close()
+//	crawler.AddSink(NewStoreSink(s))
+func NewStoreSink(s store.Store) *StoreSink {
+	return &StoreSink{store: s}
+}
+
+// Write 保存包元数据和 tarball
+func (x *StoreSink) Write(ctx context.Context, result *Result) error {
+	rev, err := x.store.Revision(ctx, result.Name)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	if err != nil || rev == "" || rev != result.Package.Rev {
+		if err := x.store.PutPackage(ctx, result.Package); err != nil {
+			return err
+		}
+	}
+	for _, tarball := range result.Tarballs {
+		if err := x.store.PutTarball(ctx, result.Name, tarball.Version, bytes.NewReader(tarball.Data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SnapshotSink 把抓取结果写入快照目录，生成的目录可以用 registry.Options.SetSnapshot 离线读取
+//
+// 包元数据和 tarball 按 store.FileStore 的结构保存，下载统计通过 registry.WriteSnapshotDownloadStats 保存。
+// 爬取结束后需要调用 registry.BuildSnapshotIndex 生成搜索索引
+//
+// 使用示例:
+//
+//	sink, err := NewSnapshotSink("/data/npm-snapshot")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	crawler := NewCrawler(registry.NewRegistry(), NewQueue(), NewOptions().SetDownloadStats(true)).
+//		AddSeeder(SeedNames("react", "vue")).
+//		AddSink(sink)
+//	if err := crawler.Run(ctx); err != nil {
+//		// 处理错误
+//	}
+//	err = registry.BuildSnapshotIndex(ctx, "/data/npm-snapshot")
+type SnapshotSink struct {
+	dir   string
+	store *StoreSink
+}
+
+var _ Sink = &SnapshotSink{}
+
+// NewSnapshotSink 创建写入 dir 的 SnapshotSink，目录不存在时会自动创建
+func NewSnapshotSink(dir string) (*SnapshotSink, error) {
+	s, err := store.NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &SnapshotSink{dir: dir, store: NewStoreSink(s)}, nil
+}
+
+// Write 保存包元数据、tarball 和下载统计
+func (x *SnapshotSink) Write(ctx context.Context, result *Result) error {
+	if err := x.store.Write(ctx, result); err != nil {
+		return err
+	}
+	if result.DownloadStats != nil {
+		stats := *result.DownloadStats
+		if stats.Package == "" {
+			stats.Package = result.Name
+		}
+		return registry.WriteSnapshotDownloadStats(x.dir, result.DownloadPeriod, &stats)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/scagogogo/npm-crawler/pkg/tarball"
+)
+
+// FileStatus 表示文件的变化类型
+type FileStatus string
+
+const (
+	FileAdded    FileStatus = "added"
+	FileRemoved  FileStatus = "removed"
+	FileModified FileStatus = "modified"
+)
+
+// binarySniffSize 是判断二进制文件时检查的字节数，与 git 相同
+const binarySniffSize = 8000
+
+// FileDiff 表示一个文件在两个版本之间的变化
+//
+// 主要字段说明:
+//   - Path: 相对于包根目录的路径
+//   - Status: 变化类型
+//   - Binary: 新旧内容中任意一个是二进制文件，此时不生成文本差异
+//   - TooLarge: 文件超过 Options.MaxTextSize，此时不生成文本差异
+//   - OldSize / NewSize: 新旧文件大小（字节），不存在时为 0
+//   - SizeDelta: NewSize - OldSize
+//   - OldMode / NewMode: 新旧文件权限，不存在时为 0
+//   - Additions / Deletions: 新增和删除的行数
+//   - Hunks: 统一格式的变更块，以 "@@" 开头，不包含文件头
+type FileDiff struct {
+	Path      string      `json:"path"`
+	Status    FileStatus  `json:"status"`
+	Binary    bool        `json:"binary,omitempty"`
+	TooLarge  bool        `json:"tooLarge,omitempty"`
+	OldSize   int64       `json:"oldSize"`
+	NewSize   int64       `json:"newSize"`
+	SizeDelta int64       `json:"sizeDelta"`
+	OldMode   fs.FileMode `json:"oldMode,omitempty"`
+	NewMode   fs.FileMode `json:"newMode,omitempty"`
+	Additions int         `json:"additions"`
+	Deletions int         `json:"deletions"`
+	Hunks     string      `json:"hunks,omitempty"`
+}
+
+// Result 表示两个版本的 tarball 之间的差异
+//
+// 主要字段说明:
+//   - Package: 包名称
+//   - From / To: 比较的两个版本
+//   - Files: 发生变化的文件，按路径排序
+//   - SizeDelta: 解压后总大小的变化（字节）
+//   - Additions / Deletions: 所有文件新增和删除的行数之和
+type Result struct {
+	Package   string      `json:"package"`
+	From      string      `json:"from"`
+	To        string      `json:"to"`
+	Files     []*FileDiff `json:"files"`
+	SizeDelta int64       `json:"sizeDelta"`
+	Additions int         `json:"additions"`
+	Deletions int         `json:"deletions"`
+}
+
+// File 根据路径查找文件的变化，没有变化时返回 nil
+func (x *Result) File(path string) *FileDiff {
+	for _, file := range x.Files {
+		if file.Path == path {
+			return file
+		}
+	}
+	return nil
+}
+
+// Unified 返回 git diff 风格的统一格式差异文本
+func (x *Result) Unified() string {
+	var buf bytes.Buffer
+	_ = x.WriteUnified(&buf)
+	return buf.String()
+}
+
+// WriteUnified 将 git diff 风格的统一格式差异写入 w
+//
+// 使用示例:
+//
+//	result, _ := differ.DiffVersions(ctx, "left-pad", "1.2.0", "1.3.0")
+//	result.WriteUnified(os.Stdout)
+func (x *Result) WriteUnified(w io.Writer) error {
+	for _, file := range x.Files {
+		var sb strings.Builder
+		oldName, newName := "a/"+file.Path, "b/"+file.Path
+		fmt.Fprintf(&sb, "diff --git %s %s\n", oldName, newName)
+		switch file.Status {
+		case FileAdded:
+			fmt.Fprintf(&sb, "new file mode %o\n", modeBits(file.NewMode))
+			oldName = "/dev/null"
+		case FileRemoved:
+			fmt.Fprintf(&sb, "deleted file mode %o\n", modeBits(file.OldMode))
+			newName = "/dev/null"
+		default:
+			if file.OldMode != file.NewMode {
+				fmt.Fprintf(&sb, "old mode %o\nnew mode %o\n", modeBits(file.OldMode), modeBits(file.NewMode))
+			}
+		}
+		switch {
+		case file.Binary:
+			fmt.Fprintf(&sb, "Binary files %s and %s differ\n", oldName, newName)
+		case file.TooLarge:
+			fmt.Fprintf(&sb, "Files %s and %s differ (%d -> %d bytes, too large to diff)\n", oldName, newName, file.OldSize, file.NewSize)
+		case file.Hunks != "":
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n%s", oldName, newName, file.Hunks)
+		}
+		if _, err := io.WriteString(w, sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// modeBits 将文件权限转换为 git 使用的格式，例如 100644、100755、120000
+func modeBits(mode fs.FileMode) uint32 {
+	if mode&fs.ModeSymlink != 0 {
+		return 0120000
+	}
+	return 0100000 | uint32(mode.Perm())
+}
+
+// Compare 比较两个已经读取的 tarball
+//
+// 参数:
+//   - from: 旧版本的 tarball
+//   - to: 新版本的 tarball
+//   - options: 可选的配置选项，如未提供则使用 NewOptions() 的默认配置
+//
+// 返回值:
+//   - *Result: 比较结果，Package、From 和 To 字段为空，由调用方填写
+func Compare(from, to *tarball.Tarball, options ...*Options) *Result {
+	if len(options) == 0 {
+		options = append(options, NewOptions())
+	}
+	opts := options[0]
+
+	oldFiles, newFiles := contents(from), contents(to)
+	paths := make(map[string]bool)
+	for path := range oldFiles {
+		paths[path] = true
+	}
+	for path := range newFiles {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	result := &Result{SizeDelta: to.Size() - from.Size()}
+	for _, path := range sorted {
+		oldFile, inOld := oldFiles[path]
+		newFile, inNew := newFiles[path]
+		if inOld && inNew && oldFile.data == newFile.data && oldFile.mode == newFile.mode {
+			continue
+		}
+		file := &FileDiff{Path: path, Status: FileModified}
+		if !inOld {
+			file.Status = FileAdded
+		} else if !inNew {
+			file.Status = FileRemoved
+		}
+		file.OldSize, file.NewSize = int64(len(oldFile.data)), int64(len(newFile.data))
+		file.SizeDelta = file.NewSize - file.OldSize
+		file.OldMode, file.NewMode = oldFile.mode, newFile.mode
+
+		switch {
+		case isBinary(oldFile.data) || isBinary(newFile.data):
+			file.Binary = oldFile.data != newFile.data
+		case opts.MaxTextSize > 0 && (file.OldSize > opts.MaxTextSize || file.NewSize > opts.MaxTextSize):
+			file.TooLarge = true
+		default:
+			edits := diffLines(splitLines(oldFile.data), splitLines(newFile.data))
+			file.Hunks, file.Additions, file.Deletions = unified(edits, opts.ContextLines)
+		}
+		result.Additions += file.Additions
+		result.Deletions += file.Deletions
+		result.Files = append(result.Files, file)
+	}
+	return result
+}
+
+type fileContent struct {
+	data string
+	mode fs.FileMode
+}
+
+// contents 返回 tarball 中所有文件和符号链接的内容，符号链接的内容为链接目标，与 git 的处理方式相同
+func contents(t *tarball.Tarball) map[string]fileContent {
+	result := make(map[string]fileContent)
+	for _, entry := range t.Entries() {
+		switch entry.Type {
+		case tarball.EntryTypeSymlink:
+			result[entry.Path] = fileContent{data: entry.LinkTarget, mode: fs.ModeSymlink | 0777}
+		case tarball.EntryTypeFile, tarball.EntryTypeHardlink:
+			data, err := t.ReadFile(entry.Path)
+			if err != nil {
+				continue
+			}
+			result[entry.Path] = fileContent{data: string(data), mode: entry.Mode.Perm()}
+		}
+	}
+	return result
+}
+
+// isBinary 判断内容是否是二进制数据：开头部分包含 NUL 字节或不是合法的 UTF-8
+func isBinary(data string) bool {
+	if len(data) > binarySniffSize {
+		data = data[:binarySniffSize]
+		// 截断位置可能在一个多字节字符的中间
+		for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.ValidString(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+	return strings.IndexByte(data, 0) >= 0 || !utf8.ValidString(data)
+}
@@ -0,0 +1,143 @@
+package diff
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/tarball"
+	"github.com/stretchr/testify/assert"
+)
+
+// testFile 用于在测试中构建 tarball，linkname 不为空时表示符号链接
+type testFile struct {
+	name     string
+	body     string
+	mode     int64
+	linkname string
+}
+
+func pack(t *testing.T, files ...testFile) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		header := &tar.Header{Name: "package/" + file.name, Mode: file.mode, Size: int64(len(file.body)), Typeflag: tar.TypeReg}
+		if header.Mode == 0 {
+			header.Mode = 0644
+		}
+		if file.linkname != "" {
+			header.Typeflag, header.Linkname, header.Size = tar.TypeSymlink, file.linkname, 0
+		}
+		assert.Nil(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(file.body))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gz.Close())
+	return buf.Bytes()
+}
+
+func read(t *testing.T, files ...testFile) *tarball.Tarball {
+	tb, err := tarball.Read(bytes.NewReader(pack(t, files...)))
+	assert.Nil(t, err)
+	return tb
+}
+
+func TestCompare(t *testing.T) {
+	from := read(t,
+		testFile{name: "package.json", body: "{\n  \"version\": \"1.0.0\"\n}\n"},
+		testFile{name: "index.js", body: "module.exports = 1\n"},
+		testFile{name: "old.js", body: "gone\n"},
+		testFile{name: "logo.png", body: "\x89PNG\x00\x01"},
+		testFile{name: "cli.js", body: "#!/usr/bin/env node\n"},
+	)
+	to := read(t,
+		testFile{name: "package.json", body: "{\n  \"version\": \"1.0.1\"\n}\n"},
+		testFile{name: "index.js", body: "module.exports = 1\n"},
+		testFile{name: "new.js", body: "fresh\n"},
+		testFile{name: "logo.png", body: "\x89PNG\x00\x02\x03"},
+		testFile{name: "cli.js", body: "#!/usr/bin/env node\n", mode: 0755},
+		testFile{name: "main.js", linkname: "index.js"},
+	)
+
+	result := Compare(from, to)
+	var paths []string
+	for _, file := range result.Files {
+		paths = append(paths, file.Path)
+	}
+	assert.Equal(t, []string{"cli.js", "logo.png", "main.js", "new.js", "old.js", "package.json"}, paths)
+	assert.Equal(t, 3, result.Additions)
+	assert.Equal(t, 2, result.Deletions)
+	assert.Equal(t, to.Size()-from.Size(), result.SizeDelta)
+
+	logo := result.File("logo.png")
+	assert.True(t, logo.Binary)
+	assert.Equal(t, FileModified, logo.Status)
+	assert.Equal(t, int64(1), logo.SizeDelta)
+	assert.Empty(t, logo.Hunks)
+	assert.Equal(t, FileAdded, result.File("new.js").Status)
+	assert.Equal(t, FileRemoved, result.File("old.js").Status)
+	assert.Nil(t, result.File("index.js"))
+
+	assert.Equal(t, `diff --git a/cli.js b/cli.js
+old mode 100644
+new mode 100755
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+diff --git a/main.js b/main.js
+new file mode 120000
+--- /dev/null
++++ b/main.js
+@@ -0,0 +1 @@
++index.js
+\ No newline at end of file
+diff --git a/new.js b/new.js
+new file mode 100644
+--- /dev/null
++++ b/new.js
+@@ -0,0 +1 @@
++fresh
+diff --git a/old.js b/old.js
+deleted file mode 100644
+--- a/old.js
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+diff --git a/package.json b/package.json
+--- a/package.json
++++ b/package.json
+@@ -1,3 +1,3 @@
+ {
+-  "version": "1.0.0"
++  "version": "1.0.1"
+ }
+`, result.Unified())
+
+	data, err := json.Marshal(result)
+	assert.Nil(t, err)
+	var decoded Result
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, result.Files[5].Hunks, decoded.Files[5].Hunks)
+}
+
+func TestCompareTooLarge(t *testing.T) {
+	from := read(t, testFile{name: "bundle.js", body: "var a = 1\n"})
+	to := read(t, testFile{name: "bundle.js", body: "var a = 2\n"})
+	result := Compare(from, to, NewOptions().SetMaxTextSize(5))
+	file := result.File("bundle.js")
+	assert.True(t, file.TooLarge)
+	assert.Empty(t, file.Hunks)
+	assert.Contains(t, result.Unified(), "too large to diff")
+}
+
+func TestIsBinary(t *testing.T) {
+	assert.False(t, isBinary("plain text 中文"))
+	assert.True(t, isBinary("a\x00b"))
+	assert.True(t, isBinary("\xff\xfe"))
+	// 截断位置在多字节字符中间时不应被判断为二进制
+	long := string(bytes.Repeat([]byte("a"), binarySniffSize-1)) + "中"
+	assert.False(t, isBinary(long))
+}
@@ -0,0 +1,102 @@
+package diff
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/scagogogo/npm-crawler/pkg/tarball"
+)
+
+// Differ 比较同一个包两个已发布版本的实际内容
+//
+// 与比较 GitHub 仓库不同，Differ 比较的是 Registry 中发布的 tarball，
+// 能够发现只存在于发布产物中的修改，例如构建后被注入的代码
+type Differ struct {
+	registry *registry.Registry
+	options  *Options
+}
+
+// NewDiffer 创建一个新的版本比较器
+//
+// 参数:
+//   - reg: 用于获取版本元数据和下载 tarball 的 Registry 客户端
+//   - options: 可选的配置选项，如未提供则使用 NewOptions() 的默认配置
+//
+// 返回值:
+//   - *Differ: 新创建的版本比较器
+//
+// 使用示例:
+//
+//	differ := diff.NewDiffer(registry.NewRegistry())
+//	result, err := differ.DiffVersions(ctx, "event-stream", "3.3.5", "3.3.6")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	for _, file := range result.Files {
+//		fmt.Println(file.Status, file.Path, file.SizeDelta)
+//	}
+//	fmt.Print(result.Unified())
+func NewDiffer(reg *registry.Registry, options ...*Options) *Differ {
+	if len(options) == 0 {
+		options = append(options, NewOptions())
+	}
+	return &Differ{
+		registry: reg,
+		options:  options[0],
+	}
+}
+
+// GetOptions 获取当前比较器的配置选项
+func (x *Differ) GetOptions() *Options {
+	return x.options
+}
+
+// DiffVersions 下载同一个包两个版本的 tarball 并比较内容
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - pkg: 包名称
+//   - from: 旧版本号
+//   - to: 新版本号
+//
+// 返回值:
+//   - *Result: 比较结果，可以通过 Unified 渲染为统一格式的差异文本，也可以直接序列化为 JSON
+//   - error: 获取元数据、下载或读取 tarball 失败时返回错误
+func (x *Differ) DiffVersions(ctx context.Context, pkg, from, to string) (*Result, error) {
+	oldTarball, err := x.fetch(ctx, pkg, from)
+	if err != nil {
+		return nil, err
+	}
+	newTarball, err := x.fetch(ctx, pkg, to)
+	if err != nil {
+		return nil, err
+	}
+	result := Compare(oldTarball, newTarball, x.options)
+	result.Package, result.From, result.To = pkg, from, to
+	return result, nil
+}
+
+func (x *Differ) fetch(ctx context.Context, pkg, version string) (*tarball.Tarball, error) {
+	manifest, err := x.registry.GetPackageVersion(ctx, pkg, version)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil || manifest.Name == "" {
+		return nil, fmt.Errorf("%s@%s not found", pkg, version)
+	}
+	var buf bytes.Buffer
+	if err := x.registry.DownloadTarball(ctx, manifest, &buf); err != nil {
+		return nil, err
+	}
+	limits := x.options.Tarball
+	if limits == nil {
+		limits = tarball.NewOptions()
+	}
+	t, err := tarball.Read(&buf, limits)
+	if err != nil {
+		return nil, fmt.Errorf("read tarball of %s@%s: %w", pkg, version, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,48 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffVersions(t *testing.T) {
+	tarballs := map[string][]byte{
+		"1.0.0": pack(t, testFile{name: "index.js", body: "console.log(1)\n"}),
+		"1.1.0": pack(t, testFile{name: "index.js", body: "console.log(2)\n"}),
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/demo/-/") {
+			w.Write(tarballs[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/demo/-/demo-"), ".tgz")])
+			return
+		}
+		version := strings.TrimPrefix(r.URL.Path, "/demo/")
+		if _, ok := tarballs[version]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "Not found"}`))
+			return
+		}
+		fmt.Fprintf(w, `{"name": "demo", "version": %q, "dist": {"tarball": "%s/demo/-/demo-%s.tgz"}}`, version, server.URL, version)
+	}))
+	defer server.Close()
+
+	differ := NewDiffer(registry.NewRegistry(registry.NewOptions().SetRegistryURL(server.URL)))
+	assert.Equal(t, DefaultContextLines, differ.GetOptions().ContextLines)
+	result, err := differ.DiffVersions(context.Background(), "demo", "1.0.0", "1.1.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "demo", result.Package)
+	assert.Equal(t, "1.0.0", result.From)
+	assert.Equal(t, "1.1.0", result.To)
+	assert.Len(t, result.Files, 1)
+	assert.Equal(t, "@@ -1 +1 @@\n-console.log(1)\n+console.log(2)\n", result.Files[0].Hunks)
+
+	_, err = differ.DiffVersions(context.Background(), "demo", "1.0.0", "9.9.9")
+	assert.NotNil(t, err)
+}
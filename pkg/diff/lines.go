@@ -0,0 +1,235 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxEditDistance 是逐行比较时允许的最大编辑距离，超过后将整个文件视为被替换，避免占用过多内存
+const maxEditDistance = 2000
+
+// opKind 表示编辑脚本中一行的操作
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+// edit 表示编辑脚本中的一行
+type edit struct {
+	kind opKind
+	line string
+}
+
+// splitLines 将文本拆分为行，每行保留结尾的换行符，最后一行可能没有换行符
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines 使用 Myers 算法计算从 a 到 b 的最短编辑脚本
+func diffLines(a, b []string) []edit {
+	// 去掉相同的前缀和后缀，大多数修改只涉及文件的一小部分
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []edit
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{opEqual, line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{opEqual, line})
+	}
+	return edits
+}
+
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(a, b)
+	}
+	limit := n + m
+	if limit > maxEditDistance {
+		limit = maxEditDistance
+	}
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				snapshot := make([]int, len(v))
+				copy(snapshot, v)
+				trace = append(trace, snapshot)
+				return backtrack(trace, a, b, offset)
+			}
+		}
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+	}
+	return replaceAll(a, b)
+}
+
+// backtrack 根据每一步保存的状态还原编辑脚本
+func backtrack(trace [][]int, a, b []string, offset int) []edit {
+	// get 返回第 d 步结束时对角线 k 上到达的最远 x
+	get := func(d, k int) int {
+		if d == len(trace)-1 {
+			return trace[d][offset+k]
+		}
+		return trace[d][k+d]
+	}
+
+	x, y := len(a), len(b)
+	var reversed []edit
+	for d := len(trace) - 1; d > 0; d-- {
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(d-1, k-1) < get(d-1, k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := get(d-1, prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, edit{opEqual, a[x]})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, edit{opInsert, b[y]})
+		} else {
+			x--
+			reversed = append(reversed, edit{opDelete, a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, edit{opEqual, a[x]})
+	}
+
+	edits := make([]edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
+
+func replaceAll(a, b []string) []edit {
+	edits := make([]edit, 0, len(a)+len(b))
+	for _, line := range a {
+		edits = append(edits, edit{opDelete, line})
+	}
+	for _, line := range b {
+		edits = append(edits, edit{opInsert, line})
+	}
+	return edits
+}
+
+// unified 将编辑脚本格式化为统一格式的变更块，返回变更块文本以及新增和删除的行数
+func unified(edits []edit, context int) (string, int, int) {
+	if context < 0 {
+		context = 0
+	}
+	var sb strings.Builder
+	additions, deletions := 0, 0
+	for _, e := range edits {
+		switch e.kind {
+		case opInsert:
+			additions++
+		case opDelete:
+			deletions++
+		}
+	}
+
+	// oldLine 和 newLine 记录每个编辑之前的行号（从 0 开始）
+	oldLine := make([]int, len(edits)+1)
+	newLine := make([]int, len(edits)+1)
+	for i, e := range edits {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if e.kind != opInsert {
+			oldLine[i+1]++
+		}
+		if e.kind != opDelete {
+			newLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(edits); {
+		if edits[i].kind == opEqual {
+			i++
+			continue
+		}
+		// 找到变更块的范围，两处修改之间的相同行不超过 2*context 时合并为一个变更块
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].kind != opEqual {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		stop := end + context
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+
+		oldCount, newCount := oldLine[stop]-oldLine[start], newLine[stop]-newLine[start]
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(oldLine[start], oldCount), hunkRange(newLine[start], newCount))
+		for _, e := range edits[start:stop] {
+			sb.WriteByte(byte(e.kind))
+			sb.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = stop
+	}
+	return sb.String(), additions, deletions
+}
+
+// hunkRange 格式化变更块头中的行号范围，与 diff -u 的格式相同
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,91 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitLines(t *testing.T) {
+	assert.Nil(t, splitLines(""))
+	assert.Equal(t, []string{"a\n", "b"}, splitLines("a\nb"))
+	assert.Equal(t, []string{"a\n", "\n"}, splitLines("a\n\n"))
+}
+
+func TestUnified(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	new := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13"
+	hunks, additions, deletions := unified(diffLines(splitLines(old), splitLines(new)), 3)
+	assert.Equal(t, 2, additions)
+	assert.Equal(t, 1, deletions)
+	assert.Equal(t, `@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+\ No newline at end of file
+`, hunks)
+
+	// 两处修改之间的相同行不超过 2*context 时合并为一个变更块
+	hunks, _, _ = unified(diffLines(splitLines("a\nb\nc\nd\n"), splitLines("A\nb\nc\nD\n")), 1)
+	assert.Equal(t, "@@ -1,4 +1,4 @@\n-a\n+A\n b\n c\n-d\n+D\n", hunks)
+
+	// 新增文件
+	hunks, _, _ = unified(diffLines(nil, splitLines("x\n")), 3)
+	assert.Equal(t, "@@ -0,0 +1 @@\n+x\n", hunks)
+
+	hunks, _, _ = unified(diffLines(splitLines("same\n"), splitLines("same\n")), 3)
+	assert.Empty(t, hunks)
+}
+
+// apply 将编辑脚本应用到 a 上，用于验证编辑脚本的正确性
+func apply(edits []edit) (string, string) {
+	var a, b strings.Builder
+	for _, e := range edits {
+		if e.kind != opInsert {
+			a.WriteString(e.line)
+		}
+		if e.kind != opDelete {
+			b.WriteString(e.line)
+		}
+	}
+	return a.String(), b.String()
+}
+
+func TestDiffLinesRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randomText := func() string {
+		var sb strings.Builder
+		for i := r.Intn(40); i > 0; i-- {
+			sb.WriteString(string(rune('a'+r.Intn(4))) + "\n")
+		}
+		return sb.String()
+	}
+	for i := 0; i < 200; i++ {
+		a, b := randomText(), randomText()
+		edits := diffLines(splitLines(a), splitLines(b))
+		gotA, gotB := apply(edits)
+		assert.Equal(t, a, gotA)
+		assert.Equal(t, b, gotB)
+	}
+
+	// 超过最大编辑距离时退化为整体替换，结果仍然正确
+	var a, b strings.Builder
+	for i := 0; i < maxEditDistance; i++ {
+		a.WriteString("a\n")
+		b.WriteString("b\n")
+	}
+	gotA, gotB := apply(diffLines(splitLines(a.String()), splitLines(b.String())))
+	assert.Equal(t, a.String(), gotA)
+	assert.Equal(t, b.String(), gotB)
+}
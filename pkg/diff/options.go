@@ -0,0 +1,62 @@
+package diff
+
+import (
+	"github.com/scagogogo/npm-crawler/pkg/tarball"
+)
+
+const (
+	// DefaultContextLines 是统一格式差异中每个变更块前后保留的上下文行数
+	DefaultContextLines = 3
+
+	// DefaultMaxTextSize 是生成文本差异的最大文件大小，1 MiB
+	DefaultMaxTextSize int64 = 1 << 20
+)
+
+// Options 表示版本比较的配置选项
+//
+// 包含字段:
+//   - ContextLines: 每个变更块前后保留的上下文行数
+//   - MaxTextSize: 新旧文件任意一个超过该大小（字节）时不生成文本差异，只记录大小变化，小于等于 0 时不限制
+//   - Tarball: 读取 tarball 时的安全限制
+//
+// 使用示例:
+//
+//	options := NewOptions().SetContextLines(5)
+//	differ := NewDiffer(registry.NewRegistry(), options)
+type Options struct {
+	ContextLines int
+	MaxTextSize  int64
+	Tarball      *tarball.Options
+}
+
+// NewOptions 创建并返回默认的配置选项
+//
+// 默认配置:
+//   - ContextLines: 3，与 git diff 相同
+//   - MaxTextSize: 1 MiB
+//   - Tarball: tarball.NewOptions() 的默认限制
+func NewOptions() *Options {
+	return &Options{
+		ContextLines: DefaultContextLines,
+		MaxTextSize:  DefaultMaxTextSize,
+		Tarball:      tarball.NewOptions(),
+	}
+}
+
+// SetContextLines 设置每个变更块前后保留的上下文行数
+func (o *Options) SetContextLines(contextLines int) *Options {
+	o.ContextLines = contextLines
+	return o
+}
+
+// SetMaxTextSize 设置生成文本差异的最大文件大小
+func (o *Options) SetMaxTextSize(maxTextSize int64) *Options {
+	o.MaxTextSize = maxTextSize
+	return o
+}
+
+// SetTarball 设置读取 tarball 时的安全限制
+func (o *Options) SetTarball(options *tarball.Options) *Options {
+	o.Tarball = options
+	return o
+}
@@ -0,0 +1,396 @@
+package layout
+
+import (
+	"container/heap"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+)
+
+// Placement 表示 node_modules 树中的一个目录，即某个包被实际安装到的位置
+//
+// 主要字段说明:
+//   - Name: 目录名称，别名依赖时为别名，例如 "string-width-cjs"
+//   - Path: 相对于项目根目录的路径，例如 "node_modules/a/node_modules/b"，根节点为空字符串
+//   - Node: 安装在该位置的包，根节点为 nil
+//   - Parent: 父目录，根节点为 nil
+//   - Children: 该目录下 node_modules 中的包，键为目录名称
+//   - Edges: 解析到该位置的所有依赖声明
+//   - Dev: 只被开发依赖引用，生产环境安装时不需要
+//   - Optional: 只被可选依赖引用，安装失败不影响整体安装
+//   - DevOptional: 只被开发依赖或可选依赖引用，但不满足单独的 Dev 或 Optional
+//   - Peer: 只被同级依赖引用
+type Placement struct {
+	Name        string
+	Path        string
+	Node        *resolver.Node
+	Parent      *Placement
+	Children    map[string]*Placement
+	Edges       []*resolver.Edge
+	Dev         bool
+	Optional    bool
+	DevOptional bool
+	Peer        bool
+
+	depth     int
+	processed bool
+}
+
+// IsRoot 判断是否是项目根目录
+func (x *Placement) IsRoot() bool {
+	return x.Parent == nil
+}
+
+// Depth 返回嵌套深度，项目根目录为 0，顶层 node_modules 中的包为 1
+func (x *Placement) Depth() int {
+	return x.depth
+}
+
+// Resolve 按照 Node.js 的模块查找规则，返回从该位置 require(name) 会找到的包
+//
+// 查找顺序为当前目录的 node_modules、父目录的 node_modules，一直到项目根目录
+//
+// 返回值:
+//   - *Placement: 找到的包的位置，找不到时返回 nil
+func (x *Placement) Resolve(name string) *Placement {
+	for cur := x; cur != nil; cur = cur.Parent {
+		if child, ok := cur.Children[name]; ok {
+			return child
+		}
+	}
+	return nil
+}
+
+// SortedChildren 返回按名称排序后的子目录
+func (x *Placement) SortedChildren() []*Placement {
+	children := make([]*Placement, 0, len(x.Children))
+	for _, child := range x.Children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Name < children[j].Name
+	})
+	return children
+}
+
+// Conflict 表示无法满足的同级依赖放置，对应 npm 的 ERESOLVE 警告
+//
+// 主要字段说明:
+//   - Edge: 无法被放置为同级的同级依赖声明
+//   - Dependent: 声明该同级依赖的包所在的位置
+//   - Existing: 父目录中已经存在的冲突版本
+//   - Placement: 最终被嵌套放置的位置
+type Conflict struct {
+	Edge      *resolver.Edge
+	Dependent *Placement
+	Existing  *Placement
+	Placement *Placement
+}
+
+// Tree 表示 npm v7 及以上版本会生成的 node_modules 目录树
+type Tree struct {
+	Root      *Placement
+	Conflicts []*Conflict
+}
+
+// Plan 根据依赖图计算 node_modules 的目录结构
+//
+// 放置规则与 npm v7 及以上版本（arborist）保持一致:
+//   - 按照深度由浅到深、路径字典序的顺序逐层处理依赖
+//   - 每个依赖被尽可能提升到更高的目录，直到遇到同名的不同版本为止
+//   - 提升不能改变已经放置的其他包的解析结果，否则嵌套放置在更深的目录中
+//   - 如果从依赖方可以找到满足要求的相同版本，则直接复用，不会重复放置
+//   - 同级依赖被放置为依赖方的兄弟节点（或更高），无法满足时嵌套放置并记录冲突
+//
+// 参数:
+//   - graph: 解析器生成的依赖图
+//
+// 返回值:
+//   - *Tree: 计算得到的目录树
+//
+// 使用示例:
+//
+//	graph, _ := r.Resolve(ctx, "express@^4")
+//	tree := layout.Plan(graph)
+//	for _, p := range tree.Placements() {
+//		fmt.Println(p.Path, p.Node.Version)
+//	}
+//	fmt.Println("安装大小:", tree.Stats().InstallSize)
+func Plan(graph *resolver.Graph) *Tree {
+	root := &Placement{Children: make(map[string]*Placement)}
+	p := &planner{
+		graph:      graph,
+		tree:       &Tree{Root: root},
+		dependents: make(map[string][]*Placement),
+	}
+	heap.Push(&p.queue, root)
+	for p.queue.Len() > 0 {
+		p.process(heap.Pop(&p.queue).(*Placement))
+	}
+	p.calcFlags()
+	return p.tree
+}
+
+// Placements 返回除根目录之外的所有位置，按路径排序
+func (x *Tree) Placements() []*Placement {
+	var result []*Placement
+	x.Walk(func(p *Placement) {
+		if !p.IsRoot() {
+			result = append(result, p)
+		}
+	})
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// Walk 按照深度优先、名称字典序遍历目录树（包括根目录）
+func (x *Tree) Walk(fn func(p *Placement)) {
+	var walk func(p *Placement)
+	walk = func(p *Placement) {
+		fn(p)
+		for _, child := range p.SortedChildren() {
+			walk(child)
+		}
+	}
+	walk(x.Root)
+}
+
+// Find 根据路径查找位置，例如 "node_modules/a/node_modules/b"，空字符串返回根目录
+func (x *Tree) Find(path string) *Placement {
+	if path == "" {
+		return x.Root
+	}
+	cur := x.Root
+	for _, name := range splitPath(path) {
+		child, ok := cur.Children[name]
+		if !ok {
+			return nil
+		}
+		cur = child
+	}
+	return cur
+}
+
+// planner 保存一次布局计算过程中的状态
+type planner struct {
+	graph *resolver.Graph
+	tree  *Tree
+	queue placementQueue
+
+	// dependents 记录已经处理过的、依赖某个名称的位置，用于判断提升是否会影响已有的解析结果
+	dependents map[string][]*Placement
+}
+
+func (x *planner) process(from *Placement) {
+	for _, edge := range sortedEdges(x.edges(from)) {
+		if edge.To == nil {
+			continue
+		}
+		x.dependents[edge.Name] = append(x.dependents[edge.Name], from)
+		x.place(from, edge)
+	}
+	from.processed = true
+}
+
+// place 为一条依赖声明找到合适的位置
+func (x *planner) place(from *Placement, edge *resolver.Edge) {
+	start := from
+	if edge.Type == resolver.DependencyTypePeer && from.Parent != nil {
+		start = from.Parent
+	}
+
+	// 从起始位置向上查找，直到遇到同名的包
+	var chain []*Placement
+	var existing *Placement
+	for cur := start; cur != nil; cur = cur.Parent {
+		if child, ok := cur.Children[edge.Name]; ok {
+			existing = child
+			break
+		}
+		chain = append(chain, cur)
+	}
+	if existing != nil && existing.Node == edge.To {
+		existing.Edges = append(existing.Edges, edge)
+		return
+	}
+
+	// 从最高的候选位置开始尝试，保证尽可能提升
+	for i := len(chain) - 1; i >= 0; i-- {
+		if x.canPlace(chain[i], edge) {
+			x.add(chain[i], edge)
+			return
+		}
+	}
+
+	// 同级依赖与父目录中已有的版本冲突，嵌套放置在依赖方下面
+	if from.Children[edge.Name] == nil {
+		placement := x.add(from, edge)
+		x.tree.Conflicts = append(x.tree.Conflicts, &Conflict{
+			Edge:      edge,
+			Dependent: from,
+			Existing:  existing,
+			Placement: placement,
+		})
+	}
+}
+
+// canPlace 判断将依赖放置在 target 目录下是否会改变已经处理过的包的解析结果
+func (x *planner) canPlace(target *Placement, edge *resolver.Edge) bool {
+	for _, dependent := range x.dependents[edge.Name] {
+		if !dependent.processed || !isAncestorOrSelf(target, dependent) {
+			continue
+		}
+		shielded := false
+		for cur := dependent; cur != target; cur = cur.Parent {
+			if _, ok := cur.Children[edge.Name]; ok {
+				shielded = true
+				break
+			}
+		}
+		if shielded {
+			continue
+		}
+		for _, dependency := range x.edges(dependent) {
+			if dependency.Name == edge.Name && dependency.To != nil && dependency.To != edge.To {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// calcFlags 计算每个位置的 dev、optional、devOptional 和 peer 标记，算法与 npm 的 calcDepFlags 相同
+//
+// 所有包的标记初始都为 true，从根目录出发沿依赖关系传播：只要存在一条不经过开发依赖的路径，
+// 该包就不是开发依赖，可选依赖和同级依赖同理
+func (x *planner) calcFlags() {
+	x.tree.Walk(func(p *Placement) {
+		if !p.IsRoot() {
+			p.Dev, p.Optional, p.DevOptional, p.Peer = true, true, true, true
+		}
+	})
+
+	queue := []*Placement{x.tree.Root}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		for _, edge := range x.edges(from) {
+			if edge.To == nil {
+				continue
+			}
+			to := from.Resolve(edge.Name)
+			if to == nil {
+				continue
+			}
+			dev := edge.Type == resolver.DependencyTypeDev
+			optional := edge.Type == resolver.DependencyTypeOptional
+			peer := edge.Type == resolver.DependencyTypePeer
+
+			unsetDevOptional := !from.DevOptional && !from.Dev && !from.Optional && !dev && !optional
+			unsetDev := unsetDevOptional || !from.Dev && !dev
+			unsetOptional := unsetDevOptional || !from.Optional && !optional
+			unsetPeer := !from.Peer && !peer
+
+			changed := false
+			if unsetPeer && to.Peer {
+				to.Peer, changed = false, true
+			}
+			if unsetDevOptional && to.DevOptional {
+				to.DevOptional, changed = false, true
+			}
+			if unsetDev && to.Dev {
+				to.Dev, changed = false, true
+			}
+			if unsetOptional && to.Optional {
+				to.Optional, changed = false, true
+			}
+			if changed {
+				queue = append(queue, to)
+			}
+		}
+	}
+}
+
+// edges 返回位置上的包声明的依赖，根目录返回依赖图的根依赖
+func (x *planner) edges(p *Placement) []*resolver.Edge {
+	if p.Node == nil {
+		return x.graph.Roots
+	}
+	return p.Node.Dependencies
+}
+
+func (x *planner) add(parent *Placement, edge *resolver.Edge) *Placement {
+	path := "node_modules/" + edge.Name
+	if parent.Path != "" {
+		path = parent.Path + "/" + path
+	}
+	placement := &Placement{
+		Name:     edge.Name,
+		Path:     path,
+		Node:     edge.To,
+		Parent:   parent,
+		Children: make(map[string]*Placement),
+		Edges:    []*resolver.Edge{edge},
+		depth:    parent.depth + 1,
+	}
+	parent.Children[edge.Name] = placement
+	heap.Push(&x.queue, placement)
+	return placement
+}
+
+func isAncestorOrSelf(ancestor, p *Placement) bool {
+	for cur := p; cur != nil; cur = cur.Parent {
+		if cur == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedEdges(edges []*resolver.Edge) []*resolver.Edge {
+	sorted := make([]*resolver.Edge, len(edges))
+	copy(sorted, edges)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// splitPath 将 "node_modules/a/node_modules/@scope/b" 拆分为 ["a", "@scope/b"]
+func splitPath(path string) []string {
+	var names []string
+	for _, part := range strings.Split(path, "node_modules/") {
+		part = strings.TrimSuffix(part, "/")
+		if part != "" {
+			names = append(names, part)
+		}
+	}
+	return names
+}
+
+// placementQueue 是按照深度和路径排序的优先队列
+type placementQueue []*Placement
+
+func (q placementQueue) Len() int { return len(q) }
+
+func (q placementQueue) Less(i, j int) bool {
+	if q[i].depth != q[j].depth {
+		return q[i].depth < q[j].depth
+	}
+	return q[i].Path < q[j].Path
+}
+
+func (q placementQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *placementQueue) Push(x any) { *q = append(*q, x.(*Placement)) }
+
+func (q *placementQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
@@ -0,0 +1,219 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+// graphBuilder 用于在测试中手动构建依赖图
+type graphBuilder struct {
+	graph *resolver.Graph
+}
+
+func newGraphBuilder() *graphBuilder {
+	return &graphBuilder{graph: resolver.NewGraph()}
+}
+
+func (x *graphBuilder) node(name, version string, size int64) *resolver.Node {
+	if node := x.graph.Node(name, version); node != nil {
+		return node
+	}
+	node := &resolver.Node{
+		Name:    name,
+		Version: version,
+		Manifest: &models.Version{
+			Name:    name,
+			Version: version,
+			Dist:    &models.Dist{UnpackedSize: size, FileCount: 1},
+		},
+	}
+	x.graph.Nodes[node.ID()] = node
+	return node
+}
+
+func (x *graphBuilder) root(to *resolver.Node) {
+	x.graph.Roots = append(x.graph.Roots, &resolver.Edge{Name: to.Name, Spec: to.Version, Type: resolver.DependencyTypeProd, To: to})
+}
+
+func (x *graphBuilder) dep(from, to *resolver.Node, depType resolver.DependencyType) {
+	from.Dependencies = append(from.Dependencies, &resolver.Edge{From: from, Name: to.Name, Spec: to.Version, Type: depType, To: to})
+}
+
+func paths(tree *Tree) map[string]string {
+	result := make(map[string]string)
+	for _, p := range tree.Placements() {
+		result[p.Path] = p.Node.Version
+	}
+	return result
+}
+
+func TestPlanHoisting(t *testing.T) {
+	b := newGraphBuilder()
+	a1, b1 := b.node("a", "1.0.0", 100), b.node("b", "1.0.0", 100)
+	c1, c2 := b.node("c", "1.0.0", 10), b.node("c", "2.0.0", 20)
+	b.root(a1)
+	b.root(b1)
+	b.dep(a1, c1, resolver.DependencyTypeProd)
+	b.dep(b1, c2, resolver.DependencyTypeProd)
+
+	tree := Plan(b.graph)
+	assert.Equal(t, map[string]string{
+		"node_modules/a":                "1.0.0",
+		"node_modules/b":                "1.0.0",
+		"node_modules/c":                "1.0.0",
+		"node_modules/b/node_modules/c": "2.0.0",
+	}, paths(tree))
+	assert.Empty(t, tree.Conflicts)
+
+	nested := tree.Find("node_modules/b/node_modules/c")
+	assert.NotNil(t, nested)
+	assert.Equal(t, 2, nested.Depth())
+	assert.Equal(t, tree.Find("node_modules/b"), nested.Parent)
+	assert.Equal(t, c2, tree.Find("node_modules/b").Resolve("c").Node)
+	assert.Equal(t, c1, tree.Find("node_modules/a").Resolve("c").Node)
+	assert.Nil(t, tree.Find("node_modules/x"))
+	assert.Equal(t, tree.Root, tree.Find(""))
+
+	stats := tree.Stats()
+	assert.Equal(t, 4, stats.Packages)
+	assert.Equal(t, 4, stats.UniquePackages)
+	assert.Equal(t, 1, stats.DuplicatedPackages)
+	assert.Equal(t, int64(230), stats.InstallSize)
+	assert.Equal(t, 4, stats.FileCount)
+	assert.Equal(t, map[string][]string{"c": {"node_modules/b/node_modules/c", "node_modules/c"}}, tree.Duplicates())
+	assert.Equal(t, []string{"1.0.0", "2.0.0"}, tree.Versions()["c"])
+}
+
+func TestPlanDeduplication(t *testing.T) {
+	b := newGraphBuilder()
+	a1, b1, shared := b.node("a", "1.0.0", 1), b.node("b", "1.0.0", 1), b.node("shared", "1.0.0", 1)
+	b.root(a1)
+	b.root(b1)
+	b.dep(a1, shared, resolver.DependencyTypeProd)
+	b.dep(b1, shared, resolver.DependencyTypeProd)
+	// 循环依赖
+	b.dep(shared, a1, resolver.DependencyTypeProd)
+
+	tree := Plan(b.graph)
+	assert.Len(t, tree.Placements(), 3)
+	assert.Len(t, tree.Find("node_modules/shared").Edges, 2)
+	assert.Len(t, tree.Find("node_modules/a").Edges, 2)
+	assert.Empty(t, tree.Duplicates())
+}
+
+func TestPlanDoesNotShadowResolvedDependents(t *testing.T) {
+	b := newGraphBuilder()
+	a1 := b.node("a", "1.0.0", 1)
+	b1, b2 := b.node("b", "1.0.0", 1), b.node("b", "2.0.0", 1)
+	c1, c2 := b.node("c", "1.0.0", 1), b.node("c", "2.0.0", 1)
+	d1, d2 := b.node("d", "1.0.0", 1), b.node("d", "2.0.0", 1)
+	b.root(a1)
+	b.root(b2)
+	b.root(c2)
+	b.root(d1)
+	b.dep(a1, b1, resolver.DependencyTypeProd)
+	b.dep(a1, c1, resolver.DependencyTypeProd)
+	b.dep(b1, d1, resolver.DependencyTypeProd)
+	b.dep(c1, d2, resolver.DependencyTypeProd)
+
+	tree := Plan(b.graph)
+	// d@2 不能被提升到 node_modules/a 下，否则会改变 a/node_modules/b 对 d 的解析结果
+	assert.Equal(t, map[string]string{
+		"node_modules/a":                               "1.0.0",
+		"node_modules/b":                               "2.0.0",
+		"node_modules/c":                               "2.0.0",
+		"node_modules/d":                               "1.0.0",
+		"node_modules/a/node_modules/b":                "1.0.0",
+		"node_modules/a/node_modules/c":                "1.0.0",
+		"node_modules/a/node_modules/c/node_modules/d": "2.0.0",
+	}, paths(tree))
+	assert.Equal(t, d1, tree.Find("node_modules/a/node_modules/b").Resolve("d").Node)
+}
+
+func TestPlanPeerDependencies(t *testing.T) {
+	b := newGraphBuilder()
+	plugin, host1, host2 := b.node("plugin", "1.0.0", 1), b.node("host", "1.0.0", 1), b.node("host", "2.0.0", 1)
+	wrapper, lib := b.node("wrapper", "1.0.0", 1), b.node("lib", "1.0.0", 1)
+	inner := b.node("inner", "1.0.0", 1)
+	b.root(plugin)
+	b.root(host2)
+	b.root(wrapper)
+	b.dep(plugin, host1, resolver.DependencyTypePeer)
+	b.dep(wrapper, inner, resolver.DependencyTypeProd)
+	b.dep(inner, lib, resolver.DependencyTypePeer)
+
+	tree := Plan(b.graph)
+	assert.Equal(t, map[string]string{
+		"node_modules/host":                     "2.0.0",
+		"node_modules/inner":                    "1.0.0",
+		"node_modules/lib":                      "1.0.0",
+		"node_modules/plugin":                   "1.0.0",
+		"node_modules/plugin/node_modules/host": "1.0.0",
+		"node_modules/wrapper":                  "1.0.0",
+	}, paths(tree))
+
+	assert.Len(t, tree.Conflicts, 1)
+	conflict := tree.Conflicts[0]
+	assert.Equal(t, "host", conflict.Edge.Name)
+	assert.Equal(t, "node_modules/plugin", conflict.Dependent.Path)
+	assert.Equal(t, host2, conflict.Existing.Node)
+	assert.Equal(t, "node_modules/plugin/node_modules/host", conflict.Placement.Path)
+}
+
+func TestPlanAliasAndUnresolved(t *testing.T) {
+	b := newGraphBuilder()
+	app, sw := b.node("app", "1.0.0", 1), b.node("string-width", "4.2.3", 1)
+	b.root(app)
+	app.Dependencies = append(app.Dependencies,
+		&resolver.Edge{From: app, Name: "string-width-cjs", Spec: "npm:string-width@^4", Type: resolver.DependencyTypeProd, To: sw},
+		&resolver.Edge{From: app, Name: "fsevents", Spec: "^2", Type: resolver.DependencyTypeOptional},
+	)
+
+	tree := Plan(b.graph)
+	alias := tree.Find("node_modules/string-width-cjs")
+	assert.NotNil(t, alias)
+	assert.Equal(t, "string-width-cjs", alias.Name)
+	assert.Equal(t, "string-width", alias.Node.Name)
+	assert.Nil(t, tree.Find("node_modules/fsevents"))
+
+	// 缺少大小信息
+	sw.Manifest.Dist = nil
+	assert.Equal(t, 1, Plan(b.graph).Stats().UnknownSize)
+}
+
+func TestSplitPath(t *testing.T) {
+	assert.Equal(t, []string{"a", "@scope/b"}, splitPath("node_modules/a/node_modules/@scope/b"))
+	assert.Nil(t, splitPath(""))
+}
+
+func TestPlanFlags(t *testing.T) {
+	b := newGraphBuilder()
+	app, test := b.node("app", "1.0.0", 1), b.node("test", "1.0.0", 1)
+	shared, devOnly := b.node("shared", "1.0.0", 1), b.node("dev-only", "1.0.0", 1)
+	opt, optDev := b.node("opt", "1.0.0", 1), b.node("opt-dev", "1.0.0", 1)
+	peer := b.node("peer", "1.0.0", 1)
+	b.root(app)
+	b.graph.Roots = append(b.graph.Roots, &resolver.Edge{Name: "test", Spec: "1.0.0", Type: resolver.DependencyTypeDev, To: test})
+	b.dep(app, shared, resolver.DependencyTypeProd)
+	b.dep(app, opt, resolver.DependencyTypeOptional)
+	b.dep(app, peer, resolver.DependencyTypePeer)
+	b.dep(test, shared, resolver.DependencyTypeProd)
+	b.dep(test, devOnly, resolver.DependencyTypeProd)
+	b.dep(test, optDev, resolver.DependencyTypeOptional)
+
+	tree := Plan(b.graph)
+	flags := func(path string) [4]bool {
+		p := tree.Find(path)
+		return [4]bool{p.Dev, p.Optional, p.DevOptional, p.Peer}
+	}
+	assert.Equal(t, [4]bool{false, false, false, false}, flags("node_modules/app"))
+	assert.Equal(t, [4]bool{false, false, false, false}, flags("node_modules/shared"))
+	assert.Equal(t, [4]bool{true, false, true, false}, flags("node_modules/test"))
+	assert.Equal(t, [4]bool{true, false, true, false}, flags("node_modules/dev-only"))
+	assert.Equal(t, [4]bool{false, true, true, false}, flags("node_modules/opt"))
+	assert.Equal(t, [4]bool{true, true, true, false}, flags("node_modules/opt-dev"))
+	assert.Equal(t, [4]bool{false, false, false, true}, flags("node_modules/peer"))
+}
@@ -0,0 +1,85 @@
+package layout
+
+import (
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// Stats 表示 node_modules 目录树的统计信息
+//
+// 主要字段说明:
+//   - Packages: 实际安装的包副本数量，同一个版本被嵌套安装在多个位置时会被重复计算
+//   - UniquePackages: 不同的 "name@version" 数量
+//   - DuplicatedPackages: 安装了多个副本的包名数量，无论这些副本是否是同一个版本
+//   - InstallSize: 所有包副本解压后的总大小（字节），来自 dist.unpackedSize
+//   - FileCount: 所有包副本的文件总数，来自 dist.fileCount
+//   - UnknownSize: 缺少 dist.unpackedSize 信息的包副本数量
+type Stats struct {
+	Packages           int   `json:"packages"`
+	UniquePackages     int   `json:"uniquePackages"`
+	DuplicatedPackages int   `json:"duplicatedPackages"`
+	InstallSize        int64 `json:"installSize"`
+	FileCount          int   `json:"fileCount"`
+	UnknownSize        int   `json:"unknownSize"`
+}
+
+// Stats 计算目录树的统计信息，可用于在不执行 npm install 的情况下估算安装大小
+func (x *Tree) Stats() *Stats {
+	stats := &Stats{}
+	unique := make(map[string]bool)
+	copies := make(map[string]int)
+	for _, p := range x.Placements() {
+		stats.Packages++
+		unique[p.Node.ID()] = true
+		copies[p.Node.Name]++
+		if dist := p.Node.Manifest.Dist; dist != nil && dist.UnpackedSize > 0 {
+			stats.InstallSize += dist.UnpackedSize
+			stats.FileCount += dist.FileCount
+		} else {
+			stats.UnknownSize++
+		}
+	}
+	stats.UniquePackages = len(unique)
+	for _, n := range copies {
+		if n > 1 {
+			stats.DuplicatedPackages++
+		}
+	}
+	return stats
+}
+
+// Duplicates 返回被安装了多个副本的包，键为包名，值为每个副本所在的路径
+//
+// 既包括不同版本的包，也包括同一版本由于提升冲突被嵌套安装多次的情况
+func (x *Tree) Duplicates() map[string][]string {
+	paths := make(map[string][]string)
+	for _, p := range x.Placements() {
+		paths[p.Node.Name] = append(paths[p.Node.Name], p.Path)
+	}
+	for name, list := range paths {
+		if len(list) < 2 {
+			delete(paths, name)
+		}
+	}
+	return paths
+}
+
+// Versions 返回每个包被安装的所有不同版本，版本从小到大排序
+func (x *Tree) Versions() map[string][]string {
+	seen := make(map[string]map[string]bool)
+	for _, p := range x.Placements() {
+		if seen[p.Node.Name] == nil {
+			seen[p.Node.Name] = make(map[string]bool)
+		}
+		seen[p.Node.Name][p.Node.Version] = true
+	}
+	versions := make(map[string][]string, len(seen))
+	for name, set := range seen {
+		list := make([]string, 0, len(set))
+		for version := range set {
+			list = append(list, version)
+		}
+		semver.Sort(list)
+		versions[name] = list
+	}
+	return versions
+}
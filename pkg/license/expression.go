@@ -0,0 +1,410 @@
+package license
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// ErrInvalidExpression 表示许可证字符串不是合法的 SPDX 许可证表达式
+var ErrInvalidExpression = errors.New("invalid license expression")
+
+// Operator 表示 SPDX 许可证表达式中的组合运算符
+type Operator string
+
+const (
+	// OperatorAnd 需要同时遵守所有许可证
+	OperatorAnd Operator = "AND"
+
+	// OperatorOr 可以选择遵守其中任意一个许可证
+	OperatorOr Operator = "OR"
+)
+
+// Expression 表示解析后的 SPDX 许可证表达式
+//
+// Operator 为空时表示单个许可证，否则表示由 Operands 组成的 AND 或 OR 表达式，
+// 相同运算符的嵌套会被展开，例如 "MIT OR (ISC OR 0BSD)" 的 Operands 为三个许可证
+//
+// 主要字段说明:
+//   - Operator: 组合运算符，单个许可证时为空
+//   - Operands: 组合表达式的操作数
+//   - License: 许可证标识符，例如 "MIT"、"LicenseRef-Proprietary"
+//   - OrLater: 许可证标识符后是否带有 "+"，表示该版本或更高版本
+//   - Exception: WITH 之后的许可证例外，例如 "Classpath-exception-2.0"
+type Expression struct {
+	Operator  Operator
+	Operands  []*Expression
+	License   string
+	OrLater   bool
+	Exception string
+}
+
+// String 返回表达式的字符串形式，只在必要时添加括号
+func (x *Expression) String() string {
+	if x.Operator == "" {
+		s := x.License
+		if x.OrLater {
+			s += "+"
+		}
+		if x.Exception != "" {
+			s += " WITH " + x.Exception
+		}
+		return s
+	}
+	parts := make([]string, 0, len(x.Operands))
+	for _, operand := range x.Operands {
+		s := operand.String()
+		// AND 的优先级高于 OR，只有 AND 中的 OR 需要括号
+		if x.Operator == OperatorAnd && operand.Operator == OperatorOr {
+			s = "(" + s + ")"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " "+string(x.Operator)+" ")
+}
+
+// Licenses 返回表达式中出现的所有许可证标识符，去重并排序，不包含 "+" 和例外
+func (x *Expression) Licenses() []string {
+	seen := make(map[string]bool)
+	var walk func(e *Expression)
+	walk = func(e *Expression) {
+		if e.Operator == "" {
+			seen[e.License] = true
+			return
+		}
+		for _, operand := range e.Operands {
+			walk(operand)
+		}
+	}
+	walk(x)
+	licenses := make([]string, 0, len(seen))
+	for license := range seen {
+		licenses = append(licenses, license)
+	}
+	sort.Strings(licenses)
+	return licenses
+}
+
+// IsCompound 判断是否是由 AND、OR 或 WITH 组成的复合表达式
+func (x *Expression) IsCompound() bool {
+	return x.Operator != "" || x.Exception != ""
+}
+
+// Normalize 返回规范化后的表达式副本
+//
+// 许可证和例外标识符会被转换为 SPDX 许可证列表中的大小写，已弃用的标识符会被替换，
+// 例如 "gpl-2.0+" 被替换为 "GPL-2.0-or-later"、"GPL-2.0-with-classpath-exception" 被替换为
+// "GPL-2.0-only WITH Classpath-exception-2.0"，无法识别的标识符保持原样
+func (x *Expression) Normalize() *Expression {
+	if x.Operator != "" {
+		operands := make([]*Expression, 0, len(x.Operands))
+		for _, operand := range x.Operands {
+			operands = append(operands, operand.Normalize())
+		}
+		return combine(x.Operator, operands)
+	}
+
+	e := &Expression{License: x.License, OrLater: x.OrLater, Exception: x.Exception}
+	if e.Exception != "" {
+		if id, ok := exceptions[strings.ToLower(e.Exception)]; ok {
+			e.Exception = id
+		}
+	}
+	if isLicenseRef(e.License) {
+		return e
+	}
+	lower := strings.ToLower(e.License)
+	if e.OrLater {
+		// "GPL-2.0+" 本身就是一个已弃用的标识符
+		if replacement, ok := deprecated[lower+"+"]; ok {
+			return replace(e, replacement, false)
+		}
+	}
+	if replacement, ok := deprecated[lower]; ok {
+		return replace(e, replacement, e.OrLater)
+	}
+	if id, ok := licenses[lower]; ok {
+		e.License = id
+	}
+	return e
+}
+
+// replace 使用已弃用标识符的替代表达式替换单个许可证，保留原有的例外
+func replace(e *Expression, replacement string, orLater bool) *Expression {
+	r, err := Parse(replacement)
+	if err != nil || r.Operator != "" {
+		return e
+	}
+	if e.Exception != "" {
+		r.Exception = e.Exception
+	}
+	if orLater && strings.HasSuffix(r.License, "-only") {
+		r.License = strings.TrimSuffix(r.License, "-only") + "-or-later"
+	} else if orLater && !strings.HasSuffix(r.License, "-or-later") {
+		r.OrLater = true
+	}
+	return r
+}
+
+// combine 创建组合表达式，展开相同运算符的嵌套
+func combine(op Operator, operands []*Expression) *Expression {
+	if len(operands) == 1 {
+		return operands[0]
+	}
+	e := &Expression{Operator: op}
+	for _, operand := range operands {
+		if operand.Operator == op {
+			e.Operands = append(e.Operands, operand.Operands...)
+		} else {
+			e.Operands = append(e.Operands, operand)
+		}
+	}
+	return e
+}
+
+// isLicenseRef 判断是否是自定义的许可证引用，例如 "LicenseRef-Proprietary" 或 "DocumentRef-spdx:LicenseRef-X"
+func isLicenseRef(id string) bool {
+	if strings.HasPrefix(id, "DocumentRef-") {
+		doc, ref, ok := strings.Cut(id, ":")
+		if !ok || !isIdentifier(strings.TrimPrefix(doc, "DocumentRef-")) {
+			return false
+		}
+		id = ref
+	}
+	return strings.HasPrefix(id, "LicenseRef-") && isIdentifier(strings.TrimPrefix(id, "LicenseRef-"))
+}
+
+// isIdentifier 判断是否只包含 SPDX 标识符允许的字符
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Parse 解析 SPDX 许可证表达式
+//
+// 运算符的优先级从高到低依次为 WITH、AND、OR，运算符大小写不敏感，
+// 许可证标识符只检查语法，不要求出现在 SPDX 许可证列表中，也不会被规范化，需要时调用 Expression.Normalize
+//
+// 参数:
+//   - s: 许可证表达式，例如 "(MIT OR Apache-2.0) AND BSD-3-Clause"
+//
+// 返回值:
+//   - *Expression: 解析后的表达式
+//   - error: 语法错误时返回包装了 ErrInvalidExpression 的错误
+//
+// 使用示例:
+//
+//	expr, err := license.Parse("MIT OR GPL-2.0+")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	fmt.Println(expr.Normalize()) // MIT OR GPL-2.0-or-later
+func Parse(s string) (*Expression, error) {
+	p := &parser{source: s, tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("%w: empty expression", ErrInvalidExpression)
+	}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+// tokenize 将表达式拆分为标识符、运算符和括号
+func tokenize(s string) []string {
+	return strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s))
+}
+
+// parser SPDX 许可证表达式的递归下降解析器
+type parser struct {
+	source string
+	tokens []string
+	pos    int
+}
+
+func (x *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %q: %s", ErrInvalidExpression, x.source, fmt.Sprintf(format, args...))
+}
+
+// peekOperator 判断下一个记号是否是指定的运算符，大小写不敏感
+func (x *parser) peekOperator(op string) bool {
+	return x.pos < len(x.tokens) && strings.EqualFold(x.tokens[x.pos], op)
+}
+
+func (x *parser) parseOr() (*Expression, error) {
+	return x.parseBinary(OperatorOr, x.parseAnd)
+}
+
+func (x *parser) parseAnd() (*Expression, error) {
+	return x.parseBinary(OperatorAnd, x.parseWith)
+}
+
+// parseBinary 解析由同一个运算符连接的操作数序列
+func (x *parser) parseBinary(op Operator, next func() (*Expression, error)) (*Expression, error) {
+	first, err := next()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*Expression{first}
+	for x.peekOperator(string(op)) {
+		x.pos++
+		operand, err := next()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	return combine(op, operands), nil
+}
+
+func (x *parser) parseWith() (*Expression, error) {
+	e, err := x.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if !x.peekOperator("WITH") {
+		return e, nil
+	}
+	if e.Operator != "" || e.Exception != "" {
+		return nil, x.errorf("WITH must follow a single license")
+	}
+	x.pos++
+	if x.pos >= len(x.tokens) || !isIdentifier(x.tokens[x.pos]) || isOperator(x.tokens[x.pos]) {
+		return nil, x.errorf("expected license exception after WITH")
+	}
+	e.Exception = x.tokens[x.pos]
+	x.pos++
+	return e, nil
+}
+
+func (x *parser) parsePrimary() (*Expression, error) {
+	if x.pos >= len(x.tokens) {
+		return nil, x.errorf("unexpected end of expression")
+	}
+	token := x.tokens[x.pos]
+	x.pos++
+	if token == "(" {
+		e, err := x.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if x.pos >= len(x.tokens) || x.tokens[x.pos] != ")" {
+			return nil, x.errorf("missing closing parenthesis")
+		}
+		x.pos++
+		return e, nil
+	}
+	if token == ")" || isOperator(token) {
+		return nil, x.errorf("unexpected %q", token)
+	}
+
+	e := &Expression{License: token}
+	if strings.HasSuffix(token, "+") {
+		e.License = strings.TrimSuffix(token, "+")
+		e.OrLater = true
+	}
+	if strings.HasPrefix(e.License, "DocumentRef-") || strings.HasPrefix(e.License, "LicenseRef-") {
+		if e.OrLater || !isLicenseRef(e.License) {
+			return nil, x.errorf("invalid license reference %q", token)
+		}
+		return e, nil
+	}
+	if !isIdentifier(e.License) {
+		return nil, x.errorf("invalid license identifier %q", token)
+	}
+	return e, nil
+}
+
+// isOperator 判断记号是否是运算符，大小写不敏感
+func isOperator(token string) bool {
+	return strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH")
+}
+
+// Normalize 将许可证字符串规范化为 SPDX 许可证表达式
+//
+// 除了 Expression.Normalize 的规则之外，还会识别 npm 包中常见的非 SPDX 写法，
+// 例如 "Apache 2.0"、"MIT License"、"BSD"
+//
+// 参数:
+//   - s: 许可证字符串
+//
+// 返回值:
+//   - string: 规范化后的表达式
+//   - error: 无法解析时返回包装了 ErrInvalidExpression 的错误，例如 "SEE LICENSE IN LICENSE.md"
+func Normalize(s string) (string, error) {
+	e, err := ParseNormalized(s)
+	if err != nil {
+		return "", err
+	}
+	return e.String(), nil
+}
+
+// ParseNormalized 解析并规范化许可证字符串，规则与 Normalize 相同
+func ParseNormalized(s string) (*Expression, error) {
+	key := strings.ToLower(strings.Join(strings.Fields(s), " "))
+	if alias, ok := aliases[key]; ok {
+		s = alias
+	}
+	e, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return e.Normalize(), nil
+}
+
+// FromManifest 返回版本元数据中声明的许可证字符串
+//
+// 优先使用 license 字段；只在早期的 licenses 数组中声明许可证时，多个许可证被视为可以任选其一，
+// 使用 " OR " 连接，例如 [{"type": "MIT"}, {"type": "Apache-2.0"}] 返回 "(MIT OR Apache-2.0)"
+//
+// 参数:
+//   - version: 版本元数据，为 nil 时返回空字符串
+//
+// 返回值:
+//   - string: 许可证字符串，没有声明许可证时返回空字符串
+func FromManifest(version *models.Version) string {
+	if version == nil {
+		return ""
+	}
+	if license := strings.TrimSpace(version.License); license != "" {
+		return license
+	}
+	var types []string
+	for _, l := range version.Licenses {
+		if t := strings.TrimSpace(l.Type); t != "" {
+			types = append(types, t)
+		}
+	}
+	switch len(types) {
+	case 0:
+		return ""
+	case 1:
+		return types[0]
+	}
+	for i, t := range types {
+		// 单个许可证中可能包含空格，例如 "Apache 2.0"，先尝试规范化
+		if normalized, err := Normalize(t); err == nil {
+			types[i] = normalized
+		}
+		if strings.Contains(types[i], " ") {
+			types[i] = "(" + types[i] + ")"
+		}
+	}
+	return "(" + strings.Join(types, " OR ") + ")"
+}
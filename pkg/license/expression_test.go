@@ -0,0 +1,115 @@
+package license
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]string{
+		"MIT":                               "MIT",
+		"(MIT)":                             "MIT",
+		"MIT OR Apache-2.0":                 "MIT OR Apache-2.0",
+		"(MIT OR (ISC OR 0BSD))":            "MIT OR ISC OR 0BSD",
+		"MIT AND (BSD-2-Clause OR ISC)":     "MIT AND (BSD-2-Clause OR ISC)",
+		"MIT AND BSD-2-Clause OR ISC":       "MIT AND BSD-2-Clause OR ISC",
+		"mit and isc or 0bsd":               "mit AND isc OR 0bsd",
+		"GPL-2.0+ WITH Bison-exception-2.2": "GPL-2.0+ WITH Bison-exception-2.2",
+		"Apache-2.0 with LLVM-exception":    "Apache-2.0 WITH LLVM-exception",
+		"LicenseRef-Proprietary":            "LicenseRef-Proprietary",
+		"DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2": "DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2",
+	}
+	for input, expected := range cases {
+		e, err := Parse(input)
+		assert.Nil(t, err, input)
+		assert.Equal(t, expected, e.String(), input)
+	}
+
+	e, err := Parse("MIT AND (BSD-2-Clause OR ISC)")
+	assert.Nil(t, err)
+	assert.Equal(t, OperatorAnd, e.Operator)
+	assert.Len(t, e.Operands, 2)
+	assert.Equal(t, OperatorOr, e.Operands[1].Operator)
+	assert.Equal(t, []string{"BSD-2-Clause", "ISC", "MIT"}, e.Licenses())
+	assert.True(t, e.IsCompound())
+
+	e, err = Parse("GPL-3.0+")
+	assert.Nil(t, err)
+	assert.Equal(t, "GPL-3.0", e.License)
+	assert.True(t, e.OrLater)
+	assert.False(t, e.IsCompound())
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"SEE LICENSE IN LICENSE.md",
+		"MIT OR",
+		"(MIT",
+		"MIT)",
+		"MIT Apache-2.0",
+		"AND MIT",
+		"(MIT OR ISC) WITH LLVM-exception",
+		"MIT WITH",
+		"MIT WITH OR",
+		"LicenseRef-",
+		"LicenseRef-X+",
+		"DocumentRef-:LicenseRef-X",
+		"MIT/X11",
+	} {
+		_, err := Parse(input)
+		assert.True(t, errors.Is(err, ErrInvalidExpression), input)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"mit":                                  "MIT",
+		"apache-2.0 or bsd-3-clause":           "Apache-2.0 OR BSD-3-Clause",
+		"GPL-2.0":                              "GPL-2.0-only",
+		"GPL-2.0+":                             "GPL-2.0-or-later",
+		"gpl-3.0+":                             "GPL-3.0-or-later",
+		"LGPL-2.1 OR MIT":                      "LGPL-2.1-only OR MIT",
+		"AGPL-3.0":                             "AGPL-3.0-only",
+		"GPL-2.0-with-classpath-exception":     "GPL-2.0-only WITH Classpath-exception-2.0",
+		"GPL-2.0 WITH classpath-exception-2.0": "GPL-2.0-only WITH Classpath-exception-2.0",
+		"Apache-2.0+":                          "Apache-2.0+",
+		"Apache 2.0":                           "Apache-2.0",
+		"MIT License":                          "MIT",
+		"  BSD ":                               "BSD-2-Clause",
+		"LicenseRef-Internal":                  "LicenseRef-Internal",
+		"UNLICENSED":                           "UNLICENSED",
+	}
+	for input, expected := range cases {
+		normalized, err := Normalize(input)
+		assert.Nil(t, err, input)
+		assert.Equal(t, expected, normalized, input)
+	}
+
+	_, err := Normalize("SEE LICENSE IN LICENSE")
+	assert.True(t, errors.Is(err, ErrInvalidExpression))
+}
+
+func TestIdentifiers(t *testing.T) {
+	assert.True(t, IsLicense("MIT"))
+	assert.True(t, IsLicense("apache-2.0"))
+	assert.False(t, IsLicense("GPL-2.0"))
+	assert.False(t, IsLicense("UNLICENSED"))
+	assert.True(t, IsDeprecated("GPL-2.0"))
+	assert.True(t, IsDeprecated("gpl-2.0+"))
+	assert.False(t, IsDeprecated("GPL-2.0-only"))
+	assert.True(t, IsException("LLVM-exception"))
+	assert.False(t, IsException("MIT"))
+}
+
+func TestFromManifest(t *testing.T) {
+	assert.Equal(t, "", FromManifest(nil))
+	assert.Equal(t, "MIT", FromManifest(&models.Version{License: " MIT ", Licenses: []models.LicenseInfo{{Type: "ISC"}}}))
+	assert.Equal(t, "ISC", FromManifest(&models.Version{Licenses: []models.LicenseInfo{{Type: "ISC"}}}))
+	assert.Equal(t, "(MIT OR Apache-2.0)", FromManifest(&models.Version{Licenses: []models.LicenseInfo{{Type: "MIT"}, {Type: "Apache 2.0"}}}))
+	assert.Equal(t, "(GPL-2.0-only OR (MIT AND ISC))", FromManifest(&models.Version{Licenses: []models.LicenseInfo{{Type: "GPL-2.0"}, {Type: "MIT AND ISC"}, {}}}))
+	assert.Equal(t, "", FromManifest(&models.Version{}))
+}
@@ -0,0 +1,257 @@
+package license
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// Exemption 表示策略中的一条豁免规则，被豁免的包即使违反策略也不会被视为违规
+//
+// 主要字段说明:
+//   - Package: 包名，或 "name@range" 形式的包名和版本范围，例如 "caniuse-lite@^1.0.0"
+//   - License: 只在包的许可证规范化之后等于该表达式时才豁免，为空时不限制许可证
+//   - Reason: 豁免的原因，会被原样带到检查结果中
+type Exemption struct {
+	Package string
+	License string
+	Reason  string
+}
+
+// Matches 判断豁免规则是否适用于指定的包版本和许可证
+//
+// 参数:
+//   - name: 包名称
+//   - version: 包版本
+//   - license: 包声明的许可证，会先规范化再与 Exemption.License 比较
+//
+// 返回值:
+//   - bool: 是否适用
+func (x *Exemption) Matches(name, version, license string) bool {
+	exemptName, exemptRange := splitPackage(x.Package)
+	if exemptName != name {
+		return false
+	}
+	if exemptRange != "" {
+		r, err := semver.ParseRange(exemptRange)
+		if err != nil {
+			return false
+		}
+		v, err := semver.Parse(version)
+		if err != nil || !r.SatisfiesIncludingPrerelease(v) {
+			return false
+		}
+	}
+	if x.License != "" {
+		return normalizeOrTrim(x.License) == normalizeOrTrim(license)
+	}
+	return true
+}
+
+// normalizeOrTrim 规范化许可证表达式，无法解析时返回去掉首尾空白的原始字符串
+func normalizeOrTrim(license string) string {
+	if normalized, err := Normalize(license); err == nil {
+		return normalized
+	}
+	return strings.TrimSpace(license)
+}
+
+// splitPackage 将 "name@range" 拆分为包名和版本范围，正确处理作用域包 "@scope/name@range"
+func splitPackage(s string) (string, string) {
+	start := 0
+	if strings.HasPrefix(s, "@") {
+		start = 1
+	}
+	if i := strings.Index(s[start:], "@"); i >= 0 {
+		return s[:start+i], s[start+i+1:]
+	}
+	return s, ""
+}
+
+// Policy 表示许可证合规策略
+//
+// 单个许可证按以下顺序判断，OR 表达式中任意一个许可证被允许即可，AND 表达式要求所有许可证都被允许:
+//  1. 带例外的完整形式（例如 "GPL-2.0-only WITH Classpath-exception-2.0"）在允许列表中时允许
+//  2. 许可证或带例外的完整形式在拒绝列表中时拒绝
+//  3. 许可证在允许列表中时允许
+//  4. 允许列表不为空时拒绝
+//  5. 不在 SPDX 许可证列表中的许可证（例如 "UNLICENSED"）只在 AllowUnknown 为 true 时允许，
+//     LicenseRef- 形式的自定义许可证视为已知
+//
+// 缺失许可证或无法解析的许可证（例如 "SEE LICENSE IN LICENSE.md"）同样只在 AllowUnknown 为 true 时允许
+//
+// 包含字段:
+//   - Allow: 允许的许可证，为空时允许拒绝列表之外的所有已知许可证
+//   - Deny: 拒绝的许可证
+//   - Exemptions: 豁免规则
+//   - AllowUnknown: 是否允许缺失、无法解析或不在 SPDX 许可证列表中的许可证
+//   - OmitDev: 是否忽略只被开发依赖引用的包
+//
+// 使用示例:
+//
+//	policy := license.NewPolicy().
+//		SetAllow("MIT", "ISC", "Apache-2.0", "BSD-2-Clause", "BSD-3-Clause").
+//		AddExemption("caniuse-lite", "CC-BY-4.0 只用于数据文件").
+//		SetOmitDev(true)
+//	report := policy.CheckGraph(graph)
+type Policy struct {
+	Allow        []string
+	Deny         []string
+	Exemptions   []*Exemption
+	AllowUnknown bool
+	OmitDev      bool
+}
+
+// NewPolicy 创建并返回一个空的策略，允许所有已知许可证
+//
+// 默认配置:
+//   - Allow: 空，允许拒绝列表之外的所有已知许可证
+//   - Deny: 空
+//   - AllowUnknown: false
+//   - OmitDev: false
+func NewPolicy() *Policy {
+	return &Policy{}
+}
+
+// SetAllow 设置允许的许可证，可以使用已弃用的标识符或常见写法，比较前会被规范化
+func (x *Policy) SetAllow(licenses ...string) *Policy {
+	x.Allow = licenses
+	return x
+}
+
+// SetDeny 设置拒绝的许可证，可以使用已弃用的标识符或常见写法，比较前会被规范化
+func (x *Policy) SetDeny(licenses ...string) *Policy {
+	x.Deny = licenses
+	return x
+}
+
+// AddExemption 添加一条不限制许可证的豁免规则
+//
+// 参数:
+//   - pkg: 包名，或 "name@range" 形式的包名和版本范围
+//   - reason: 豁免的原因
+func (x *Policy) AddExemption(pkg, reason string) *Policy {
+	x.Exemptions = append(x.Exemptions, &Exemption{Package: pkg, Reason: reason})
+	return x
+}
+
+// SetAllowUnknown 设置是否允许缺失、无法解析或不在 SPDX 许可证列表中的许可证
+func (x *Policy) SetAllowUnknown(allowUnknown bool) *Policy {
+	x.AllowUnknown = allowUnknown
+	return x
+}
+
+// SetOmitDev 设置是否忽略只被开发依赖引用的包
+func (x *Policy) SetOmitDev(omitDev bool) *Policy {
+	x.OmitDev = omitDev
+	return x
+}
+
+// Evaluate 判断许可证是否满足策略，不考虑豁免规则
+//
+// 参数:
+//   - license: 包声明的许可证，可以是 SPDX 表达式或常见的非标准写法
+//
+// 返回值:
+//   - bool: 是否满足策略
+//   - string: 不满足时的原因，满足时为空字符串
+//
+// 使用示例:
+//
+//	ok, reason := license.NewPolicy().SetDeny("GPL-3.0").Evaluate("MIT OR GPL-3.0-only")
+//	fmt.Println(ok, reason) // true
+func (x *Policy) Evaluate(license string) (bool, string) {
+	return x.compile().evaluate(license)
+}
+
+// exemption 返回适用于指定包的第一条豁免规则，没有时返回 nil
+func (x *Policy) exemption(name, version, license string) *Exemption {
+	for _, exemption := range x.Exemptions {
+		if exemption.Matches(name, version, license) {
+			return exemption
+		}
+	}
+	return nil
+}
+
+// evaluator 预先规范化了允许和拒绝列表的策略
+type evaluator struct {
+	allow        map[string]bool
+	deny         map[string]bool
+	allowUnknown bool
+}
+
+func (x *Policy) compile() *evaluator {
+	return &evaluator{
+		allow:        licenseSet(x.Allow),
+		deny:         licenseSet(x.Deny),
+		allowUnknown: x.AllowUnknown,
+	}
+}
+
+// licenseSet 规范化许可证列表并转换为集合
+func licenseSet(list []string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, license := range list {
+		set[normalizeOrTrim(license)] = true
+	}
+	return set
+}
+
+func (x *evaluator) evaluate(license string) (bool, string) {
+	if strings.TrimSpace(license) == "" {
+		return x.allowUnknown, unlessAllowed(x.allowUnknown, "missing license")
+	}
+	e, err := ParseNormalized(license)
+	if err != nil {
+		return x.allowUnknown, unlessAllowed(x.allowUnknown, fmt.Sprintf("unrecognized license %q", strings.TrimSpace(license)))
+	}
+	return x.expression(e)
+}
+
+// unlessAllowed 允许时返回空的原因
+func unlessAllowed(allowed bool, reason string) string {
+	if allowed {
+		return ""
+	}
+	return reason
+}
+
+func (x *evaluator) expression(e *Expression) (bool, string) {
+	switch e.Operator {
+	case OperatorOr:
+		var reasons []string
+		for _, operand := range e.Operands {
+			ok, reason := x.expression(operand)
+			if ok {
+				return true, ""
+			}
+			reasons = append(reasons, reason)
+		}
+		return false, strings.Join(reasons, "; ")
+	case OperatorAnd:
+		var reasons []string
+		for _, operand := range e.Operands {
+			if ok, reason := x.expression(operand); !ok {
+				reasons = append(reasons, reason)
+			}
+		}
+		return len(reasons) == 0, strings.Join(reasons, "; ")
+	}
+
+	full := e.String()
+	switch {
+	case x.allow[full]:
+		return true, ""
+	case x.deny[e.License] || x.deny[full]:
+		return false, fmt.Sprintf("license %s is denied", full)
+	case x.allow[e.License]:
+		return true, ""
+	case len(x.allow) > 0:
+		return false, fmt.Sprintf("license %s is not allowed", full)
+	case !IsLicense(e.License) && !isLicenseRef(e.License) && !x.allowUnknown:
+		return false, fmt.Sprintf("unknown license %s", full)
+	}
+	return true, ""
+}
@@ -0,0 +1,79 @@
+package license
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	allow := NewPolicy().SetAllow("MIT", "ISC", "Apache-2.0", "BSD-3-Clause", "GPL-2.0-only WITH Classpath-exception-2.0")
+	cases := []struct {
+		license string
+		ok      bool
+		reason  string
+	}{
+		{"MIT", true, ""},
+		{"mit", true, ""},
+		{"MIT License", true, ""},
+		{"(GPL-3.0-only OR MIT)", true, ""},
+		{"MIT AND ISC", true, ""},
+		{"MIT AND GPL-3.0-only", false, "license GPL-3.0-only is not allowed"},
+		{"GPL-3.0-only OR LGPL-3.0-only", false, "license GPL-3.0-only is not allowed; license LGPL-3.0-only is not allowed"},
+		{"Apache-2.0 WITH LLVM-exception", true, ""},
+		{"GPL-2.0 WITH Classpath-exception-2.0", true, ""},
+		{"GPL-2.0-only", false, "license GPL-2.0-only is not allowed"},
+		{"", false, "missing license"},
+		{"SEE LICENSE IN LICENSE.md", false, `unrecognized license "SEE LICENSE IN LICENSE.md"`},
+		{"UNLICENSED", false, "license UNLICENSED is not allowed"},
+	}
+	for _, c := range cases {
+		ok, reason := allow.Evaluate(c.license)
+		assert.Equal(t, c.ok, ok, c.license)
+		assert.Equal(t, c.reason, reason, c.license)
+	}
+
+	deny := NewPolicy().SetDeny("GPL-2.0", "AGPL-3.0-only", "LicenseRef-Internal")
+	cases = []struct {
+		license string
+		ok      bool
+		reason  string
+	}{
+		{"MIT", true, ""},
+		{"GPL-2.0-only", false, "license GPL-2.0-only is denied"},
+		{"GPL-2.0", false, "license GPL-2.0-only is denied"},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", false, "license GPL-2.0-only WITH Classpath-exception-2.0 is denied"},
+		{"GPL-2.0-or-later", true, ""},
+		{"MIT OR AGPL-3.0-only", true, ""},
+		{"MIT AND AGPL-3.0-only", false, "license AGPL-3.0-only is denied"},
+		{"LicenseRef-Internal", false, "license LicenseRef-Internal is denied"},
+		{"LicenseRef-Other", true, ""},
+		{"UNLICENSED", false, "unknown license UNLICENSED"},
+		{"", false, "missing license"},
+	}
+	for _, c := range cases {
+		ok, reason := deny.Evaluate(c.license)
+		assert.Equal(t, c.ok, ok, c.license)
+		assert.Equal(t, c.reason, reason, c.license)
+	}
+
+	deny.SetAllowUnknown(true)
+	for _, license := range []string{"UNLICENSED", "", "SEE LICENSE IN LICENSE"} {
+		ok, reason := deny.Evaluate(license)
+		assert.True(t, ok, license)
+		assert.Equal(t, "", reason, license)
+	}
+	ok, _ := deny.Evaluate("GPL-2.0")
+	assert.False(t, ok)
+}
+
+func TestExemptionMatches(t *testing.T) {
+	assert.True(t, (&Exemption{Package: "caniuse-lite"}).Matches("caniuse-lite", "1.0.30001", "CC-BY-4.0"))
+	assert.False(t, (&Exemption{Package: "caniuse-lite"}).Matches("caniuse", "1.0.0", "CC-BY-4.0"))
+	assert.True(t, (&Exemption{Package: "@scope/pkg@^1.0.0"}).Matches("@scope/pkg", "1.2.0", ""))
+	assert.True(t, (&Exemption{Package: "@scope/pkg@^1.0.0"}).Matches("@scope/pkg", "1.3.0-beta.1", ""))
+	assert.False(t, (&Exemption{Package: "@scope/pkg@^1.0.0"}).Matches("@scope/pkg", "2.0.0", ""))
+	assert.False(t, (&Exemption{Package: "pkg@^1.0.0"}).Matches("pkg", "not-a-version", ""))
+	assert.True(t, (&Exemption{Package: "pkg", License: "GPL-3.0"}).Matches("pkg", "1.0.0", "gpl-3.0-only"))
+	assert.False(t, (&Exemption{Package: "pkg", License: "GPL-3.0"}).Matches("pkg", "1.0.0", "AGPL-3.0-only"))
+}
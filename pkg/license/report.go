@@ -0,0 +1,189 @@
+package license
+
+import (
+	"sort"
+
+	"github.com/scagogogo/npm-crawler/pkg/lockfile"
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// Violation 表示一个违反许可证策略的包版本
+//
+// 主要字段说明:
+//   - Name: 包名称
+//   - Version: 包版本
+//   - License: 包声明的许可证，早期的 licenses 数组会被转换为 OR 表达式
+//   - Reason: 违反策略的原因
+//   - Path: 从项目的直接依赖到该包的最短引入路径，元素为 "name@version"，最后一个元素是该包本身
+//   - Exemption: 适用的豁免规则，只在 Report.Exempted 中不为 nil
+type Violation struct {
+	Name      string
+	Version   string
+	License   string
+	Reason    string
+	Path      []string
+	Exemption *Exemption
+}
+
+// Report 表示许可证策略的检查结果
+//
+// 主要字段说明:
+//   - Violations: 违反策略的包，按包名和版本排序
+//   - Exempted: 违反策略但被豁免的包，排序规则与 Violations 相同
+//   - Checked: 检查过的包版本数量
+type Report struct {
+	Violations []*Violation
+	Exempted   []*Violation
+	Checked    int
+}
+
+// OK 判断是否没有违反策略的包
+func (x *Report) OK() bool {
+	return len(x.Violations) == 0
+}
+
+// candidate 表示一个待检查的包版本
+type candidate struct {
+	name     string
+	version  string
+	manifest *models.Version
+	path     []string
+}
+
+// CheckGraph 使用策略检查依赖图中的所有节点
+//
+// 许可证来自节点的 Manifest，引入路径是从根依赖出发的最短路径
+//
+// 参数:
+//   - graph: 解析得到的依赖图
+//
+// 返回值:
+//   - *Report: 检查结果
+//
+// 使用示例:
+//
+//	graph, _ := resolver.NewResolver(cache).ResolveManifest(ctx, manifest)
+//	report := license.NewPolicy().SetDeny("GPL-3.0-only", "AGPL-3.0-only").CheckGraph(graph)
+//	for _, v := range report.Violations {
+//		fmt.Println(strings.Join(v.Path, " > "), v.License, v.Reason)
+//	}
+func (x *Policy) CheckGraph(graph *resolver.Graph) *Report {
+	parents := make(map[*resolver.Node]*resolver.Node)
+	visited := make(map[*resolver.Node]bool)
+	var queue []*resolver.Node
+	for _, edge := range graph.Roots {
+		if edge.To == nil || visited[edge.To] || (x.OmitDev && edge.Type == resolver.DependencyTypeDev) {
+			continue
+		}
+		visited[edge.To] = true
+		queue = append(queue, edge.To)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, edge := range node.Dependencies {
+			if edge.To != nil && !visited[edge.To] {
+				visited[edge.To] = true
+				parents[edge.To] = node
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+
+	var candidates []*candidate
+	for _, node := range graph.SortedNodes() {
+		if x.OmitDev && !visited[node] {
+			continue
+		}
+		var path []string
+		for n := node; n != nil; n = parents[n] {
+			path = append([]string{n.ID()}, path...)
+		}
+		candidates = append(candidates, &candidate{name: node.Name, version: node.Version, manifest: node.Manifest, path: path})
+	}
+	return x.check(candidates)
+}
+
+// CheckProject 使用策略检查锁文件中锁定的所有包
+//
+// 锁文件中不记录许可证，需要先调用 Project.Enrich 从 Registry 获取元数据，否则所有包都会被视为缺失许可证；
+// 锁文件没有记录项目的直接依赖时（package-lock.json v1 和 Yarn Classic），引入路径只包含包本身
+//
+// 参数:
+//   - project: 解析后的锁文件
+//
+// 返回值:
+//   - *Report: 检查结果
+func (x *Policy) CheckProject(project *lockfile.Project) *Report {
+	parents := make(map[*lockfile.Package]*lockfile.Package)
+	visited := make(map[*lockfile.Package]bool)
+	var queue []*lockfile.Package
+	for _, dep := range project.Dependencies {
+		if x.OmitDev && dep.Type == resolver.DependencyTypeDev {
+			continue
+		}
+		if pkg := project.Resolve(dep); pkg != nil && !visited[pkg] {
+			visited[pkg] = true
+			queue = append(queue, pkg)
+		}
+	}
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		for _, dep := range pkg.Dependencies {
+			if child := project.Resolve(dep); child != nil && !visited[child] {
+				visited[child] = true
+				parents[child] = pkg
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	var candidates []*candidate
+	for _, pkg := range project.Packages {
+		if x.OmitDev && pkg.Dev {
+			continue
+		}
+		var path []string
+		for p := pkg; p != nil; p = parents[p] {
+			path = append([]string{p.ID()}, path...)
+		}
+		candidates = append(candidates, &candidate{name: pkg.Name, version: pkg.Version, manifest: pkg.Manifest, path: path})
+	}
+	return x.check(candidates)
+}
+
+// check 检查所有候选包并生成排序后的结果
+func (x *Policy) check(candidates []*candidate) *Report {
+	e := x.compile()
+	report := &Report{Checked: len(candidates)}
+	for _, c := range candidates {
+		license := FromManifest(c.manifest)
+		ok, reason := e.evaluate(license)
+		if ok {
+			continue
+		}
+		violation := &Violation{Name: c.name, Version: c.version, License: license, Reason: reason, Path: c.path}
+		if exemption := x.exemption(c.name, c.version, license); exemption != nil {
+			violation.Exemption = exemption
+			report.Exempted = append(report.Exempted, violation)
+		} else {
+			report.Violations = append(report.Violations, violation)
+		}
+	}
+	sortViolations(report.Violations)
+	sortViolations(report.Exempted)
+	return report
+}
+
+// sortViolations 按包名和版本排序
+func sortViolations(violations []*Violation) {
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Name != violations[j].Name {
+			return violations[i].Name < violations[j].Name
+		}
+		return semver.Compare(violations[i].Version, violations[j].Version) < 0
+	})
+}
@@ -0,0 +1,107 @@
+package license
+
+import (
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/lockfile"
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+// testGraph 构造依赖图:
+//
+//	express (prod) -> debug -> ms
+//	               -> copyleft
+//	devtool (dev)  -> copyleft
+//	               -> legacy
+func testGraph() *resolver.Graph {
+	graph := resolver.NewGraph()
+	node := func(name, version string, manifest *models.Version) *resolver.Node {
+		n := &resolver.Node{Name: name, Version: version, Manifest: manifest}
+		graph.Nodes[n.ID()] = n
+		return n
+	}
+	link := func(from, to *resolver.Node) {
+		from.Dependencies = append(from.Dependencies, &resolver.Edge{From: from, Name: to.Name, To: to, Type: resolver.DependencyTypeProd})
+	}
+	express := node("express", "4.18.2", &models.Version{License: "MIT"})
+	debug := node("debug", "2.6.9", &models.Version{License: "MIT"})
+	ms := node("ms", "2.0.0", &models.Version{License: "MIT"})
+	copyleft := node("copyleft", "1.0.0", &models.Version{License: "GPL-3.0"})
+	devtool := node("devtool", "0.1.0", &models.Version{License: "UNLICENSED"})
+	legacy := node("legacy", "0.0.1", &models.Version{Licenses: []models.LicenseInfo{{Type: "MIT"}, {Type: "GPL-2.0"}}})
+	link(express, debug)
+	link(debug, ms)
+	link(express, copyleft)
+	link(devtool, copyleft)
+	link(devtool, legacy)
+	graph.Roots = []*resolver.Edge{
+		{Name: "devtool", To: devtool, Type: resolver.DependencyTypeDev},
+		{Name: "express", To: express, Type: resolver.DependencyTypeProd},
+	}
+	return graph
+}
+
+func TestCheckGraph(t *testing.T) {
+	policy := NewPolicy().SetAllow("MIT", "ISC")
+	report := policy.CheckGraph(testGraph())
+	assert.False(t, report.OK())
+	assert.Equal(t, 6, report.Checked)
+	assert.Len(t, report.Violations, 2)
+
+	assert.Equal(t, "copyleft", report.Violations[0].Name)
+	assert.Equal(t, "GPL-3.0", report.Violations[0].License)
+	assert.Equal(t, "license GPL-3.0-only is not allowed", report.Violations[0].Reason)
+	assert.Equal(t, []string{"devtool@0.1.0", "copyleft@1.0.0"}, report.Violations[0].Path)
+	assert.Equal(t, "devtool", report.Violations[1].Name)
+	assert.Equal(t, []string{"devtool@0.1.0"}, report.Violations[1].Path)
+
+	policy.SetOmitDev(true)
+	report = policy.CheckGraph(testGraph())
+	assert.Equal(t, 4, report.Checked)
+	assert.Len(t, report.Violations, 1)
+	assert.Equal(t, []string{"express@4.18.2", "copyleft@1.0.0"}, report.Violations[0].Path)
+
+	policy.AddExemption("copyleft@1.x", "只在构建时使用")
+	report = policy.CheckGraph(testGraph())
+	assert.True(t, report.OK())
+	assert.Len(t, report.Exempted, 1)
+	assert.Equal(t, "只在构建时使用", report.Exempted[0].Exemption.Reason)
+}
+
+func TestCheckProject(t *testing.T) {
+	copyleft := &lockfile.Package{Name: "copyleft", Version: "1.0.0", Manifest: &models.Version{License: "AGPL-3.0"}}
+	wrapper := &lockfile.Package{Name: "wrapper", Version: "2.0.0", Manifest: &models.Version{License: "MIT"},
+		Dependencies: []*lockfile.Dependency{{Name: "copyleft", Spec: "^1.0.0", Version: "1.0.0"}}}
+	aliased := &lockfile.Package{Name: "strip-ansi", Version: "6.0.1", Manifest: &models.Version{License: "MIT"}}
+	unknown := &lockfile.Package{Name: "unknown", Version: "1.0.0"}
+	devOnly := &lockfile.Package{Name: "test-only", Version: "1.0.0", Dev: true, Manifest: &models.Version{License: "AGPL-3.0-only"}}
+	project := &lockfile.Project{
+		Dependencies: []*lockfile.Dependency{
+			{Name: "wrapper", Spec: "^2.0.0", Version: "2.0.0", Type: resolver.DependencyTypeProd},
+			{Name: "strip-ansi-cjs", Spec: "npm:strip-ansi@^6.0.1", Version: "6.0.1", Type: resolver.DependencyTypeProd},
+			{Name: "test-only", Spec: "^1.0.0", Version: "1.0.0", Type: resolver.DependencyTypeDev},
+		},
+		Packages: []*lockfile.Package{copyleft, aliased, devOnly, unknown, wrapper},
+	}
+
+	report := NewPolicy().SetDeny("AGPL-3.0-only").CheckProject(project)
+	assert.Equal(t, 5, report.Checked)
+	var got []string
+	for _, v := range report.Violations {
+		got = append(got, v.Name+": "+v.Reason)
+	}
+	assert.Equal(t, []string{
+		"copyleft: license AGPL-3.0-only is denied",
+		"test-only: license AGPL-3.0-only is denied",
+		"unknown: missing license",
+	}, got)
+	assert.Equal(t, []string{"wrapper@2.0.0", "copyleft@1.0.0"}, report.Violations[0].Path)
+	assert.Equal(t, []string{"unknown@1.0.0"}, report.Violations[2].Path)
+
+	report = NewPolicy().SetDeny("AGPL-3.0-only").SetOmitDev(true).SetAllowUnknown(true).CheckProject(project)
+	assert.Equal(t, 4, report.Checked)
+	assert.Len(t, report.Violations, 1)
+	assert.Equal(t, "copyleft", report.Violations[0].Name)
+}
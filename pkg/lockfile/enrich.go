@@ -0,0 +1,93 @@
+package lockfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// DefaultEnrichConcurrency 是 Enrich 默认同时请求 Registry 的数量
+const DefaultEnrichConcurrency = 8
+
+// Enrich 从 Registry 获取每个锁定版本的元数据，填充 Package.Manifest，并补全缺失的 Resolved 和 Integrity
+//
+// 版本号不是合法 semver 的包（例如 git 依赖）会被跳过；Registry 中不存在的包或版本
+// （例如私有包）不会导致失败，对应的 Manifest 保持为 nil
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - cache: 元数据缓存，同一个缓存可以在多个项目之间共享，避免重复请求
+//   - concurrency: 同时请求的包数量，小于等于 0 时使用 DefaultEnrichConcurrency
+//
+// 返回值:
+//   - error: 请求失败的包的错误，多个错误会被合并返回
+//
+// 使用示例:
+//
+//	reg := registry.NewRegistry()
+//	cache := resolver.NewMetadataCache(reg.GetPackageInformation)
+//	project, _ := lockfile.ParseFile("package-lock.json")
+//	if err := project.Enrich(ctx, cache, 0); err != nil {
+//		// 处理错误
+//	}
+//	for _, p := range project.Packages {
+//		if p.Manifest != nil && p.Manifest.Deprecated != "" {
+//			fmt.Println(p.ID(), "已弃用:", p.Manifest.Deprecated)
+//		}
+//	}
+func (x *Project) Enrich(ctx context.Context, cache *resolver.MetadataCache, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = DefaultEnrichConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var errs []error
+	for _, p := range x.Packages {
+		if !semver.Valid(p.Version) {
+			continue
+		}
+		wg.Add(1)
+		go func(p *Package) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				lock.Lock()
+				errs = append(errs, ctx.Err())
+				lock.Unlock()
+				return
+			}
+
+			pkg, err := cache.Get(ctx, p.Name)
+			if err != nil {
+				if !errors.Is(err, resolver.ErrPackageNotFound) {
+					lock.Lock()
+					errs = append(errs, fmt.Errorf("enrich %s: %w", p.ID(), err))
+					lock.Unlock()
+				}
+				return
+			}
+			manifest, ok := pkg.Versions[p.Version]
+			if !ok {
+				return
+			}
+			p.Manifest = &manifest
+			if manifest.Dist != nil {
+				if p.Resolved == "" {
+					p.Resolved = manifest.Dist.Tarball
+				}
+				if p.Integrity == "" {
+					p.Integrity = manifest.Dist.Integrity
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
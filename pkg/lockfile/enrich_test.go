@@ -0,0 +1,52 @@
+package lockfile
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnrich(t *testing.T) {
+	project, err := ParseYarnLock([]byte(yarnBerryLock))
+	assert.Nil(t, err)
+
+	packages := map[string]*models.Package{
+		"@babel/code-frame": {
+			Name: "@babel/code-frame",
+			Versions: map[string]models.Version{
+				"7.12.13": {
+					Name:    "@babel/code-frame",
+					Version: "7.12.13",
+					License: "MIT",
+					Dist:    &models.Dist{Tarball: "https://registry.npmjs.org/@babel/code-frame/-/code-frame-7.12.13.tgz", Integrity: "sha512-code-frame"},
+				},
+			},
+		},
+		// 缺少锁定的版本
+		"string-width": {Name: "string-width", Versions: map[string]models.Version{}},
+	}
+	cache := resolver.NewMetadataCache(func(ctx context.Context, packageName string) (*models.Package, error) {
+		if packageName == "@babel/highlight" {
+			return nil, errors.New("connection reset")
+		}
+		if pkg, ok := packages[packageName]; ok {
+			return pkg, nil
+		}
+		return &models.Package{}, nil
+	})
+
+	err = project.Enrich(context.Background(), cache, 2)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "@babel/highlight@7.13.10")
+
+	codeFrame := project.Package("@babel/code-frame", "7.12.13")
+	assert.Equal(t, "MIT", codeFrame.Manifest.License)
+	assert.Equal(t, "https://registry.npmjs.org/@babel/code-frame/-/code-frame-7.12.13.tgz", codeFrame.Resolved)
+	assert.Equal(t, "sha512-code-frame", codeFrame.Integrity)
+	assert.Nil(t, project.Package("string-width", "4.2.3").Manifest)
+	assert.Nil(t, project.Package("@babel/highlight", "7.13.10").Manifest)
+}
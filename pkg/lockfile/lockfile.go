@@ -0,0 +1,59 @@
+package lockfile
+
+import (
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// LockfileVersion 是 Write 生成的锁文件版本，npm v9 及以上版本默认使用该版本
+const LockfileVersion = 3
+
+// Lockfile 表示 package-lock.json 或 npm-shrinkwrap.json 文件的内容
+//
+// 主要字段说明:
+//   - Name: 项目名称
+//   - Version: 项目版本
+//   - LockfileVersion: 锁文件格式版本
+//   - Requires: npm v7 及以上版本总是为 true
+//   - Packages: 键为包在项目中的路径，例如 "node_modules/a/node_modules/b"，项目本身的键为空字符串
+type Lockfile struct {
+	Name            string            `json:"name,omitempty"`
+	Version         string            `json:"version,omitempty"`
+	LockfileVersion int               `json:"lockfileVersion"`
+	Requires        bool              `json:"requires,omitempty"`
+	Packages        map[string]*Entry `json:"packages"`
+}
+
+// Entry 表示锁文件 packages 中的一项
+//
+// 主要字段说明:
+//   - Name: 包的真实名称，只在别名依赖和项目本身的条目中出现
+//   - Version: 安装的版本
+//   - Resolved: tarball 的下载地址
+//   - Integrity: tarball 的完整性校验值
+//   - Dev / Optional / DevOptional / Peer: 依赖类型标记，含义与 layout.Placement 中的同名字段相同
+//   - Link: 是否是指向本地目录（例如 workspace）的符号链接，此时 Resolved 为目标目录
+//   - 其余字段从包的清单中复制
+type Entry struct {
+	Name                 string                               `json:"name,omitempty"`
+	Version              string                               `json:"version,omitempty"`
+	Resolved             string                               `json:"resolved,omitempty"`
+	Integrity            string                               `json:"integrity,omitempty"`
+	Dev                  bool                                 `json:"dev,omitempty"`
+	Optional             bool                                 `json:"optional,omitempty"`
+	DevOptional          bool                                 `json:"devOptional,omitempty"`
+	Peer                 bool                                 `json:"peer,omitempty"`
+	Link                 bool                                 `json:"link,omitempty"`
+	License              string                               `json:"license,omitempty"`
+	Dependencies         map[string]string                    `json:"dependencies,omitempty"`
+	DevDependencies      map[string]string                    `json:"devDependencies,omitempty"`
+	OptionalDependencies map[string]string                    `json:"optionalDependencies,omitempty"`
+	PeerDependencies     map[string]string                    `json:"peerDependencies,omitempty"`
+	PeerDependenciesMeta map[string]models.PeerDependencyMeta `json:"peerDependenciesMeta,omitempty"`
+	Engines              models.Engines                       `json:"engines,omitempty"`
+	Os                   []string                             `json:"os,omitempty"`
+	Cpu                  []string                             `json:"cpu,omitempty"`
+	Bin                  map[string]string                    `json:"bin,omitempty"`
+	Funding              interface{}                          `json:"funding,omitempty"`
+	HasInstallScript     bool                                 `json:"hasInstallScript,omitempty"`
+	Deprecated           string                               `json:"deprecated,omitempty"`
+}
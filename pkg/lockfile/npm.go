@@ -0,0 +1,176 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+)
+
+// packageLock 表示任意版本的 package-lock.json，v1 使用 dependencies 字段，v2 和 v3 使用 packages 字段
+type packageLock struct {
+	Name            string                  `json:"name"`
+	Version         string                  `json:"version"`
+	LockfileVersion int                     `json:"lockfileVersion"`
+	Packages        map[string]*Entry       `json:"packages"`
+	Dependencies    map[string]*legacyEntry `json:"dependencies"`
+}
+
+// legacyEntry 表示 lockfileVersion 1 中 dependencies 的一项，嵌套安装的包记录在 Dependencies 中
+type legacyEntry struct {
+	Version      string                  `json:"version"`
+	Resolved     string                  `json:"resolved"`
+	Integrity    string                  `json:"integrity"`
+	Dev          bool                    `json:"dev"`
+	Optional     bool                    `json:"optional"`
+	Requires     map[string]string       `json:"requires"`
+	Dependencies map[string]*legacyEntry `json:"dependencies"`
+}
+
+// ParsePackageLock 解析 package-lock.json 或 npm-shrinkwrap.json，支持 lockfileVersion 1 到 3
+//
+// 参数:
+//   - data: 锁文件内容
+//
+// 返回值:
+//   - *Project: 解析得到的项目依赖信息，Package.Keys 为包的安装路径，例如 "node_modules/a/node_modules/b"
+//   - error: 如果内容不是合法的 JSON 则返回错误
+func ParsePackageLock(data []byte) (*Project, error) {
+	var lock packageLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parse package-lock.json: %w", err)
+	}
+	project := &Project{
+		Format:          FormatNpm,
+		LockfileVersion: strconv.Itoa(lock.LockfileVersion),
+		Name:            lock.Name,
+		Version:         lock.Version,
+	}
+	c := newCollector()
+	if len(lock.Packages) > 0 {
+		parsePackages(project, c, lock.Packages)
+	} else {
+		parseLegacyDependencies(c, lock.Dependencies, nil, "")
+	}
+	project.Packages = c.sorted()
+	return project, nil
+}
+
+// parsePackages 解析 lockfileVersion 2 和 3 中的 packages 字段
+func parsePackages(project *Project, c *collector, packages map[string]*Entry) {
+	// 按照 Node.js 的模块查找规则查找从 from 目录 require(name) 得到的包
+	lookup := func(from, name string) string {
+		for {
+			key := "node_modules/" + name
+			if from != "" {
+				key = from + "/" + key
+			}
+			if entry, ok := packages[key]; ok && !entry.Link {
+				return entry.Version
+			}
+			if from == "" {
+				return ""
+			}
+			if i := strings.LastIndex(from, "/node_modules/"); i >= 0 {
+				from = from[:i]
+			} else {
+				from = ""
+			}
+		}
+	}
+	entryDependencies := func(path string, entry *Entry, includeDev bool) []*Dependency {
+		version := func(name, spec string) string {
+			return lookup(path, name)
+		}
+		var deps []*Dependency
+		deps = append(deps, dependencies(entry.Dependencies, resolver.DependencyTypeProd, version)...)
+		if includeDev {
+			deps = append(deps, dependencies(entry.DevDependencies, resolver.DependencyTypeDev, version)...)
+		}
+		deps = append(deps, dependencies(entry.OptionalDependencies, resolver.DependencyTypeOptional, version)...)
+		deps = append(deps, dependencies(entry.PeerDependencies, resolver.DependencyTypePeer, version)...)
+		sortDependencies(deps)
+		return deps
+	}
+
+	for path, entry := range packages {
+		if path == "" {
+			if entry.Name != "" {
+				project.Name = entry.Name
+			}
+			if entry.Version != "" {
+				project.Version = entry.Version
+			}
+			project.Dependencies = entryDependencies(path, entry, true)
+			continue
+		}
+		i := strings.LastIndex(path, "node_modules/")
+		if i < 0 || entry.Link {
+			// workspace 目录和指向它们的符号链接不是来自 Registry 的包
+			continue
+		}
+		name := entry.Name
+		if name == "" {
+			name = path[i+len("node_modules/"):]
+		}
+		c.add(&Package{
+			Name:         name,
+			Version:      entry.Version,
+			Resolved:     entry.Resolved,
+			Integrity:    entry.Integrity,
+			Dev:          entry.Dev,
+			Optional:     entry.Optional,
+			Keys:         []string{path},
+			Dependencies: entryDependencies(path, entry, false),
+		})
+	}
+}
+
+// parseLegacyDependencies 递归解析 lockfileVersion 1 中嵌套的 dependencies 字段
+//
+// ancestors 为从根目录到当前目录的所有 dependencies，用于查找 requires 中依赖的版本
+func parseLegacyDependencies(c *collector, entries map[string]*legacyEntry, ancestors []map[string]*legacyEntry, path string) {
+	ancestors = append(ancestors[:len(ancestors):len(ancestors)], entries)
+	for alias, entry := range entries {
+		entryPath := "node_modules/" + alias
+		if path != "" {
+			entryPath = path + "/" + entryPath
+		}
+		chain := ancestors
+		if len(entry.Dependencies) > 0 {
+			chain = append(chain[:len(chain):len(chain)], entry.Dependencies)
+		}
+		version := func(name, spec string) string {
+			for i := len(chain) - 1; i >= 0; i-- {
+				if dep, ok := chain[i][name]; ok {
+					_, v := legacyNameAndVersion(name, dep.Version)
+					return v
+				}
+			}
+			return ""
+		}
+
+		name, v := legacyNameAndVersion(alias, entry.Version)
+		c.add(&Package{
+			Name:         name,
+			Version:      v,
+			Resolved:     entry.Resolved,
+			Integrity:    entry.Integrity,
+			Dev:          entry.Dev,
+			Optional:     entry.Optional,
+			Keys:         []string{entryPath},
+			Dependencies: dependencies(entry.Requires, resolver.DependencyTypeProd, version),
+		})
+		parseLegacyDependencies(c, entry.Dependencies, ancestors, entryPath)
+	}
+}
+
+// legacyNameAndVersion 处理 lockfileVersion 1 中别名依赖的版本，例如 "npm:string-width@4.2.3"
+func legacyNameAndVersion(alias, version string) (string, string) {
+	if !strings.HasPrefix(version, "npm:") {
+		return alias, version
+	}
+	return splitNameAndVersion(strings.TrimPrefix(version, "npm:"))
+}
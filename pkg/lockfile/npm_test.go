@@ -0,0 +1,179 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+const packageLockV3 = `{
+  "name": "my-app",
+  "version": "1.0.0",
+  "lockfileVersion": 3,
+  "requires": true,
+  "packages": {
+    "": {
+      "name": "my-app",
+      "version": "1.0.0",
+      "workspaces": ["packages/*"],
+      "dependencies": {
+        "a": "^1.0.0",
+        "string-width-cjs": "npm:string-width@^4"
+      },
+      "devDependencies": {
+        "test": "^2.0.0"
+      }
+    },
+    "node_modules/a": {
+      "version": "1.0.0",
+      "resolved": "https://registry.npmjs.org/a/-/a-1.0.0.tgz",
+      "integrity": "sha512-a",
+      "dependencies": {
+        "b": "^1.0.0"
+      }
+    },
+    "node_modules/b": {
+      "version": "1.0.0",
+      "resolved": "https://registry.npmjs.org/b/-/b-1.0.0.tgz",
+      "integrity": "sha512-b1",
+      "engines": [
+        "node >=0.6.0"
+      ]
+    },
+    "node_modules/local": {
+      "resolved": "packages/local",
+      "link": true
+    },
+    "node_modules/string-width-cjs": {
+      "name": "string-width",
+      "version": "4.2.3",
+      "resolved": "https://registry.npmjs.org/string-width/-/string-width-4.2.3.tgz",
+      "integrity": "sha512-sw"
+    },
+    "node_modules/test": {
+      "version": "2.0.0",
+      "dev": true,
+      "dependencies": {
+        "b": "^2.0.0"
+      },
+      "optionalDependencies": {
+        "fsevents": "^2"
+      }
+    },
+    "node_modules/test/node_modules/b": {
+      "version": "2.0.0",
+      "dev": true
+    },
+    "packages/local": {
+      "version": "0.0.1"
+    },
+    "packages/local/node_modules/b": {
+      "version": "2.0.0"
+    }
+  }
+}`
+
+const packageLockV1 = `{
+  "name": "legacy",
+  "version": "1.0.0",
+  "lockfileVersion": 1,
+  "requires": true,
+  "dependencies": {
+    "a": {
+      "version": "1.0.0",
+      "resolved": "https://registry.npmjs.org/a/-/a-1.0.0.tgz",
+      "integrity": "sha512-a",
+      "requires": {
+        "b": "^2.0.0",
+        "c": "^1.0.0"
+      },
+      "dependencies": {
+        "b": {
+          "version": "2.0.0",
+          "integrity": "sha512-b2"
+        }
+      }
+    },
+    "b": {
+      "version": "1.0.0",
+      "dev": true
+    },
+    "c": {
+      "version": "1.0.0"
+    },
+    "sw": {
+      "version": "npm:string-width@4.2.3"
+    }
+  }
+}`
+
+func TestParsePackageLockV3(t *testing.T) {
+	project, err := ParsePackageLock([]byte(packageLockV3))
+	assert.Nil(t, err)
+	assert.Equal(t, FormatNpm, project.Format)
+	assert.Equal(t, "3", project.LockfileVersion)
+	assert.Equal(t, "my-app", project.Name)
+	assert.Equal(t, []*Dependency{
+		{Name: "a", Spec: "^1.0.0", Version: "1.0.0", Type: resolver.DependencyTypeProd},
+		{Name: "string-width-cjs", Spec: "npm:string-width@^4", Version: "4.2.3", Type: resolver.DependencyTypeProd},
+		{Name: "test", Spec: "^2.0.0", Version: "2.0.0", Type: resolver.DependencyTypeDev},
+	}, project.Dependencies)
+
+	var ids []string
+	for _, p := range project.Packages {
+		ids = append(ids, p.ID())
+	}
+	assert.Equal(t, []string{"a@1.0.0", "b@1.0.0", "b@2.0.0", "string-width@4.2.3", "test@2.0.0"}, ids)
+
+	a := project.Package("a", "1.0.0")
+	assert.Equal(t, "https://registry.npmjs.org/a/-/a-1.0.0.tgz", a.Resolved)
+	assert.Equal(t, "sha512-a", a.Integrity)
+	assert.Equal(t, []*Dependency{{Name: "b", Spec: "^1.0.0", Version: "1.0.0", Type: resolver.DependencyTypeProd}}, a.Dependencies)
+
+	test := project.Package("test", "2.0.0")
+	assert.True(t, test.Dev)
+	assert.Equal(t, []*Dependency{
+		{Name: "b", Spec: "^2.0.0", Version: "2.0.0", Type: resolver.DependencyTypeProd},
+		{Name: "fsevents", Spec: "^2", Type: resolver.DependencyTypeOptional},
+	}, test.Dependencies)
+
+	// 同一个版本被安装在多个位置时合并，只要有一个位置不是开发依赖就不是开发依赖
+	b2 := project.Package("b", "2.0.0")
+	assert.Equal(t, []string{"node_modules/test/node_modules/b", "packages/local/node_modules/b"}, b2.Keys)
+	assert.False(t, b2.Dev)
+
+	assert.Equal(t, []string{"node_modules/string-width-cjs"}, project.Package("string-width", "4.2.3").Keys)
+	assert.Nil(t, project.Package("local", "0.0.1"))
+}
+
+func TestEntryEngines(t *testing.T) {
+	// verror 等早期发布的包使用数组形式的 engines
+	var entry Entry
+	assert.Nil(t, json.Unmarshal([]byte(`{"version": "1.10.0", "engines": ["node >=0.6.0"]}`), &entry))
+	assert.Equal(t, models.Engines{"node": ">=0.6.0"}, entry.Engines)
+	assert.Nil(t, json.Unmarshal([]byte(`{"version": "1.0.0", "engines": {"node": ">=14"}}`), &entry))
+	assert.Equal(t, models.Engines{"node": ">=14"}, entry.Engines)
+}
+
+func TestParsePackageLockV1(t *testing.T) {
+	project, err := ParsePackageLock([]byte(packageLockV1))
+	assert.Nil(t, err)
+	assert.Equal(t, "1", project.LockfileVersion)
+	assert.Nil(t, project.Dependencies)
+	assert.Len(t, project.Packages, 5)
+
+	a := project.Package("a", "1.0.0")
+	assert.Equal(t, []*Dependency{
+		{Name: "b", Spec: "^2.0.0", Version: "2.0.0", Type: resolver.DependencyTypeProd},
+		{Name: "c", Spec: "^1.0.0", Version: "1.0.0", Type: resolver.DependencyTypeProd},
+	}, a.Dependencies)
+	assert.Equal(t, []string{"node_modules/a/node_modules/b"}, project.Package("b", "2.0.0").Keys)
+	assert.True(t, project.Package("b", "1.0.0").Dev)
+	assert.Equal(t, []string{"node_modules/sw"}, project.Package("string-width", "4.2.3").Keys)
+
+	_, err = ParsePackageLock([]byte("not json"))
+	assert.NotNil(t, err)
+}
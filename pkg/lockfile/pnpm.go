@@ -0,0 +1,208 @@
+package lockfile
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"gopkg.in/yaml.v3"
+)
+
+// pnpmLock 表示任意版本的 pnpm-lock.yaml
+//
+// lockfileVersion 5.x 和 6.0 中包的依赖记录在 packages 中，9.0 中记录在 snapshots 中；
+// 单项目仓库的直接依赖记录在顶层，多项目仓库记录在 importers 中
+type pnpmLock struct {
+	LockfileVersion string                   `yaml:"lockfileVersion"`
+	Importers       map[string]*pnpmImporter `yaml:"importers"`
+	pnpmImporter    `yaml:",inline"`
+	Packages        map[string]*pnpmPackage `yaml:"packages"`
+	Snapshots       map[string]*pnpmPackage `yaml:"snapshots"`
+}
+
+// pnpmImporter 表示一个项目的直接依赖
+type pnpmImporter struct {
+	Specifiers           map[string]string         `yaml:"specifiers"`
+	Dependencies         map[string]*pnpmReference `yaml:"dependencies"`
+	DevDependencies      map[string]*pnpmReference `yaml:"devDependencies"`
+	OptionalDependencies map[string]*pnpmReference `yaml:"optionalDependencies"`
+}
+
+// pnpmReference 表示直接依赖锁定的版本，5.x 中为字符串，6.0 及以上版本为 {specifier, version}
+type pnpmReference struct {
+	Specifier string `yaml:"specifier"`
+	Version   string `yaml:"version"`
+}
+
+// UnmarshalYAML 同时支持字符串和对象两种格式
+func (x *pnpmReference) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		x.Version = node.Value
+		return nil
+	}
+	type plain pnpmReference
+	return node.Decode((*plain)(x))
+}
+
+// pnpmPackage 表示 packages 或 snapshots 中的一项
+type pnpmPackage struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version"`
+	Resolution struct {
+		Integrity string `yaml:"integrity"`
+		Tarball   string `yaml:"tarball"`
+	} `yaml:"resolution"`
+	Dependencies         map[string]string `yaml:"dependencies"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies"`
+	PeerDependencies     map[string]string `yaml:"peerDependencies"`
+	Dev                  bool              `yaml:"dev"`
+	Optional             bool              `yaml:"optional"`
+}
+
+// ParsePnpmLock 解析 pnpm-lock.yaml，支持 lockfileVersion 5.x、6.0 和 9.0
+//
+// pnpm 的包条目中不记录依赖的版本范围，Package.Dependencies 中的 Spec 为锁定的版本引用，
+// 例如 "17.0.2(react@17.0.2)"，lockfileVersion 9.0 不再记录 dev 和 optional 标记
+//
+// 参数:
+//   - data: 锁文件内容
+//
+// 返回值:
+//   - *Project: 解析得到的项目依赖信息，Package.Keys 为 packages（或 snapshots）中的键
+//   - error: 如果格式不正确则返回错误
+func ParsePnpmLock(data []byte) (*Project, error) {
+	var lock pnpmLock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parse pnpm-lock.yaml: %w", err)
+	}
+	major, _ := strconv.Atoi(strings.SplitN(lock.LockfileVersion, ".", 2)[0])
+	legacy := major < 6
+
+	project := &Project{Format: FormatPnpm, LockfileVersion: lock.LockfileVersion}
+	root := &lock.pnpmImporter
+	if importer, ok := lock.Importers["."]; ok {
+		root = importer
+	}
+	for depType, refs := range map[resolver.DependencyType]map[string]*pnpmReference{
+		resolver.DependencyTypeProd:     root.Dependencies,
+		resolver.DependencyTypeDev:      root.DevDependencies,
+		resolver.DependencyTypeOptional: root.OptionalDependencies,
+	} {
+		for name, ref := range refs {
+			spec := ref.Specifier
+			if spec == "" {
+				spec = root.Specifiers[name]
+			}
+			_, version := parsePnpmReference(name, ref.Version, legacy)
+			project.Dependencies = append(project.Dependencies, &Dependency{Name: name, Spec: spec, Version: version, Type: depType})
+		}
+	}
+	sortDependencies(project.Dependencies)
+
+	// 9.0 中 packages 只记录包本身的信息，依赖关系记录在 snapshots 中
+	snapshots := lock.Snapshots
+	if snapshots == nil {
+		snapshots = lock.Packages
+	}
+	keys := make([]string, 0, len(snapshots))
+	for key := range snapshots {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	c := newCollector()
+	for _, key := range keys {
+		snapshot := snapshots[key]
+		name, version := parsePnpmKey(key, legacy)
+		info := snapshot
+		if lock.Snapshots != nil {
+			if p, ok := lock.Packages[name+"@"+version]; ok {
+				info = p
+			}
+		}
+		if info.Name != "" {
+			name = info.Name
+		}
+		if info.Version != "" {
+			version = info.Version
+		}
+		if name == "" || version == "" {
+			continue
+		}
+
+		var deps []*Dependency
+		for depType, refs := range map[resolver.DependencyType]map[string]string{
+			resolver.DependencyTypeProd:     snapshot.Dependencies,
+			resolver.DependencyTypeOptional: snapshot.OptionalDependencies,
+		} {
+			for depName, ref := range refs {
+				_, depVersion := parsePnpmReference(depName, ref, legacy)
+				deps = append(deps, &Dependency{Name: depName, Spec: ref, Version: depVersion, Type: depType})
+			}
+		}
+		deps = append(deps, dependencies(info.PeerDependencies, resolver.DependencyTypePeer, nil)...)
+		sortDependencies(deps)
+
+		c.add(&Package{
+			Name:         name,
+			Version:      version,
+			Resolved:     info.Resolution.Tarball,
+			Integrity:    info.Resolution.Integrity,
+			Dev:          snapshot.Dev || info.Dev,
+			Optional:     snapshot.Optional || info.Optional,
+			Keys:         []string{key},
+			Dependencies: deps,
+		})
+	}
+	project.Packages = c.sorted()
+	return project, nil
+}
+
+// parsePnpmKey 解析 packages 中的键，返回包名和版本
+//
+// 支持的格式:
+//   - 5.x: "/name/1.0.0"、"/@scope/name/1.0.0_peer@1.0.0"
+//   - 6.0: "/name@1.0.0"、"/@scope/name@1.0.0(peer@1.0.0)"
+//   - 9.0: "name@1.0.0"、"@scope/name@1.0.0(peer@1.0.0)"
+func parsePnpmKey(key string, legacy bool) (string, string) {
+	key = strings.TrimPrefix(key, "/")
+	if legacy {
+		i := strings.LastIndex(key, "/")
+		if i < 0 {
+			return "", ""
+		}
+		version, _, _ := strings.Cut(key[i+1:], "_")
+		return key[:i], version
+	}
+	if i := strings.Index(key, "("); i > 0 {
+		key = key[:i]
+	}
+	return splitNameAndVersion(key)
+}
+
+// parsePnpmReference 解析依赖锁定的版本引用，返回真实的包名和版本
+//
+// 引用可能是版本号 "1.0.0"、带有同级依赖后缀的版本号 "1.0.0(react@17.0.2)" 或 "1.0.0_react@17.0.2"，
+// 也可能是别名依赖指向的键，例如 "/string-width/4.2.3"、"/string-width@4.2.3" 或 "string-width@4.2.3"，
+// 指向本地目录的 "link:" 引用返回空版本
+func parsePnpmReference(name, ref string, legacy bool) (string, string) {
+	if strings.HasPrefix(ref, "link:") || strings.HasPrefix(ref, "file:") {
+		return name, ""
+	}
+	if strings.HasPrefix(ref, "/") {
+		return parsePnpmKey(ref, legacy)
+	}
+	if i := strings.Index(ref, "("); i > 0 {
+		ref = ref[:i]
+	}
+	if legacy {
+		version, _, _ := strings.Cut(ref, "_")
+		return name, version
+	}
+	if i := strings.LastIndex(ref, "@"); i > 0 {
+		return splitNameAndVersion(ref)
+	}
+	return name, ref
+}
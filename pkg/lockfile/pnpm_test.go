@@ -0,0 +1,184 @@
+package lockfile
+
+import (
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+const pnpmLockV5 = `lockfileVersion: 5.4
+
+specifiers:
+  react-dom: ^17.0.0
+  sw: npm:string-width@^4
+
+dependencies:
+  react-dom: 17.0.2_react@17.0.2
+  sw: /string-width/4.2.3
+
+packages:
+
+  /react-dom/17.0.2_react@17.0.2:
+    resolution: {integrity: sha512-react-dom}
+    peerDependencies:
+      react: 17.0.2
+    dependencies:
+      react: 17.0.2
+    dev: false
+
+  /react/17.0.2:
+    resolution: {integrity: sha512-react}
+    dev: false
+
+  /string-width/4.2.3:
+    resolution: {integrity: sha512-sw}
+    dev: true
+`
+
+const pnpmLockV6 = `lockfileVersion: '6.0'
+
+importers:
+
+  .:
+    dependencies:
+      react-dom:
+        specifier: ^17.0.0
+        version: 17.0.2(react@17.0.2)
+      local:
+        specifier: workspace:*
+        version: link:packages/local
+    devDependencies:
+      '@scope/tool':
+        specifier: ^1.0.0
+        version: 1.0.0
+
+packages:
+
+  /@scope/tool@1.0.0:
+    resolution: {integrity: sha512-tool, tarball: https://npm.example.com/@scope/tool/-/tool-1.0.0.tgz}
+    dependencies:
+      sw: /string-width@4.2.3
+    dev: true
+
+  /react-dom@17.0.2(react@17.0.2):
+    resolution: {integrity: sha512-react-dom}
+    peerDependencies:
+      react: 17.0.2
+    dependencies:
+      react: 17.0.2
+    dev: false
+
+  /react@17.0.2:
+    resolution: {integrity: sha512-react}
+    dev: false
+
+  /string-width@4.2.3:
+    resolution: {integrity: sha512-sw}
+    dev: true
+`
+
+const pnpmLockV9 = `lockfileVersion: '9.0'
+
+settings:
+  autoInstallPeers: true
+
+importers:
+
+  .:
+    dependencies:
+      react-dom:
+        specifier: ^17.0.0
+        version: 17.0.2(react@17.0.2)
+      sw:
+        specifier: npm:string-width@^4
+        version: string-width@4.2.3
+
+packages:
+
+  react-dom@17.0.2:
+    resolution: {integrity: sha512-react-dom}
+    peerDependencies:
+      react: 17.0.2
+
+  react@17.0.2:
+    resolution: {integrity: sha512-react}
+
+  string-width@4.2.3:
+    resolution: {integrity: sha512-sw}
+    engines: {node: '>=8'}
+
+snapshots:
+
+  react-dom@17.0.2(react@17.0.2):
+    dependencies:
+      react: 17.0.2
+
+  react@17.0.2: {}
+
+  string-width@4.2.3:
+    optionalDependencies:
+      fsevents: 2.3.3
+`
+
+func TestParsePnpmLockV5(t *testing.T) {
+	project, err := ParsePnpmLock([]byte(pnpmLockV5))
+	assert.Nil(t, err)
+	assert.Equal(t, FormatPnpm, project.Format)
+	assert.Equal(t, "5.4", project.LockfileVersion)
+	assert.Equal(t, []*Dependency{
+		{Name: "react-dom", Spec: "^17.0.0", Version: "17.0.2", Type: resolver.DependencyTypeProd},
+		{Name: "sw", Spec: "npm:string-width@^4", Version: "4.2.3", Type: resolver.DependencyTypeProd},
+	}, project.Dependencies)
+
+	reactDom := project.Package("react-dom", "17.0.2")
+	assert.Equal(t, "sha512-react-dom", reactDom.Integrity)
+	assert.Equal(t, []string{"/react-dom/17.0.2_react@17.0.2"}, reactDom.Keys)
+	assert.Equal(t, []*Dependency{
+		{Name: "react", Spec: "17.0.2", Version: "17.0.2", Type: resolver.DependencyTypeProd},
+		{Name: "react", Spec: "17.0.2", Type: resolver.DependencyTypePeer},
+	}, reactDom.Dependencies)
+	assert.True(t, project.Package("string-width", "4.2.3").Dev)
+	assert.False(t, project.Package("react", "17.0.2").Dev)
+}
+
+func TestParsePnpmLockV6(t *testing.T) {
+	project, err := ParsePnpmLock([]byte(pnpmLockV6))
+	assert.Nil(t, err)
+	assert.Equal(t, "6.0", project.LockfileVersion)
+	assert.Equal(t, []*Dependency{
+		{Name: "@scope/tool", Spec: "^1.0.0", Version: "1.0.0", Type: resolver.DependencyTypeDev},
+		{Name: "local", Spec: "workspace:*", Type: resolver.DependencyTypeProd},
+		{Name: "react-dom", Spec: "^17.0.0", Version: "17.0.2", Type: resolver.DependencyTypeProd},
+	}, project.Dependencies)
+	assert.Len(t, project.Packages, 4)
+
+	tool := project.Package("@scope/tool", "1.0.0")
+	assert.Equal(t, "https://npm.example.com/@scope/tool/-/tool-1.0.0.tgz", tool.Resolved)
+	assert.True(t, tool.Dev)
+	assert.Equal(t, []*Dependency{{Name: "sw", Spec: "/string-width@4.2.3", Version: "4.2.3", Type: resolver.DependencyTypeProd}}, tool.Dependencies)
+	assert.NotNil(t, project.Package("react-dom", "17.0.2"))
+}
+
+func TestParsePnpmLockV9(t *testing.T) {
+	project, err := ParsePnpmLock([]byte(pnpmLockV9))
+	assert.Nil(t, err)
+	assert.Equal(t, "9.0", project.LockfileVersion)
+	assert.Equal(t, "4.2.3", project.Dependencies[1].Version)
+
+	var ids []string
+	for _, p := range project.Packages {
+		ids = append(ids, p.ID())
+	}
+	assert.Equal(t, []string{"react@17.0.2", "react-dom@17.0.2", "string-width@4.2.3"}, ids)
+
+	reactDom := project.Package("react-dom", "17.0.2")
+	assert.Equal(t, "sha512-react-dom", reactDom.Integrity)
+	assert.Equal(t, []string{"react-dom@17.0.2(react@17.0.2)"}, reactDom.Keys)
+	assert.Len(t, reactDom.Dependencies, 2)
+	assert.Equal(t, []*Dependency{{Name: "fsevents", Spec: "2.3.3", Version: "2.3.3", Type: resolver.DependencyTypeOptional}},
+		project.Package("string-width", "4.2.3").Dependencies)
+
+	_, err = ParsePnpmLock([]byte("lockfileVersion: [\n"))
+	assert.NotNil(t, err)
+}
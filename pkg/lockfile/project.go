@@ -0,0 +1,264 @@
+package lockfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// ErrUnknownFormat 表示无法识别的锁文件格式
+var ErrUnknownFormat = errors.New("unknown lockfile format")
+
+// Format 表示锁文件的格式
+type Format string
+
+const (
+	// FormatNpm package-lock.json 或 npm-shrinkwrap.json，lockfileVersion 1 到 3
+	FormatNpm Format = "npm"
+
+	// FormatYarnClassic Yarn 1.x 使用的 yarn.lock
+	FormatYarnClassic Format = "yarn-classic"
+
+	// FormatYarnBerry Yarn 2 及以上版本使用的 YAML 格式的 yarn.lock
+	FormatYarnBerry Format = "yarn-berry"
+
+	// FormatPnpm pnpm-lock.yaml，lockfileVersion 5.x、6.0 和 9.0
+	FormatPnpm Format = "pnpm"
+)
+
+// Dependency 表示锁文件中记录的一条依赖关系
+//
+// 主要字段说明:
+//   - Name: 依赖名称，别名依赖时为别名
+//   - Spec: 声明的版本范围，pnpm 的包条目中不记录版本范围，此时为锁定的版本引用
+//   - Version: 锁定的版本，无法确定时（例如未安装的同级依赖）为空字符串
+//   - Type: 依赖类型，只有项目本身的依赖会出现 dev 类型
+type Dependency struct {
+	Name    string
+	Spec    string
+	Version string
+	Type    resolver.DependencyType
+}
+
+// Package 表示锁文件中锁定的一个包版本
+//
+// 同一个 "name@version" 被安装在多个位置时会被合并为一个 Package
+//
+// 主要字段说明:
+//   - Name: 包在 Registry 中的真实名称
+//   - Version: 锁定的版本
+//   - Resolved: tarball 的下载地址，锁文件中没有记录时为空（例如 Yarn Berry）
+//   - Integrity: SRI 格式的完整性校验值，锁文件中没有记录时为空
+//   - Dev: 只被开发依赖引用，锁文件中没有记录时为 false
+//   - Optional: 只被可选依赖引用，锁文件中没有记录时为 false
+//   - Keys: 包在锁文件中对应的所有键，例如 npm 的安装路径或 yarn 的依赖描述
+//   - Dependencies: 该包的依赖，按名称排序
+//   - Manifest: 该版本在 Registry 中的元数据，调用 Project.Enrich 之后才会被填充
+type Package struct {
+	Name         string
+	Version      string
+	Resolved     string
+	Integrity    string
+	Dev          bool
+	Optional     bool
+	Keys         []string
+	Dependencies []*Dependency
+	Manifest     *models.Version
+}
+
+// ID 返回包的唯一标识，格式为 "name@version"
+func (x *Package) ID() string {
+	return x.Name + "@" + x.Version
+}
+
+// Project 表示从锁文件中解析出的项目依赖信息
+//
+// 主要字段说明:
+//   - Format: 锁文件格式
+//   - LockfileVersion: 锁文件中记录的格式版本，例如 "3"、"v1"、"6"、"9.0"
+//   - Name: 项目名称，锁文件中没有记录时为空
+//   - Version: 项目版本，锁文件中没有记录时为空
+//   - Dependencies: 项目的直接依赖，package-lock.json v1 和 Yarn Classic 不记录直接依赖，此时为空
+//   - Packages: 所有被锁定的包，按名称和版本排序
+type Project struct {
+	Format          Format
+	LockfileVersion string
+	Name            string
+	Version         string
+	Dependencies    []*Dependency
+	Packages        []*Package
+}
+
+// Package 根据名称和版本查找锁定的包，不存在时返回 nil
+func (x *Project) Package(name, version string) *Package {
+	for _, p := range x.Packages {
+		if p.Name == name && p.Version == version {
+			return p
+		}
+	}
+	return nil
+}
+
+// Resolve 查找依赖关系锁定的包，别名依赖 "npm:real-name@range" 会使用真实的包名查找
+//
+// 参数:
+//   - dep: 项目或包的依赖关系
+//
+// 返回值:
+//   - *Package: 锁定的包，依赖没有锁定版本或锁文件中不存在对应的包时返回 nil
+func (x *Project) Resolve(dep *Dependency) *Package {
+	if dep.Version == "" {
+		return nil
+	}
+	name := dep.Name
+	if strings.HasPrefix(dep.Spec, "npm:") {
+		if realName, _ := splitNameAndVersion(strings.TrimPrefix(dep.Spec, "npm:")); realName != "" {
+			name = realName
+		}
+	}
+	return x.Package(name, dep.Version)
+}
+
+// Parse 根据文件名选择对应的解析器解析锁文件
+//
+// 支持的文件名为 package-lock.json、npm-shrinkwrap.json、yarn.lock 和 pnpm-lock.yaml，
+// yarn.lock 会根据内容自动区分 Yarn Classic 和 Yarn Berry
+//
+// 参数:
+//   - filename: 锁文件的文件名或路径，只使用最后一段文件名
+//   - data: 锁文件内容
+//
+// 返回值:
+//   - *Project: 解析得到的项目依赖信息
+//   - error: 文件名无法识别时返回包装了 ErrUnknownFormat 的错误，解析失败时返回对应的错误
+//
+// 使用示例:
+//
+//	data, _ := os.ReadFile("yarn.lock")
+//	project, err := lockfile.Parse("yarn.lock", data)
+//	if err != nil {
+//		// 处理错误
+//	}
+//	for _, p := range project.Packages {
+//		fmt.Println(p.ID(), p.Integrity)
+//	}
+func Parse(filename string, data []byte) (*Project, error) {
+	switch filepath.Base(filename) {
+	case "package-lock.json", "npm-shrinkwrap.json":
+		return ParsePackageLock(data)
+	case "yarn.lock":
+		return ParseYarnLock(data)
+	case "pnpm-lock.yaml", "pnpm-lock.yml":
+		return ParsePnpmLock(data)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, filename)
+}
+
+// ParseFile 读取并解析锁文件，文件格式由文件名决定，规则与 Parse 相同
+func ParseFile(filename string) (*Project, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(filename, data)
+}
+
+// collector 用于在解析过程中按 "name@version" 合并包
+type collector struct {
+	packages map[string]*Package
+}
+
+func newCollector() *collector {
+	return &collector{packages: make(map[string]*Package)}
+}
+
+// add 加入一个包，已经存在时合并键，dev 和 optional 只有在所有位置都成立时才成立
+func (x *collector) add(p *Package) *Package {
+	existing, ok := x.packages[p.ID()]
+	if !ok {
+		x.packages[p.ID()] = p
+		return p
+	}
+	existing.Keys = append(existing.Keys, p.Keys...)
+	existing.Dev = existing.Dev && p.Dev
+	existing.Optional = existing.Optional && p.Optional
+	if existing.Resolved == "" {
+		existing.Resolved = p.Resolved
+	}
+	if existing.Integrity == "" {
+		existing.Integrity = p.Integrity
+	}
+	if len(existing.Dependencies) == 0 {
+		existing.Dependencies = p.Dependencies
+	}
+	return existing
+}
+
+// sorted 返回按名称和版本排序后的包
+func (x *collector) sorted() []*Package {
+	packages := make([]*Package, 0, len(x.packages))
+	for _, p := range x.packages {
+		sort.Strings(p.Keys)
+		packages = append(packages, p)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Name != packages[j].Name {
+			return packages[i].Name < packages[j].Name
+		}
+		return semver.Compare(packages[i].Version, packages[j].Version) < 0
+	})
+	return packages
+}
+
+// dependencies 将依赖声明转换为按名称排序的依赖列表
+func dependencies(specs map[string]string, depType resolver.DependencyType, version func(name, spec string) string) []*Dependency {
+	result := make([]*Dependency, 0, len(specs))
+	for name, spec := range specs {
+		dep := &Dependency{Name: name, Spec: spec, Type: depType}
+		if version != nil {
+			dep.Version = version(name, spec)
+		}
+		result = append(result, dep)
+	}
+	sortDependencies(result)
+	return result
+}
+
+// dependencyTypeOrder 同名依赖按照该顺序排列
+var dependencyTypeOrder = map[resolver.DependencyType]int{
+	resolver.DependencyTypeProd:     0,
+	resolver.DependencyTypeDev:      1,
+	resolver.DependencyTypeOptional: 2,
+	resolver.DependencyTypePeer:     3,
+}
+
+// sortDependencies 按照名称排序，同名的依赖按照 prod、dev、optional、peer 的顺序排列
+func sortDependencies(deps []*Dependency) {
+	sort.SliceStable(deps, func(i, j int) bool {
+		if deps[i].Name != deps[j].Name {
+			return deps[i].Name < deps[j].Name
+		}
+		return dependencyTypeOrder[deps[i].Type] < dependencyTypeOrder[deps[j].Type]
+	})
+}
+
+// splitNameAndVersion 将 "name@version" 拆分为名称和版本，正确处理作用域包 "@scope/name@version"
+func splitNameAndVersion(s string) (string, string) {
+	start := 0
+	if strings.HasPrefix(s, "@") {
+		start = 1
+	}
+	i := strings.Index(s[start:], "@")
+	if i < 0 {
+		return s, ""
+	}
+	i += start
+	return s[:i], s[i+1:]
+}
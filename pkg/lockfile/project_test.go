@@ -0,0 +1,55 @@
+package lockfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for filename, expected := range map[string]Format{
+		"package-lock.json":       FormatNpm,
+		"app/npm-shrinkwrap.json": FormatNpm,
+		"yarn.lock":               FormatYarnClassic,
+		"/repo/pnpm-lock.yaml":    FormatPnpm,
+		"berry/yarn.lock":         FormatYarnBerry,
+	} {
+		data := map[Format]string{
+			FormatNpm:         packageLockV3,
+			FormatYarnClassic: yarnClassicLock,
+			FormatYarnBerry:   yarnBerryLock,
+			FormatPnpm:        pnpmLockV9,
+		}[expected]
+		project, err := Parse(filename, []byte(data))
+		assert.Nil(t, err, filename)
+		assert.Equal(t, expected, project.Format, filename)
+	}
+
+	_, err := Parse("Gemfile.lock", nil)
+	assert.True(t, errors.Is(err, ErrUnknownFormat))
+}
+
+func TestParseFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "package-lock.json")
+	assert.Nil(t, os.WriteFile(filename, []byte(packageLockV1), 0644))
+	project, err := ParseFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, "legacy", project.Name)
+
+	_, err = ParseFile(filepath.Join(t.TempDir(), "yarn.lock"))
+	assert.NotNil(t, err)
+}
+
+func TestProjectResolve(t *testing.T) {
+	project := &Project{Packages: []*Package{
+		{Name: "lodash", Version: "4.17.21"},
+		{Name: "string-width", Version: "4.2.3"},
+	}}
+	assert.Equal(t, "lodash@4.17.21", project.Resolve(&Dependency{Name: "lodash", Spec: "^4.17.0", Version: "4.17.21"}).ID())
+	assert.Equal(t, "string-width@4.2.3", project.Resolve(&Dependency{Name: "string-width-cjs", Spec: "npm:string-width@^4.2.0", Version: "4.2.3"}).ID())
+	assert.Nil(t, project.Resolve(&Dependency{Name: "lodash", Spec: "^3.0.0", Version: "3.10.1"}))
+	assert.Nil(t, project.Resolve(&Dependency{Name: "react-dom", Spec: "^18.0.0"}))
+}
@@ -0,0 +1,225 @@
+package lockfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// keyOrder 是 npm 写入锁文件时优先输出的键，其余的键按照字母顺序排列在后面
+var keyOrder = []string{
+	"name",
+	"version",
+	"lockfileVersion",
+	"resolved",
+	"integrity",
+	"requires",
+	"packages",
+	"dependencies",
+}
+
+// stringify 将任意值编码为与 npm 写入的锁文件完全相同的 JSON 文本
+//
+// npm 使用 json-stringify-nice 输出锁文件，规则为:
+//   - 使用两个空格缩进，文件以换行符结尾
+//   - 对象中先输出非对象的值（数组也视为非对象），再输出对象值
+//   - 每一组中 keyOrder 中的键排在前面，其余的键按照 localeCompare 排序
+func stringify(v interface{}) ([]byte, error) {
+	// 先编码再解码，统一转换为 map[string]interface{} 等通用类型
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(&buf)
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := writeValue(&out, value, ""); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+func writeValue(out *bytes.Buffer, value interface{}, indent string) error {
+	switch v := value.(type) {
+	case nil:
+		out.WriteString("null")
+	case bool:
+		if v {
+			out.WriteString("true")
+		} else {
+			out.WriteString("false")
+		}
+	case json.Number:
+		out.WriteString(v.String())
+	case string:
+		writeString(out, v)
+	case []interface{}:
+		if len(v) == 0 {
+			out.WriteString("[]")
+			return nil
+		}
+		out.WriteString("[\n")
+		for i, item := range v {
+			out.WriteString(indent + "  ")
+			if err := writeValue(out, item, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				out.WriteByte(',')
+			}
+			out.WriteByte('\n')
+		}
+		out.WriteString(indent + "]")
+	case map[string]interface{}:
+		if len(v) == 0 {
+			out.WriteString("{}")
+			return nil
+		}
+		out.WriteString("{\n")
+		keys := sortedKeys(v)
+		for i, key := range keys {
+			out.WriteString(indent + "  ")
+			writeString(out, key)
+			out.WriteString(": ")
+			if err := writeValue(out, v[key], indent+"  "); err != nil {
+				return err
+			}
+			if i < len(keys)-1 {
+				out.WriteByte(',')
+			}
+			out.WriteByte('\n')
+		}
+		out.WriteString(indent + "}")
+	default:
+		return fmt.Errorf("unsupported json value type %T", value)
+	}
+	return nil
+}
+
+// writeString 按照 JSON.stringify 的规则转义字符串，不会转义 HTML 字符和非 ASCII 字符
+func writeString(out *bytes.Buffer, s string) {
+	out.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\b':
+			out.WriteString(`\b`)
+		case '\f':
+			out.WriteString(`\f`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '\t':
+			out.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(out, `\u%04x`, r)
+			} else {
+				out.WriteRune(r)
+			}
+		}
+	}
+	out.WriteByte('"')
+}
+
+// sortedKeys 返回按照 json-stringify-nice 规则排序后的键
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	rank := func(key string) int {
+		for i, k := range keyOrder {
+			if k == key {
+				return i
+			}
+		}
+		return len(keyOrder)
+	}
+	isObject := func(key string) bool {
+		_, ok := m[key].(map[string]interface{})
+		return ok
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if oa, ob := isObject(a), isObject(b); oa != ob {
+			return !oa
+		}
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra < rb
+		}
+		return localeCompare(a, b) < 0
+	})
+	return keys
+}
+
+// punctuationOrder 是 ASCII 标点和符号在 Unicode 默认排序规则（ICU root locale）中的先后顺序
+const punctuationOrder = "\t\n\v\f\r _-,;:!?.'\"()[]{}@*/\\&#%`^+<=>|~$"
+
+// collationKey 返回字符在第一级比较中的权重，标点符号排在数字前面，数字排在字母前面，字母不区分大小写
+func collationKey(r rune) int {
+	if i := strings.IndexRune(punctuationOrder, r); i >= 0 {
+		return i
+	}
+	switch {
+	case r >= '0' && r <= '9':
+		return 100 + int(r-'0')
+	case r >= 'a' && r <= 'z':
+		return 200 + int(r-'a')
+	case r >= 'A' && r <= 'Z':
+		return 200 + int(r-'A')
+	}
+	return 1000 + int(r)
+}
+
+// localeCompare 近似实现 JavaScript 中 a.localeCompare(b, 'en') 的比较结果
+//
+// 先忽略大小写按照 collationKey 比较，如果相同则小写字母排在大写字母前面
+func localeCompare(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	for i := 0; i < len(ra) && i < len(rb); i++ {
+		if ka, kb := collationKey(ra[i]), collationKey(rb[i]); ka != kb {
+			if ka < kb {
+				return -1
+			}
+			return 1
+		}
+	}
+	if len(ra) != len(rb) {
+		if len(ra) < len(rb) {
+			return -1
+		}
+		return 1
+	}
+	for i := range ra {
+		if ra[i] == rb[i] {
+			continue
+		}
+		lowerA, lowerB := ra[i] >= 'a' && ra[i] <= 'z', rb[i] >= 'a' && rb[i] <= 'z'
+		if lowerA != lowerB {
+			if lowerA {
+				return -1
+			}
+			return 1
+		}
+		if ra[i] < rb[i] {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
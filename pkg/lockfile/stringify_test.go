@@ -0,0 +1,42 @@
+package lockfile
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocaleCompare(t *testing.T) {
+	keys := []string{"b", "B", "a", "_a", "-a", "@scope/a", "a-b", "a_b", "ab", "1", "A"}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return localeCompare(keys[i], keys[j]) < 0
+	})
+	assert.Equal(t, []string{"_a", "-a", "@scope/a", "1", "a", "A", "a_b", "a-b", "ab", "b", "B"}, keys)
+	assert.Equal(t, 0, localeCompare("same", "same"))
+}
+
+func TestStringify(t *testing.T) {
+	data, err := stringify(map[string]interface{}{
+		"z":            "<tag>\n\u0001",
+		"packages":     map[string]interface{}{},
+		"dependencies": map[string]interface{}{"x": "1"},
+		"list":         []string{},
+		"version":      "1.0.0",
+		"count":        1.5,
+		"nothing":      nil,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `{
+  "version": "1.0.0",
+  "count": 1.5,
+  "list": [],
+  "nothing": null,
+  "z": "<tag>\n\u0001",
+  "packages": {},
+  "dependencies": {
+    "x": "1"
+  }
+}
+`, string(data))
+}
@@ -0,0 +1,128 @@
+package lockfile
+
+import (
+	"io"
+
+	"github.com/scagogogo/npm-crawler/pkg/layout"
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// New 根据项目的清单和计算好的 node_modules 目录树生成 lockfileVersion 3 的锁文件
+//
+// 参数:
+//   - root: 项目本身的 package.json
+//   - tree: layout.Plan 生成的目录树
+//
+// 返回值:
+//   - *Lockfile: 生成的锁文件，可以通过 Marshal 序列化
+//
+// 使用示例:
+//
+//	graph, _ := r.ResolveManifest(ctx, root)
+//	lock := lockfile.New(root, layout.Plan(graph))
+//	data, _ := lock.Marshal()
+//	os.WriteFile("package-lock.json", data, 0644)
+func New(root *models.Version, tree *layout.Tree) *Lockfile {
+	lock := &Lockfile{
+		Name:            root.Name,
+		Version:         root.Version,
+		LockfileVersion: LockfileVersion,
+		Requires:        true,
+		Packages:        make(map[string]*Entry),
+	}
+
+	rootEntry := manifestEntry(root, root.Name)
+	rootEntry.Name = root.Name
+	rootEntry.DevDependencies = nonEmpty(root.DevDependencies)
+	lock.Packages[""] = rootEntry
+
+	for _, p := range tree.Placements() {
+		entry := manifestEntry(p.Node.Manifest, p.Node.Name)
+		entry.Version = p.Node.Version
+		if p.Node.Name != p.Name {
+			entry.Name = p.Node.Name
+		}
+		entry.Resolved = p.Node.Resolved
+		entry.Integrity = p.Node.Integrity
+		entry.Peer = p.Peer
+		entry.Dev = p.Dev
+		entry.Optional = p.Optional
+		entry.DevOptional = p.DevOptional && !p.Dev && !p.Optional
+		lock.Packages[p.Path] = entry
+	}
+	return lock
+}
+
+// Marshal 将锁文件序列化为与 npm 写入结果逐字节相同的 JSON 文本
+func (x *Lockfile) Marshal() ([]byte, error) {
+	return stringify(x)
+}
+
+// Write 根据项目的清单和目录树生成锁文件并写入 w
+//
+// 参数:
+//   - w: 输出目标，例如打开的 package-lock.json 文件
+//   - root: 项目本身的 package.json
+//   - tree: layout.Plan 生成的目录树
+//
+// 返回值:
+//   - error: 序列化或写入失败时返回错误
+func Write(w io.Writer, root *models.Version, tree *layout.Tree) error {
+	data, err := New(root, tree).Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// manifestEntry 从清单中复制锁文件需要的字段，与 npm 一样忽略空值和空对象
+func manifestEntry(manifest *models.Version, name string) *Entry {
+	entry := &Entry{}
+	if manifest == nil {
+		return entry
+	}
+	entry.Version = manifest.Version
+	entry.License = manifest.License
+	entry.Dependencies = nonEmpty(manifest.Dependencies)
+	entry.OptionalDependencies = nonEmpty(manifest.OptionalDependencies)
+	entry.PeerDependencies = nonEmpty(manifest.PeerDependencies)
+	if len(manifest.PeerDependenciesMeta) > 0 {
+		entry.PeerDependenciesMeta = manifest.PeerDependenciesMeta
+	}
+	entry.Engines = nonEmpty(manifest.Engines)
+	if len(manifest.Os) > 0 {
+		entry.Os = manifest.Os
+	}
+	if len(manifest.Cpu) > 0 {
+		entry.Cpu = manifest.Cpu
+	}
+	entry.Bin = manifest.Bin.Normalize(name)
+	if !isEmptyValue(manifest.Funding) {
+		entry.Funding = manifest.Funding
+	}
+	entry.HasInstallScript = manifest.HasInstallScript
+	entry.Deprecated = manifest.Deprecated
+	return entry
+}
+
+func nonEmpty(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case map[string]interface{}:
+		return len(value) == 0
+	case []interface{}:
+		return len(value) == 0
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package lockfile
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/layout"
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+const expectedLockfile = `{
+  "name": "my-app",
+  "version": "1.0.0",
+  "lockfileVersion": 3,
+  "requires": true,
+  "packages": {
+    "": {
+      "name": "my-app",
+      "version": "1.0.0",
+      "license": "MIT",
+      "dependencies": {
+        "a": "^1.0.0",
+        "string-width-cjs": "npm:string-width@^4"
+      },
+      "devDependencies": {
+        "test": "^2.0.0"
+      }
+    },
+    "node_modules/a": {
+      "version": "1.0.0",
+      "resolved": "https://registry.npmjs.org/a/-/a-1.0.0.tgz",
+      "integrity": "sha512-a",
+      "license": "MIT",
+      "dependencies": {
+        "b": "^1.0.0"
+      },
+      "bin": {
+        "a": "cli.js"
+      },
+      "engines": {
+        "node": ">=14"
+      }
+    },
+    "node_modules/b": {
+      "version": "1.0.0",
+      "resolved": "https://registry.npmjs.org/b/-/b-1.0.0.tgz",
+      "integrity": "sha512-b1",
+      "os": [
+        "darwin"
+      ],
+      "funding": {
+        "url": "https://example.com/fund?a=1&b=<2>"
+      }
+    },
+    "node_modules/string-width-cjs": {
+      "name": "string-width",
+      "version": "4.2.3",
+      "resolved": "https://registry.npmjs.org/string-width/-/string-width-4.2.3.tgz",
+      "integrity": "sha512-sw"
+    },
+    "node_modules/test": {
+      "version": "2.0.0",
+      "resolved": "https://registry.npmjs.org/test/-/test-2.0.0.tgz",
+      "integrity": "sha512-test",
+      "deprecated": "use x",
+      "dev": true,
+      "hasInstallScript": true,
+      "dependencies": {
+        "b": "^2.0.0"
+      }
+    },
+    "node_modules/test/node_modules/b": {
+      "version": "2.0.0",
+      "resolved": "https://registry.npmjs.org/b/-/b-2.0.0.tgz",
+      "integrity": "sha512-b2",
+      "dev": true
+    }
+  }
+}
+`
+
+func newNode(manifest *models.Version, integrity string) *resolver.Node {
+	return &resolver.Node{
+		Name:      manifest.Name,
+		Version:   manifest.Version,
+		Integrity: integrity,
+		Resolved:  "https://registry.npmjs.org/" + manifest.Name + "/-/" + manifest.Name + "-" + manifest.Version + ".tgz",
+		Manifest:  manifest,
+	}
+}
+
+func TestWrite(t *testing.T) {
+	root := &models.Version{
+		Name:            "my-app",
+		Version:         "1.0.0",
+		License:         "MIT",
+		Dependencies:    map[string]string{"a": "^1.0.0", "string-width-cjs": "npm:string-width@^4"},
+		DevDependencies: map[string]string{"test": "^2.0.0"},
+	}
+	a := newNode(&models.Version{
+		Name:         "a",
+		Version:      "1.0.0",
+		License:      "MIT",
+		Dependencies: map[string]string{"b": "^1.0.0"},
+		Bin:          models.Bin{"": "./cli.js"},
+		Engines:      models.Engines{"node": ">=14"},
+	}, "sha512-a")
+	b1 := newNode(&models.Version{
+		Name:    "b",
+		Version: "1.0.0",
+		Os:      []string{"darwin"},
+		Funding: map[string]interface{}{"url": "https://example.com/fund?a=1&b=<2>"},
+		// 空对象不会被写入锁文件
+		PeerDependencies: map[string]string{},
+	}, "sha512-b1")
+	b2 := newNode(&models.Version{Name: "b", Version: "2.0.0"}, "sha512-b2")
+	sw := newNode(&models.Version{Name: "string-width", Version: "4.2.3"}, "sha512-sw")
+	test := newNode(&models.Version{
+		Name:             "test",
+		Version:          "2.0.0",
+		Dependencies:     map[string]string{"b": "^2.0.0"},
+		HasInstallScript: true,
+		Deprecated:       "use x",
+	}, "sha512-test")
+
+	a.Dependencies = []*resolver.Edge{{From: a, Name: "b", Spec: "^1.0.0", Type: resolver.DependencyTypeProd, To: b1}}
+	test.Dependencies = []*resolver.Edge{{From: test, Name: "b", Spec: "^2.0.0", Type: resolver.DependencyTypeProd, To: b2}}
+	graph := resolver.NewGraph()
+	graph.Roots = []*resolver.Edge{
+		{Name: "a", Spec: "^1.0.0", Type: resolver.DependencyTypeProd, To: a},
+		{Name: "string-width-cjs", Spec: "npm:string-width@^4", Type: resolver.DependencyTypeProd, To: sw},
+		{Name: "test", Spec: "^2.0.0", Type: resolver.DependencyTypeDev, To: test},
+	}
+	for _, node := range []*resolver.Node{a, b1, b2, sw, test} {
+		graph.Nodes[node.ID()] = node
+	}
+
+	var buf bytes.Buffer
+	err := Write(&buf, root, layout.Plan(graph))
+	assert.Nil(t, err)
+	assert.Equal(t, expectedLockfile, buf.String())
+}
@@ -0,0 +1,243 @@
+package lockfile
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"gopkg.in/yaml.v3"
+)
+
+// ParseYarnLock 解析 yarn.lock，根据内容自动区分 Yarn Classic（1.x）和 Yarn Berry（2 及以上版本）
+//
+// Yarn Classic 的 resolved 字段中 "#" 之后的 sha1 校验值会被去掉，
+// 缺少 integrity 字段时会使用该校验值生成 "sha1-" 开头的 SRI。
+// Yarn Berry 的锁文件不记录下载地址和 SRI，Package 的 Resolved 和 Integrity 为空，
+// 可以通过 Project.Enrich 从 Registry 补全
+//
+// 参数:
+//   - data: 锁文件内容
+//
+// 返回值:
+//   - *Project: 解析得到的项目依赖信息，Package.Keys 为 yarn.lock 中的依赖描述，例如 "lodash@^4.17.0"
+//   - error: 如果格式不正确则返回错误
+func ParseYarnLock(data []byte) (*Project, error) {
+	if isYarnBerry(data) {
+		return parseYarnBerry(data)
+	}
+	return parseYarnClassic(data)
+}
+
+// isYarnBerry 判断是否是 Yarn Berry 的锁文件，Berry 的锁文件总是包含 __metadata 字段
+func isYarnBerry(data []byte) bool {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if string(bytes.TrimRight(line, "\r ")) == "__metadata:" {
+			return true
+		}
+	}
+	return false
+}
+
+// yarnClassicEntry 表示 Yarn Classic 锁文件中的一项
+type yarnClassicEntry struct {
+	descriptors []string
+	fields      map[string]string
+	sections    map[string]map[string]string
+}
+
+func parseYarnClassic(data []byte) (*Project, error) {
+	var entries []*yarnClassicEntry
+	var current *yarnClassicEntry
+	var section map[string]string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r ")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		switch {
+		case indent == 0:
+			if !strings.HasSuffix(line, ":") {
+				return nil, fmt.Errorf("parse yarn.lock: line %d: unexpected %q", i+1, line)
+			}
+			current = &yarnClassicEntry{
+				fields:   make(map[string]string),
+				sections: make(map[string]map[string]string),
+			}
+			for _, descriptor := range strings.Split(strings.TrimSuffix(line, ":"), ",") {
+				current.descriptors = append(current.descriptors, unquoteYarn(strings.TrimSpace(descriptor)))
+			}
+			entries = append(entries, current)
+			section = nil
+		case current == nil:
+			return nil, fmt.Errorf("parse yarn.lock: line %d: unexpected indentation", i+1)
+		case indent == 2 && strings.HasSuffix(trimmed, ":"):
+			section = make(map[string]string)
+			current.sections[unquoteYarn(strings.TrimSuffix(trimmed, ":"))] = section
+		case indent == 2:
+			key, value := splitYarnField(trimmed)
+			current.fields[key] = value
+			section = nil
+		default:
+			if section == nil {
+				return nil, fmt.Errorf("parse yarn.lock: line %d: unexpected indentation", i+1)
+			}
+			key, value := splitYarnField(trimmed)
+			section[key] = value
+		}
+	}
+
+	byDescriptor := make(map[string]*yarnClassicEntry)
+	for _, entry := range entries {
+		for _, descriptor := range entry.descriptors {
+			byDescriptor[descriptor] = entry
+		}
+	}
+	version := func(name, spec string) string {
+		if entry, ok := byDescriptor[name+"@"+spec]; ok {
+			return entry.fields["version"]
+		}
+		return ""
+	}
+
+	c := newCollector()
+	for _, entry := range entries {
+		alias, spec := splitNameAndVersion(entry.descriptors[0])
+		name := alias
+		if strings.HasPrefix(spec, "npm:") {
+			name, _ = splitNameAndVersion(strings.TrimPrefix(spec, "npm:"))
+		}
+		resolved, integrity := entry.fields["resolved"], entry.fields["integrity"]
+		if url, hash, ok := strings.Cut(resolved, "#"); ok {
+			resolved = url
+			if sum, err := hex.DecodeString(hash); err == nil && integrity == "" && len(sum) == 20 {
+				integrity = "sha1-" + base64.StdEncoding.EncodeToString(sum)
+			}
+		}
+		deps := append(
+			dependencies(entry.sections["dependencies"], resolver.DependencyTypeProd, version),
+			dependencies(entry.sections["optionalDependencies"], resolver.DependencyTypeOptional, version)...,
+		)
+		sortDependencies(deps)
+		c.add(&Package{
+			Name:         name,
+			Version:      entry.fields["version"],
+			Resolved:     resolved,
+			Integrity:    integrity,
+			Keys:         entry.descriptors,
+			Dependencies: deps,
+		})
+	}
+	return &Project{
+		Format:          FormatYarnClassic,
+		LockfileVersion: "v1",
+		Packages:        c.sorted(),
+	}, nil
+}
+
+// splitYarnField 将 `key "value"` 格式的一行拆分为键和值，键和值都可能带有引号
+func splitYarnField(line string) (string, string) {
+	var key, rest string
+	if strings.HasPrefix(line, `"`) {
+		if prefix, err := strconv.QuotedPrefix(line); err == nil {
+			key, rest = unquoteYarn(prefix), line[len(prefix):]
+		} else {
+			key = line
+		}
+	} else {
+		key, rest, _ = strings.Cut(line, " ")
+	}
+	return key, unquoteYarn(strings.TrimSpace(rest))
+}
+
+func unquoteYarn(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+	}
+	return s
+}
+
+// berryEntry 表示 Yarn Berry 锁文件中的一项
+type berryEntry struct {
+	Version          string            `yaml:"version"`
+	Resolution       string            `yaml:"resolution"`
+	Dependencies     map[string]string `yaml:"dependencies"`
+	PeerDependencies map[string]string `yaml:"peerDependencies"`
+	DependenciesMeta map[string]struct {
+		Optional bool `yaml:"optional"`
+	} `yaml:"dependenciesMeta"`
+}
+
+func parseYarnBerry(data []byte) (*Project, error) {
+	var entries map[string]*berryEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse yarn.lock: %w", err)
+	}
+	project := &Project{Format: FormatYarnBerry}
+	if metadata, ok := entries["__metadata"]; ok {
+		project.LockfileVersion = metadata.Version
+		delete(entries, "__metadata")
+	}
+
+	byDescriptor := make(map[string]*berryEntry)
+	keys := make(map[*berryEntry][]string)
+	for key, entry := range entries {
+		for _, descriptor := range strings.Split(key, ",") {
+			descriptor = strings.TrimSpace(descriptor)
+			byDescriptor[descriptor] = entry
+			keys[entry] = append(keys[entry], descriptor)
+		}
+	}
+	version := func(name, spec string) string {
+		descriptor := name + "@" + spec
+		if !strings.Contains(spec, ":") {
+			descriptor = name + "@npm:" + spec
+		}
+		if entry, ok := byDescriptor[descriptor]; ok {
+			return entry.Version
+		}
+		return ""
+	}
+	entryDependencies := func(entry *berryEntry) []*Dependency {
+		var deps []*Dependency
+		for name, spec := range entry.Dependencies {
+			depType := resolver.DependencyTypeProd
+			if entry.DependenciesMeta[name].Optional {
+				depType = resolver.DependencyTypeOptional
+			}
+			deps = append(deps, &Dependency{Name: name, Spec: spec, Version: version(name, spec), Type: depType})
+		}
+		deps = append(deps, dependencies(entry.PeerDependencies, resolver.DependencyTypePeer, nil)...)
+		sortDependencies(deps)
+		return deps
+	}
+
+	c := newCollector()
+	for _, entry := range entries {
+		name, reference := splitNameAndVersion(entry.Resolution)
+		if reference == "workspace:." {
+			project.Name, project.Version = name, entry.Version
+			project.Dependencies = entryDependencies(entry)
+			continue
+		}
+		if !strings.HasPrefix(reference, "npm:") {
+			// workspace、patch、git 等协议的包不是直接来自 Registry
+			continue
+		}
+		c.add(&Package{
+			Name:         name,
+			Version:      entry.Version,
+			Keys:         keys[entry],
+			Dependencies: entryDependencies(entry),
+		})
+	}
+	project.Packages = c.sorted()
+	return project, nil
+}
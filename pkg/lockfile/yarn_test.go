@@ -0,0 +1,139 @@
+package lockfile
+
+import (
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+const yarnClassicLock = `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@babel/code-frame@^7.0.0", "@babel/code-frame@^7.10.4":
+  version "7.12.13"
+  resolved "https://registry.yarnpkg.com/@babel/code-frame/-/code-frame-7.12.13.tgz#dcfc826beef65e75c50e21d3837d7d95798dd658"
+  integrity sha512-code-frame
+  dependencies:
+    "@babel/highlight" "^7.10.4"
+
+"@babel/highlight@^7.10.4":
+  version "7.13.10"
+  resolved "https://registry.yarnpkg.com/@babel/highlight/-/highlight-7.13.10.tgz#a8b2a66148f5b27d666b15d81774347a731d52d1"
+  dependencies:
+    chalk "^2.0.0"
+  optionalDependencies:
+    fsevents "~2.3.1"
+
+"string-width-cjs@npm:string-width@^4.2.0":
+  version "4.2.3"
+  resolved "https://registry.yarnpkg.com/string-width/-/string-width-4.2.3.tgz"
+  integrity sha512-sw
+`
+
+const yarnBerryLock = `# This file is generated by running "yarn install" inside your project.
+# Manual changes might be lost - proceed with caution!
+
+__metadata:
+  version: 6
+  cacheKey: 8
+
+"@babel/code-frame@npm:^7.0.0, @babel/code-frame@npm:^7.10.4":
+  version: 7.12.13
+  resolution: "@babel/code-frame@npm:7.12.13"
+  dependencies:
+    "@babel/highlight": ^7.10.4
+  checksum: 471532bb7cf4224adb9ebb7b9a3a8b5b0a9b0a5d
+  languageName: node
+  linkType: hard
+
+"@babel/highlight@npm:^7.10.4":
+  version: 7.13.10
+  resolution: "@babel/highlight@npm:7.13.10"
+  dependencies:
+    fsevents: ~2.3.1
+  peerDependencies:
+    react: "*"
+  dependenciesMeta:
+    fsevents:
+      optional: true
+  languageName: node
+  linkType: hard
+
+"my-app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "my-app@workspace:."
+  dependencies:
+    "@babel/code-frame": ^7.0.0
+    string-width-cjs: "npm:string-width@^4.2.0"
+  languageName: unknown
+  linkType: soft
+
+"resolve@patch:resolve@npm%3A^1.0.0#~builtin<compat/resolve>":
+  version: 1.22.1
+  resolution: "resolve@patch:resolve@npm%3A1.22.1#~builtin<compat/resolve>::version=1.22.1&hash=07638b"
+  languageName: node
+  linkType: hard
+
+"string-width-cjs@npm:string-width@^4.2.0":
+  version: 4.2.3
+  resolution: "string-width@npm:4.2.3"
+  languageName: node
+  linkType: hard
+`
+
+func TestParseYarnClassic(t *testing.T) {
+	project, err := ParseYarnLock([]byte(yarnClassicLock))
+	assert.Nil(t, err)
+	assert.Equal(t, FormatYarnClassic, project.Format)
+	assert.Equal(t, "v1", project.LockfileVersion)
+	assert.Len(t, project.Packages, 3)
+
+	codeFrame := project.Package("@babel/code-frame", "7.12.13")
+	assert.Equal(t, []string{"@babel/code-frame@^7.0.0", "@babel/code-frame@^7.10.4"}, codeFrame.Keys)
+	assert.Equal(t, "https://registry.yarnpkg.com/@babel/code-frame/-/code-frame-7.12.13.tgz", codeFrame.Resolved)
+	assert.Equal(t, "sha512-code-frame", codeFrame.Integrity)
+	assert.Equal(t, []*Dependency{{Name: "@babel/highlight", Spec: "^7.10.4", Version: "7.13.10", Type: resolver.DependencyTypeProd}}, codeFrame.Dependencies)
+
+	// 没有 integrity 时使用 resolved 中的 sha1
+	highlight := project.Package("@babel/highlight", "7.13.10")
+	assert.Equal(t, "sha1-qLKmYUj1sn1maxXYF3Q0enMdUtE=", highlight.Integrity)
+	assert.Equal(t, []*Dependency{
+		{Name: "chalk", Spec: "^2.0.0", Type: resolver.DependencyTypeProd},
+		{Name: "fsevents", Spec: "~2.3.1", Type: resolver.DependencyTypeOptional},
+	}, highlight.Dependencies)
+
+	assert.NotNil(t, project.Package("string-width", "4.2.3"))
+
+	_, err = ParseYarnLock([]byte("  version \"1.0.0\"\n"))
+	assert.NotNil(t, err)
+}
+
+func TestParseYarnBerry(t *testing.T) {
+	project, err := ParseYarnLock([]byte(yarnBerryLock))
+	assert.Nil(t, err)
+	assert.Equal(t, FormatYarnBerry, project.Format)
+	assert.Equal(t, "6", project.LockfileVersion)
+	assert.Equal(t, "my-app", project.Name)
+	assert.Equal(t, []*Dependency{
+		{Name: "@babel/code-frame", Spec: "^7.0.0", Version: "7.12.13", Type: resolver.DependencyTypeProd},
+		{Name: "string-width-cjs", Spec: "npm:string-width@^4.2.0", Version: "4.2.3", Type: resolver.DependencyTypeProd},
+	}, project.Dependencies)
+
+	var ids []string
+	for _, p := range project.Packages {
+		ids = append(ids, p.ID())
+	}
+	assert.Equal(t, []string{"@babel/code-frame@7.12.13", "@babel/highlight@7.13.10", "string-width@4.2.3"}, ids)
+
+	codeFrame := project.Package("@babel/code-frame", "7.12.13")
+	assert.Equal(t, []string{"@babel/code-frame@npm:^7.0.0", "@babel/code-frame@npm:^7.10.4"}, codeFrame.Keys)
+	assert.Empty(t, codeFrame.Resolved)
+	assert.Equal(t, "7.13.10", codeFrame.Dependencies[0].Version)
+
+	assert.Equal(t, []*Dependency{
+		{Name: "fsevents", Spec: "~2.3.1", Type: resolver.DependencyTypeOptional},
+		{Name: "react", Spec: "*", Type: resolver.DependencyTypePeer},
+	}, project.Package("@babel/highlight", "7.13.10").Dependencies)
+}
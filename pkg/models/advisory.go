@@ -0,0 +1,139 @@
+package models
+
+import "encoding/json"
+
+// Severity 表示安全公告的严重程度
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityLow      Severity = "low"
+	SeverityModerate Severity = "moderate"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// Level 返回严重程度的等级，用于比较和排序，info 为 1，critical 为 5，未知的严重程度为 0
+func (x Severity) Level() int {
+	switch x {
+	case SeverityInfo:
+		return 1
+	case SeverityLow:
+		return 2
+	case SeverityModerate:
+		return 3
+	case SeverityHigh:
+		return 4
+	case SeverityCritical:
+		return 5
+	default:
+		return 0
+	}
+}
+
+// Advisory 表示 npm 安全公告
+//
+// bulk 接口和 quick audit 接口返回的公告字段大体一致，quick audit 额外包含
+// ModuleName、PatchedVersions 和 Findings 等字段
+//
+// 主要字段说明:
+//   - ID: 公告 ID
+//   - URL: 公告地址，通常指向 GitHub Advisory Database
+//   - Title: 公告标题
+//   - Severity: 严重程度
+//   - VulnerableVersions: 受影响的版本范围，例如 "<4.17.21"
+//   - PatchedVersions: 已修复的版本范围，只有 quick audit 返回
+//   - ModuleName: 受影响的包名，只有 quick audit 返回
+//   - GithubAdvisoryID: GHSA 编号，只有 quick audit 返回
+//   - CWE: CWE 编号列表，例如 ["CWE-1321"]
+//   - CVSS: CVSS 评分
+//   - Findings: 项目中命中该公告的版本和依赖路径，只有 quick audit 返回
+type Advisory struct {
+	ID                 int64      `json:"id"`
+	URL                string     `json:"url"`
+	Title              string     `json:"title"`
+	Severity           Severity   `json:"severity"`
+	VulnerableVersions string     `json:"vulnerable_versions"`
+	PatchedVersions    string     `json:"patched_versions,omitempty"`
+	ModuleName         string     `json:"module_name,omitempty"`
+	GithubAdvisoryID   string     `json:"github_advisory_id,omitempty"`
+	CWE                CWEList    `json:"cwe,omitempty"`
+	CVSS               *CVSS      `json:"cvss,omitempty"`
+	Overview           string     `json:"overview,omitempty"`
+	Recommendation     string     `json:"recommendation,omitempty"`
+	Findings           []*Finding `json:"findings,omitempty"`
+}
+
+// CVSS 表示 CVSS 评分
+type CVSS struct {
+	Score        float64 `json:"score"`        // 评分，0 到 10
+	VectorString string  `json:"vectorString"` // 评分向量，例如 "CVSS:3.1/AV:N/AC:L/..."
+}
+
+// Finding 表示 quick audit 中命中公告的一个版本
+type Finding struct {
+	Version string   `json:"version"` // 命中的版本
+	Paths   []string `json:"paths"`   // 依赖路径，例如 "express>qs"
+}
+
+// CWEList 表示 CWE 编号列表
+//
+// quick audit 接口中 cwe 可能是单个字符串也可能是数组，反序列化时统一转换为列表
+type CWEList []string
+
+// UnmarshalJSON 同时支持字符串和字符串数组两种格式
+func (x *CWEList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		if single == "" {
+			*x = nil
+		} else {
+			*x = CWEList{single}
+		}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*x = list
+	return nil
+}
+
+// BulkAdvisories 表示 /-/npm/v1/security/advisories/bulk 接口的响应，键为包名
+type BulkAdvisories map[string][]*Advisory
+
+// AuditRequest 表示 quick audit 接口的请求体，结构与 npm audit 发送的依赖树一致
+type AuditRequest struct {
+	Name         string                      `json:"name"`
+	Version      string                      `json:"version"`
+	Requires     map[string]string           `json:"requires"`
+	Dependencies map[string]*AuditDependency `json:"dependencies"`
+}
+
+// AuditDependency 表示 quick audit 请求中依赖树的一个节点
+type AuditDependency struct {
+	Version      string                      `json:"version"`
+	Dev          bool                        `json:"dev,omitempty"`
+	Requires     map[string]string           `json:"requires,omitempty"`
+	Dependencies map[string]*AuditDependency `json:"dependencies,omitempty"`
+}
+
+// QuickAuditReport 表示 /-/npm/v1/security/audits/quick 接口的响应
+//
+// 主要字段说明:
+//   - Advisories: 命中的公告，键为公告 ID
+//   - Metadata: 统计信息
+type QuickAuditReport struct {
+	Advisories map[string]*Advisory `json:"advisories"`
+	Metadata   *AuditMetadata       `json:"metadata"`
+}
+
+// AuditMetadata 表示 quick audit 的统计信息
+type AuditMetadata struct {
+	Vulnerabilities      map[Severity]int `json:"vulnerabilities"`      // 各严重程度的公告数量
+	Dependencies         int              `json:"dependencies"`         // 生产依赖数量
+	DevDependencies      int              `json:"devDependencies"`      // 开发依赖数量
+	OptionalDependencies int              `json:"optionalDependencies"` // 可选依赖数量
+	TotalDependencies    int              `json:"totalDependencies"`    // 依赖总数
+}
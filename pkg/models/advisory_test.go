@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeverityLevel(t *testing.T) {
+	assert.True(t, SeverityCritical.Level() > SeverityHigh.Level())
+	assert.True(t, SeverityModerate.Level() > SeverityLow.Level())
+	assert.True(t, SeverityLow.Level() > SeverityInfo.Level())
+	assert.Equal(t, 0, Severity("").Level())
+}
+
+func TestAdvisoryCWE(t *testing.T) {
+	var advisories []*Advisory
+	data := `[
+  {"id": 1, "severity": "high", "vulnerable_versions": "<1.0.0", "cwe": ["CWE-79", "CWE-80"], "cvss": {"score": 7.5, "vectorString": "CVSS:3.1/AV:N"}},
+  {"id": 2, "severity": "low", "vulnerable_versions": "<2.0.0", "cwe": "CWE-400"},
+  {"id": 3, "severity": "low", "vulnerable_versions": "<3.0.0", "cwe": ""}
+]`
+	assert.Nil(t, json.Unmarshal([]byte(data), &advisories))
+	assert.Equal(t, CWEList{"CWE-79", "CWE-80"}, advisories[0].CWE)
+	assert.Equal(t, 7.5, advisories[0].CVSS.Score)
+	assert.Equal(t, CWEList{"CWE-400"}, advisories[1].CWE)
+	assert.Nil(t, advisories[2].CWE)
+}
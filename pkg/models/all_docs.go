@@ -0,0 +1,36 @@
+package models
+
+// AllDocsResponse 表示 CouchDB _all_docs 接口的响应，在 npm 的 CouchDB 镜像中每个文档对应一个包
+//
+// 主要字段说明:
+//   - TotalRows: 数据库中的文档总数，包括设计文档，可以用来估算遍历进度
+//   - Offset: 本页第一行在全部文档中的偏移量
+//   - Rows: 本页的文档，按 key 排序
+//
+// 数据样例:
+//
+//	{
+//	  "total_rows": 3000000,
+//	  "offset": 0,
+//	  "rows": [
+//	    {"id": "@babel/core", "key": "@babel/core", "value": {"rev": "512-abc"}},
+//	    {"id": "left-pad", "key": "left-pad", "value": {"rev": "12-def"}}
+//	  ]
+//	}
+type AllDocsResponse struct {
+	TotalRows int           `json:"total_rows"`
+	Offset    int           `json:"offset"`
+	Rows      []*AllDocsRow `json:"rows"`
+}
+
+// AllDocsRow 表示 _all_docs 接口返回的一个文档
+//
+// 主要字段说明:
+//   - ID: 文档 ID，即包名，作用域包的格式为 "@scope/name"，设计文档以 "_design/" 开头
+//   - Key: 排序使用的 key，与 ID 相同
+//   - Value: 文档的当前修订版本
+type AllDocsRow struct {
+	ID    string         `json:"id"`
+	Key   string         `json:"key"`
+	Value ChangeRevision `json:"value"`
+}
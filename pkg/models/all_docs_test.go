@@ -0,0 +1,28 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const allDocsResponseJSON = `{
+  "total_rows": 3,
+  "offset": 1,
+  "rows": [
+    {"id": "@babel/core", "key": "@babel/core", "value": {"rev": "512-abc"}},
+    {"id": "left-pad", "key": "left-pad", "value": {"rev": "12-def"}}
+  ]
+}`
+
+func TestAllDocsResponse(t *testing.T) {
+	var response AllDocsResponse
+	assert.Nil(t, json.Unmarshal([]byte(allDocsResponseJSON), &response))
+	assert.Equal(t, 3, response.TotalRows)
+	assert.Equal(t, 1, response.Offset)
+	assert.Len(t, response.Rows, 2)
+	assert.Equal(t, "@babel/core", response.Rows[0].ID)
+	assert.Equal(t, "@babel/core", response.Rows[0].Key)
+	assert.Equal(t, "512-abc", response.Rows[0].Value.Rev)
+}
@@ -0,0 +1,151 @@
+package models
+
+// 常见的 attestation predicateType
+const (
+	// PredicateTypeSLSAProvenanceV1 使用 --provenance 发布时由 CI 生成的 SLSA v1 构建来源证明
+	PredicateTypeSLSAProvenanceV1 = "https://slsa.dev/provenance/v1"
+
+	// PredicateTypeSLSAProvenanceV02 旧版的 SLSA v0.2 构建来源证明
+	PredicateTypeSLSAProvenanceV02 = "https://slsa.dev/provenance/v0.2"
+
+	// PredicateTypeNpmPublish npm Registry 在接受发布时签发的发布证明
+	PredicateTypeNpmPublish = "https://github.com/npm/attestation/tree/main/specs/publish/v0.1"
+)
+
+// Attestations 表示 /-/npm/v1/attestations/{name}@{version} 接口返回的证明列表
+//
+// 数据样例:
+//
+//	{
+//	  "attestations": [
+//	    {
+//	      "predicateType": "https://slsa.dev/provenance/v1",
+//	      "bundle": {
+//	        "mediaType": "application/vnd.dev.sigstore.bundle+json;version=0.2",
+//	        "verificationMaterial": {...},
+//	        "dsseEnvelope": {...}
+//	      }
+//	    }
+//	  ]
+//	}
+type Attestations struct {
+	Attestations []*Attestation `json:"attestations"` // 证明列表
+}
+
+// Attestation 表示一条证明，由 predicateType 和 Sigstore bundle 组成
+type Attestation struct {
+	PredicateType string          `json:"predicateType"` // 证明类型
+	Bundle        *SigstoreBundle `json:"bundle"`        // Sigstore bundle
+}
+
+// Attestation 根据 predicateType 查找证明
+//
+// 参数:
+//   - predicateType: 证明类型，例如 PredicateTypeSLSAProvenanceV1
+//
+// 返回值:
+//   - *Attestation: 找到的证明，找不到时返回 nil
+func (x *Attestations) Attestation(predicateType string) *Attestation {
+	for _, attestation := range x.Attestations {
+		if attestation != nil && attestation.PredicateType == predicateType {
+			return attestation
+		}
+	}
+	return nil
+}
+
+// SigstoreBundle 表示 Sigstore bundle，包含签名的 DSSE 信封以及校验所需的证书和透明日志记录
+type SigstoreBundle struct {
+	MediaType            string                `json:"mediaType"`            // bundle 格式版本
+	VerificationMaterial *VerificationMaterial `json:"verificationMaterial"` // 校验材料
+	DSSEEnvelope         *DSSEEnvelope         `json:"dsseEnvelope"`         // 签名的 DSSE 信封
+}
+
+// VerificationMaterial 表示校验签名所需的材料
+//
+// 主要字段说明:
+//   - Certificate: 签名证书（bundle v0.3）
+//   - X509CertificateChain: 签名证书链，第一个为叶子证书（bundle v0.1、v0.2）
+//   - PublicKey: 使用公钥签名时的公钥提示，npm 发布证明中为 Registry 公钥的 keyid
+//   - TlogEntries: 透明日志（Rekor）记录
+type VerificationMaterial struct {
+	Certificate          *RawBytes            `json:"certificate,omitempty"`
+	X509CertificateChain *CertificateChain    `json:"x509CertificateChain,omitempty"`
+	PublicKey            *PublicKeyIdentifier `json:"publicKey,omitempty"`
+	TlogEntries          []*TlogEntry         `json:"tlogEntries,omitempty"`
+}
+
+// RawBytes 表示 Base64 编码的二进制数据，例如 DER 格式的证书
+type RawBytes struct {
+	RawBytes string `json:"rawBytes"`
+}
+
+// CertificateChain 表示证书链
+type CertificateChain struct {
+	Certificates []*RawBytes `json:"certificates"`
+}
+
+// PublicKeyIdentifier 表示签名公钥的提示信息
+type PublicKeyIdentifier struct {
+	Hint string `json:"hint"`
+}
+
+// TlogEntry 表示一条透明日志记录
+//
+// 主要字段说明:
+//   - LogIndex: 日志索引
+//   - LogID: 日志 ID，即日志公钥的 SHA-256 摘要
+//   - KindVersion: 记录类型，例如 intoto 0.0.2 或 dsse 0.0.1
+//   - IntegratedTime: 写入日志的 Unix 时间戳
+//   - InclusionPromise: 日志签发的签名时间戳（SET）
+//   - CanonicalizedBody: Base64 编码的记录内容
+type TlogEntry struct {
+	LogIndex          string            `json:"logIndex"`
+	LogID             *LogID            `json:"logId"`
+	KindVersion       *KindVersion      `json:"kindVersion"`
+	IntegratedTime    string            `json:"integratedTime"`
+	InclusionPromise  *InclusionPromise `json:"inclusionPromise,omitempty"`
+	CanonicalizedBody string            `json:"canonicalizedBody"`
+}
+
+// LogID 表示透明日志 ID
+type LogID struct {
+	KeyID string `json:"keyId"` // Base64 编码的日志公钥 SHA-256 摘要
+}
+
+// KindVersion 表示透明日志记录的类型和版本
+type KindVersion struct {
+	Kind    string `json:"kind"`
+	Version string `json:"version"`
+}
+
+// InclusionPromise 表示透明日志对记录签发的签名时间戳
+type InclusionPromise struct {
+	SignedEntryTimestamp string `json:"signedEntryTimestamp"` // Base64 编码的签名
+}
+
+// DSSEEnvelope 表示 DSSE 签名信封
+//
+// 主要字段说明:
+//   - Payload: Base64 编码的 in-toto Statement
+//   - PayloadType: 负载类型，通常为 "application/vnd.in-toto+json"
+//   - Signatures: 签名列表
+type DSSEEnvelope struct {
+	Payload     string           `json:"payload"`
+	PayloadType string           `json:"payloadType"`
+	Signatures  []*DSSESignature `json:"signatures"`
+}
+
+// DSSESignature 表示 DSSE 信封中的一个签名
+type DSSESignature struct {
+	Sig   string `json:"sig"`   // Base64 编码的签名
+	Keyid string `json:"keyid"` // 公钥 ID，可以为空
+}
+
+// DistAttestations 表示版本元数据 dist.attestations 中的证明入口
+type DistAttestations struct {
+	URL        string `json:"url"` // 证明列表的地址
+	Provenance *struct {
+		PredicateType string `json:"predicateType"`
+	} `json:"provenance,omitempty"` // 构建来源证明的类型
+}
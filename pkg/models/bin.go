@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+)
+
+// Bin 表示 package.json 中的 bin 字段，键为命令名，值为可执行文件在包中的路径
+//
+// bin 字段可以是字符串形式 "./cli.js"，此时命令名与包名相同（不含作用域），
+// 解析时会以空字符串作为键保存，可以通过 Normalize 转换为以包名为键的形式
+type Bin map[string]string
+
+// UnmarshalJSON 同时支持字符串和对象两种格式
+func (x *Bin) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch value := raw.(type) {
+	case string:
+		*x = Bin{"": value}
+	case map[string]interface{}:
+		bin := make(Bin, len(value))
+		for name, path := range value {
+			if s, ok := path.(string); ok {
+				bin[name] = s
+			}
+		}
+		*x = bin
+	default:
+		*x = nil
+	}
+	return nil
+}
+
+// MarshalJSON 字符串形式的 bin 字段会被还原为字符串
+func (x Bin) MarshalJSON() ([]byte, error) {
+	if path, ok := x[""]; ok && len(x) == 1 {
+		return json.Marshal(path)
+	}
+	return json.Marshal(map[string]string(x))
+}
+
+// Normalize 返回与 npm 规范化结果一致的 bin 映射
+//
+// 规范化规则与 npm-normalize-package-bin 相同:
+//   - 字符串形式的 bin 使用包名作为命令名
+//   - 命令名只保留最后一段，例如 "@scope/tool" 变为 "tool"
+//   - 文件路径被清理为相对路径，例如 "./bin/cli.js" 变为 "bin/cli.js"
+//
+// 参数:
+//   - packageName: 包名，用于字符串形式的 bin 字段
+//
+// 返回值:
+//   - map[string]string: 键为命令名，值为可执行文件路径，没有有效的命令时返回 nil
+//
+// 使用示例:
+//
+//	bin := Bin{"": "./cli.js"}
+//	bin.Normalize("@scope/tool") // {"tool": "cli.js"}
+func (x Bin) Normalize(packageName string) map[string]string {
+	result := make(map[string]string, len(x))
+	for name, target := range x {
+		if name == "" {
+			name = packageName
+		}
+		name = strings.NewReplacer("\\", "/", ":", "/").Replace(name)
+		name = strings.TrimPrefix(path.Clean("/"+path.Base(name)), "/")
+		target = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(target, "\\", "/")), "/")
+		if name == "" || target == "" {
+			continue
+		}
+		result[name] = target
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBin(t *testing.T) {
+	var version Version
+	err := json.Unmarshal([]byte(`{"bin": "./cli.js"}`), &version)
+	assert.Nil(t, err)
+	assert.Equal(t, Bin{"": "./cli.js"}, version.Bin)
+	assert.Equal(t, map[string]string{"tool": "cli.js"}, version.Bin.Normalize("@scope/tool"))
+
+	// 字符串形式序列化后保持不变
+	data, err := json.Marshal(version.Bin)
+	assert.Nil(t, err)
+	assert.Equal(t, `"./cli.js"`, string(data))
+
+	err = json.Unmarshal([]byte(`{"bin": {"a": "bin/a.js", "b": "./bin/../b.js", "": "x.js", "c": 1}}`), &version)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a": "bin/a.js", "b": "b.js", "pkg": "x.js"}, version.Bin.Normalize("pkg"))
+
+	assert.Nil(t, Bin(nil).Normalize("pkg"))
+}
@@ -6,6 +6,8 @@ package models
 //
 // 主要字段说明:
 //   - URL: 问题跟踪系统的链接地址
+//   - Email: 报告问题的电子邮件地址
 type Bugs struct {
-	URL string `json:"url"` // 问题跟踪系统的链接地址
+	URL   string `json:"url"`             // 问题跟踪系统的链接地址
+	Email string `json:"email,omitempty"` // 报告问题的电子邮件地址
 }
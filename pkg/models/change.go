@@ -0,0 +1,82 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// Sequence 表示 CouchDB _changes 接口中的更新序列号
+//
+// CouchDB 1.x（包括 npm 的 replicate 接口）使用整数序列号，CouchDB 2.x 及以上版本使用不透明的字符串，
+// 因此统一使用字符串保存，解析时同时支持两种格式，序列化时纯数字的序列号仍然输出为数字
+type Sequence string
+
+// UnmarshalJSON 同时支持数字和字符串两种格式
+func (x *Sequence) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*x = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*x = Sequence(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*x = Sequence(n.String())
+	return nil
+}
+
+// MarshalJSON 纯数字的序列号输出为数字，其它输出为字符串
+func (x Sequence) MarshalJSON() ([]byte, error) {
+	if _, err := strconv.ParseUint(string(x), 10, 64); err == nil {
+		return []byte(x), nil
+	}
+	return json.Marshal(string(x))
+}
+
+// Change 表示 _changes 接口返回的一条变更
+//
+// 主要字段说明:
+//   - Seq: 该变更对应的更新序列号，作为下一次请求的 since 参数即可从该变更之后继续
+//   - ID: 发生变更的文档 ID，即包名，设计文档以 "_design/" 开头
+//   - Deleted: 文档是否已被删除（包被 unpublish）
+//   - Changes: 变更后的文档修订版本
+//   - Doc: 变更后的完整文档，只有请求时设置了 include_docs 才会返回
+type Change struct {
+	Seq     Sequence         `json:"seq"`
+	ID      string           `json:"id"`
+	Deleted bool             `json:"deleted,omitempty"`
+	Changes []ChangeRevision `json:"changes,omitempty"`
+	Doc     *Package         `json:"doc,omitempty"`
+}
+
+// ChangeRevision 表示变更后的一个文档修订版本
+type ChangeRevision struct {
+	Rev string `json:"rev"`
+}
+
+// ChangesResponse 表示 normal 和 longpoll 模式下 _changes 接口的响应
+//
+// 数据样例:
+//
+//	{
+//	  "results": [
+//	    {"seq": 101, "id": "left-pad", "changes": [{"rev": "12-abc"}]},
+//	    {"seq": 102, "id": "old-pkg", "changes": [{"rev": "3-def"}], "deleted": true}
+//	  ],
+//	  "last_seq": 102
+//	}
+type ChangesResponse struct {
+	Results []*Change `json:"results"`
+	LastSeq Sequence  `json:"last_seq"`
+	Pending int64     `json:"pending,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const changesResponseJSON = `{
+  "results": [
+    {"seq": 101, "id": "left-pad", "changes": [{"rev": "12-abc"}]},
+    {"seq": "102-g1AAAA", "id": "old-pkg", "changes": [{"rev": "3-def"}], "deleted": true, "doc": {"_id": "old-pkg", "_rev": "3-def", "_deleted": true}}
+  ],
+  "last_seq": 102,
+  "pending": 7
+}`
+
+func TestChangesResponse(t *testing.T) {
+	var response ChangesResponse
+	assert.Nil(t, json.Unmarshal([]byte(changesResponseJSON), &response))
+	assert.Len(t, response.Results, 2)
+	assert.Equal(t, Sequence("101"), response.Results[0].Seq)
+	assert.Equal(t, "left-pad", response.Results[0].ID)
+	assert.Equal(t, []ChangeRevision{{Rev: "12-abc"}}, response.Results[0].Changes)
+	assert.Nil(t, response.Results[0].Doc)
+	assert.Equal(t, Sequence("102-g1AAAA"), response.Results[1].Seq)
+	assert.True(t, response.Results[1].Deleted)
+	assert.NotNil(t, response.Results[1].Doc)
+	assert.Equal(t, Sequence("102"), response.LastSeq)
+	assert.Equal(t, int64(7), response.Pending)
+}
+
+func TestSequenceJSON(t *testing.T) {
+	data, err := json.Marshal([]Sequence{"42", "42-g1AAAA", ""})
+	assert.Nil(t, err)
+	assert.Equal(t, `[42,"42-g1AAAA",""]`, string(data))
+
+	var seq Sequence
+	assert.Nil(t, json.Unmarshal([]byte("null"), &seq))
+	assert.Equal(t, Sequence(""), seq)
+	assert.NotNil(t, json.Unmarshal([]byte("{}"), &seq))
+}
@@ -10,11 +10,18 @@ package models
 //   - Tarball: 包的下载 URL
 //   - Integrity: 完整性校验值，通常为 SRI 格式（子资源完整性）
 //   - Signatures: 包的签名信息列表
+//   - FileCount: 包中的文件数量
+//   - UnpackedSize: 包解压后的总大小（字节）
+//   - Attestations: 使用 --provenance 发布时的证明入口
 type Dist struct {
-	Shasum     string       `json:"shasum"`     // 包的 SHA1 校验和
-	Tarball    string       `json:"tarball"`    // 包的下载 URL
-	Integrity  string       `json:"integrity"`  // 完整性校验值
-	Signatures []*Signature `json:"signatures"` // 签名信息列表
+	Shasum       string       `json:"shasum"`                 // 包的 SHA1 校验和
+	Tarball      string       `json:"tarball"`                // 包的下载 URL
+	Integrity    string       `json:"integrity"`              // 完整性校验值
+	Signatures   []*Signature `json:"signatures"`             // 签名信息列表
+	FileCount    int          `json:"fileCount,omitempty"`    // 包中的文件数量
+	UnpackedSize int64        `json:"unpackedSize,omitempty"` // 解压后的总大小（字节）
+
+	Attestations *DistAttestations `json:"attestations,omitempty"` // 证明入口
 }
 
 // Signature 表示 NPM 包的签名信息
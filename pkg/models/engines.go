@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Engines 表示 package.json 中的 engines 字段，键为运行环境名称，值为版本范围
+//
+// 例如 {"node": ">=14.17", "npm": ">=6"}。一些很早期发布的包使用数组形式
+// ["node >= 0.4.0"]，解析时会被转换为 {"node": ">= 0.4.0"}
+type Engines map[string]string
+
+// UnmarshalJSON 同时支持对象和早期的数组两种格式，非字符串的值会被忽略
+func (x *Engines) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	engines := Engines{}
+	switch value := raw.(type) {
+	case map[string]interface{}:
+		for name, constraint := range value {
+			if s, ok := constraint.(string); ok {
+				engines[name] = s
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			s, ok := item.(string)
+			if !ok {
+				continue
+			}
+			name, constraint, _ := strings.Cut(strings.TrimSpace(s), " ")
+			engines[name] = strings.TrimSpace(constraint)
+		}
+	case nil:
+		engines = nil
+	}
+	*x = engines
+	return nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnginesUnmarshal(t *testing.T) {
+	var version Version
+	err := json.Unmarshal([]byte(`{"engines": {"node": ">=14", "npm": 6}}`), &version)
+	assert.Nil(t, err)
+	assert.Equal(t, Engines{"node": ">=14"}, version.Engines)
+
+	// 早期发布的包使用数组形式
+	err = json.Unmarshal([]byte(`{"engines": ["node >= 0.4.0", "npm"]}`), &version)
+	assert.Nil(t, err)
+	assert.Equal(t, Engines{"node": ">= 0.4.0", "npm": ""}, version.Engines)
+
+	version = Version{}
+	err = json.Unmarshal([]byte(`{"engines": null}`), &version)
+	assert.Nil(t, err)
+	assert.Nil(t, version.Engines)
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// LicenseInfo 表示早期 package.json 中对象形式的许可证，例如 {"type": "MIT", "url": "..."}
+//
+// 早期的包会使用 "licenses": [{"type": "MIT"}, {"type": "Apache-2.0"}] 声明多个许可证，
+// 也有包在 "license" 字段中直接使用这种对象形式
+type LicenseInfo struct {
+	Type string `json:"type"`          // 许可证名称
+	URL  string `json:"url,omitempty"` // 许可证文本地址
+}
+
+// UnmarshalJSON 同时支持字符串和对象两种格式
+func (x *LicenseInfo) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*x = LicenseInfo{Type: licenseString(raw)}
+	if value, ok := raw.(map[string]interface{}); ok {
+		x.URL, _ = value["url"].(string)
+	}
+	return nil
+}
+
+// licenseString 将 license 字段的各种历史格式转换为字符串
+//
+// 字符串原样返回；对象取其 type 字段；数组视为多个可选的许可证，使用 " OR " 连接并加上括号；
+// 其它格式返回空字符串
+func licenseString(raw interface{}) string {
+	switch value := raw.(type) {
+	case string:
+		return value
+	case map[string]interface{}:
+		if s, ok := value["type"].(string); ok {
+			return s
+		}
+		if s, ok := value["name"].(string); ok {
+			return s
+		}
+	case []interface{}:
+		var licenses []string
+		for _, item := range value {
+			if s := licenseString(item); s != "" {
+				licenses = append(licenses, s)
+			}
+		}
+		switch len(licenses) {
+		case 0:
+		case 1:
+			return licenses[0]
+		default:
+			return "(" + strings.Join(licenses, " OR ") + ")"
+		}
+	}
+	return ""
+}
+
+// decodeLicense 解析 license 字段的原始 JSON，字段不存在或格式无法识别时返回空字符串
+func decodeLicense(data json.RawMessage) string {
+	if len(data) == 0 {
+		return ""
+	}
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return ""
+	}
+	return licenseString(raw)
+}
+
+// UnmarshalJSON 解析版本元数据，license 字段除了字符串之外还支持早期的对象和数组格式
+func (x *Version) UnmarshalJSON(data []byte) error {
+	type version Version
+	aux := struct {
+		*version
+		License json.RawMessage `json:"license"`
+	}{version: (*version)(x)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	x.License = decodeLicense(aux.License)
+	return nil
+}
+
+// UnmarshalJSON 解析包元数据，license 字段除了字符串之外还支持早期的对象和数组格式
+func (x *Package) UnmarshalJSON(data []byte) error {
+	type pkg Package
+	aux := struct {
+		*pkg
+		License json.RawMessage `json:"license"`
+	}{pkg: (*pkg)(x)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	x.License = decodeLicense(aux.License)
+	return nil
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionLegacyLicense(t *testing.T) {
+	cases := map[string]string{
+		`{"name": "a", "license": "MIT"}`:                                      "MIT",
+		`{"name": "a", "license": {"type": "BSD", "url": "http://x/LICENSE"}}`: "BSD",
+		`{"name": "a", "license": [{"type": "MIT"}, {"type": "Apache-2.0"}]}`:  "(MIT OR Apache-2.0)",
+		`{"name": "a", "license": ["ISC"]}`:                                    "ISC",
+		`{"name": "a", "license": 42}`:                                         "",
+		`{"name": "a"}`:                                                        "",
+	}
+	for data, expected := range cases {
+		var v Version
+		assert.Nil(t, json.Unmarshal([]byte(data), &v), data)
+		assert.Equal(t, "a", v.Name, data)
+		assert.Equal(t, expected, v.License, data)
+	}
+
+	var v Version
+	assert.Nil(t, json.Unmarshal([]byte(`{"name": "a", "licenses": [{"type": "MIT", "url": "http://x"}, "GPL-2.0"]}`), &v))
+	assert.Equal(t, []LicenseInfo{{Type: "MIT", URL: "http://x"}, {Type: "GPL-2.0"}}, v.Licenses)
+}
+
+func TestPackageLegacyLicense(t *testing.T) {
+	var p Package
+	assert.Nil(t, json.Unmarshal([]byte(`{"name": "a", "license": {"type": "MIT"}, "versions": {"1.0.0": {"license": {"type": "ISC"}}}}`), &p))
+	assert.Equal(t, "MIT", p.License)
+	assert.Equal(t, "ISC", p.Versions["1.0.0"].License)
+}
@@ -31,6 +31,7 @@ type Package struct {
 	Homepage       string                 `json:"homepage"`
 	Bugs           map[string]interface{} `json:"bugs"`
 	License        string                 `json:"license"`
+	Licenses       []LicenseInfo          `json:"licenses,omitempty"`
 	Users          map[string]bool        `json:"users"`
 	Keywords       []string               `json:"keywords"`
 	Author         Author                 `json:"author"`
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// Package.Time 中除了版本号之外的两个特殊键
+const (
+	TimeKeyCreated  = "created"
+	TimeKeyModified = "modified"
+)
+
+// Created 返回包的创建时间
+//
+// 返回值:
+//   - time.Time: 包的创建时间，如果 Time 中没有 "created" 字段或无法解析则返回零值
+func (x *Package) Created() time.Time {
+	return x.parseTime(TimeKeyCreated)
+}
+
+// Modified 返回包元数据的最后修改时间
+//
+// 返回值:
+//   - time.Time: 包的最后修改时间，如果 Time 中没有 "modified" 字段或无法解析则返回零值
+func (x *Package) Modified() time.Time {
+	return x.parseTime(TimeKeyModified)
+}
+
+// ReleaseTime 返回指定版本的发布时间
+//
+// 参数:
+//   - version: 版本号，例如 "1.0.0"
+//
+// 返回值:
+//   - time.Time: 版本的发布时间
+//   - bool: 如果 Time 中没有该版本或者时间无法解析则返回 false
+func (x *Package) ReleaseTime(version string) (time.Time, bool) {
+	if version == TimeKeyCreated || version == TimeKeyModified {
+		return time.Time{}, false
+	}
+	t := x.parseTime(version)
+	return t, !t.IsZero()
+}
+
+// ReleaseTimes 返回所有版本的发布时间
+//
+// 返回的映射中不包含 "created" 和 "modified" 两个特殊键，无法解析的时间会被忽略
+//
+// 返回值:
+//   - map[string]time.Time: 键为版本号，值为发布时间
+//
+// 使用示例:
+//
+//	pkg, _ := registry.GetPackageInformation(ctx, "axios")
+//	for version, publishedAt := range pkg.ReleaseTimes() {
+//		fmt.Println(version, publishedAt.Format(time.DateOnly))
+//	}
+func (x *Package) ReleaseTimes() map[string]time.Time {
+	times := make(map[string]time.Time, len(x.Time))
+	for key := range x.Time {
+		if t, ok := x.ReleaseTime(key); ok {
+			times[key] = t
+		}
+	}
+	return times
+}
+
+// AsOf 返回包在指定时间点的视图，用于重现历史上某一时刻 npm install 会解析到的结果
+//
+// 返回的 Package 是一个浅拷贝，只包含在 t 之前发布的版本（没有发布时间记录的版本会被排除），
+// Time 中只保留这些版本的时间，"modified" 被设置为视图中最后一次发布的时间。
+// dist-tags 会被重新计算：
+//   - latest: 视图中最大的正式版本，如果没有正式版本则为最大的预发布版本
+//   - 其他标签: 如果指向的版本仍然在视图中则保留，否则移除
+//
+// 参数:
+//   - t: 时间点
+//
+// 返回值:
+//   - *Package: 指定时间点的包视图，如果包在 t 时刻还没有发布任何版本，则 Versions 为空
+//
+// 使用示例:
+//
+//	incident := time.Date(2021, 11, 4, 0, 0, 0, 0, time.UTC)
+//	snapshot := pkg.AsOf(incident)
+//	fmt.Println("当时的最新版本:", snapshot.DistTags["latest"])
+func (x *Package) AsOf(t time.Time) *Package {
+	view := *x
+	view.Versions = make(map[string]Version)
+	view.Time = make(map[string]string)
+	view.DistTags = make(map[string]string)
+
+	var lastRelease time.Time
+	for version, published := range x.ReleaseTimes() {
+		if !published.Before(t) {
+			continue
+		}
+		v, ok := x.Versions[version]
+		if !ok {
+			continue
+		}
+		view.Versions[version] = v
+		view.Time[version] = x.Time[version]
+		if published.After(lastRelease) {
+			lastRelease = published
+		}
+	}
+
+	if created := x.Created(); !created.IsZero() && created.Before(t) {
+		view.Time[TimeKeyCreated] = x.Time[TimeKeyCreated]
+	}
+	if !lastRelease.IsZero() {
+		view.Time[TimeKeyModified] = lastRelease.UTC().Format(time.RFC3339Nano)
+	}
+
+	versions := make([]string, 0, len(view.Versions))
+	for version := range view.Versions {
+		versions = append(versions, version)
+	}
+	latest := semver.Max(versions, false)
+	if latest == "" {
+		latest = semver.Max(versions, true)
+	}
+	for tag, version := range x.DistTags {
+		if tag == "latest" {
+			continue
+		}
+		if _, ok := view.Versions[version]; ok {
+			view.DistTags[tag] = version
+		}
+	}
+	if latest != "" {
+		view.DistTags["latest"] = latest
+	}
+	return &view
+}
+
+func (x *Package) parseTime(key string) time.Time {
+	raw, ok := x.Time[key]
+	if !ok {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTimedPackage() *Package {
+	return &Package{
+		ID:   "timed-package",
+		Name: "timed-package",
+		DistTags: map[string]string{
+			"latest": "2.0.0",
+			"next":   "3.0.0-rc.1",
+			"legacy": "1.0.1",
+		},
+		Versions: map[string]Version{
+			"1.0.0":      {Name: "timed-package", Version: "1.0.0"},
+			"1.0.1":      {Name: "timed-package", Version: "1.0.1"},
+			"2.0.0":      {Name: "timed-package", Version: "2.0.0"},
+			"3.0.0-rc.1": {Name: "timed-package", Version: "3.0.0-rc.1"},
+		},
+		Time: map[string]string{
+			"created":    "2020-01-01T00:00:00.000Z",
+			"modified":   "2023-06-01T12:00:00.000Z",
+			"1.0.0":      "2020-01-01T00:00:00.000Z",
+			"2.0.0":      "2021-03-01T00:00:00.000Z",
+			"1.0.1":      "2021-05-01T00:00:00.000Z",
+			"3.0.0-rc.1": "2023-06-01T12:00:00.000Z",
+			"0.0.1":      "not a time",
+		},
+	}
+}
+
+func TestPackageTimeAccessors(t *testing.T) {
+	pkg := newTimedPackage()
+
+	assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), pkg.Created())
+	assert.Equal(t, time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC), pkg.Modified())
+
+	releaseTimes := pkg.ReleaseTimes()
+	assert.Len(t, releaseTimes, 4)
+	assert.NotContains(t, releaseTimes, "created")
+	assert.NotContains(t, releaseTimes, "modified")
+	assert.NotContains(t, releaseTimes, "0.0.1")
+	assert.Equal(t, time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), releaseTimes["2.0.0"])
+
+	_, ok := pkg.ReleaseTime("created")
+	assert.False(t, ok)
+	_, ok = pkg.ReleaseTime("9.9.9")
+	assert.False(t, ok)
+
+	// 没有时间信息的包
+	empty := &Package{}
+	assert.True(t, empty.Created().IsZero())
+	assert.True(t, empty.Modified().IsZero())
+	assert.Empty(t, empty.ReleaseTimes())
+}
+
+func TestPackageAsOf(t *testing.T) {
+	pkg := newTimedPackage()
+
+	// 2.0.0 发布之后、1.0.1 发布之前
+	view := pkg.AsOf(time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	assert.Len(t, view.Versions, 2)
+	assert.Contains(t, view.Versions, "1.0.0")
+	assert.Contains(t, view.Versions, "2.0.0")
+	assert.Equal(t, map[string]string{"latest": "2.0.0"}, view.DistTags)
+	assert.Equal(t, time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), view.Modified())
+	assert.Equal(t, pkg.Created(), view.Created())
+	assert.NotContains(t, view.Time, "1.0.1")
+
+	// 所有版本都已发布，latest 仍然是最大的正式版本
+	view = pkg.AsOf(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Len(t, view.Versions, 4)
+	assert.Equal(t, "2.0.0", view.DistTags["latest"])
+	assert.Equal(t, "3.0.0-rc.1", view.DistTags["next"])
+	assert.Equal(t, "1.0.1", view.DistTags["legacy"])
+
+	// 时间点恰好等于发布时间时该版本不包含在内
+	view = pkg.AsOf(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Empty(t, view.Versions)
+	assert.Empty(t, view.DistTags)
+	assert.NotContains(t, view.Time, "created")
+
+	// 原始对象不应被修改
+	assert.Len(t, pkg.Versions, 4)
+	assert.Equal(t, "2.0.0", pkg.DistTags["latest"])
+}
+
+func TestPackageAsOfOnlyPrerelease(t *testing.T) {
+	pkg := &Package{
+		Name:     "pre-only",
+		DistTags: map[string]string{"latest": "1.0.0"},
+		Versions: map[string]Version{
+			"1.0.0-alpha.1": {Version: "1.0.0-alpha.1"},
+			"1.0.0-alpha.2": {Version: "1.0.0-alpha.2"},
+			"1.0.0":         {Version: "1.0.0"},
+		},
+		Time: map[string]string{
+			"1.0.0-alpha.1": "2022-01-01T00:00:00Z",
+			"1.0.0-alpha.2": "2022-02-01T00:00:00Z",
+			"1.0.0":         "2022-03-01T00:00:00Z",
+		},
+	}
+
+	view := pkg.AsOf(time.Date(2022, 2, 15, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, "1.0.0-alpha.2", view.DistTags["latest"])
+}
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// RegistryKeys 表示 NPM Registry 公开的签名公钥列表
+//
+// 通过 /-/npm/v1/keys 接口获取，用于校验 Dist.Signatures 中的 Registry 签名
+//
+// 数据样例:
+//
+//	{
+//	  "keys": [
+//	    {
+//	      "expires": null,
+//	      "keyid": "SHA256:jl3bwswu80PjjokCgh0o2w5c2U4LhQAE57gj9cz1kzA",
+//	      "keytype": "ecdsa-sha2-nistp256",
+//	      "scheme": "ecdsa-sha2-nistp256",
+//	      "key": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE..."
+//	    }
+//	  ]
+//	}
+type RegistryKeys struct {
+	Keys []*RegistryKey `json:"keys"` // 公钥列表
+}
+
+// RegistryKey 表示 Registry 用于签名的一把公钥
+//
+// 主要字段说明:
+//   - Expires: 过期时间（RFC 3339），为空表示尚未过期
+//   - Keyid: 公钥 ID，与 Signature.Keyid 对应
+//   - Keytype: 公钥类型，例如 "ecdsa-sha2-nistp256"
+//   - Scheme: 签名方案，例如 "ecdsa-sha2-nistp256"
+//   - Key: Base64 编码的 DER 格式 SPKI 公钥
+type RegistryKey struct {
+	Expires *string `json:"expires"` // 过期时间
+	Keyid   string  `json:"keyid"`   // 公钥 ID
+	Keytype string  `json:"keytype"` // 公钥类型
+	Scheme  string  `json:"scheme"`  // 签名方案
+	Key     string  `json:"key"`     // Base64 编码的公钥
+}
+
+// ExpiresAt 返回公钥的过期时间
+//
+// 返回值:
+//   - time.Time: 过期时间
+//   - bool: 如果公钥没有设置过期时间或者时间无法解析则返回 false
+func (x *RegistryKey) ExpiresAt() (time.Time, bool) {
+	if x.Expires == nil || *x.Expires == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, *x.Expires)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Key 根据 keyid 查找公钥
+//
+// 参数:
+//   - keyid: 公钥 ID
+//
+// 返回值:
+//   - *RegistryKey: 找到的公钥，找不到时返回 nil
+func (x *RegistryKeys) Key(keyid string) *RegistryKey {
+	for _, key := range x.Keys {
+		if key != nil && key.Keyid == keyid {
+			return key
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const registryKeysJSON = `{
+  "keys": [
+    {
+      "expires": null,
+      "keyid": "SHA256:current",
+      "keytype": "ecdsa-sha2-nistp256",
+      "scheme": "ecdsa-sha2-nistp256",
+      "key": "AAAA"
+    },
+    {
+      "expires": "2025-01-29T00:00:00.000Z",
+      "keyid": "SHA256:old",
+      "keytype": "ecdsa-sha2-nistp256",
+      "scheme": "ecdsa-sha2-nistp256",
+      "key": "BBBB"
+    }
+  ]
+}`
+
+func TestRegistryKeys(t *testing.T) {
+	var keys RegistryKeys
+	assert.Nil(t, json.Unmarshal([]byte(registryKeysJSON), &keys))
+	assert.Len(t, keys.Keys, 2)
+
+	current := keys.Key("SHA256:current")
+	assert.NotNil(t, current)
+	_, ok := current.ExpiresAt()
+	assert.False(t, ok)
+
+	old := keys.Key("SHA256:old")
+	assert.NotNil(t, old)
+	expires, ok := old.ExpiresAt()
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 1, 29, 0, 0, 0, 0, time.UTC), expires)
+
+	assert.Nil(t, keys.Key("SHA256:missing"))
+}
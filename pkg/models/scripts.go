@@ -1,5 +1,9 @@
 package models
 
+import (
+	"encoding/json"
+)
+
 // Script 表示 NPM 包的脚本命令定义
 //
 // 包含 NPM 包中定义的各种脚本命令，这些脚本可以通过 npm run [script-name] 来执行
@@ -8,7 +12,61 @@ package models
 // 主要字段说明:
 //   - Test: 测试脚本命令
 //   - Start: 启动项目脚本命令
+//   - All: 所有脚本命令（包括 test 和 start），键为脚本名称，反序列化时自动填充
 type Script struct {
-	Test  string `json:"test"`  // 测试脚本命令
-	Start string `json:"start"` // 启动项目脚本命令
+	Test  string            `json:"test"`  // 测试脚本命令
+	Start string            `json:"start"` // 启动项目脚本命令
+	All   map[string]string `json:"-"`     // 所有脚本命令
+}
+
+// installScripts 是安装包时会被自动执行的生命周期脚本
+var installScripts = []string{"preinstall", "install", "postinstall"}
+
+// UnmarshalJSON 解析 scripts 字段，所有字符串类型的脚本都会被保存到 All 中
+func (x *Script) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	x.All = make(map[string]string, len(raw))
+	for name, value := range raw {
+		if s, ok := value.(string); ok {
+			x.All[name] = s
+		}
+	}
+	x.Test, x.Start = x.All["test"], x.All["start"]
+	return nil
+}
+
+// MarshalJSON 序列化 scripts 字段，All 为空时只输出 test 和 start
+func (x Script) MarshalJSON() ([]byte, error) {
+	if x.All == nil {
+		type plain Script
+		return json.Marshal(plain(x))
+	}
+	all := make(map[string]string, len(x.All)+2)
+	for name, script := range x.All {
+		all[name] = script
+	}
+	if x.Test != "" {
+		all["test"] = x.Test
+	}
+	if x.Start != "" {
+		all["start"] = x.Start
+	}
+	return json.Marshal(all)
+}
+
+// InstallScripts 返回安装时会被自动执行的脚本（preinstall、install、postinstall）
+//
+// 返回值:
+//   - map[string]string: 键为脚本名称，值为脚本命令，没有时返回空映射
+func (x *Script) InstallScripts() map[string]string {
+	result := make(map[string]string)
+	for _, name := range installScripts {
+		if script, ok := x.All[name]; ok {
+			result[name] = script
+		}
+	}
+	return result
 }
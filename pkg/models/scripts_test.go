@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScript(t *testing.T) {
+	var version Version
+	err := json.Unmarshal([]byte(`{"scripts": {"test": "jest", "postinstall": "node setup.js", "build": "tsc", "bad": 1}}`), &version)
+	assert.Nil(t, err)
+	assert.Equal(t, "jest", version.Scripts.Test)
+	assert.Equal(t, map[string]string{"test": "jest", "postinstall": "node setup.js", "build": "tsc"}, version.Scripts.All)
+	assert.Equal(t, map[string]string{"postinstall": "node setup.js"}, version.Scripts.InstallScripts())
+
+	data, err := json.Marshal(version.Scripts)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"test": "jest", "postinstall": "node setup.js", "build": "tsc"}`, string(data))
+
+	// 没有 All 时与之前的格式保持一致
+	data, err = json.Marshal(&Script{Test: "mocha"})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"test": "mocha", "start": ""}`, string(data))
+}
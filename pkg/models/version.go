@@ -21,13 +21,27 @@ type Version struct {
 	Repository  *Repository `json:"repository"`  // 代码仓库信息
 	Keywords    []string    `json:"keywords"`    // 关键词列表
 	Author      *User       `json:"author"`      // 作者信息
-	License     string      `json:"license"`     // 许可证类型
+	License     string      `json:"license"`     // 许可证类型，对象和数组形式会被转换为字符串
 	Bugs        *Bugs       `json:"bugs"`        // 问题跟踪链接
 	Homepage    string      `json:"homepage"`    // 项目主页
 
+	// 早期 package.json 中的许可证列表，例如 [{"type": "MIT", "url": "..."}]
+	Licenses []LicenseInfo `json:"licenses,omitempty"`
+
 	// 依赖关系，key是依赖的包，value是版本约束
-	Dependencies    map[string]string `json:"dependencies"`    // 运行时依赖
-	DevDependencies map[string]string `json:"devDependencies"` // 开发时依赖
+	Dependencies         map[string]string             `json:"dependencies"`                   // 运行时依赖
+	DevDependencies      map[string]string             `json:"devDependencies"`                // 开发时依赖
+	PeerDependencies     map[string]string             `json:"peerDependencies,omitempty"`     // 同级依赖
+	PeerDependenciesMeta map[string]PeerDependencyMeta `json:"peerDependenciesMeta,omitempty"` // 同级依赖的附加信息
+	OptionalDependencies map[string]string             `json:"optionalDependencies,omitempty"` // 可选依赖，安装失败不影响整体安装
+
+	// 安装相关信息
+	Engines          Engines     `json:"engines,omitempty"`          // 运行环境要求，例如 {"node": ">=14"}
+	Bin              Bin         `json:"bin,omitempty"`              // 可执行文件，键为命令名，值为文件路径
+	Os               []string    `json:"os,omitempty"`               // 支持的操作系统，例如 ["darwin", "!win32"]
+	Cpu              []string    `json:"cpu,omitempty"`              // 支持的 CPU 架构，例如 ["x64", "arm64"]
+	Funding          interface{} `json:"funding,omitempty"`          // 资助信息，可以是字符串、对象或数组
+	HasInstallScript bool        `json:"hasInstallScript,omitempty"` // 是否包含 install 相关的生命周期脚本
 
 	ID          string  `json:"_id"`         // 包ID，通常为 "name@version"
 	Dist        *Dist   `json:"dist"`        // 分发信息，包含下载URL和校验和
@@ -43,6 +57,14 @@ type Version struct {
 	Deprecated string `json:"deprecated"` // 弃用说明，如果为空则表示未弃用
 }
 
+// PeerDependencyMeta 表示 peerDependenciesMeta 中单个同级依赖的附加信息
+//
+// 主要字段说明:
+//   - Optional: 为 true 时表示该同级依赖是可选的，缺失时不会报错
+type PeerDependencyMeta struct {
+	Optional bool `json:"optional"` // 是否为可选的同级依赖
+}
+
 // Script 类型定义在其他文件中
 // 表示 NPM 包的脚本命令定义
 //
@@ -0,0 +1,159 @@
+package provenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// Statement 表示 DSSE 信封中携带的 in-toto Statement
+//
+// 主要字段说明:
+//   - Type: Statement 版本，例如 "https://in-toto.io/Statement/v1"
+//   - Subject: 被证明的制品，npm 中为 tarball，名称为 purl 格式
+//   - PredicateType: 证明类型，与 Attestation.PredicateType 一致
+//   - Predicate: 证明内容，格式由 PredicateType 决定
+type Statement struct {
+	Type          string          `json:"_type"`
+	Subject       []*Subject      `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+// Subject 表示被证明的制品
+//
+// 主要字段说明:
+//   - Name: 制品名称，npm 中为 "pkg:npm/%40scope/name@1.0.0" 形式的 purl
+//   - Digest: 制品摘要，键为算法名，值为十六进制摘要，npm 中为 sha512
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Provenance 表示从 SLSA 构建来源证明中提取的关键信息
+//
+// 主要字段说明:
+//   - BuildType: 构建类型，例如 GitHub Actions 工作流
+//   - Builder: 构建者 ID，例如 "https://github.com/actions/runner/github-hosted"
+//   - Repository: 源码仓库地址，例如 "https://github.com/owner/repo"
+//   - Ref: 构建使用的 git 引用，例如 "refs/heads/main"
+//   - Commit: 构建使用的提交
+//   - Workflow: 工作流文件路径，例如 ".github/workflows/publish.yml"
+//   - InvocationID: 本次构建的 ID，通常是 CI 任务的地址
+type Provenance struct {
+	BuildType    string `json:"buildType,omitempty"`
+	Builder      string `json:"builder,omitempty"`
+	Repository   string `json:"repository,omitempty"`
+	Ref          string `json:"ref,omitempty"`
+	Commit       string `json:"commit,omitempty"`
+	Workflow     string `json:"workflow,omitempty"`
+	InvocationID string `json:"invocationId,omitempty"`
+}
+
+// slsaV1 SLSA v1 predicate 中用到的字段
+type slsaV1 struct {
+	BuildDefinition struct {
+		BuildType          string `json:"buildType"`
+		ExternalParameters struct {
+			Workflow struct {
+				Ref        string `json:"ref"`
+				Repository string `json:"repository"`
+				Path       string `json:"path"`
+			} `json:"workflow"`
+		} `json:"externalParameters"`
+		ResolvedDependencies []struct {
+			URI    string            `json:"uri"`
+			Digest map[string]string `json:"digest"`
+		} `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		Metadata struct {
+			InvocationID string `json:"invocationId"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+// slsaV02 SLSA v0.2 predicate 中用到的字段
+type slsaV02 struct {
+	Builder struct {
+		ID string `json:"id"`
+	} `json:"builder"`
+	BuildType  string `json:"buildType"`
+	Invocation struct {
+		ConfigSource struct {
+			URI        string            `json:"uri"`
+			Digest     map[string]string `json:"digest"`
+			EntryPoint string            `json:"entryPoint"`
+		} `json:"configSource"`
+	} `json:"invocation"`
+	Metadata struct {
+		BuildInvocationID string `json:"buildInvocationId"`
+	} `json:"metadata"`
+}
+
+// ParseProvenance 从 Statement 中解析 SLSA 构建来源信息，支持 SLSA v1 和 v0.2
+//
+// 参数:
+//   - statement: in-toto Statement
+//
+// 返回值:
+//   - *Provenance: 构建来源信息
+//   - error: 如果 Statement 不是 SLSA 构建来源证明或者解析失败则返回错误
+func ParseProvenance(statement *Statement) (*Provenance, error) {
+	switch statement.PredicateType {
+	case models.PredicateTypeSLSAProvenanceV1:
+		var predicate slsaV1
+		if err := json.Unmarshal(statement.Predicate, &predicate); err != nil {
+			return nil, fmt.Errorf("parse SLSA v1 predicate: %w", err)
+		}
+		workflow := predicate.BuildDefinition.ExternalParameters.Workflow
+		provenance := &Provenance{
+			BuildType:    predicate.BuildDefinition.BuildType,
+			Builder:      predicate.RunDetails.Builder.ID,
+			Repository:   workflow.Repository,
+			Ref:          workflow.Ref,
+			Workflow:     workflow.Path,
+			InvocationID: predicate.RunDetails.Metadata.InvocationID,
+		}
+		for _, dependency := range predicate.BuildDefinition.ResolvedDependencies {
+			if commit := dependency.Digest["gitCommit"]; commit != "" {
+				provenance.Commit = commit
+				break
+			}
+		}
+		return provenance, nil
+	case models.PredicateTypeSLSAProvenanceV02:
+		var predicate slsaV02
+		if err := json.Unmarshal(statement.Predicate, &predicate); err != nil {
+			return nil, fmt.Errorf("parse SLSA v0.2 predicate: %w", err)
+		}
+		source := predicate.Invocation.ConfigSource
+		repository, ref := splitSourceURI(source.URI)
+		return &Provenance{
+			BuildType:    predicate.BuildType,
+			Builder:      predicate.Builder.ID,
+			Repository:   repository,
+			Ref:          ref,
+			Commit:       source.Digest["sha1"],
+			Workflow:     source.EntryPoint,
+			InvocationID: predicate.Metadata.BuildInvocationID,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported predicate type %q", statement.PredicateType)
+	}
+}
+
+// splitSourceURI 将 "git+https://github.com/owner/repo@refs/heads/main" 拆分为仓库地址和 git 引用
+func splitSourceURI(uri string) (string, string) {
+	uri = strings.TrimPrefix(uri, "git+")
+	index := strings.LastIndex(uri, "@")
+	if index < 0 {
+		return uri, ""
+	}
+	return uri[:index], uri[index+1:]
+}
@@ -0,0 +1,80 @@
+package provenance
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const slsaV1Predicate = `{
+  "buildDefinition": {
+    "buildType": "https://slsa-framework.github.io/github-actions-buildtypes/workflow/v1",
+    "externalParameters": {
+      "workflow": {
+        "ref": "refs/heads/main",
+        "repository": "https://github.com/owner/repo",
+        "path": ".github/workflows/publish.yml"
+      }
+    },
+    "resolvedDependencies": [
+      {
+        "uri": "git+https://github.com/owner/repo@refs/heads/main",
+        "digest": {"gitCommit": "0123456789abcdef0123456789abcdef01234567"}
+      }
+    ]
+  },
+  "runDetails": {
+    "builder": {"id": "https://github.com/actions/runner/github-hosted"},
+    "metadata": {"invocationId": "https://github.com/owner/repo/actions/runs/1/attempts/1"}
+  }
+}`
+
+const slsaV02Predicate = `{
+  "buildType": "https://github.com/npm/cli/gha/v2",
+  "builder": {"id": "https://github.com/actions/runner"},
+  "invocation": {
+    "configSource": {
+      "uri": "git+https://github.com/owner/repo@refs/tags/v1.0.0",
+      "digest": {"sha1": "0123456789abcdef0123456789abcdef01234567"},
+      "entryPoint": ".github/workflows/release.yml"
+    }
+  },
+  "metadata": {"buildInvocationId": "1-1"}
+}`
+
+func TestParseProvenance(t *testing.T) {
+	provenance, err := ParseProvenance(&Statement{
+		PredicateType: models.PredicateTypeSLSAProvenanceV1,
+		Predicate:     json.RawMessage(slsaV1Predicate),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, &Provenance{
+		BuildType:    "https://slsa-framework.github.io/github-actions-buildtypes/workflow/v1",
+		Builder:      "https://github.com/actions/runner/github-hosted",
+		Repository:   "https://github.com/owner/repo",
+		Ref:          "refs/heads/main",
+		Commit:       "0123456789abcdef0123456789abcdef01234567",
+		Workflow:     ".github/workflows/publish.yml",
+		InvocationID: "https://github.com/owner/repo/actions/runs/1/attempts/1",
+	}, provenance)
+
+	provenance, err = ParseProvenance(&Statement{
+		PredicateType: models.PredicateTypeSLSAProvenanceV02,
+		Predicate:     json.RawMessage(slsaV02Predicate),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, &Provenance{
+		BuildType:    "https://github.com/npm/cli/gha/v2",
+		Builder:      "https://github.com/actions/runner",
+		Repository:   "https://github.com/owner/repo",
+		Ref:          "refs/tags/v1.0.0",
+		Commit:       "0123456789abcdef0123456789abcdef01234567",
+		Workflow:     ".github/workflows/release.yml",
+		InvocationID: "1-1",
+	}, provenance)
+
+	_, err = ParseProvenance(&Statement{PredicateType: models.PredicateTypeNpmPublish})
+	assert.NotNil(t, err)
+}
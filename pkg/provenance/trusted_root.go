@@ -0,0 +1,178 @@
+package provenance
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// TrustedRoot 校验证明时信任的根材料
+//
+// 包含 Fulcio 证书颁发机构、Rekor 透明日志公钥以及 npm Registry 的签名公钥，
+// 全部由调用方在本地提供，校验过程不会访问网络，便于离线使用和测试
+type TrustedRoot struct {
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+	logs          map[string]crypto.PublicKey
+	registryKeys  *models.RegistryKeys
+}
+
+// NewTrustedRoot 创建一个空的 TrustedRoot
+//
+// 返回值:
+//   - *TrustedRoot: 不信任任何证书和日志的 TrustedRoot，需要通过 Add 系列方法添加
+//
+// 使用示例:
+//
+//	root := provenance.NewTrustedRoot().
+//		AddCertificateAuthority(fulcioRoot, fulcioIntermediate).
+//		AddTransparencyLog(rekorKey).
+//		SetRegistryKeys(keys)
+func NewTrustedRoot() *TrustedRoot {
+	return &TrustedRoot{
+		roots:         x509.NewCertPool(),
+		intermediates: x509.NewCertPool(),
+		logs:          make(map[string]crypto.PublicKey),
+	}
+}
+
+// AddCertificateAuthority 添加信任的证书颁发机构（Fulcio）证书
+//
+// 参数:
+//   - certificates: 证书链，自签名的证书作为根证书，其它作为中间证书
+//
+// 返回值:
+//   - *TrustedRoot: 返回自身以支持链式调用
+func (x *TrustedRoot) AddCertificateAuthority(certificates ...*x509.Certificate) *TrustedRoot {
+	for _, certificate := range certificates {
+		if bytes.Equal(certificate.RawSubject, certificate.RawIssuer) {
+			x.roots.AddCert(certificate)
+		} else {
+			x.intermediates.AddCert(certificate)
+		}
+	}
+	return x
+}
+
+// AddTransparencyLog 添加信任的透明日志（Rekor）公钥
+//
+// 参数:
+//   - publicKey: 日志公钥，日志 ID 为其 DER 编码的 SHA-256 摘要
+//
+// 返回值:
+//   - *TrustedRoot: 返回自身以支持链式调用
+//   - error: 如果公钥无法编码则返回错误
+func (x *TrustedRoot) AddTransparencyLog(publicKey crypto.PublicKey) (*TrustedRoot, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return x, fmt.Errorf("marshal transparency log key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	x.logs[base64.StdEncoding.EncodeToString(sum[:])] = publicKey
+	return x, nil
+}
+
+// SetRegistryKeys 设置 npm Registry 的签名公钥，用于校验 npm 发布证明
+//
+// 参数:
+//   - keys: Registry 公钥列表，通常来自 Registry.GetSigningKeys
+//
+// 返回值:
+//   - *TrustedRoot: 返回自身以支持链式调用
+func (x *TrustedRoot) SetRegistryKeys(keys *models.RegistryKeys) *TrustedRoot {
+	x.registryKeys = keys
+	return x
+}
+
+// trustedRootJSON Sigstore trusted_root.json 中用到的字段
+type trustedRootJSON struct {
+	Tlogs []struct {
+		PublicKey struct {
+			RawBytes string `json:"rawBytes"`
+		} `json:"publicKey"`
+	} `json:"tlogs"`
+	CertificateAuthorities []struct {
+		CertChain models.CertificateChain `json:"certChain"`
+	} `json:"certificateAuthorities"`
+}
+
+// ParseTrustedRoot 解析 Sigstore 的 trusted_root.json
+//
+// 可以使用 sigstore 的 TUF 仓库中发布的 trusted_root.json，也可以是自己搭建的私有实例的配置。
+// 只读取 tlogs 和 certificateAuthorities，Registry 公钥需要另外通过 SetRegistryKeys 设置
+//
+// 参数:
+//   - data: trusted_root.json 的内容
+//
+// 返回值:
+//   - *TrustedRoot: 解析得到的 TrustedRoot
+//   - error: 如果 JSON、证书或公钥无法解析则返回错误
+func ParseTrustedRoot(data []byte) (*TrustedRoot, error) {
+	var document trustedRootJSON
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("parse trusted root: %w", err)
+	}
+	root := NewTrustedRoot()
+	for _, authority := range document.CertificateAuthorities {
+		for _, raw := range authority.CertChain.Certificates {
+			certificate, err := parseCertificate(raw)
+			if err != nil {
+				return nil, err
+			}
+			root.AddCertificateAuthority(certificate)
+		}
+	}
+	for _, tlog := range document.Tlogs {
+		der, err := base64.StdEncoding.DecodeString(tlog.PublicKey.RawBytes)
+		if err != nil {
+			return nil, fmt.Errorf("decode transparency log key: %w", err)
+		}
+		publicKey, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("parse transparency log key: %w", err)
+		}
+		if _, err := root.AddTransparencyLog(publicKey); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// ParseTrustedRootFile 从文件中读取并解析 Sigstore 的 trusted_root.json
+//
+// 参数:
+//   - filename: 文件路径
+//
+// 返回值:
+//   - *TrustedRoot: 解析得到的 TrustedRoot
+//   - error: 如果文件读取或解析失败则返回错误
+func ParseTrustedRootFile(filename string) (*TrustedRoot, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTrustedRoot(data)
+}
+
+// parseCertificate 解析 Base64 编码的 DER 证书
+func parseCertificate(raw *models.RawBytes) (*x509.Certificate, error) {
+	if raw == nil {
+		return nil, fmt.Errorf("empty certificate")
+	}
+	der, err := base64.StdEncoding.DecodeString(raw.RawBytes)
+	if err != nil {
+		return nil, fmt.Errorf("decode certificate: %w", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse certificate: %w", err)
+	}
+	return certificate, nil
+}
@@ -0,0 +1,430 @@
+package provenance
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/purl"
+)
+
+var (
+	// ErrSubjectMismatch 证明的 subject 与版本的名称或 integrity 不一致
+	ErrSubjectMismatch = errors.New("attestation subject does not match package")
+
+	// ErrSignature DSSE 信封的签名校验失败
+	ErrSignature = errors.New("attestation signature verification failed")
+
+	// ErrCertificate 签名证书不受信任或者 Registry 公钥不可用
+	ErrCertificate = errors.New("attestation signer is not trusted")
+
+	// ErrTransparencyLog 没有可以校验通过的透明日志记录
+	ErrTransparencyLog = errors.New("attestation transparency log verification failed")
+)
+
+// Result 表示一条证明的校验结果
+//
+// 主要字段说明:
+//   - PredicateType: 证明类型
+//   - Statement: 解析后的 in-toto Statement
+//   - Provenance: SLSA 构建来源信息，只有构建来源证明才有
+//   - Certificate: Fulcio 签名证书，使用 Registry 公钥签名的发布证明中为 nil
+//   - Identity: 签名证书中的身份，GitHub Actions 中为工作流地址
+//   - Keyid: 使用 Registry 公钥签名时的 keyid
+//   - IntegratedTime: 写入透明日志的时间
+//   - LogIndex: 透明日志索引
+type Result struct {
+	PredicateType  string
+	Statement      *Statement
+	Provenance     *Provenance
+	Certificate    *x509.Certificate
+	Identity       string
+	Keyid          string
+	IntegratedTime time.Time
+	LogIndex       int64
+}
+
+// Verify 校验一条证明
+//
+// 校验步骤:
+//  1. 透明日志记录的签名时间戳（SET）由受信任的日志签发，且记录中的 payload 摘要与信封一致
+//  2. 签名者受信任：Fulcio 证书能在写入日志的时间链到受信任的根证书，
+//     或者使用的 Registry 公钥在写入日志时未过期
+//  3. DSSE 信封的签名有效
+//  4. Statement 的 subject 为该版本的 purl，且 sha512 摘要与版本的 integrity 一致
+//
+// 参数:
+//   - version: 被证明的版本，需要包含 Dist.Integrity
+//   - attestation: 证明，通常来自 Registry.GetAttestations
+//
+// 返回值:
+//   - *Result: 校验结果
+//   - error: 校验失败时返回错误，可以通过 errors.Is 与 ErrSubjectMismatch 等比较
+//
+// 使用示例:
+//
+//	root, _ := provenance.ParseTrustedRootFile("trusted_root.json")
+//	attestations, _ := registry.GetAttestations(ctx, "sigstore", "2.0.0")
+//	attestation := attestations.Attestation(models.PredicateTypeSLSAProvenanceV1)
+//	result, err := root.Verify(version, attestation)
+//	if err != nil {
+//		// 校验失败
+//	}
+//	fmt.Println(result.Provenance.Repository, result.Provenance.Commit)
+func (x *TrustedRoot) Verify(version *models.Version, attestation *models.Attestation) (*Result, error) {
+	if attestation == nil || attestation.Bundle == nil || attestation.Bundle.DSSEEnvelope == nil ||
+		attestation.Bundle.VerificationMaterial == nil {
+		return nil, errors.New("attestation bundle is incomplete")
+	}
+	envelope := attestation.Bundle.DSSEEnvelope
+	material := attestation.Bundle.VerificationMaterial
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode attestation payload: %w", err)
+	}
+
+	result := &Result{PredicateType: attestation.PredicateType}
+	if err := x.verifyTlogEntries(material.TlogEntries, payload, result); err != nil {
+		return nil, err
+	}
+	publicKey, err := x.verifySigner(material, result)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyEnvelope(publicKey, envelope, payload); err != nil {
+		return nil, err
+	}
+
+	var statement Statement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return nil, fmt.Errorf("parse attestation statement: %w", err)
+	}
+	if statement.PredicateType != attestation.PredicateType {
+		return nil, fmt.Errorf("%w: predicate type %q, expected %q", ErrSubjectMismatch, statement.PredicateType, attestation.PredicateType)
+	}
+	if err := checkSubject(&statement, version); err != nil {
+		return nil, err
+	}
+	result.Statement = &statement
+	if statement.PredicateType == models.PredicateTypeSLSAProvenanceV1 || statement.PredicateType == models.PredicateTypeSLSAProvenanceV02 {
+		if result.Provenance, err = ParseProvenance(&statement); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// VerifyAll 校验版本的所有证明
+//
+// 参数:
+//   - version: 被证明的版本
+//   - attestations: 证明列表
+//
+// 返回值:
+//   - []*Result: 与 attestations 顺序一致的校验结果
+//   - error: 任意一条证明校验失败时返回错误
+func (x *TrustedRoot) VerifyAll(version *models.Version, attestations *models.Attestations) ([]*Result, error) {
+	results := make([]*Result, 0, len(attestations.Attestations))
+	for _, attestation := range attestations.Attestations {
+		result, err := x.Verify(version, attestation)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", attestation.PredicateType, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// setPayload 透明日志签名时间戳覆盖的内容，字段按照字母顺序排列以得到规范化的 JSON
+type setPayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// verifyTlogEntries 校验透明日志记录，至少需要一条来自受信任日志的记录校验通过
+func (x *TrustedRoot) verifyTlogEntries(entries []*models.TlogEntry, payload []byte, result *Result) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("%w: no transparency log entries", ErrTransparencyLog)
+	}
+	var errs []error
+	for _, entry := range entries {
+		err := x.verifyTlogEntry(entry, payload, result)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("%w: %v", ErrTransparencyLog, errors.Join(errs...))
+}
+
+// verifyTlogEntry 校验一条透明日志记录的签名时间戳以及记录内容与 payload 的对应关系
+func (x *TrustedRoot) verifyTlogEntry(entry *models.TlogEntry, payload []byte, result *Result) error {
+	if entry == nil || entry.LogID == nil || entry.InclusionPromise == nil {
+		return errors.New("incomplete log entry")
+	}
+	logKey, ok := x.logs[entry.LogID.KeyID]
+	if !ok {
+		return fmt.Errorf("unknown log %s", entry.LogID.KeyID)
+	}
+	logID, err := base64.StdEncoding.DecodeString(entry.LogID.KeyID)
+	if err != nil {
+		return fmt.Errorf("decode log id: %w", err)
+	}
+	integratedTime, err := strconv.ParseInt(entry.IntegratedTime, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parse integrated time: %w", err)
+	}
+	logIndex, err := strconv.ParseInt(entry.LogIndex, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parse log index: %w", err)
+	}
+
+	message, err := json.Marshal(&setPayload{
+		Body:           entry.CanonicalizedBody,
+		IntegratedTime: integratedTime,
+		LogID:          hex.EncodeToString(logID),
+		LogIndex:       logIndex,
+	})
+	if err != nil {
+		return err
+	}
+	set, err := base64.StdEncoding.DecodeString(entry.InclusionPromise.SignedEntryTimestamp)
+	if err != nil {
+		return fmt.Errorf("decode signed entry timestamp: %w", err)
+	}
+	if err := verifySignature(logKey, message, set); err != nil {
+		return fmt.Errorf("signed entry timestamp: %w", err)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(entry.CanonicalizedBody)
+	if err != nil {
+		return fmt.Errorf("decode log entry body: %w", err)
+	}
+	payloadHash, err := entryPayloadHash(body)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(payload)
+	if !strings.EqualFold(payloadHash, hex.EncodeToString(sum[:])) {
+		return errors.New("log entry does not match attestation payload")
+	}
+
+	result.IntegratedTime = time.Unix(integratedTime, 0).UTC()
+	result.LogIndex = logIndex
+	return nil
+}
+
+// entryPayloadHash 从 intoto（spec.content.payloadHash）或 dsse（spec.payloadHash）记录中取出 payload 摘要
+func entryPayloadHash(body []byte) (string, error) {
+	type hash struct {
+		Algorithm string `json:"algorithm"`
+		Value     string `json:"value"`
+	}
+	var entry struct {
+		Spec struct {
+			Content struct {
+				PayloadHash *hash `json:"payloadHash"`
+			} `json:"content"`
+			PayloadHash *hash `json:"payloadHash"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return "", fmt.Errorf("parse log entry body: %w", err)
+	}
+	payloadHash := entry.Spec.Content.PayloadHash
+	if payloadHash == nil {
+		payloadHash = entry.Spec.PayloadHash
+	}
+	if payloadHash == nil || payloadHash.Algorithm != "sha256" {
+		return "", errors.New("log entry has no sha256 payload hash")
+	}
+	return payloadHash.Value, nil
+}
+
+// verifySigner 确认签名者受信任并返回用于校验信封的公钥
+func (x *TrustedRoot) verifySigner(material *models.VerificationMaterial, result *Result) (crypto.PublicKey, error) {
+	if material.PublicKey != nil {
+		return x.registryKey(material.PublicKey.Hint, result)
+	}
+
+	var chain []*models.RawBytes
+	if material.Certificate != nil {
+		chain = append(chain, material.Certificate)
+	} else if material.X509CertificateChain != nil {
+		chain = material.X509CertificateChain.Certificates
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%w: no certificate or public key", ErrCertificate)
+	}
+	leaf, err := parseCertificate(chain[0])
+	if err != nil {
+		return nil, err
+	}
+	intermediates := x.intermediates.Clone()
+	for _, raw := range chain[1:] {
+		certificate, err := parseCertificate(raw)
+		if err != nil {
+			return nil, err
+		}
+		intermediates.AddCert(certificate)
+	}
+	// Fulcio 证书的有效期只有几分钟，按照写入透明日志的时间校验
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         x.roots,
+		Intermediates: intermediates,
+		CurrentTime:   result.IntegratedTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCertificate, err)
+	}
+	result.Certificate = leaf
+	if len(leaf.URIs) > 0 {
+		result.Identity = leaf.URIs[0].String()
+	} else if len(leaf.EmailAddresses) > 0 {
+		result.Identity = leaf.EmailAddresses[0]
+	}
+	return leaf.PublicKey, nil
+}
+
+// registryKey 查找 npm 发布证明使用的 Registry 公钥，并确认其在写入透明日志时未过期
+func (x *TrustedRoot) registryKey(keyid string, result *Result) (crypto.PublicKey, error) {
+	var key *models.RegistryKey
+	if x.registryKeys != nil {
+		key = x.registryKeys.Key(keyid)
+	}
+	if key == nil {
+		return nil, fmt.Errorf("%w: unknown registry key %s", ErrCertificate, keyid)
+	}
+	if expires, ok := key.ExpiresAt(); ok && !result.IntegratedTime.Before(expires) {
+		return nil, fmt.Errorf("%w: registry key %s expired at %s", ErrCertificate, keyid, expires.Format(time.RFC3339))
+	}
+	der, err := base64.StdEncoding.DecodeString(key.Key)
+	if err != nil {
+		return nil, fmt.Errorf("decode registry key %s: %w", keyid, err)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse registry key %s: %w", keyid, err)
+	}
+	result.Keyid = keyid
+	return publicKey, nil
+}
+
+// verifyEnvelope 校验 DSSE 信封，任意一个签名有效即可
+func verifyEnvelope(publicKey crypto.PublicKey, envelope *models.DSSEEnvelope, payload []byte) error {
+	message := preAuthEncoding(envelope.PayloadType, payload)
+	for _, signature := range envelope.Signatures {
+		if signature == nil {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(signature.Sig)
+		if err != nil {
+			continue
+		}
+		if verifySignature(publicKey, message, sig) == nil {
+			return nil
+		}
+	}
+	return ErrSignature
+}
+
+// preAuthEncoding 计算 DSSE 的 PAE 编码，即实际被签名的内容
+func preAuthEncoding(payloadType string, payload []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "DSSEv1 %d %s %d ", len(payloadType), payloadType, len(payload))
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+// verifySignature 使用公钥校验签名，支持 ECDSA、Ed25519 和 RSA PKCS#1 v1.5
+func verifySignature(publicKey crypto.PublicKey, message, signature []byte) error {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		var digest []byte
+		switch key.Curve {
+		case elliptic.P384():
+			sum := sha512.Sum384(message)
+			digest = sum[:]
+		case elliptic.P521():
+			sum := sha512.Sum512(message)
+			digest = sum[:]
+		default:
+			sum := sha256.Sum256(message)
+			digest = sum[:]
+		}
+		if !ecdsa.VerifyASN1(key, digest, signature) {
+			return ErrSignature
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, message, signature) {
+			return ErrSignature
+		}
+		return nil
+	case *rsa.PublicKey:
+		sum := sha256.Sum256(message)
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], signature) != nil {
+			return ErrSignature
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+// checkSubject 确认 Statement 的 subject 是该版本的 tarball
+func checkSubject(statement *Statement, version *models.Version) error {
+	expected, err := integritySHA512(version)
+	if err != nil {
+		return err
+	}
+	name := purl.NPM(version.Name, version.Version)
+	for _, subject := range statement.Subject {
+		if subject == nil || subject.Name != name {
+			continue
+		}
+		if !strings.EqualFold(subject.Digest["sha512"], expected) {
+			return fmt.Errorf("%w: digest of %s does not match integrity", ErrSubjectMismatch, name)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: no subject named %s", ErrSubjectMismatch, name)
+}
+
+// integritySHA512 从版本的 integrity 中取出 sha512 摘要，返回十六进制编码
+func integritySHA512(version *models.Version) (string, error) {
+	if version.Dist != nil {
+		for _, token := range strings.Fields(version.Dist.Integrity) {
+			algorithm, digest, ok := strings.Cut(token, "-")
+			if !ok || algorithm != "sha512" {
+				continue
+			}
+			// 去掉 SRI 中可能存在的 "?opt" 选项
+			digest, _, _ = strings.Cut(digest, "?")
+			raw, err := base64.StdEncoding.DecodeString(digest)
+			if err != nil {
+				return "", fmt.Errorf("decode integrity: %w", err)
+			}
+			return hex.EncodeToString(raw), nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s@%s has no sha512 integrity", ErrSubjectMismatch, version.Name, version.Version)
+}
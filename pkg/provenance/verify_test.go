@@ -0,0 +1,309 @@
+package provenance
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	tarballContent = []byte("fake tarball content")
+	integratedTime = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+)
+
+// fixture 测试用的 Sigstore 基础设施：证书颁发机构、透明日志和 Registry 公钥
+type fixture struct {
+	caKey       *ecdsa.PrivateKey
+	ca          *x509.Certificate
+	logKey      *ecdsa.PrivateKey
+	registryKey *ecdsa.PrivateKey
+	keys        *models.RegistryKeys
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	return key
+}
+
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{caKey: newKey(t), logKey: newKey(t), registryKey: newKey(t)}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-fulcio"},
+		NotBefore:             integratedTime.Add(-24 * time.Hour),
+		NotAfter:              integratedTime.Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &f.caKey.PublicKey, f.caKey)
+	assert.Nil(t, err)
+	f.ca, err = x509.ParseCertificate(der)
+	assert.Nil(t, err)
+
+	registryDer, err := x509.MarshalPKIXPublicKey(&f.registryKey.PublicKey)
+	assert.Nil(t, err)
+	f.keys = &models.RegistryKeys{Keys: []*models.RegistryKey{{
+		Keyid: "SHA256:registry",
+		Key:   base64.StdEncoding.EncodeToString(registryDer),
+	}}}
+	return f
+}
+
+func (f *fixture) trustedRoot(t *testing.T) *TrustedRoot {
+	root, err := NewTrustedRoot().AddCertificateAuthority(f.ca).AddTransparencyLog(&f.logKey.PublicKey)
+	assert.Nil(t, err)
+	return root.SetRegistryKeys(f.keys)
+}
+
+// leafCertificate 签发一个有效期 10 分钟的 Fulcio 风格签名证书
+func (f *fixture) leafCertificate(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	identity, _ := url.Parse("https://github.com/owner/repo/.github/workflows/publish.yml@refs/heads/main")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    integratedTime.Add(-time.Minute),
+		NotAfter:     integratedTime.Add(9 * time.Minute),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		URIs:         []*url.URL{identity},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, f.ca, &key.PublicKey, f.caKey)
+	assert.Nil(t, err)
+	return der
+}
+
+func testVersion() *models.Version {
+	sum := sha512.Sum512(tarballContent)
+	return &models.Version{
+		Name:    "@scope/pkg",
+		Version: "1.0.0",
+		Dist:    &models.Dist{Integrity: "sha512-" + base64.StdEncoding.EncodeToString(sum[:])},
+	}
+}
+
+func testStatement(predicateType, predicate string) []byte {
+	sum := sha512.Sum512(tarballContent)
+	return []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"pkg:npm/%%40scope/pkg@1.0.0","digest":{"sha512":"%s"}}],"predicateType":"%s","predicate":%s}`,
+		hex.EncodeToString(sum[:]), predicateType, predicate))
+}
+
+// sign 对 payload 生成 DSSE 信封和透明日志记录
+func (f *fixture) sign(t *testing.T, key *ecdsa.PrivateKey, payload []byte) (*models.DSSEEnvelope, *models.TlogEntry) {
+	payloadType := "application/vnd.in-toto+json"
+	digest := sha256.Sum256(preAuthEncoding(payloadType, payload))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	assert.Nil(t, err)
+	envelope := &models.DSSEEnvelope{
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		PayloadType: payloadType,
+		Signatures:  []*models.DSSESignature{{Sig: base64.StdEncoding.EncodeToString(sig)}},
+	}
+
+	payloadHash := sha256.Sum256(payload)
+	body := fmt.Sprintf(`{"apiVersion":"0.0.2","kind":"intoto","spec":{"content":{"payloadHash":{"algorithm":"sha256","value":"%s"}}}}`,
+		hex.EncodeToString(payloadHash[:]))
+	logDer, err := x509.MarshalPKIXPublicKey(&f.logKey.PublicKey)
+	assert.Nil(t, err)
+	logID := sha256.Sum256(logDer)
+	entry := &models.TlogEntry{
+		LogIndex:          "42",
+		LogID:             &models.LogID{KeyID: base64.StdEncoding.EncodeToString(logID[:])},
+		KindVersion:       &models.KindVersion{Kind: "intoto", Version: "0.0.2"},
+		IntegratedTime:    strconv.FormatInt(integratedTime.Unix(), 10),
+		CanonicalizedBody: base64.StdEncoding.EncodeToString([]byte(body)),
+	}
+	message, err := json.Marshal(&setPayload{
+		Body:           entry.CanonicalizedBody,
+		IntegratedTime: integratedTime.Unix(),
+		LogID:          hex.EncodeToString(logID[:]),
+		LogIndex:       42,
+	})
+	assert.Nil(t, err)
+	setDigest := sha256.Sum256(message)
+	set, err := ecdsa.SignASN1(rand.Reader, f.logKey, setDigest[:])
+	assert.Nil(t, err)
+	entry.InclusionPromise = &models.InclusionPromise{SignedEntryTimestamp: base64.StdEncoding.EncodeToString(set)}
+	return envelope, entry
+}
+
+func (f *fixture) provenanceAttestation(t *testing.T, payload []byte) *models.Attestation {
+	key := newKey(t)
+	envelope, entry := f.sign(t, key, payload)
+	return &models.Attestation{
+		PredicateType: models.PredicateTypeSLSAProvenanceV1,
+		Bundle: &models.SigstoreBundle{
+			MediaType: "application/vnd.dev.sigstore.bundle+json;version=0.2",
+			VerificationMaterial: &models.VerificationMaterial{
+				X509CertificateChain: &models.CertificateChain{Certificates: []*models.RawBytes{
+					{RawBytes: base64.StdEncoding.EncodeToString(f.leafCertificate(t, key))},
+				}},
+				TlogEntries: []*models.TlogEntry{entry},
+			},
+			DSSEEnvelope: envelope,
+		},
+	}
+}
+
+func (f *fixture) publishAttestation(t *testing.T) *models.Attestation {
+	payload := testStatement(models.PredicateTypeNpmPublish, `{"name":"@scope/pkg","version":"1.0.0"}`)
+	envelope, entry := f.sign(t, f.registryKey, payload)
+	return &models.Attestation{
+		PredicateType: models.PredicateTypeNpmPublish,
+		Bundle: &models.SigstoreBundle{
+			VerificationMaterial: &models.VerificationMaterial{
+				PublicKey:   &models.PublicKeyIdentifier{Hint: "SHA256:registry"},
+				TlogEntries: []*models.TlogEntry{entry},
+			},
+			DSSEEnvelope: envelope,
+		},
+	}
+}
+
+func TestVerifyProvenance(t *testing.T) {
+	f := newFixture(t)
+	root := f.trustedRoot(t)
+	attestation := f.provenanceAttestation(t, testStatement(models.PredicateTypeSLSAProvenanceV1, slsaV1Predicate))
+
+	result, err := root.Verify(testVersion(), attestation)
+	assert.Nil(t, err)
+	assert.Equal(t, models.PredicateTypeSLSAProvenanceV1, result.PredicateType)
+	assert.Equal(t, "https://github.com/owner/repo/.github/workflows/publish.yml@refs/heads/main", result.Identity)
+	assert.Equal(t, integratedTime, result.IntegratedTime)
+	assert.Equal(t, int64(42), result.LogIndex)
+	assert.NotNil(t, result.Certificate)
+	assert.Equal(t, "https://github.com/owner/repo", result.Provenance.Repository)
+	assert.Equal(t, "0123456789abcdef0123456789abcdef01234567", result.Provenance.Commit)
+
+	// tarball 与证明不一致
+	version := testVersion()
+	sum := sha512.Sum512([]byte("other content"))
+	version.Dist.Integrity = "sha512-" + base64.StdEncoding.EncodeToString(sum[:])
+	_, err = root.Verify(version, attestation)
+	assert.ErrorIs(t, err, ErrSubjectMismatch)
+
+	// 版本号不一致
+	version = testVersion()
+	version.Version = "1.0.1"
+	_, err = root.Verify(version, attestation)
+	assert.ErrorIs(t, err, ErrSubjectMismatch)
+}
+
+func TestCheckSubject(t *testing.T) {
+	sum := sha512.Sum512(tarballContent)
+	digest := map[string]string{"sha512": hex.EncodeToString(sum[:])}
+	version := testVersion()
+	version.Name = "@sigstore/cli"
+	version.Version = "1.0.0+build.5"
+
+	// 作用域的 "@" 和构建元数据的 "+" 都需要编码
+	statement := &Statement{Subject: []*Subject{{Name: "pkg:npm/%40sigstore/cli@1.0.0%2Bbuild.5", Digest: digest}}}
+	assert.Nil(t, checkSubject(statement, version))
+
+	for _, name := range []string{"pkg:npm/@sigstore/cli@1.0.0+build.5", "pkg:npm/%40sigstore/cli@1.0.0+build.5", "pkg:npm/%40sigstore/cli@1.0.0"} {
+		statement = &Statement{Subject: []*Subject{{Name: name, Digest: digest}}}
+		assert.ErrorIs(t, checkSubject(statement, version), ErrSubjectMismatch, name)
+	}
+}
+
+func TestVerifyRejectsUntrusted(t *testing.T) {
+	f := newFixture(t)
+	payload := testStatement(models.PredicateTypeSLSAProvenanceV1, slsaV1Predicate)
+
+	// 不信任的证书颁发机构
+	other := newFixture(t)
+	root := other.trustedRoot(t)
+	_, err := root.AddTransparencyLog(&f.logKey.PublicKey)
+	assert.Nil(t, err)
+	_, err = root.Verify(testVersion(), f.provenanceAttestation(t, payload))
+	assert.ErrorIs(t, err, ErrCertificate)
+
+	// 不信任的透明日志
+	root, err = NewTrustedRoot().AddCertificateAuthority(f.ca).AddTransparencyLog(&other.logKey.PublicKey)
+	assert.Nil(t, err)
+	_, err = root.Verify(testVersion(), f.provenanceAttestation(t, payload))
+	assert.ErrorIs(t, err, ErrTransparencyLog)
+
+	// 篡改信封中的 payload，透明日志中的摘要不再匹配
+	root = f.trustedRoot(t)
+	attestation := f.provenanceAttestation(t, payload)
+	tampered := testStatement(models.PredicateTypeSLSAProvenanceV1, slsaV02Predicate)
+	attestation.Bundle.DSSEEnvelope.Payload = base64.StdEncoding.EncodeToString(tampered)
+	_, err = root.Verify(testVersion(), attestation)
+	assert.ErrorIs(t, err, ErrTransparencyLog)
+
+	// 信封签名无效
+	attestation = f.provenanceAttestation(t, payload)
+	attestation.Bundle.DSSEEnvelope.PayloadType = "text/plain"
+	_, err = root.Verify(testVersion(), attestation)
+	assert.ErrorIs(t, err, ErrSignature)
+
+	// 篡改写入日志的时间，签名时间戳不再有效
+	attestation = f.provenanceAttestation(t, payload)
+	late := integratedTime.Add(time.Hour)
+	entry := attestation.Bundle.VerificationMaterial.TlogEntries[0]
+	entry.IntegratedTime = strconv.FormatInt(late.Unix(), 10)
+	_, err = root.Verify(testVersion(), attestation)
+	assert.ErrorIs(t, err, ErrTransparencyLog)
+}
+
+func TestVerifyPublishAttestation(t *testing.T) {
+	f := newFixture(t)
+	root := f.trustedRoot(t)
+	provenance := f.provenanceAttestation(t, testStatement(models.PredicateTypeSLSAProvenanceV1, slsaV1Predicate))
+	publish := f.publishAttestation(t)
+
+	results, err := root.VerifyAll(testVersion(), &models.Attestations{Attestations: []*models.Attestation{provenance, publish}})
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "SHA256:registry", results[1].Keyid)
+	assert.Nil(t, results[1].Certificate)
+	assert.Nil(t, results[1].Provenance)
+
+	// Registry 公钥在发布前已过期
+	expires := integratedTime.Add(-time.Hour).Format(time.RFC3339)
+	f.keys.Keys[0].Expires = &expires
+	_, err = root.Verify(testVersion(), publish)
+	assert.ErrorIs(t, err, ErrCertificate)
+
+	// 未知的 Registry 公钥
+	root, err = NewTrustedRoot().AddTransparencyLog(&f.logKey.PublicKey)
+	assert.Nil(t, err)
+	_, err = root.Verify(testVersion(), publish)
+	assert.ErrorIs(t, err, ErrCertificate)
+}
+
+func TestParseTrustedRoot(t *testing.T) {
+	f := newFixture(t)
+	logDer, err := x509.MarshalPKIXPublicKey(&f.logKey.PublicKey)
+	assert.Nil(t, err)
+	document := fmt.Sprintf(`{
+  "mediaType": "application/vnd.dev.sigstore.trustedroot+json;version=0.1",
+  "tlogs": [{"baseUrl": "https://rekor.example.com", "publicKey": {"rawBytes": "%s"}}],
+  "certificateAuthorities": [{"certChain": {"certificates": [{"rawBytes": "%s"}]}}]
+}`, base64.StdEncoding.EncodeToString(logDer), base64.StdEncoding.EncodeToString(f.ca.Raw))
+
+	root, err := ParseTrustedRoot([]byte(document))
+	assert.Nil(t, err)
+	_, err = root.Verify(testVersion(), f.provenanceAttestation(t, testStatement(models.PredicateTypeSLSAProvenanceV1, slsaV1Predicate)))
+	assert.Nil(t, err)
+
+	_, err = ParseTrustedRoot([]byte(`{"tlogs": [{"publicKey": {"rawBytes": "!!"}}]}`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,28 @@
+package purl
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// NPM 返回 npm 包版本的 purl，与 npm 证明中 Subject.Name 的格式一致
+//
+// 作用域的 "@" 编码为 "%40"，版本号按路径规则转义，其中构建元数据的 "+" 编码为 "%2B"
+//
+// 参数:
+//   - name: 包名称，例如 "@scope/name"
+//   - version: 版本号
+//
+// 返回值:
+//   - string: purl，例如 "pkg:npm/%40scope/name@1.0.0"
+//
+// 使用示例:
+//
+//	purl.NPM("@sigstore/cli", "1.0.0+build.5") // "pkg:npm/%40sigstore/cli@1.0.0%2Bbuild.5"
+func NPM(name, version string) string {
+	if strings.HasPrefix(name, "@") {
+		name = "%40" + name[1:]
+	}
+	return fmt.Sprintf("pkg:npm/%s@%s", name, strings.ReplaceAll(url.PathEscape(version), "+", "%2B"))
+}
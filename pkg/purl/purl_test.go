@@ -0,0 +1,14 @@
+package purl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNPM(t *testing.T) {
+	assert.Equal(t, "pkg:npm/left-pad@1.3.0", NPM("left-pad", "1.3.0"))
+	assert.Equal(t, "pkg:npm/%40babel/core@7.0.0-beta.1", NPM("@babel/core", "7.0.0-beta.1"))
+	assert.Equal(t, "pkg:npm/%40sigstore/cli@0.1.0", NPM("@sigstore/cli", "0.1.0"))
+	assert.Equal(t, "pkg:npm/pkg@1.0.0%2Bsha.abc", NPM("pkg", "1.0.0+sha.abc"))
+}
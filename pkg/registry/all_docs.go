@@ -0,0 +1,205 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// DefaultAllPackagesPageSize 是 AllPackages 每次请求 _all_docs 的默认文档数量
+const DefaultAllPackagesPageSize = 10000
+
+// AllPackagesOptions 表示 AllPackages 的配置选项
+//
+// 包含字段:
+//   - PageSize: 每次请求的文档数量，默认为 10000
+//   - StartAfter: 从这个包名之后开始遍历（不包含该包名本身），为空时从头开始，
+//     保存 AllPackagesIterator.Name 并在重启后设置到这里即可断点续传
+//
+// 使用示例:
+//
+//	options := NewAllPackagesOptions().SetPageSize(5000).SetStartAfter("@babel/core")
+type AllPackagesOptions struct {
+	PageSize   int
+	StartAfter string
+}
+
+// NewAllPackagesOptions 创建并返回默认的配置选项
+func NewAllPackagesOptions() *AllPackagesOptions {
+	return &AllPackagesOptions{
+		PageSize: DefaultAllPackagesPageSize,
+	}
+}
+
+// SetPageSize 设置每次请求的文档数量
+func (o *AllPackagesOptions) SetPageSize(pageSize int) *AllPackagesOptions {
+	o.PageSize = pageSize
+	return o
+}
+
+// SetStartAfter 设置从哪个包名之后开始遍历
+func (o *AllPackagesOptions) SetStartAfter(name string) *AllPackagesOptions {
+	o.StartAfter = name
+	return o
+}
+
+// AllPackagesIterator 按包名顺序遍历 Registry 中的全部包，每次只在内存中保留一页
+//
+// 使用方式与 bufio.Scanner 相同: 循环调用 Next，通过 Name 或 Row 获取当前的包，循环结束后检查 Err
+type AllPackagesIterator struct {
+	ctx      context.Context
+	registry *Registry
+	pageSize int
+
+	after string
+	done  bool
+	page  []*models.AllDocsRow
+	row   *models.AllDocsRow
+	count int
+	total int
+	err   error
+}
+
+// AllPackages 通过 _all_docs 接口分页遍历 Registry 中的全部包名
+//
+// 分页使用 startkey 和 limit 参数，而不是 skip，因此遍历几百万个包时每页的请求开销是固定的。
+// registry.npmjs.org 本身并不提供 _all_docs 接口，需要使用 NewNpmjsComRegistry（skimdb）
+// 或 NewReplicateRegistry 等 CouchDB 镜像；以 "_design/" 开头的设计文档会被跳过
+//
+// 参数:
+//   - ctx: 上下文，可用于取消遍历
+//   - options: 配置选项，为 nil 时使用默认选项
+//
+// 返回值:
+//   - *AllPackagesIterator: 包名迭代器，第一次调用 Next 时才会发起请求
+//
+// 使用示例:
+//
+//	registry := NewNpmjsComRegistry()
+//	it := registry.AllPackages(ctx, NewAllPackagesOptions().SetStartAfter(checkpoint))
+//	for it.Next() {
+//		fmt.Println(it.Name())
+//		if it.Count()%10000 == 0 {
+//			fmt.Printf("%d / %d\n", it.Count(), it.Total())
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		// 处理错误，可以用最后一个 it.Name() 作为 StartAfter 断点续传
+//	}
+func (x *Registry) AllPackages(ctx context.Context, options *AllPackagesOptions) *AllPackagesIterator {
+	if options == nil {
+		options = NewAllPackagesOptions()
+	}
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultAllPackagesPageSize
+	}
+	return &AllPackagesIterator{
+		ctx:      ctx,
+		registry: x,
+		pageSize: pageSize,
+		after:    options.StartAfter,
+	}
+}
+
+// Next 前进到下一个包，没有更多的包或者出现错误时返回 false
+func (x *AllPackagesIterator) Next() bool {
+	for len(x.page) == 0 {
+		if x.done || x.err != nil {
+			x.row = nil
+			return false
+		}
+		if x.err = x.fetch(); x.err != nil {
+			x.row = nil
+			return false
+		}
+	}
+	x.row = x.page[0]
+	x.page = x.page[1:]
+	x.count++
+	return true
+}
+
+// Row 返回当前包在 _all_docs 中的文档，包含包名和当前修订版本
+func (x *AllPackagesIterator) Row() *models.AllDocsRow {
+	return x.row
+}
+
+// Name 返回当前的包名
+func (x *AllPackagesIterator) Name() string {
+	if x.row == nil {
+		return ""
+	}
+	return x.row.ID
+}
+
+// Count 返回到目前为止已经遍历的包数量
+func (x *AllPackagesIterator) Count() int {
+	return x.count
+}
+
+// Total 返回数据库中的文档总数（即 total_rows，与 RegistryInformation.DocCount 一致），用于估算进度，
+// 第一页请求完成之前为 0
+func (x *AllPackagesIterator) Total() int {
+	return x.total
+}
+
+// Err 返回遍历过程中出现的错误，正常遍历完成时返回 nil
+func (x *AllPackagesIterator) Err() error {
+	return x.err
+}
+
+// fetch 请求下一页
+//
+// 每次都从上一页最后一个 key 开始请求 pageSize+1 个文档，并丢弃与该 key 相同的第一行，
+// 返回的文档数量少于请求数量时说明已经到达末尾
+func (x *AllPackagesIterator) fetch() error {
+	if err := x.ctx.Err(); err != nil {
+		return err
+	}
+	limit := x.pageSize + 1
+	targetUrl := strings.TrimSuffix(x.registry.options.RegistryURL, "/") + "/_all_docs?limit=" + strconv.Itoa(limit)
+	if x.after != "" {
+		startKey, err := json.Marshal(x.after)
+		if err != nil {
+			return err
+		}
+		targetUrl += "&startkey=" + url.QueryEscape(string(startKey))
+	}
+	bytes, err := x.registry.getBytes(x.ctx, targetUrl)
+	if err != nil {
+		return err
+	}
+	response, err := unmarshalJson[*models.AllDocsResponse](bytes)
+	if err != nil {
+		return err
+	}
+	if response.Rows == nil {
+		// 出错时 CouchDB 返回的是 {"error": ..., "reason": ...}，没有 rows 字段
+		return fmt.Errorf("_all_docs %s: unexpected response: %s", targetUrl, bytes)
+	}
+
+	x.total = response.TotalRows
+	x.done = len(response.Rows) < limit
+	rows := response.Rows
+	if len(rows) > 0 && x.after != "" && rows[0].Key == x.after {
+		rows = rows[1:]
+	}
+	if len(rows) == 0 {
+		x.done = true
+		return nil
+	}
+	x.after = rows[len(rows)-1].Key
+	x.page = make([]*models.AllDocsRow, 0, len(rows))
+	for _, row := range rows {
+		if !strings.HasPrefix(row.ID, "_design/") {
+			x.page = append(x.page, row)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupAllDocsServer 创建按 startkey 和 limit 分页返回 names 的模拟 _all_docs 服务器，并记录收到的 startkey
+func setupAllDocsServer(names []string, startKeys *[]string) *httptest.Server {
+	sort.Strings(names)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_all_docs" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "not_found", "reason": "missing"}`))
+			return
+		}
+		var startKey string
+		if raw := r.URL.Query().Get("startkey"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &startKey); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		*startKeys = append(*startKeys, startKey)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset := sort.SearchStrings(names, startKey)
+		end := offset + limit
+		if end > len(names) {
+			end = len(names)
+		}
+		fmt.Fprintf(w, `{"total_rows": %d, "offset": %d, "rows": [`, len(names), offset)
+		for i := offset; i < end; i++ {
+			if i > offset {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"id": %q, "key": %q, "value": {"rev": "1-a"}}`, names[i], names[i])
+		}
+		fmt.Fprint(w, "]}")
+	}))
+}
+
+func TestAllPackages(t *testing.T) {
+	names := []string{"@babel/core", "@babel/parser", "_design/app", "express", "left-pad", "react"}
+	var startKeys []string
+	server := setupAllDocsServer(names, &startKeys)
+	defer server.Close()
+	registry := NewRegistry(NewOptions().SetRegistryURL(server.URL))
+	ctx := context.Background()
+
+	it := registry.AllPackages(ctx, NewAllPackagesOptions().SetPageSize(2))
+	var got []string
+	for it.Next() {
+		got = append(got, it.Name())
+		assert.Equal(t, "1-a", it.Row().Value.Rev)
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, []string{"@babel/core", "@babel/parser", "express", "left-pad", "react"}, got)
+	assert.Equal(t, 5, it.Count())
+	assert.Equal(t, 6, it.Total())
+	assert.Equal(t, []string{"", "_design/app", "left-pad"}, startKeys)
+	assert.Equal(t, "", it.Name())
+
+	// 从指定包名之后继续
+	it = registry.AllPackages(ctx, NewAllPackagesOptions().SetStartAfter("@babel/parser"))
+	got = nil
+	for it.Next() {
+		got = append(got, it.Name())
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, []string{"express", "left-pad", "react"}, got)
+
+	// 错误响应
+	it = NewRegistry(NewOptions().SetRegistryURL(server.URL+"/missing")).AllPackages(ctx, nil)
+	assert.False(t, it.Next())
+	assert.NotNil(t, it.Err())
+
+	// 已取消的上下文
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	it = registry.AllPackages(canceled, nil)
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), context.Canceled)
+}
@@ -0,0 +1,43 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// GetAttestations 获取版本的 Sigstore 证明列表
+//
+// 使用 --provenance 发布的版本会同时包含构建来源证明（SLSA provenance）和 npm 发布证明，
+// 其它版本通常只有发布证明或者没有证明。证明的校验见 pkg/provenance
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - packageName: 包名称，作用域包会按照 npm 的方式转义为 "@scope%2fname"
+//   - version: 版本号
+//
+// 返回值:
+//   - *models.Attestations: 证明列表，版本没有证明时 Attestations 为空
+//   - error: 如果请求失败则返回错误
+//
+// 使用示例:
+//
+//	registry := NewRegistry()
+//	attestations, err := registry.GetAttestations(ctx, "sigstore", "2.0.0")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	if provenance := attestations.Attestation(models.PredicateTypeSLSAProvenanceV1); provenance != nil {
+//		fmt.Println("有构建来源证明")
+//	}
+func (x *Registry) GetAttestations(ctx context.Context, packageName, version string) (*models.Attestations, error) {
+	escapedName := strings.Replace(packageName, "/", "%2f", 1)
+	targetUrl := fmt.Sprintf("%s/-/npm/v1/attestations/%s@%s", x.options.RegistryURL, escapedName, version)
+	bytes, err := x.getBytes(ctx, targetUrl)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalJson[*models.Attestations](bytes)
+}
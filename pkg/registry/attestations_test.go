@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const attestationsJSON = `{
+  "attestations": [
+    {
+      "predicateType": "https://slsa.dev/provenance/v1",
+      "bundle": {
+        "mediaType": "application/vnd.dev.sigstore.bundle+json;version=0.2",
+        "verificationMaterial": {
+          "x509CertificateChain": {"certificates": [{"rawBytes": "MIIB"}]},
+          "tlogEntries": [
+            {
+              "logIndex": "42",
+              "logId": {"keyId": "wNI9atQGlz+VWfO6LRygH4QUfY/8W4RFwiT5i5WRgB0="},
+              "kindVersion": {"kind": "intoto", "version": "0.0.2"},
+              "integratedTime": "1717243200",
+              "inclusionPromise": {"signedEntryTimestamp": "MEUC"},
+              "canonicalizedBody": "e30="
+            }
+          ]
+        },
+        "dsseEnvelope": {
+          "payload": "e30=",
+          "payloadType": "application/vnd.in-toto+json",
+          "signatures": [{"sig": "MEUC", "keyid": ""}]
+        }
+      }
+    },
+    {
+      "predicateType": "https://github.com/npm/attestation/tree/main/specs/publish/v0.1",
+      "bundle": {
+        "verificationMaterial": {"publicKey": {"hint": "SHA256:jl3bwswu80PjjokCgh0o2w5c2U4LhQAE57gj9cz1kzA"}},
+        "dsseEnvelope": {"payload": "e30=", "payloadType": "application/vnd.in-toto+json", "signatures": []}
+      }
+    }
+  ]
+}`
+
+func TestGetAttestations(t *testing.T) {
+	var requestURI string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURI = r.RequestURI
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(attestationsJSON))
+	}))
+	defer server.Close()
+
+	registry := NewRegistry(NewOptions().SetRegistryURL(server.URL))
+	attestations, err := registry.GetAttestations(context.Background(), "@sigstore/cli", "0.1.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "/-/npm/v1/attestations/@sigstore%2fcli@0.1.0", requestURI)
+	assert.Len(t, attestations.Attestations, 2)
+
+	provenance := attestations.Attestation(models.PredicateTypeSLSAProvenanceV1)
+	assert.NotNil(t, provenance)
+	entry := provenance.Bundle.VerificationMaterial.TlogEntries[0]
+	assert.Equal(t, "42", entry.LogIndex)
+	assert.Equal(t, "intoto", entry.KindVersion.Kind)
+	assert.Equal(t, "MEUC", entry.InclusionPromise.SignedEntryTimestamp)
+
+	publish := attestations.Attestation(models.PredicateTypeNpmPublish)
+	assert.Equal(t, "SHA256:jl3bwswu80PjjokCgh0o2w5c2U4LhQAE57gj9cz1kzA", publish.Bundle.VerificationMaterial.PublicKey.Hint)
+	assert.Nil(t, attestations.Attestation("unknown"))
+}
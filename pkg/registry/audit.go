@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// GetBulkAdvisories 批量查询安全公告
+//
+// 调用 /-/npm/v1/security/advisories/bulk 接口，npm 7 及之后的 npm audit 使用的就是这个接口。
+// 接口按包名返回可能相关的公告，公告是否真正影响某个版本需要调用方根据 VulnerableVersions 判断，
+// 可以使用 pkg/audit 完成匹配
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - versions: 包名到已安装版本列表的映射
+//
+// 返回值:
+//   - models.BulkAdvisories: 包名到公告列表的映射，没有公告的包不会出现在结果中
+//   - error: 如果请求失败或者响应无法解析则返回错误
+//
+// 使用示例:
+//
+//	registry := NewRegistry()
+//	advisories, err := registry.GetBulkAdvisories(ctx, map[string][]string{
+//		"lodash": {"4.17.20"},
+//	})
+//	if err != nil {
+//		// 处理错误
+//	}
+//	for _, advisory := range advisories["lodash"] {
+//		fmt.Println(advisory.Severity, advisory.Title, advisory.VulnerableVersions)
+//	}
+func (x *Registry) GetBulkAdvisories(ctx context.Context, versions map[string][]string) (models.BulkAdvisories, error) {
+	targetUrl := fmt.Sprintf("%s/-/npm/v1/security/advisories/bulk", x.options.RegistryURL)
+	bytes, err := x.postJSON(ctx, targetUrl, versions)
+	if err != nil {
+		return nil, err
+	}
+	advisories, err := unmarshalJson[models.BulkAdvisories](bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse bulk advisories: %w", err)
+	}
+	return advisories, nil
+}
+
+// QuickAudit 使用旧版的 quick audit 接口审计依赖树
+//
+// 调用 /-/npm/v1/security/audits/quick 接口，npm 6 的 npm audit 使用的是这个接口。
+// 与 bulk 接口不同，服务端会完成版本匹配，只返回真正受影响的公告及其依赖路径
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - request: 依赖树，可以使用 NewAuditRequest 根据包名和版本构造
+//
+// 返回值:
+//   - *models.QuickAuditReport: 审计结果
+//   - error: 如果请求失败或者响应无法解析则返回错误
+func (x *Registry) QuickAudit(ctx context.Context, request *models.AuditRequest) (*models.QuickAuditReport, error) {
+	targetUrl := fmt.Sprintf("%s/-/npm/v1/security/audits/quick", x.options.RegistryURL)
+	bytes, err := x.postJSON(ctx, targetUrl, request)
+	if err != nil {
+		return nil, err
+	}
+	report, err := unmarshalJson[*models.QuickAuditReport](bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse quick audit report: %w", err)
+	}
+	return report, nil
+}
+
+// NewAuditRequest 根据包名和已安装版本构造 quick audit 请求
+//
+// 每个包的最小版本放在依赖树的第一层，同名包的其它版本嵌套在其下，
+// 因此这些版本在审计结果中的依赖路径形如 "lodash>lodash"
+//
+// 参数:
+//   - name: 项目名称
+//   - version: 项目版本
+//   - versions: 包名到已安装版本列表的映射
+//
+// 返回值:
+//   - *models.AuditRequest: quick audit 请求
+func NewAuditRequest(name, version string, versions map[string][]string) *models.AuditRequest {
+	request := &models.AuditRequest{
+		Name:         name,
+		Version:      version,
+		Requires:     make(map[string]string),
+		Dependencies: make(map[string]*models.AuditDependency),
+	}
+	for pkg, list := range versions {
+		if len(list) == 0 {
+			continue
+		}
+		list = append([]string(nil), list...)
+		semver.Sort(list)
+		top := &models.AuditDependency{Version: list[0]}
+		// 同一层级中包名唯一，更多的版本依次向下嵌套
+		parent := top
+		for _, v := range list[1:] {
+			nested := &models.AuditDependency{Version: v}
+			parent.Dependencies = map[string]*models.AuditDependency{pkg: nested}
+			parent = nested
+		}
+		request.Requires[pkg] = list[0]
+		request.Dependencies[pkg] = top
+	}
+	return request
+}
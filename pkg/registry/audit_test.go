@@ -0,0 +1,130 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const bulkAdvisoriesJSON = `{
+  "lodash": [
+    {
+      "id": 1106913,
+      "url": "https://github.com/advisories/GHSA-35jh-r3h4-6jhm",
+      "title": "Command Injection in lodash",
+      "severity": "high",
+      "vulnerable_versions": "<4.17.21",
+      "cwe": ["CWE-77", "CWE-94"],
+      "cvss": {"score": 7.2, "vectorString": "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H"}
+    }
+  ]
+}`
+
+const quickAuditJSON = `{
+  "actions": [],
+  "advisories": {
+    "1106913": {
+      "id": 1106913,
+      "title": "Command Injection in lodash",
+      "module_name": "lodash",
+      "vulnerable_versions": "<4.17.21",
+      "patched_versions": ">=4.17.21",
+      "severity": "high",
+      "cwe": "CWE-77",
+      "github_advisory_id": "GHSA-35jh-r3h4-6jhm",
+      "url": "https://github.com/advisories/GHSA-35jh-r3h4-6jhm",
+      "findings": [{"version": "4.17.20", "paths": ["lodash"]}]
+    }
+  },
+  "muted": [],
+  "metadata": {
+    "vulnerabilities": {"info": 0, "low": 0, "moderate": 0, "high": 1, "critical": 0},
+    "dependencies": 1,
+    "devDependencies": 0,
+    "optionalDependencies": 0,
+    "totalDependencies": 1
+  }
+}`
+
+func TestGetBulkAdvisories(t *testing.T) {
+	var method, contentType, authorization string
+	var body map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		contentType = r.Header.Get("Content-Type")
+		authorization = r.Header.Get("Authorization")
+		if r.URL.Path != "/-/npm/v1/security/advisories/bulk" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		w.Write([]byte(bulkAdvisoriesJSON))
+	}))
+	defer server.Close()
+
+	registry := NewRegistry(NewOptions().SetRegistryURL(server.URL).SetAuthToken("secret"))
+	versions := map[string][]string{"lodash": {"4.17.20", "4.17.21"}}
+	advisories, err := registry.GetBulkAdvisories(context.Background(), versions)
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodPost, method)
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, "Bearer secret", authorization)
+	assert.Equal(t, versions, body)
+
+	assert.Len(t, advisories["lodash"], 1)
+	advisory := advisories["lodash"][0]
+	assert.Equal(t, int64(1106913), advisory.ID)
+	assert.Equal(t, models.SeverityHigh, advisory.Severity)
+	assert.Equal(t, "<4.17.21", advisory.VulnerableVersions)
+	assert.Equal(t, models.CWEList{"CWE-77", "CWE-94"}, advisory.CWE)
+	assert.Equal(t, 7.2, advisory.CVSS.Score)
+}
+
+func TestQuickAudit(t *testing.T) {
+	var request models.AuditRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/-/npm/v1/security/audits/quick" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &request)
+		w.Write([]byte(quickAuditJSON))
+	}))
+	defer server.Close()
+
+	registry := NewRegistry(NewOptions().SetRegistryURL(server.URL))
+	report, err := registry.QuickAudit(context.Background(), NewAuditRequest("app", "1.0.0", map[string][]string{
+		"lodash": {"4.17.20"},
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, "app", request.Name)
+	assert.Equal(t, "4.17.20", request.Dependencies["lodash"].Version)
+
+	advisory := report.Advisories["1106913"]
+	assert.Equal(t, "lodash", advisory.ModuleName)
+	assert.Equal(t, ">=4.17.21", advisory.PatchedVersions)
+	assert.Equal(t, models.CWEList{"CWE-77"}, advisory.CWE)
+	assert.Equal(t, []string{"lodash"}, advisory.Findings[0].Paths)
+	assert.Equal(t, 1, report.Metadata.Vulnerabilities[models.SeverityHigh])
+}
+
+func TestNewAuditRequest(t *testing.T) {
+	request := NewAuditRequest("app", "1.0.0", map[string][]string{
+		"lodash": {"4.17.21", "3.10.1", "4.17.20"},
+		"empty":  {},
+	})
+	assert.Equal(t, map[string]string{"lodash": "3.10.1"}, request.Requires)
+	top := request.Dependencies["lodash"]
+	assert.Equal(t, "3.10.1", top.Version)
+	assert.Equal(t, "4.17.20", top.Dependencies["lodash"].Version)
+	assert.Equal(t, "4.17.21", top.Dependencies["lodash"].Dependencies["lodash"].Version)
+	assert.Nil(t, request.Dependencies["empty"])
+}
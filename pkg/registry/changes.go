@@ -0,0 +1,237 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// ChangesFeed 表示 _changes 接口的 feed 模式
+type ChangesFeed string
+
+const (
+	// ChangesFeedNormal 立即返回 since 之后的变更，没有变更时返回空列表
+	ChangesFeedNormal ChangesFeed = "normal"
+
+	// ChangesFeedLongpoll 没有新变更时服务端保持连接，直到出现变更或者超时才返回
+	ChangesFeedLongpoll ChangesFeed = "longpoll"
+
+	// ChangesFeedContinuous 服务端保持连接并持续推送变更，每行一条 JSON，空行为心跳
+	ChangesFeedContinuous ChangesFeed = "continuous"
+)
+
+// ChangesOptions 表示 _changes 接口的请求参数
+//
+// 包含字段:
+//   - Feed: feed 模式，默认为 normal
+//   - Since: 从哪个序列号之后开始返回变更，为空时从头开始，"now" 表示只返回之后的新变更
+//   - Limit: 单次请求最多返回的变更数量，为 0 时不限制
+//   - IncludeDocs: 是否同时返回变更后的完整文档
+//   - Heartbeat: continuous 和 longpoll 模式下服务端发送心跳的间隔，为 0 时不发送
+//   - Timeout: continuous 和 longpoll 模式下没有变更时服务端关闭连接的超时时间，为 0 时使用服务端默认值
+//
+// 使用示例:
+//
+//	options := NewChangesOptions().
+//		SetFeed(ChangesFeedContinuous).
+//		SetSince("now").
+//		SetHeartbeat(30 * time.Second)
+type ChangesOptions struct {
+	Feed        ChangesFeed
+	Since       models.Sequence
+	Limit       int
+	IncludeDocs bool
+	Heartbeat   time.Duration
+	Timeout     time.Duration
+}
+
+// NewChangesOptions 创建并返回默认的请求参数
+//
+// 默认配置:
+//   - Feed: normal
+//   - 从头开始，不限制数量，不返回完整文档
+func NewChangesOptions() *ChangesOptions {
+	return &ChangesOptions{
+		Feed: ChangesFeedNormal,
+	}
+}
+
+// SetFeed 设置 feed 模式
+func (o *ChangesOptions) SetFeed(feed ChangesFeed) *ChangesOptions {
+	o.Feed = feed
+	return o
+}
+
+// SetSince 设置从哪个序列号之后开始返回变更
+func (o *ChangesOptions) SetSince(since models.Sequence) *ChangesOptions {
+	o.Since = since
+	return o
+}
+
+// SetLimit 设置单次请求最多返回的变更数量
+func (o *ChangesOptions) SetLimit(limit int) *ChangesOptions {
+	o.Limit = limit
+	return o
+}
+
+// SetIncludeDocs 设置是否同时返回变更后的完整文档
+func (o *ChangesOptions) SetIncludeDocs(includeDocs bool) *ChangesOptions {
+	o.IncludeDocs = includeDocs
+	return o
+}
+
+// SetHeartbeat 设置服务端发送心跳的间隔
+func (o *ChangesOptions) SetHeartbeat(heartbeat time.Duration) *ChangesOptions {
+	o.Heartbeat = heartbeat
+	return o
+}
+
+// SetTimeout 设置没有变更时服务端关闭连接的超时时间
+func (o *ChangesOptions) SetTimeout(timeout time.Duration) *ChangesOptions {
+	o.Timeout = timeout
+	return o
+}
+
+// query 返回请求 _changes 接口的查询参数，since 参数使用传入的序列号
+func (o *ChangesOptions) query(since models.Sequence) url.Values {
+	query := url.Values{}
+	if o.Feed != "" {
+		query.Set("feed", string(o.Feed))
+	}
+	if since != "" {
+		query.Set("since", string(since))
+	}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.IncludeDocs {
+		query.Set("include_docs", "true")
+	}
+	if o.Feed != ChangesFeedNormal && o.Feed != "" {
+		if o.Heartbeat > 0 {
+			query.Set("heartbeat", strconv.FormatInt(o.Heartbeat.Milliseconds(), 10))
+		}
+		if o.Timeout > 0 {
+			query.Set("timeout", strconv.FormatInt(o.Timeout.Milliseconds(), 10))
+		}
+	}
+	return query
+}
+
+// GetChanges 获取 Registry 的变更列表，对应 CouchDB 的 _changes 接口
+//
+// 只支持 normal 和 longpoll 模式，continuous 模式需要持续读取响应，请使用 ChangesFollower。
+// registry.npmjs.org 本身并不提供 _changes 接口，需要使用 RegistryUrlReplicate 等 CouchDB 镜像
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - options: 请求参数，为 nil 时使用默认参数
+//
+// 返回值:
+//   - *models.ChangesResponse: 变更列表以及最后一条变更的序列号
+//   - error: 如果请求失败或者使用了 continuous 模式则返回错误
+//
+// 使用示例:
+//
+//	registry := NewReplicateRegistry()
+//	changes, err := registry.GetChanges(ctx, NewChangesOptions().SetSince("now").SetFeed(ChangesFeedLongpoll))
+//	if err != nil {
+//		// 处理错误
+//	}
+//	for _, change := range changes.Results {
+//		fmt.Println(change.Seq, change.ID, change.Deleted)
+//	}
+func (x *Registry) GetChanges(ctx context.Context, options *ChangesOptions) (*models.ChangesResponse, error) {
+	if options == nil {
+		options = NewChangesOptions()
+	}
+	if options.Feed == ChangesFeedContinuous {
+		return nil, errors.New("continuous feed is not supported by GetChanges, use ChangesFollower instead")
+	}
+	return x.getChanges(ctx, options, options.Since)
+}
+
+// getChanges 以 normal 或 longpoll 模式请求 since 之后的变更
+func (x *Registry) getChanges(ctx context.Context, options *ChangesOptions, since models.Sequence) (*models.ChangesResponse, error) {
+	bytes, err := x.getBytes(ctx, x.changesURL(options, since))
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalJson[*models.ChangesResponse](bytes)
+}
+
+// streamChanges 以 continuous 模式请求 since 之后的变更，每读到一条变更就调用一次 handler
+//
+// 服务端正常结束响应时会发送一行 last_seq，此时返回该序列号；
+// 连接在没有 last_seq 的情况下断开会返回 io.ErrUnexpectedEOF，handler 返回错误时直接返回该错误
+func (x *Registry) streamChanges(ctx context.Context, options *ChangesOptions, since models.Sequence, handler func(change *models.Change) error) (models.Sequence, error) {
+	targetUrl := x.changesURL(options, since)
+	if x.options.Snapshot != "" {
+		return "", errOffline(targetUrl)
+	}
+	client, err := x.options.GetHttpClient()
+	if err != nil {
+		return "", err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, targetUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	if x.shouldAuthorize(targetUrl) {
+		request.Header.Set("Authorization", "Bearer "+x.options.AuthToken)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", &StatusError{URL: targetUrl, StatusCode: response.StatusCode}
+	}
+
+	reader := bufio.NewReader(response.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var row struct {
+				models.Change
+				LastSeq models.Sequence `json:"last_seq"`
+			}
+			if err := json.Unmarshal(line, &row); err != nil {
+				return "", fmt.Errorf("changes %s: %w", targetUrl, err)
+			}
+			if row.ID == "" && row.LastSeq != "" {
+				return row.LastSeq, nil
+			}
+			if err := handler(&row.Change); err != nil {
+				return "", err
+			}
+		}
+		if err == io.EOF {
+			return "", io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// changesURL 返回 _changes 接口的请求地址
+func (x *Registry) changesURL(options *ChangesOptions, since models.Sequence) string {
+	targetUrl := strings.TrimSuffix(x.options.RegistryURL, "/") + "/_changes"
+	if query := options.query(since); len(query) > 0 {
+		targetUrl += "?" + query.Encode()
+	}
+	return targetUrl
+}
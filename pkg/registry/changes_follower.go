@@ -0,0 +1,327 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// CheckpointStore 用于保存 ChangesFollower 最后处理完成的序列号，重启后从该序列号继续
+type CheckpointStore interface {
+
+	// Load 返回保存的序列号，从未保存过时返回空序列号
+	Load(ctx context.Context) (models.Sequence, error)
+
+	// Save 保存序列号
+	Save(ctx context.Context, seq models.Sequence) error
+}
+
+// MemoryCheckpointStore 把序列号保存在内存中的 CheckpointStore，进程退出后丢失，主要用于测试
+type MemoryCheckpointStore struct {
+	lock sync.Mutex
+	seq  models.Sequence
+}
+
+var _ CheckpointStore = &MemoryCheckpointStore{}
+
+// NewMemoryCheckpointStore 创建一个初始序列号为 seq 的 MemoryCheckpointStore
+func NewMemoryCheckpointStore(seq models.Sequence) *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{seq: seq}
+}
+
+// Load 返回保存的序列号
+func (x *MemoryCheckpointStore) Load(ctx context.Context) (models.Sequence, error) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	return x.seq, nil
+}
+
+// Save 保存序列号
+func (x *MemoryCheckpointStore) Save(ctx context.Context, seq models.Sequence) error {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.seq = seq
+	return nil
+}
+
+// FileCheckpointStore 把序列号保存在文件中的 CheckpointStore
+//
+// 文件内容就是序列号本身，写入时先写临时文件再重命名，进程在写入过程中崩溃也不会留下损坏的文件
+type FileCheckpointStore struct {
+	filename string
+}
+
+var _ CheckpointStore = &FileCheckpointStore{}
+
+// NewFileCheckpointStore 创建一个保存到 filename 的 FileCheckpointStore，文件所在目录必须已经存在
+func NewFileCheckpointStore(filename string) *FileCheckpointStore {
+	return &FileCheckpointStore{filename: filename}
+}
+
+// Load 读取保存的序列号，文件不存在时返回空序列号
+func (x *FileCheckpointStore) Load(ctx context.Context) (models.Sequence, error) {
+	data, err := os.ReadFile(x.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return models.Sequence(strings.TrimSpace(string(data))), nil
+}
+
+// Save 保存序列号
+func (x *FileCheckpointStore) Save(ctx context.Context, seq models.Sequence) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(x.filename), ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+	if _, err = file.WriteString(string(seq) + "\n"); err != nil {
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), x.filename)
+}
+
+const (
+	// DefaultChangesMinBackoff 是 ChangesFollower 重连的初始等待时间
+	DefaultChangesMinBackoff = time.Second
+
+	// DefaultChangesMaxBackoff 是 ChangesFollower 重连的最长等待时间
+	DefaultChangesMaxBackoff = 5 * time.Minute
+
+	// DefaultChangesPollInterval 是 normal 模式下没有新变更时再次请求的间隔
+	DefaultChangesPollInterval = 30 * time.Second
+)
+
+// ChangesFollower 持续跟踪 Registry 的 _changes 接口，用于增量更新本地索引
+//
+// 工作方式:
+//   - 启动时从 CheckpointStore 读取序列号，没有保存过时使用 ChangesOptions.Since
+//   - normal 模式下分批请求，没有新变更时等待 PollInterval 后再次请求
+//   - longpoll 模式下每次请求由服务端挂起直到出现变更，返回后立即发起下一次请求
+//   - continuous 模式下持续读取同一个连接，服务端按 timeout 正常结束时立即重连
+//   - 请求失败或连接异常断开时调用 ErrorHandler，按指数退避等待后从最后处理完成的序列号重连
+//   - 每条变更处理完成后把它的序列号写入 CheckpointStore，重启后不会丢失变更，但可能重复处理最后一条
+//   - "_design/" 开头的设计文档不是包，会被跳过
+//
+// 使用示例:
+//
+//	follower := NewChangesFollower(NewReplicateRegistry(), NewChangesOptions().SetFeed(ChangesFeedContinuous)).
+//		SetCheckpointStore(NewFileCheckpointStore("changes.seq"))
+//	changes := follower.Follow(ctx)
+//	for change := range changes {
+//		fmt.Println(change.Seq, change.ID, change.Deleted)
+//	}
+//	if err := follower.Err(); err != nil && !errors.Is(err, context.Canceled) {
+//		// 处理错误
+//	}
+type ChangesFollower struct {
+	registry *Registry
+	options  *ChangesOptions
+
+	store        CheckpointStore
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	pollInterval time.Duration
+	errorHandler func(err error)
+
+	err error
+}
+
+// NewChangesFollower 创建一个 ChangesFollower
+//
+// 参数:
+//   - registry: 提供 _changes 接口的 Registry 客户端
+//   - options: 请求参数，为 nil 时使用默认参数，其中 Since 只在 CheckpointStore 中没有序列号时使用
+//
+// 返回值:
+//   - *ChangesFollower: 默认使用 MemoryCheckpointStore，退避时间为 1 秒到 5 分钟
+func NewChangesFollower(registry *Registry, options *ChangesOptions) *ChangesFollower {
+	if options == nil {
+		options = NewChangesOptions()
+	}
+	return &ChangesFollower{
+		registry:     registry,
+		options:      options,
+		store:        NewMemoryCheckpointStore(""),
+		minBackoff:   DefaultChangesMinBackoff,
+		maxBackoff:   DefaultChangesMaxBackoff,
+		pollInterval: DefaultChangesPollInterval,
+	}
+}
+
+// SetCheckpointStore 设置保存序列号的 CheckpointStore
+func (x *ChangesFollower) SetCheckpointStore(store CheckpointStore) *ChangesFollower {
+	x.store = store
+	return x
+}
+
+// SetBackoff 设置重连的初始等待时间和最长等待时间，每次连续失败后等待时间翻倍
+func (x *ChangesFollower) SetBackoff(min, max time.Duration) *ChangesFollower {
+	x.minBackoff = min
+	x.maxBackoff = max
+	return x
+}
+
+// SetPollInterval 设置 normal 模式下没有新变更时再次请求的间隔
+func (x *ChangesFollower) SetPollInterval(interval time.Duration) *ChangesFollower {
+	x.pollInterval = interval
+	return x
+}
+
+// SetErrorHandler 设置请求失败时的回调，可用于记录日志，回调返回后会按退避时间重连
+func (x *ChangesFollower) SetErrorHandler(handler func(err error)) *ChangesFollower {
+	x.errorHandler = handler
+	return x
+}
+
+// Follow 在后台跟踪变更，并通过返回的 channel 依次发送每条变更
+//
+// channel 没有缓冲，变更被接收后即视为处理完成并写入 CheckpointStore；需要在处理完成后才写入时请使用 Run。
+// ctx 被取消或者读写 CheckpointStore 失败时 channel 会被关闭，之后可以通过 Err 获取原因
+func (x *ChangesFollower) Follow(ctx context.Context) <-chan *models.Change {
+	changes := make(chan *models.Change)
+	go func() {
+		defer close(changes)
+		x.err = x.Run(ctx, func(change *models.Change) error {
+			select {
+			case changes <- change:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return changes
+}
+
+// Err 返回 Follow 结束的原因，只有在 Follow 返回的 channel 被关闭后调用才有意义
+func (x *ChangesFollower) Err() error {
+	return x.err
+}
+
+// Run 跟踪变更并对每条变更调用 handler，直到 ctx 被取消或者出现无法通过重连恢复的错误
+//
+// handler 返回 nil 后该变更的序列号才会写入 CheckpointStore；handler 返回错误时 Run 立即返回该错误，
+// 下次启动会从这条变更重新开始
+//
+// 返回值:
+//   - error: ctx 被取消时返回 ctx.Err()，否则返回 handler 或 CheckpointStore 的错误
+func (x *ChangesFollower) Run(ctx context.Context, handler func(change *models.Change) error) error {
+	since, err := x.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if since == "" {
+		since = x.options.Since
+	}
+
+	backoff := time.Duration(0)
+	for ctx.Err() == nil {
+		var count int
+		var fatal error
+		process := func(change *models.Change) error {
+			count++
+			if !strings.HasPrefix(change.ID, "_design/") {
+				if err := handler(change); err != nil {
+					fatal = err
+					return err
+				}
+			}
+			if err := x.store.Save(ctx, change.Seq); err != nil {
+				fatal = err
+				return err
+			}
+			since = change.Seq
+			return nil
+		}
+
+		lastSeq, err := x.fetch(ctx, since, process)
+		if fatal != nil {
+			return fatal
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			if x.errorHandler != nil {
+				x.errorHandler(err)
+			}
+			backoff = x.nextBackoff(backoff)
+			if !sleep(ctx, backoff) {
+				break
+			}
+			continue
+		}
+		backoff = 0
+
+		if lastSeq != "" && lastSeq != since {
+			if err := x.store.Save(ctx, lastSeq); err != nil {
+				return err
+			}
+			since = lastSeq
+		}
+		if count == 0 && x.options.Feed != ChangesFeedLongpoll && x.options.Feed != ChangesFeedContinuous {
+			if !sleep(ctx, x.pollInterval) {
+				break
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// fetch 请求一次 since 之后的变更并交给 process 处理，返回服务端给出的 last_seq
+func (x *ChangesFollower) fetch(ctx context.Context, since models.Sequence, process func(change *models.Change) error) (models.Sequence, error) {
+	if x.options.Feed == ChangesFeedContinuous {
+		return x.registry.streamChanges(ctx, x.options, since, process)
+	}
+	response, err := x.registry.getChanges(ctx, x.options, since)
+	if err != nil {
+		return "", err
+	}
+	for _, change := range response.Results {
+		if err := process(change); err != nil {
+			return "", err
+		}
+	}
+	return response.LastSeq, nil
+}
+
+// nextBackoff 返回下一次重连前的等待时间
+func (x *ChangesFollower) nextBackoff(backoff time.Duration) time.Duration {
+	if backoff < x.minBackoff {
+		return x.minBackoff
+	}
+	backoff *= 2
+	if backoff > x.maxBackoff {
+		backoff = x.maxBackoff
+	}
+	return backoff
+}
+
+// sleep 等待 d 时间，ctx 被取消时提前返回 false
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,189 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetChanges(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		if r.URL.Path != "/_changes" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"results": [{"seq": 11, "id": "left-pad", "changes": [{"rev": "1-a"}]}], "last_seq": 11}`))
+	}))
+	defer server.Close()
+	registry := NewRegistry(NewOptions().SetRegistryURL(server.URL))
+	ctx := context.Background()
+
+	changes, err := registry.GetChanges(ctx, NewChangesOptions().
+		SetFeed(ChangesFeedLongpoll).
+		SetSince("10").
+		SetLimit(100).
+		SetIncludeDocs(true).
+		SetHeartbeat(30*time.Second).
+		SetTimeout(time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, "feed=longpoll&heartbeat=30000&include_docs=true&limit=100&since=10&timeout=60000", query)
+	assert.Len(t, changes.Results, 1)
+	assert.Equal(t, "left-pad", changes.Results[0].ID)
+	assert.Equal(t, models.Sequence("11"), changes.LastSeq)
+
+	// 默认参数，normal 模式下不发送 heartbeat 和 timeout
+	_, err = registry.GetChanges(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "feed=normal", query)
+
+	_, err = registry.GetChanges(ctx, NewChangesOptions().SetFeed(ChangesFeedContinuous))
+	assert.NotNil(t, err)
+}
+
+// setupChangesServer 创建按 since 参数分页返回 changes 中变更的模拟服务器，每页最多 2 条
+func setupChangesServer(changes []string, requests *[]string) *httptest.Server {
+	var lock sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		*requests = append(*requests, r.URL.Query().Get("since"))
+		lock.Unlock()
+		since := 0
+		fmt.Sscanf(r.URL.Query().Get("since"), "%d", &since)
+		end := since + 2
+		if end > len(changes) {
+			end = len(changes)
+		}
+		if r.URL.Query().Get("feed") == "continuous" {
+			for i := since; i < end; i++ {
+				fmt.Fprintf(w, "{\"seq\":%d,\"id\":%q}\n\n", i+1, changes[i])
+			}
+			if end == len(changes) {
+				// 模拟连接异常断开
+				return
+			}
+			fmt.Fprintf(w, "{\"last_seq\":%d}\n", end)
+			return
+		}
+		fmt.Fprint(w, `{"results":[`)
+		for i := since; i < end; i++ {
+			if i > since {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"seq":%d,"id":%q,"deleted":%t}`, i+1, changes[i], changes[i] == "old-pkg")
+		}
+		if end < since {
+			end = since
+		}
+		fmt.Fprintf(w, `],"last_seq":%d}`, end)
+	}))
+}
+
+func TestChangesFollowerRun(t *testing.T) {
+	names := []string{"a", "_design/app", "old-pkg", "b", "c"}
+	var requests []string
+	server := setupChangesServer(names, &requests)
+	defer server.Close()
+	store := NewMemoryCheckpointStore("1")
+	follower := NewChangesFollower(NewRegistry(NewOptions().SetRegistryURL(server.URL)), NewChangesOptions().SetSince("100")).
+		SetCheckpointStore(store).
+		SetPollInterval(time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []*models.Change
+	err := follower.Run(ctx, func(change *models.Change) error {
+		got = append(got, change)
+		if len(got) == 3 {
+			cancel()
+		}
+		return nil
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+	// 从 CheckpointStore 中的序列号开始，跳过设计文档
+	assert.Equal(t, "1", requests[0])
+	assert.Len(t, got, 3)
+	assert.Equal(t, "old-pkg", got[0].ID)
+	assert.True(t, got[0].Deleted)
+	assert.Equal(t, "b", got[1].ID)
+	assert.Equal(t, "c", got[2].ID)
+	seq, _ := store.Load(ctx)
+	assert.Equal(t, models.Sequence("5"), seq)
+
+	// handler 返回错误时立即返回，且不保存该变更的序列号
+	store = NewMemoryCheckpointStore("0")
+	follower.SetCheckpointStore(store)
+	err = follower.Run(context.Background(), func(change *models.Change) error {
+		if change.ID == "old-pkg" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	assert.Equal(t, "boom", err.Error())
+	seq, _ = store.Load(context.Background())
+	assert.Equal(t, models.Sequence("2"), seq)
+}
+
+func TestChangesFollowerContinuous(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	var requests []string
+	server := setupChangesServer(names, &requests)
+	defer server.Close()
+	var errs []error
+	follower := NewChangesFollower(NewRegistry(NewOptions().SetRegistryURL(server.URL)), NewChangesOptions().SetFeed(ChangesFeedContinuous)).
+		SetBackoff(time.Millisecond, 2*time.Millisecond).
+		SetErrorHandler(func(err error) {
+			errs = append(errs, err)
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var ids []string
+	changes := follower.Follow(ctx)
+	for change := range changes {
+		ids = append(ids, change.ID)
+		if len(ids) == len(names) {
+			cancel()
+		}
+	}
+	assert.True(t, errors.Is(follower.Err(), context.Canceled))
+	assert.Equal(t, names, ids)
+	// 第一次连接正常结束后立即从 last_seq 重连
+	assert.Equal(t, []string{"", "2"}, requests[:2])
+	for _, err := range errs {
+		assert.False(t, errors.Is(err, context.Canceled))
+	}
+}
+
+func TestChangesFollowerBackoff(t *testing.T) {
+	follower := NewChangesFollower(NewRegistry(), nil).SetBackoff(time.Second, 3*time.Second)
+	backoff := follower.nextBackoff(0)
+	assert.Equal(t, time.Second, backoff)
+	backoff = follower.nextBackoff(backoff)
+	assert.Equal(t, 2*time.Second, backoff)
+	backoff = follower.nextBackoff(backoff)
+	assert.Equal(t, 3*time.Second, backoff)
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "changes.seq"))
+	seq, err := store.Load(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, models.Sequence(""), seq)
+
+	assert.Nil(t, store.Save(ctx, "42-g1AAAA"))
+	seq, err = store.Load(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, models.Sequence("42-g1AAAA"), seq)
+}
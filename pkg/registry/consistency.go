@@ -0,0 +1,385 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// ConsistencyOptions 表示镜像一致性比较的配置选项
+//
+// 包含字段:
+//   - Concurrency: 同时比较的包数量，默认为 4，每个包会同时请求所有镜像
+type ConsistencyOptions struct {
+	Concurrency int
+}
+
+// NewConsistencyOptions 创建并返回默认的一致性比较选项
+func NewConsistencyOptions() *ConsistencyOptions {
+	return &ConsistencyOptions{
+		Concurrency: 4,
+	}
+}
+
+// SetConcurrency 设置同时比较的包数量
+func (o *ConsistencyOptions) SetConcurrency(concurrency int) *ConsistencyOptions {
+	o.Concurrency = concurrency
+	return o
+}
+
+// TagDifference 表示一个 dist-tag 在参照仓库和镜像中的值，不存在时为空字符串
+type TagDifference struct {
+	Reference string `json:"reference"`
+	Mirror    string `json:"mirror"`
+}
+
+// PackageDifference 表示一个包在某个镜像中与参照仓库的差异
+//
+// 主要字段说明:
+//   - URL: 镜像的 RegistryURL
+//   - Error: 请求镜像失败时的错误，此时其他字段为空
+//   - NotFound: 参照仓库中有这个包，镜像中没有
+//   - DistTags: 值不同的 dist-tag，键为 tag 名称
+//   - MissingVersions: 参照仓库中有、镜像中没有的版本，按版本号排序
+//   - ExtraVersions: 镜像中有、参照仓库中没有的版本，通常是已经 unpublish 的版本
+//   - IntegrityMismatches: 两边都有但 dist.integrity 或 dist.shasum 不同的版本，按版本号排序
+//   - ReferenceModified、Modified: 参照仓库和镜像中的 time.modified，镜像的 Modified 更早时说明镜像的元数据是旧的
+//   - Lag: 估计的同步延迟，等于当前时间减去镜像缺少的最早的变更在参照仓库中的发布时间，
+//     变更包括缺少的版本、dist-tag 指向的新版本以及整个包，镜像不缺少任何变更或者参照仓库没有发布时间时为 0
+type PackageDifference struct {
+	URL                 string                   `json:"url"`
+	Error               error                    `json:"-"`
+	NotFound            bool                     `json:"notFound,omitempty"`
+	DistTags            map[string]TagDifference `json:"distTags,omitempty"`
+	MissingVersions     []string                 `json:"missingVersions,omitempty"`
+	ExtraVersions       []string                 `json:"extraVersions,omitempty"`
+	IntegrityMismatches []string                 `json:"integrityMismatches,omitempty"`
+	ReferenceModified   time.Time                `json:"referenceModified,omitempty"`
+	Modified            time.Time                `json:"modified,omitempty"`
+	Lag                 time.Duration            `json:"lag"`
+}
+
+// Consistent 判断镜像中的包是否与参照仓库一致，请求失败时返回 false
+func (x *PackageDifference) Consistent() bool {
+	return x.Error == nil && !x.NotFound && len(x.DistTags) == 0 && len(x.MissingVersions) == 0 &&
+		len(x.ExtraVersions) == 0 && len(x.IntegrityMismatches) == 0 && !x.Modified.Before(x.ReferenceModified)
+}
+
+// PackageConsistency 表示一个包在所有镜像中的比较结果
+//
+// 主要字段说明:
+//   - Name: 包名称
+//   - Error: 请求参照仓库失败或者参照仓库中没有这个包，此时 Mirrors 为空
+//   - Mirrors: 每个镜像的差异，顺序与创建比较器时相同
+type PackageConsistency struct {
+	Name    string               `json:"name"`
+	Error   error                `json:"-"`
+	Mirrors []*PackageDifference `json:"mirrors,omitempty"`
+}
+
+// MirrorLag 表示一个镜像整体的同步情况
+//
+// 主要字段说明:
+//   - URL: 镜像的 RegistryURL
+//   - UpdateSeq、ReferenceUpdateSeq: 镜像和参照仓库的 update_seq，仓库不提供时为 0
+//   - SeqLag: ReferenceUpdateSeq - UpdateSeq，两边都提供 update_seq 时才有意义，
+//     只有镜像直接复制参照仓库的 CouchDB 时两者才是同一个序列
+//   - Lag: 所有比较的包中 PackageDifference.Lag 的最大值，即镜像缺少的最早的变更距今的时间
+//   - Inconsistent: 与参照仓库不一致的包的数量
+//   - InSync: 所有比较的包都与参照仓库一致，此时从镜像安装刚发布的版本是安全的
+//   - Error: 获取镜像 Registry 信息失败的错误，不影响包的比较
+type MirrorLag struct {
+	URL                string        `json:"url"`
+	UpdateSeq          int           `json:"updateSeq,omitempty"`
+	ReferenceUpdateSeq int           `json:"referenceUpdateSeq,omitempty"`
+	SeqLag             int           `json:"seqLag,omitempty"`
+	Lag                time.Duration `json:"lag"`
+	Inconsistent       int           `json:"inconsistent"`
+	InSync             bool          `json:"inSync"`
+	Error              error         `json:"-"`
+}
+
+// ConsistencyReport 表示镜像一致性比较的结果
+type ConsistencyReport struct {
+	Reference string                `json:"reference"`
+	Mirrors   []*MirrorLag          `json:"mirrors"`
+	Packages  []*PackageConsistency `json:"packages"`
+}
+
+// ConsistencyChecker 比较多个镜像与参照仓库中包元数据的一致性，用于估计镜像的同步延迟
+//
+// 对每个包比较:
+//   - dist-tags，例如镜像的 latest 还指向旧版本
+//   - 版本集合，镜像缺少新发布的版本或者还保留已删除的版本
+//   - time.modified，镜像的元数据是否比参照仓库旧
+//   - 两边都有的版本的 dist.integrity 和 dist.shasum，镜像中的 tarball 与参照仓库不同
+//
+// 同时获取每个仓库的 Registry 信息比较 update_seq
+//
+// 使用示例:
+//
+//	checker := NewConsistencyChecker(nil, NewRegistry(), NewNpmMirrorRegistry(), NewHuaWeiCloudRegistry())
+//	report, err := checker.Compare(ctx, "react", "vue")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	for _, mirror := range report.Mirrors {
+//		fmt.Println(mirror.URL, mirror.InSync, mirror.Lag)
+//	}
+type ConsistencyChecker struct {
+	reference *Registry
+	mirrors   []*Registry
+	options   *ConsistencyOptions
+	now       func() time.Time
+}
+
+// NewConsistencyChecker 创建镜像一致性比较器
+//
+// 参数:
+//   - options: 比较选项，为 nil 时使用默认选项
+//   - reference: 参照仓库，通常是官方仓库
+//   - mirrors: 要比较的镜像
+//
+// 返回值:
+//   - *ConsistencyChecker: 新创建的比较器
+func NewConsistencyChecker(options *ConsistencyOptions, reference *Registry, mirrors ...*Registry) *ConsistencyChecker {
+	if options == nil {
+		options = NewConsistencyOptions()
+	}
+	return &ConsistencyChecker{
+		reference: reference,
+		mirrors:   mirrors,
+		options:   options,
+		now:       time.Now,
+	}
+}
+
+// Compare 比较 packages 在各个镜像与参照仓库中的元数据
+//
+// 单个包或单个镜像请求失败会记录在报告中，不会中断比较
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - packages: 要比较的包名称
+//
+// 返回值:
+//   - *ConsistencyReport: 比较结果，Packages 的顺序与参数相同
+//   - error: ctx 被取消时返回错误
+func (x *ConsistencyChecker) Compare(ctx context.Context, packages ...string) (*ConsistencyReport, error) {
+	report := &ConsistencyReport{
+		Reference: x.reference.GetOptions().RegistryURL,
+		Mirrors:   make([]*MirrorLag, len(x.mirrors)),
+		Packages:  make([]*PackageConsistency, len(packages)),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		x.compareUpdateSeq(ctx, report)
+	}()
+
+	concurrency := x.options.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+	for i, name := range packages {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			report.Packages[i] = x.comparePackage(ctx, name)
+		}(i, name)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, mirror := range report.Mirrors {
+		for _, pkg := range report.Packages {
+			if pkg.Error != nil {
+				continue
+			}
+			difference := pkg.Mirrors[i]
+			if !difference.Consistent() {
+				mirror.Inconsistent++
+			}
+			if difference.Lag > mirror.Lag {
+				mirror.Lag = difference.Lag
+			}
+		}
+		mirror.InSync = mirror.Inconsistent == 0
+	}
+	return report, nil
+}
+
+// compareUpdateSeq 获取参照仓库和所有镜像的 update_seq 并填充 report.Mirrors
+func (x *ConsistencyChecker) compareUpdateSeq(ctx context.Context, report *ConsistencyReport) {
+	registries := append([]*Registry{x.reference}, x.mirrors...)
+	informations := make([]*models.RegistryInformation, len(registries))
+	errs := make([]error, len(registries))
+	var wg sync.WaitGroup
+	for i, r := range registries {
+		wg.Add(1)
+		go func(i int, r *Registry) {
+			defer wg.Done()
+			informations[i], errs[i] = r.GetRegistryInformation(ctx)
+		}(i, r)
+	}
+	wg.Wait()
+
+	referenceSeq := 0
+	if errs[0] == nil && informations[0] != nil {
+		referenceSeq = informations[0].UpdateSeq
+	}
+	for i, r := range x.mirrors {
+		mirror := &MirrorLag{
+			URL:                r.GetOptions().RegistryURL,
+			ReferenceUpdateSeq: referenceSeq,
+			Error:              errs[i+1],
+		}
+		if information := informations[i+1]; mirror.Error == nil && information != nil {
+			mirror.UpdateSeq = information.UpdateSeq
+		}
+		if mirror.UpdateSeq > 0 && referenceSeq > 0 {
+			mirror.SeqLag = referenceSeq - mirror.UpdateSeq
+		}
+		report.Mirrors[i] = mirror
+	}
+}
+
+// comparePackage 同时获取参照仓库和所有镜像中的包元数据并比较
+func (x *ConsistencyChecker) comparePackage(ctx context.Context, name string) *PackageConsistency {
+	registries := append([]*Registry{x.reference}, x.mirrors...)
+	packages := make([]*models.Package, len(registries))
+	errs := make([]error, len(registries))
+	var wg sync.WaitGroup
+	for i, r := range registries {
+		wg.Add(1)
+		go func(i int, r *Registry) {
+			defer wg.Done()
+			packages[i], errs[i] = r.GetPackageInformation(ctx, name)
+		}(i, r)
+	}
+	wg.Wait()
+
+	result := &PackageConsistency{Name: name}
+	if errs[0] != nil {
+		result.Error = errs[0]
+		return result
+	}
+	if isMissingPackage(packages[0]) {
+		result.Error = fmt.Errorf("package %s not found in %s", name, x.reference.GetOptions().RegistryURL)
+		return result
+	}
+	result.Mirrors = make([]*PackageDifference, len(x.mirrors))
+	for i, r := range x.mirrors {
+		difference := &PackageDifference{URL: r.GetOptions().RegistryURL, Error: errs[i+1]}
+		if difference.Error == nil {
+			comparePackages(packages[0], packages[i+1], difference, x.now())
+		}
+		result.Mirrors[i] = difference
+	}
+	return result
+}
+
+// comparePackages 比较参照仓库和镜像中的包元数据，把差异写入 difference，now 用于计算同步延迟
+func comparePackages(reference, mirror *models.Package, difference *PackageDifference, now time.Time) {
+	difference.ReferenceModified = reference.Modified()
+	if isMissingPackage(mirror) {
+		difference.NotFound = true
+		difference.Lag = lagSince(now, reference.Created())
+		return
+	}
+	difference.Modified = mirror.Modified()
+
+	// 镜像缺少的变更在参照仓库中的发布时间
+	var missing []time.Time
+	for tag, version := range reference.DistTags {
+		if mirror.DistTags[tag] != version {
+			addTagDifference(difference, tag, version, mirror.DistTags[tag])
+			if released, ok := reference.ReleaseTime(version); ok {
+				missing = append(missing, released)
+			}
+		}
+	}
+	for tag, version := range mirror.DistTags {
+		if _, ok := reference.DistTags[tag]; !ok {
+			addTagDifference(difference, tag, "", version)
+		}
+	}
+
+	for version, manifest := range reference.Versions {
+		mirrorManifest, ok := mirror.Versions[version]
+		if !ok {
+			difference.MissingVersions = append(difference.MissingVersions, version)
+			if released, ok := reference.ReleaseTime(version); ok {
+				missing = append(missing, released)
+			}
+			continue
+		}
+		if !sameDist(manifest.Dist, mirrorManifest.Dist) {
+			difference.IntegrityMismatches = append(difference.IntegrityMismatches, version)
+		}
+	}
+	for version := range mirror.Versions {
+		if _, ok := reference.Versions[version]; !ok {
+			difference.ExtraVersions = append(difference.ExtraVersions, version)
+		}
+	}
+	semver.Sort(difference.MissingVersions)
+	semver.Sort(difference.ExtraVersions)
+	semver.Sort(difference.IntegrityMismatches)
+
+	if len(missing) > 0 {
+		oldest := missing[0]
+		for _, released := range missing[1:] {
+			if released.Before(oldest) {
+				oldest = released
+			}
+		}
+		difference.Lag = lagSince(now, oldest)
+	}
+}
+
+// lagSince 返回 released 距离 now 的时间，released 为零值或者晚于 now 时返回 0
+func lagSince(now, released time.Time) time.Duration {
+	if released.IsZero() || !released.Before(now) {
+		return 0
+	}
+	return now.Sub(released)
+}
+
+// addTagDifference 记录一个值不同的 dist-tag
+func addTagDifference(difference *PackageDifference, tag, reference, mirror string) {
+	if difference.DistTags == nil {
+		difference.DistTags = make(map[string]TagDifference)
+	}
+	difference.DistTags[tag] = TagDifference{Reference: reference, Mirror: mirror}
+}
+
+// sameDist 比较两边的完整性校验值，只比较两边都有的字段，镜像去掉 integrity 不算差异
+func sameDist(reference, mirror *models.Dist) bool {
+	if reference == nil || mirror == nil {
+		return true
+	}
+	if reference.Integrity != "" && mirror.Integrity != "" && reference.Integrity != mirror.Integrity {
+		return false
+	}
+	if reference.Shasum != "" && mirror.Shasum != "" && reference.Shasum != mirror.Shasum {
+		return false
+	}
+	return true
+}
+
+// isMissingPackage 判断 GetPackageInformation 的结果是否表示包不存在，不存在时 Registry 返回 404，解析结果为空
+func isMissingPackage(pkg *models.Package) bool {
+	return pkg == nil || (pkg.Name == "" && pkg.ID == "")
+}
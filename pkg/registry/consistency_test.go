@@ -0,0 +1,135 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newConsistencyHandler 创建模拟仓库，root 为根路径的响应，packages 为包名到元数据的映射
+func newConsistencyHandler(root string, packages map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Write([]byte(root))
+			return
+		}
+		body, ok := packages[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "Not found"}`))
+			return
+		}
+		w.Write([]byte(body))
+	})
+}
+
+func TestConsistencyChecker(t *testing.T) {
+	react := `{"_id": "react", "name": "react", "dist-tags": {"latest": "18.2.0", "next": "19.0.0-rc"},
+		"time": {"created": "2023-01-01T00:00:00.000Z", "modified": "2024-01-02T12:00:00.000Z",
+			"18.1.0": "2023-09-01T00:00:00.000Z", "18.2.0": "2024-01-02T11:00:00.000Z", "19.0.0-rc": "2024-01-02T11:30:00.000Z"},
+		"versions": {
+			"18.1.0": {"name": "react", "version": "18.1.0", "dist": {"shasum": "aaa", "integrity": "sha512-aaa"}},
+			"18.2.0": {"name": "react", "version": "18.2.0", "dist": {"shasum": "bbb", "integrity": "sha512-bbb"}},
+			"19.0.0-rc": {"name": "react", "version": "19.0.0-rc", "dist": {"shasum": "ccc"}}}}`
+	staleReact := `{"_id": "react", "name": "react", "dist-tags": {"latest": "18.1.0", "beta": "18.0.0-beta"},
+		"time": {"created": "2023-01-01T00:00:00.000Z", "modified": "2023-09-01T00:00:00.000Z", "18.1.0": "2023-09-01T00:00:00.000Z"},
+		"versions": {
+			"18.0.0-beta": {"name": "react", "version": "18.0.0-beta"},
+			"18.1.0": {"name": "react", "version": "18.1.0", "dist": {"shasum": "aaa", "integrity": "sha512-aaa"}},
+			"18.2.0": {"name": "react", "version": "18.2.0", "dist": {"shasum": "bbb", "integrity": "sha512-tampered"}}}}`
+	vue := `{"_id": "vue", "name": "vue", "dist-tags": {"latest": "3.4.0"}, "time": {"created": "2024-01-02T11:50:00.000Z", "modified": "2024-01-02T11:50:00.000Z"},
+		"versions": {"3.4.0": {"name": "vue", "version": "3.4.0", "dist": {"shasum": "ddd"}}}}`
+
+	reference := httptest.NewServer(newConsistencyHandler(`{"db_name": "registry", "update_seq": 1000}`,
+		map[string]string{"react": react, "vue": vue}))
+	defer reference.Close()
+	synced := httptest.NewServer(newConsistencyHandler(`{"db_name": "registry", "update_seq": 1000}`,
+		map[string]string{"react": react, "vue": vue}))
+	defer synced.Close()
+	stale := httptest.NewServer(newConsistencyHandler(`{"db_name": "registry", "update_seq": 900}`,
+		map[string]string{"react": staleReact}))
+	defer stale.Close()
+	// 没有 update_seq 的镜像
+	other := httptest.NewServer(newConsistencyHandler(`{"node_name": "mirror"}`,
+		map[string]string{"react": react, "vue": vue}))
+	defer other.Close()
+
+	checker := NewConsistencyChecker(NewConsistencyOptions().SetConcurrency(2),
+		NewRegistry(NewOptions().SetRegistryURL(reference.URL)),
+		NewRegistry(NewOptions().SetRegistryURL(synced.URL)),
+		NewRegistry(NewOptions().SetRegistryURL(stale.URL)),
+		NewRegistry(NewOptions().SetRegistryURL(other.URL)),
+	)
+	now := time.Date(2024, 1, 2, 12, 10, 0, 0, time.UTC)
+	checker.now = func() time.Time { return now }
+	report, err := checker.Compare(context.Background(), "react", "vue", "missing")
+	assert.Nil(t, err)
+	assert.Equal(t, reference.URL, report.Reference)
+	assert.Len(t, report.Packages, 3)
+	assert.Equal(t, "react", report.Packages[0].Name)
+	assert.NotNil(t, report.Packages[2].Error)
+
+	// 同步的镜像与参照仓库一致
+	assert.True(t, report.Mirrors[0].InSync)
+	assert.Equal(t, 0, report.Mirrors[0].SeqLag)
+	assert.True(t, report.Packages[0].Mirrors[0].Consistent())
+
+	// 落后的镜像
+	lag := report.Mirrors[1]
+	assert.False(t, lag.InSync)
+	assert.Equal(t, 2, lag.Inconsistent)
+	assert.Equal(t, 100, lag.SeqLag)
+	// 镜像的 time.modified 是几个月前的，但只缺少了最近一小时的变更
+	assert.Equal(t, 70*time.Minute, lag.Lag)
+
+	difference := report.Packages[0].Mirrors[1]
+	assert.Equal(t, stale.URL, difference.URL)
+	assert.Equal(t, map[string]TagDifference{
+		"latest": {Reference: "18.2.0", Mirror: "18.1.0"},
+		"next":   {Reference: "19.0.0-rc", Mirror: ""},
+		"beta":   {Reference: "", Mirror: "18.0.0-beta"},
+	}, difference.DistTags)
+	assert.Equal(t, []string{"19.0.0-rc"}, difference.MissingVersions)
+	assert.Equal(t, []string{"18.0.0-beta"}, difference.ExtraVersions)
+	assert.Equal(t, []string{"18.2.0"}, difference.IntegrityMismatches)
+	// latest 指向的 18.2.0 是镜像缺少的最早的变更
+	assert.Equal(t, 70*time.Minute, difference.Lag)
+	assert.True(t, difference.Modified.Before(difference.ReferenceModified))
+	// 整个包都不存在时从包的创建时间开始计算
+	assert.True(t, report.Packages[1].Mirrors[1].NotFound)
+	assert.Equal(t, 20*time.Minute, report.Packages[1].Mirrors[1].Lag)
+
+	// 不提供 update_seq 的镜像只根据包元数据判断
+	assert.True(t, report.Mirrors[2].InSync)
+	assert.Equal(t, 0, report.Mirrors[2].UpdateSeq)
+	assert.Equal(t, 0, report.Mirrors[2].SeqLag)
+	assert.Equal(t, 1000, report.Mirrors[2].ReferenceUpdateSeq)
+}
+
+func TestConsistencyCheckerMirrorDown(t *testing.T) {
+	vue := `{"_id": "vue", "name": "vue", "dist-tags": {"latest": "3.4.0"}}`
+	reference := httptest.NewServer(newConsistencyHandler(`{"update_seq": 10}`, map[string]string{"vue": vue}))
+	defer reference.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	checker := NewConsistencyChecker(nil,
+		NewRegistry(NewOptions().SetRegistryURL(reference.URL)),
+		NewRegistry(NewOptions().SetRegistryURL(down.URL)))
+	report, err := checker.Compare(context.Background(), "vue")
+	assert.Nil(t, err)
+	assert.NotNil(t, report.Mirrors[0].Error)
+	assert.False(t, report.Mirrors[0].InSync)
+	assert.NotNil(t, report.Packages[0].Mirrors[0].Error)
+	assert.False(t, report.Packages[0].Mirrors[0].Consistent())
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = checker.Compare(canceled, "vue")
+	assert.ErrorIs(t, err, context.Canceled)
+}
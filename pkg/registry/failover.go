@@ -0,0 +1,346 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// ErrNoMirrorAvailable 表示所有镜像都请求失败或者熔断器都处于打开状态
+var ErrNoMirrorAvailable = errors.New("no mirror available")
+
+// BreakerState 表示熔断器的状态
+type BreakerState string
+
+const (
+	// BreakerClosed 正常状态，请求直接发送到镜像
+	BreakerClosed BreakerState = "closed"
+
+	// BreakerOpen 连续失败次数达到阈值，冷却时间内跳过该镜像
+	BreakerOpen BreakerState = "open"
+
+	// BreakerHalfOpen 冷却时间已过，允许一个试探请求，成功后关闭，失败后重新打开
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// FailoverOptions 表示故障转移客户端的配置选项
+//
+// 包含字段:
+//   - FailureThreshold: 连续失败多少次后打开熔断器，默认为 5
+//   - Cooldown: 熔断器打开后经过多长时间进入半开状态，默认为 30 秒
+type FailoverOptions struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// NewFailoverOptions 创建并返回默认的故障转移选项
+func NewFailoverOptions() *FailoverOptions {
+	return &FailoverOptions{
+		FailureThreshold: 5,
+		Cooldown:         30 * time.Second,
+	}
+}
+
+// SetFailureThreshold 设置打开熔断器的连续失败次数
+func (o *FailoverOptions) SetFailureThreshold(threshold int) *FailoverOptions {
+	o.FailureThreshold = threshold
+	return o
+}
+
+// SetCooldown 设置熔断器打开后的冷却时间
+func (o *FailoverOptions) SetCooldown(cooldown time.Duration) *FailoverOptions {
+	o.Cooldown = cooldown
+	return o
+}
+
+// FailoverAttempt 表示故障转移过程中对一个镜像的一次尝试
+//
+// 主要字段说明:
+//   - URL: 镜像的 RegistryURL
+//   - Skipped: 熔断器打开，没有发送请求
+//   - Error: 请求的错误，成功时为 nil
+type FailoverAttempt struct {
+	URL     string `json:"url"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   error  `json:"-"`
+}
+
+// Served 记录一次调用由哪个镜像提供，以及之前尝试过的镜像，可用于审计结果的来源
+//
+// 主要字段说明:
+//   - Registry: 提供响应的镜像，所有镜像都失败时为 nil
+//   - URL: 提供响应的镜像的 RegistryURL
+//   - Attempts: 按顺序尝试过的所有镜像，包括最终成功的一次
+type Served struct {
+	Registry *Registry         `json:"-"`
+	URL      string            `json:"url"`
+	Attempts []FailoverAttempt `json:"attempts"`
+}
+
+// MirrorState 表示一个镜像当前的熔断器状态
+type MirrorState struct {
+	URL      string       `json:"url"`
+	State    BreakerState `json:"state"`
+	Failures int          `json:"failures"`
+	OpenedAt time.Time    `json:"openedAt,omitempty"`
+}
+
+// FailoverRegistry 是按顺序使用多个 Registry 的故障转移客户端
+//
+// 每次调用按顺序尝试各个镜像，遇到网络错误或 5xx 响应时使用下一个镜像重试同一个调用，
+// 其他错误（例如解析失败）直接返回，不会重试。tarball 完整性校验失败也会换下一个镜像重试。
+//
+// 每个镜像有一个熔断器:
+//   - 连续 FailureThreshold 次可重试的失败后打开，打开期间直接跳过该镜像
+//   - 打开 Cooldown 之后进入半开状态，只放行一个试探请求
+//   - 试探请求成功后关闭，失败后重新打开并重新计算冷却时间
+//
+// 每个方法都会返回 *Served，记录响应由哪个镜像提供。Registry 的其他方法可以通过 Failover 函数调用
+//
+// 使用示例:
+//
+//	failover := NewFailoverRegistry(nil, NewNpmMirrorRegistry(), NewRegistry())
+//	pkg, served, err := failover.GetPackageInformation(ctx, "react")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	fmt.Println("served by", served.URL)
+type FailoverRegistry struct {
+	mirrors []*failoverMirror
+	options *FailoverOptions
+	now     func() time.Time
+}
+
+// failoverMirror 表示故障转移客户端中的一个镜像及其熔断器
+type failoverMirror struct {
+	registry *Registry
+
+	lock     sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewFailoverRegistry 创建故障转移客户端
+//
+// 参数:
+//   - options: 故障转移选项，为 nil 时使用默认选项
+//   - registries: 按优先级排列的镜像
+//
+// 返回值:
+//   - *FailoverRegistry: 新创建的故障转移客户端
+func NewFailoverRegistry(options *FailoverOptions, registries ...*Registry) *FailoverRegistry {
+	if options == nil {
+		options = NewFailoverOptions()
+	}
+	mirrors := make([]*failoverMirror, len(registries))
+	for i, r := range registries {
+		mirrors[i] = &failoverMirror{registry: r, state: BreakerClosed}
+	}
+	return &FailoverRegistry{
+		mirrors: mirrors,
+		options: options,
+		now:     time.Now,
+	}
+}
+
+// MirrorStates 返回每个镜像当前的熔断器状态，顺序与创建时相同
+func (x *FailoverRegistry) MirrorStates() []MirrorState {
+	states := make([]MirrorState, len(x.mirrors))
+	now := x.now()
+	for i, mirror := range x.mirrors {
+		mirror.lock.Lock()
+		state := mirror.state
+		if state == BreakerOpen && now.Sub(mirror.openedAt) >= x.options.Cooldown {
+			state = BreakerHalfOpen
+		}
+		states[i] = MirrorState{
+			URL:      mirror.registry.GetOptions().RegistryURL,
+			State:    state,
+			Failures: mirror.failures,
+			OpenedAt: mirror.openedAt,
+		}
+		mirror.lock.Unlock()
+	}
+	return states
+}
+
+// Failover 按顺序在各个镜像上执行 call，直到成功或遇到不可重试的错误
+//
+// 参数:
+//   - ctx: 上下文，被取消时立即返回，不会计入镜像的失败次数
+//   - x: 故障转移客户端
+//   - call: 在单个镜像上执行的调用，可以是 Registry 的任意方法
+//
+// 返回值:
+//   - T: 调用的结果
+//   - *Served: 提供响应的镜像和尝试记录，总是不为 nil
+//   - error: 所有镜像都失败时返回包装了 ErrNoMirrorAvailable 的错误，不可重试的错误原样返回
+//
+// 使用示例:
+//
+//	attestations, served, err := Failover(ctx, failover, func(ctx context.Context, r *Registry) (*models.Attestations, error) {
+//		return r.GetAttestations(ctx, "react", "18.2.0")
+//	})
+func Failover[T any](ctx context.Context, x *FailoverRegistry, call func(ctx context.Context, r *Registry) (T, error)) (T, *Served, error) {
+	var zero T
+	served := &Served{}
+	var errs []error
+	for _, mirror := range x.mirrors {
+		url := mirror.registry.GetOptions().RegistryURL
+		if !mirror.allow(x.now(), x.options.Cooldown) {
+			served.Attempts = append(served.Attempts, FailoverAttempt{URL: url, Skipped: true})
+			continue
+		}
+		result, err := call(ctx, mirror.registry)
+		served.Attempts = append(served.Attempts, FailoverAttempt{URL: url, Error: err})
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			mirror.release()
+			return zero, served, ctxErr
+		}
+		if err != nil && isRetryable(err) {
+			mirror.failure(x.now(), x.options.FailureThreshold)
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+			continue
+		}
+		// 镜像给出了明确的响应，即使是不可重试的错误也说明镜像是正常的
+		mirror.success()
+		if err != nil {
+			return zero, served, err
+		}
+		served.Registry = mirror.registry
+		served.URL = url
+		return result, served, nil
+	}
+	if len(errs) == 0 {
+		return zero, served, ErrNoMirrorAvailable
+	}
+	return zero, served, fmt.Errorf("%w: %w", ErrNoMirrorAvailable, errors.Join(errs...))
+}
+
+// GetRegistryInformation 按顺序从各个镜像获取 Registry 状态信息
+func (x *FailoverRegistry) GetRegistryInformation(ctx context.Context) (*models.RegistryInformation, *Served, error) {
+	return Failover(ctx, x, func(ctx context.Context, r *Registry) (*models.RegistryInformation, error) {
+		return r.GetRegistryInformation(ctx)
+	})
+}
+
+// GetPackageInformation 按顺序从各个镜像获取包的详细信息
+func (x *FailoverRegistry) GetPackageInformation(ctx context.Context, packageName string) (*models.Package, *Served, error) {
+	return Failover(ctx, x, func(ctx context.Context, r *Registry) (*models.Package, error) {
+		return r.GetPackageInformation(ctx, packageName)
+	})
+}
+
+// GetAbbreviatedPackageInformation 按顺序从各个镜像获取包的精简版元数据
+func (x *FailoverRegistry) GetAbbreviatedPackageInformation(ctx context.Context, packageName string) (*models.Package, *Served, error) {
+	return Failover(ctx, x, func(ctx context.Context, r *Registry) (*models.Package, error) {
+		return r.GetAbbreviatedPackageInformation(ctx, packageName)
+	})
+}
+
+// GetPackageVersion 按顺序从各个镜像获取包的特定版本信息
+func (x *FailoverRegistry) GetPackageVersion(ctx context.Context, packageName, version string) (*models.Version, *Served, error) {
+	return Failover(ctx, x, func(ctx context.Context, r *Registry) (*models.Version, error) {
+		return r.GetPackageVersion(ctx, packageName, version)
+	})
+}
+
+// SearchPackages 按顺序在各个镜像上搜索
+func (x *FailoverRegistry) SearchPackages(ctx context.Context, query string, limit int) (*models.SearchResult, *Served, error) {
+	return Failover(ctx, x, func(ctx context.Context, r *Registry) (*models.SearchResult, error) {
+		return r.SearchPackages(ctx, query, limit)
+	})
+}
+
+// DownloadTarball 按顺序从各个镜像下载 tarball 并校验完整性，成功后才写入 w
+//
+// 数据会先完整下载到内存，失败的尝试不会向 w 写入任何数据
+func (x *FailoverRegistry) DownloadTarball(ctx context.Context, version *models.Version, w io.Writer) (*Served, error) {
+	data, served, err := Failover(ctx, x, func(ctx context.Context, r *Registry) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := r.DownloadTarball(ctx, version, &buf); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		return served, err
+	}
+	_, err = w.Write(data)
+	return served, err
+}
+
+// allow 判断熔断器是否放行请求，冷却时间已过的打开状态转为半开状态并放行一个试探请求
+func (x *failoverMirror) allow(now time.Time, cooldown time.Duration) bool {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	switch x.state {
+	case BreakerOpen:
+		if now.Sub(x.openedAt) < cooldown {
+			return false
+		}
+		x.state = BreakerHalfOpen
+		x.probing = true
+		return true
+	case BreakerHalfOpen:
+		if x.probing {
+			return false
+		}
+		x.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success 记录一次成功，关闭熔断器
+func (x *failoverMirror) success() {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.state = BreakerClosed
+	x.failures = 0
+	x.probing = false
+}
+
+// failure 记录一次可重试的失败，连续失败达到阈值或者试探请求失败时打开熔断器
+func (x *failoverMirror) failure(now time.Time, threshold int) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.failures++
+	x.probing = false
+	if x.state == BreakerHalfOpen || x.failures >= threshold {
+		x.state = BreakerOpen
+		x.openedAt = now
+	}
+}
+
+// release 放弃一次没有结果的请求（例如 ctx 被取消），半开状态下允许下一个试探请求
+func (x *failoverMirror) release() {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.probing = false
+}
+
+// isRetryable 判断错误是否应该换下一个镜像重试: 网络错误、5xx 响应和 tarball 完整性校验失败
+func isRetryable(err error) bool {
+	if errors.Is(err, ErrIntegrity) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &netErr) || errors.As(err, &urlErr) {
+		return true
+	}
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode >= 500
+}
@@ -0,0 +1,202 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFailoverHandler 创建模拟镜像，status 为所有请求的状态码，requests 记录请求次数
+func newFailoverHandler(status int, requests *int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"_id": "react", "name": "react", "dist-tags": {"latest": "18.2.0"}}`))
+		}
+	})
+}
+
+func TestFailoverRegistry(t *testing.T) {
+	ctx := context.Background()
+	var brokenRequests, healthyRequests int32
+	broken := httptest.NewServer(newFailoverHandler(http.StatusInternalServerError, &brokenRequests))
+	defer broken.Close()
+	healthy := httptest.NewServer(newFailoverHandler(http.StatusOK, &healthyRequests))
+	defer healthy.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	failover := NewFailoverRegistry(NewFailoverOptions().SetFailureThreshold(2).SetCooldown(time.Hour),
+		NewRegistry(NewOptions().SetRegistryURL(down.URL)),
+		NewRegistry(NewOptions().SetRegistryURL(broken.URL)),
+		NewRegistry(NewOptions().SetRegistryURL(healthy.URL)),
+	)
+	now := time.Now()
+	failover.now = func() time.Time { return now }
+
+	// 网络错误和 5xx 都换下一个镜像重试
+	pkg, served, err := failover.GetPackageInformation(ctx, "react")
+	assert.Nil(t, err)
+	assert.Equal(t, "18.2.0", pkg.DistTags["latest"])
+	assert.Equal(t, healthy.URL, served.URL)
+	assert.Len(t, served.Attempts, 3)
+	assert.NotNil(t, served.Attempts[0].Error)
+	var statusErr *StatusError
+	if assert.ErrorAs(t, served.Attempts[1].Error, &statusErr) {
+		assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+	}
+	assert.Nil(t, served.Attempts[2].Error)
+
+	// 连续失败两次后熔断器打开，跳过前两个镜像
+	_, _, err = failover.GetPackageInformation(ctx, "react")
+	assert.Nil(t, err)
+	requests := atomic.LoadInt32(&brokenRequests)
+	_, served, err = failover.GetPackageInformation(ctx, "react")
+	assert.Nil(t, err)
+	assert.True(t, served.Attempts[0].Skipped)
+	assert.True(t, served.Attempts[1].Skipped)
+	assert.Equal(t, requests, atomic.LoadInt32(&brokenRequests))
+	states := failover.MirrorStates()
+	assert.Equal(t, BreakerOpen, states[0].State)
+	assert.Equal(t, BreakerOpen, states[1].State)
+	assert.Equal(t, BreakerClosed, states[2].State)
+
+	// 冷却时间过后进入半开状态，试探请求失败后重新打开
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, BreakerHalfOpen, failover.MirrorStates()[1].State)
+	_, served, err = failover.GetPackageInformation(ctx, "react")
+	assert.Nil(t, err)
+	assert.False(t, served.Attempts[1].Skipped)
+	assert.Equal(t, BreakerOpen, failover.MirrorStates()[1].State)
+	assert.Equal(t, now, failover.MirrorStates()[1].OpenedAt)
+
+	// 其他方法使用同样的故障转移
+	version, served, err := Failover(ctx, failover, func(ctx context.Context, r *Registry) (string, error) {
+		pkg, err := r.GetPackageInformation(ctx, "react")
+		if err != nil {
+			return "", err
+		}
+		return pkg.DistTags["latest"], nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "18.2.0", version)
+	assert.Equal(t, healthy.URL, served.URL)
+}
+
+func TestFailoverRegistryHalfOpenRecovers(t *testing.T) {
+	ctx := context.Background()
+	var status int32 = http.StatusBadGateway
+	var requests int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+		w.Write([]byte(`{"db_name": "registry", "update_seq": 42}`))
+	}))
+	defer flaky.Close()
+
+	failover := NewFailoverRegistry(NewFailoverOptions().SetFailureThreshold(1).SetCooldown(time.Minute),
+		NewRegistry(NewOptions().SetRegistryURL(flaky.URL)))
+	now := time.Now()
+	failover.now = func() time.Time { return now }
+
+	_, served, err := failover.GetRegistryInformation(ctx)
+	assert.ErrorIs(t, err, ErrNoMirrorAvailable)
+	assert.ErrorContains(t, err, "response status code: 502")
+	assert.Nil(t, served.Registry)
+	assert.Equal(t, BreakerOpen, failover.MirrorStates()[0].State)
+
+	// 熔断器打开期间不发送请求
+	before := atomic.LoadInt32(&requests)
+	_, served, err = failover.GetRegistryInformation(ctx)
+	assert.Equal(t, ErrNoMirrorAvailable, err)
+	assert.True(t, served.Attempts[0].Skipped)
+	assert.Equal(t, before, atomic.LoadInt32(&requests))
+
+	// 冷却后试探请求成功，熔断器关闭
+	atomic.StoreInt32(&status, http.StatusOK)
+	now = now.Add(time.Minute)
+	information, served, err := failover.GetRegistryInformation(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 42, information.UpdateSeq)
+	assert.Equal(t, flaky.URL, served.URL)
+	state := failover.MirrorStates()[0]
+	assert.Equal(t, BreakerClosed, state.State)
+	assert.Equal(t, 0, state.Failures)
+}
+
+func TestFailoverRegistryNotRetryable(t *testing.T) {
+	ctx := context.Background()
+	var requests int32
+	invalid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`not json`))
+	}))
+	defer invalid.Close()
+	healthy := httptest.NewServer(newFailoverHandler(http.StatusOK, &requests))
+	defer healthy.Close()
+
+	failover := NewFailoverRegistry(nil,
+		NewRegistry(NewOptions().SetRegistryURL(invalid.URL)),
+		NewRegistry(NewOptions().SetRegistryURL(healthy.URL)))
+
+	// 解析失败不是镜像故障，不会重试
+	_, served, err := failover.GetPackageInformation(ctx, "react")
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, ErrNoMirrorAvailable)
+	assert.Len(t, served.Attempts, 1)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+	assert.Equal(t, BreakerClosed, failover.MirrorStates()[0].State)
+
+	// ctx 被取消时立即返回
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, err = failover.GetPackageInformation(canceled, "react")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, failover.MirrorStates()[0].Failures)
+}
+
+func TestFailoverRegistryNoMirrors(t *testing.T) {
+	_, served, err := NewFailoverRegistry(nil).GetPackageInformation(context.Background(), "react")
+	assert.Equal(t, ErrNoMirrorAvailable, err)
+	assert.Equal(t, "no mirror available", err.Error())
+	assert.Empty(t, served.Attempts)
+}
+
+func TestFailoverRegistryDownloadTarball(t *testing.T) {
+	ctx := context.Background()
+	corrupted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("corrupted"))
+	}))
+	defer corrupted.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("react tarball"))
+	}))
+	defer healthy.Close()
+
+	failover := NewFailoverRegistry(nil,
+		NewRegistry(NewOptions().SetRegistryURL(corrupted.URL)),
+		NewRegistry(NewOptions().SetRegistryURL(healthy.URL)))
+	version := &models.Version{
+		Name:    "react",
+		Version: "18.2.0",
+		Dist: &models.Dist{
+			Tarball: "https://registry.npmjs.org/react/-/react-18.2.0.tgz",
+			Shasum:  "8096d1e3c5bc2c81f3aa1b76031f3685a4fa11ad",
+		},
+	}
+
+	// 完整性校验失败的镜像不会向 w 写入数据
+	var buf bytes.Buffer
+	served, err := failover.DownloadTarball(ctx, version, &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, healthy.URL, served.URL)
+	assert.ErrorIs(t, served.Attempts[0].Error, ErrIntegrity)
+	assert.Equal(t, "react tarball", buf.String())
+}
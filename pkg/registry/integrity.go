@@ -0,0 +1,115 @@
+package registry
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// ErrIntegrity 表示下载内容的校验值与元数据中记录的不一致，可以通过 errors.Is 判断
+var ErrIntegrity = errors.New("integrity check failed")
+
+// IntegrityError 表示 tarball 的完整性校验失败
+//
+// 主要字段说明:
+//   - URL: tarball 的下载地址
+//   - Expected: 元数据中记录的校验值，SRI 格式（例如 "sha512-..."）或十六进制的 sha1
+//   - Actual: 根据下载内容计算得到的校验值，格式与 Expected 相同
+type IntegrityError struct {
+	URL      string
+	Expected string
+	Actual   string
+}
+
+// Error 返回错误描述
+func (x *IntegrityError) Error() string {
+	return fmt.Sprintf("%s: %s: expected %s, got %s", ErrIntegrity, x.URL, x.Expected, x.Actual)
+}
+
+// Is 使 errors.Is(err, ErrIntegrity) 返回 true
+func (x *IntegrityError) Is(target error) bool {
+	return target == ErrIntegrity
+}
+
+// integrityAlgorithms 是支持的 SRI 算法，按照强度从高到低排列
+var integrityAlgorithms = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{"sha512", sha512.New},
+	{"sha384", sha512.New384},
+	{"sha256", sha256.New},
+	{"sha1", sha1.New},
+}
+
+// integrityChecker 在下载的同时计算校验值
+type integrityChecker struct {
+	hash     hash.Hash
+	prefix   string
+	expected []string
+	raw      string
+	hex      bool
+}
+
+// newIntegrityChecker 根据 dist 中的 integrity 或 shasum 创建校验器
+//
+// integrity 中可以包含多个以空格分隔的校验值，与 SRI 规范相同，只使用其中最强的算法，
+// 该算法的任意一个校验值匹配即为校验通过。没有可用的 integrity 时使用 shasum，
+// 两者都没有时返回 nil，表示无法校验
+func newIntegrityChecker(dist *models.Dist) *integrityChecker {
+	entries := make(map[string][]string)
+	for _, entry := range strings.Fields(dist.Integrity) {
+		entry, _, _ = strings.Cut(entry, "?")
+		algorithm, digest, ok := strings.Cut(entry, "-")
+		if ok && digest != "" {
+			entries[algorithm] = append(entries[algorithm], digest)
+		}
+	}
+	for _, algorithm := range integrityAlgorithms {
+		if digests, ok := entries[algorithm.name]; ok {
+			return &integrityChecker{
+				hash:     algorithm.new(),
+				prefix:   algorithm.name + "-",
+				expected: digests,
+				raw:      strings.TrimSpace(dist.Integrity),
+			}
+		}
+	}
+	if dist.Shasum != "" {
+		return &integrityChecker{
+			hash:     sha1.New(),
+			expected: []string{strings.ToLower(dist.Shasum)},
+			raw:      dist.Shasum,
+			hex:      true,
+		}
+	}
+	return nil
+}
+
+// Write 实现 io.Writer 接口
+func (x *integrityChecker) Write(p []byte) (int, error) {
+	return x.hash.Write(p)
+}
+
+// verify 比较计算得到的校验值与期望的校验值
+func (x *integrityChecker) verify(targetUrl string) error {
+	sum := x.hash.Sum(nil)
+	actual := base64.StdEncoding.EncodeToString(sum)
+	if x.hex {
+		actual = hex.EncodeToString(sum)
+	}
+	for _, expected := range x.expected {
+		if expected == actual {
+			return nil
+		}
+	}
+	return &IntegrityError{URL: targetUrl, Expected: x.raw, Actual: x.prefix + actual}
+}
@@ -66,6 +66,9 @@ const RegistryUrlTaoBao = "https://registry.npm.taobao.org"
 //	registry := NewTaoBaoRegistry()
 //	ctx := context.Background()
 //	pkg, err := registry.GetPackageInformation(ctx, "vue")
+//
+// Deprecated: registry.npm.taobao.org 已经停止服务，请使用 NewNpmMirrorRegistry，
+// 可以用 MirrorProbe 检查各个镜像当前是否可用
 func NewTaoBaoRegistry() *Registry {
 	return NewRegistry(NewOptions().SetRegistryURL(RegistryUrlTaoBao))
 }
@@ -166,3 +169,26 @@ func NewHuaWeiCloudRegistry() *Registry {
 }
 
 // ------------------------------------------------- --------------------------------------------------------------------
+
+const RegistryUrlReplicate = "https://replicate.npmjs.com/registry"
+
+// NewReplicateRegistry 创建使用 npm 官方 replicate 接口的 Registry 客户端
+//
+// replicate 接口特点:
+//   - 是 npm 官方提供的 CouchDB 兼容复制接口
+//   - 提供 _changes 和 _all_docs 接口，适合跟踪变更和增量同步
+//   - 不适合查询包元数据或下载 tarball，这些请求应该使用 registry.npmjs.org
+//
+// 返回值:
+//   - *Registry: 配置为使用 replicate 接口的 Registry 客户端
+//
+// 使用示例:
+//
+//	registry := NewReplicateRegistry()
+//	ctx := context.Background()
+//	changes, err := registry.GetChanges(ctx, NewChangesOptions().SetSince("now"))
+func NewReplicateRegistry() *Registry {
+	return NewRegistry(NewOptions().SetRegistryURL(RegistryUrlReplicate))
+}
+
+// ------------------------------------------------- --------------------------------------------------------------------
@@ -106,6 +106,7 @@ func TestAllRegistryImplementations(t *testing.T) {
 		{"NpmjsComRegistry", NewNpmjsComRegistry, RegistryUrlNpmjsCom, "webpack"},
 		{"TaoBaoRegistry", NewTaoBaoRegistry, RegistryUrlTaoBao, "typescript"},
 		{"TencentRegistry", NewTencentRegistry, RegistryUrlTencent, "react-dom"},
+		{"ReplicateRegistry", NewReplicateRegistry, RegistryUrlReplicate, "react"},
 	}
 
 	for _, tc := range testCases {
@@ -13,6 +13,8 @@ const DefaultRegistryURL = "https://registry.npmjs.org"
 // 包含字段:
 // - RegistryURL: NPM 仓库服务器的 URL 地址
 // - Proxy: HTTP 代理服务器的 URL，用于网络请求
+// - AuthToken: 访问私有仓库使用的令牌，只会发送给 RegistryURL 所在的主机
+// - Snapshot: 快照目录，设置后客户端只从快照读取数据，不访问网络，详见 SetSnapshot
 //
 // 使用示例:
 //
@@ -26,6 +28,8 @@ const DefaultRegistryURL = "https://registry.npmjs.org"
 type Options struct {
 	RegistryURL string
 	Proxy       string
+	AuthToken   string
+	Snapshot    string
 }
 
 // NewOptions 创建并返回一个新的默认配置选项实例
@@ -91,6 +95,56 @@ func (o *Options) SetProxy(proxyUrl string) *Options {
 	return o
 }
 
+// SetAuthToken 设置访问私有仓库使用的令牌，对应 .npmrc 中的 _authToken
+//
+// 令牌以 "Authorization: Bearer <token>" 请求头的形式发送，并且只会发送给与 RegistryURL
+// 主机相同的请求，tarball 位于其他主机（例如 CDN）时不会携带令牌，与 npm 的行为一致
+//
+// 参数:
+//   - token: 访问令牌，传入空字符串可以清除之前设置的令牌
+//
+// 返回值:
+//   - *Options: 更新后的选项对象 (支持链式调用)
+//
+// 使用示例:
+//
+//	options := NewOptions().
+//		SetRegistryURL("https://npm.corp.example.com").
+//		SetAuthToken(os.Getenv("NPM_TOKEN"))
+func (o *Options) SetAuthToken(token string) *Options {
+	o.AuthToken = token
+	return o
+}
+
+// SetSnapshot 设置快照目录，设置后 Registry 进入离线模式，所有数据都从快照读取，不会发起任何网络请求
+//
+// 离线模式下:
+//   - GetPackageInformation、GetAbbreviatedPackageInformation、GetPackageVersion 读取快照中的包元数据
+//   - SearchPackages 使用 BuildSnapshotIndex 生成的本地搜索索引
+//   - GetDownloadStats 读取快照中保存的下载统计
+//   - DownloadTarball 读取快照中保存的 tarball，同样会校验完整性
+//   - 快照中没有的数据以及其他需要访问网络的方法返回 ErrNotInSnapshot
+//
+// 快照目录的结构见 BuildSnapshotIndex，通常由爬虫的 crawler.SnapshotSink 生成
+//
+// 参数:
+//   - dir: 快照目录，传入空字符串恢复在线模式
+//
+// 返回值:
+//   - *Options: 更新后的选项对象 (支持链式调用)
+//
+// 使用示例:
+//
+//	registry := NewRegistry(NewOptions().SetSnapshot("/data/npm-snapshot-2024-01-01"))
+//	pkg, err := registry.GetPackageInformation(ctx, "react")
+//	if errors.Is(err, ErrNotInSnapshot) {
+//		// 快照中没有这个包
+//	}
+func (o *Options) SetSnapshot(dir string) *Options {
+	o.Snapshot = dir
+	return o
+}
+
 // GetHttpClient 根据当前选项配置创建并返回一个 HTTP 客户端
 //
 // 如果设置了代理，返回的 HTTP 客户端将使用配置的代理服务器
@@ -55,6 +55,24 @@ func TestSetProxy(t *testing.T) {
 	assert.Equal(t, "socks5://127.0.0.1:1080", options.Proxy)
 }
 
+func TestSetAuthToken(t *testing.T) {
+	options := NewOptions()
+	assert.Empty(t, options.AuthToken)
+
+	// 测试链式调用返回值
+	result := options.SetAuthToken("npm_token")
+	assert.Equal(t, options, result, "应该返回自身以支持链式调用")
+	assert.Equal(t, "npm_token", options.AuthToken)
+
+	// 令牌只发送给仓库所在的主机
+	registry := NewRegistry(options.SetRegistryURL("https://npm.example.com/"))
+	assert.True(t, registry.shouldAuthorize("https://npm.example.com/pkg"))
+	assert.False(t, registry.shouldAuthorize("https://cdn.example.com/pkg/-/pkg-1.0.0.tgz"))
+
+	options.SetAuthToken("")
+	assert.False(t, registry.shouldAuthorize("https://npm.example.com/pkg"))
+}
+
 func TestOptionsChaining(t *testing.T) {
 	// 测试选项链式调用
 	options := NewOptions().
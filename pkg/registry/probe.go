@@ -0,0 +1,329 @@
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// Mirror 表示一个 npm 镜像源
+type Mirror struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Mirrors 是 mirror.go 中所有可以查询包元数据的镜像源，包括官方仓库，
+// 不包括只提供复制接口的 RegistryUrlReplicate
+var Mirrors = []Mirror{
+	{Name: "npmjs", URL: DefaultRegistryURL},
+	{Name: "yarn", URL: RegistryUrlYarn},
+	{Name: "cnpm", URL: RegistryUrlCnpm},
+	{Name: "taobao", URL: RegistryUrlTaoBao},
+	{Name: "skimdb", URL: RegistryUrlNpmjsCom},
+	{Name: "tencent", URL: RegistryUrlTencent},
+	{Name: "npmmirror", URL: RegistryUrlNpmMirror},
+	{Name: "huawei", URL: RegistryUrlHuaWeiCloud},
+}
+
+// ProbeOptions 表示镜像探测的配置选项
+//
+// 包含字段:
+//   - Canary: 用于检查镜像是否可用的包，默认为 "left-pad"，包元数据小，所有镜像都有
+//   - Timeout: 探测单个镜像的超时时间，包括根路径和 canary 两个请求，默认为 10 秒
+//   - Proxy: 探测时使用的 HTTP 代理
+type ProbeOptions struct {
+	Canary  string
+	Timeout time.Duration
+	Proxy   string
+}
+
+// NewProbeOptions 创建并返回默认的探测选项
+func NewProbeOptions() *ProbeOptions {
+	return &ProbeOptions{
+		Canary:  "left-pad",
+		Timeout: 10 * time.Second,
+	}
+}
+
+// SetCanary 设置用于检查镜像是否可用的包
+func (o *ProbeOptions) SetCanary(canary string) *ProbeOptions {
+	o.Canary = canary
+	return o
+}
+
+// SetTimeout 设置探测单个镜像的超时时间
+func (o *ProbeOptions) SetTimeout(timeout time.Duration) *ProbeOptions {
+	o.Timeout = timeout
+	return o
+}
+
+// SetProxy 设置探测时使用的 HTTP 代理
+func (o *ProbeOptions) SetProxy(proxy string) *ProbeOptions {
+	o.Proxy = proxy
+	return o
+}
+
+// ProbeResult 表示一个镜像的探测结果
+//
+// 主要字段说明:
+//   - Mirror: 被探测的镜像
+//   - Alive: 根路径和 canary 包元数据都返回 200，并且包元数据可以正常解析
+//   - Status、Latency: 根路径的 HTTP 状态码和耗时（包括读取响应体），请求失败时状态码为 0
+//   - CanaryStatus、CanaryLatency: canary 包元数据的 HTTP 状态码和耗时
+//   - CanaryLatest: canary 包的 dist-tags.latest，可以用来粗略比较镜像的同步进度
+//   - HTTPS: 镜像是否使用 HTTPS
+//   - TLSValid: 证书链和主机名校验是否通过，HTTP 镜像总是 false
+//   - TLSExpiry: 证书的过期时间
+//   - Information: 根路径返回的 Registry 信息，不是 JSON 时为 nil
+//   - Error: 第一个导致镜像不可用的错误
+type ProbeResult struct {
+	Mirror        Mirror                      `json:"mirror"`
+	Alive         bool                        `json:"alive"`
+	Status        int                         `json:"status"`
+	Latency       time.Duration               `json:"latency"`
+	CanaryStatus  int                         `json:"canaryStatus"`
+	CanaryLatency time.Duration               `json:"canaryLatency"`
+	CanaryLatest  string                      `json:"canaryLatest,omitempty"`
+	HTTPS         bool                        `json:"https"`
+	TLSValid      bool                        `json:"tlsValid"`
+	TLSExpiry     time.Time                   `json:"tlsExpiry,omitempty"`
+	Information   *models.RegistryInformation `json:"information,omitempty"`
+	Error         error                       `json:"-"`
+}
+
+// TotalLatency 返回根路径和 canary 两个请求的总耗时，用于排序
+func (x *ProbeResult) TotalLatency() time.Duration {
+	return x.Latency + x.CanaryLatency
+}
+
+// MirrorProbe 并发探测多个镜像的可用性和延迟
+//
+// 对每个镜像请求两次:
+//   - 根路径，与 GetRegistryInformation 相同，检查服务状态并获取 Registry 信息
+//   - canary 包的元数据，检查镜像是否真的能提供包元数据
+//
+// 结果按可用性和总耗时排序: 可用的镜像在前，耗时短的在前，不可用的镜像按名称排序
+//
+// 使用示例:
+//
+//	results := NewMirrorProbe(nil).Probe(ctx)
+//	for _, result := range results {
+//		fmt.Println(result.Mirror.Name, result.Alive, result.TotalLatency(), result.Error)
+//	}
+type MirrorProbe struct {
+	mirrors []Mirror
+	options *ProbeOptions
+
+	// rootCAs 不为 nil 时替代系统根证书，用于测试
+	rootCAs *x509.CertPool
+}
+
+// NewMirrorProbe 创建镜像探测器
+//
+// 参数:
+//   - options: 探测选项，为 nil 时使用默认选项
+//   - mirrors: 要探测的镜像，为空时探测 Mirrors 中的所有镜像
+//
+// 返回值:
+//   - *MirrorProbe: 新创建的探测器
+func NewMirrorProbe(options *ProbeOptions, mirrors ...Mirror) *MirrorProbe {
+	if options == nil {
+		options = NewProbeOptions()
+	}
+	if len(mirrors) == 0 {
+		mirrors = Mirrors
+	}
+	return &MirrorProbe{
+		mirrors: mirrors,
+		options: options,
+	}
+}
+
+// Probe 并发探测所有镜像，返回排序后的结果，每个镜像对应一个结果
+func (x *MirrorProbe) Probe(ctx context.Context) []*ProbeResult {
+	client, err := x.httpClient()
+	results := make([]*ProbeResult, len(x.mirrors))
+	var wg sync.WaitGroup
+	for i, mirror := range x.mirrors {
+		if err != nil {
+			results[i] = &ProbeResult{Mirror: mirror, Error: err}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, mirror Mirror) {
+			defer wg.Done()
+			results[i] = x.probe(ctx, client, mirror)
+		}(i, mirror)
+	}
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Alive != b.Alive {
+			return a.Alive
+		}
+		if a.Alive {
+			return a.TotalLatency() < b.TotalLatency()
+		}
+		return a.Mirror.Name < b.Mirror.Name
+	})
+	return results
+}
+
+// Fastest 探测所有镜像并返回最快的可用镜像
+//
+// 返回值:
+//   - *ProbeResult: 最快的可用镜像的探测结果
+//   - error: 没有可用镜像时返回包含每个镜像错误的错误
+func (x *MirrorProbe) Fastest(ctx context.Context) (*ProbeResult, error) {
+	results := x.Probe(ctx)
+	if len(results) > 0 && results[0].Alive {
+		return results[0], nil
+	}
+	errs := make([]error, 0, len(results))
+	for _, result := range results {
+		errs = append(errs, fmt.Errorf("%s: %w", result.Mirror.Name, result.Error))
+	}
+	return nil, fmt.Errorf("no mirror available: %w", errors.Join(errs...))
+}
+
+// NewFastestRegistry 使用默认选项探测 Mirrors 中的所有镜像，返回使用最快的可用镜像的 Registry 客户端
+//
+// 适合部署在多个地区的服务在启动时自动选择最近的镜像
+//
+// 返回值:
+//   - *Registry: 使用最快镜像的 Registry 客户端
+//   - error: 没有可用镜像时返回错误
+//
+// 使用示例:
+//
+//	registry, err := NewFastestRegistry(ctx)
+//	if err != nil {
+//		registry = NewRegistry()
+//	}
+func NewFastestRegistry(ctx context.Context) (*Registry, error) {
+	result, err := NewMirrorProbe(nil).Fastest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewRegistry(NewOptions().SetRegistryURL(result.Mirror.URL)), nil
+}
+
+// probe 探测一个镜像
+func (x *MirrorProbe) probe(ctx context.Context, client *http.Client, mirror Mirror) *ProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, x.options.Timeout)
+	defer cancel()
+	result := &ProbeResult{
+		Mirror: mirror,
+		HTTPS:  strings.HasPrefix(mirror.URL, "https://"),
+	}
+
+	root, err := x.get(ctx, client, mirror.URL, result)
+	result.Status, result.Latency = root.status, root.latency
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	if information, err := unmarshalJson[*models.RegistryInformation](root.body); err == nil {
+		result.Information = information
+	}
+
+	canary, err := x.get(ctx, client, strings.TrimSuffix(mirror.URL, "/")+"/"+url.PathEscape(x.options.Canary), result)
+	result.CanaryStatus, result.CanaryLatency = canary.status, canary.latency
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	var pkg models.Package
+	if err := json.Unmarshal(canary.body, &pkg); err != nil {
+		result.Error = fmt.Errorf("parse %s: %w", x.options.Canary, err)
+		return result
+	}
+	if pkg.Name != x.options.Canary {
+		result.Error = fmt.Errorf("parse %s: unexpected package name %q", x.options.Canary, pkg.Name)
+		return result
+	}
+	result.CanaryLatest = pkg.DistTags["latest"]
+	result.Alive = true
+	return result
+}
+
+// probeResponse 表示一次探测请求的响应
+type probeResponse struct {
+	status  int
+	latency time.Duration
+	body    []byte
+}
+
+// get 请求 targetUrl 并读取整个响应体，同时记录 TLS 信息，状态码不是 200 时返回错误
+func (x *MirrorProbe) get(ctx context.Context, client *http.Client, targetUrl string, result *ProbeResult) (*probeResponse, error) {
+	response := &probeResponse{}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, targetUrl, nil)
+	if err != nil {
+		return response, err
+	}
+	request.Header.Set("Accept", "application/json")
+	start := time.Now()
+	resp, err := client.Do(request)
+	if err != nil {
+		response.latency = time.Since(start)
+		return response, err
+	}
+	defer resp.Body.Close()
+	response.status = resp.StatusCode
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		// 客户端会校验证书，能拿到响应说明证书有效
+		result.TLSValid = true
+		result.TLSExpiry = resp.TLS.PeerCertificates[0].NotAfter
+	}
+	response.body, err = io.ReadAll(resp.Body)
+	response.latency = time.Since(start)
+	if err != nil {
+		return response, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return response, &StatusError{URL: targetUrl, StatusCode: resp.StatusCode}
+	}
+	return response, nil
+}
+
+// httpClient 创建探测使用的 HTTP 客户端，不跟随跨主机的重定向以免测到别的镜像
+func (x *MirrorProbe) httpClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if x.options.Proxy != "" {
+		proxyUrl, err := url.Parse(x.options.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+	if x.rootCAs != nil {
+		transport.TLSClientConfig = &tls.Config{RootCAs: x.rootCAs}
+	}
+	// 每次探测都建立新连接，延迟中包含连接和 TLS 握手的耗时
+	transport.DisableKeepAlives = true
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if request.URL.Host != via[0].URL.Host {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}, nil
+}
@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newProbeHandler 创建模拟镜像，delay 为每个请求的延迟，rootStatus 为根路径的状态码，canary 为 canary 包的响应
+func newProbeHandler(delay time.Duration, rootStatus int, canary string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		if r.URL.Path == "/" {
+			w.WriteHeader(rootStatus)
+			w.Write([]byte(`{"db_name": "registry", "doc_count": 1000, "update_seq": 5000}`))
+			return
+		}
+		if r.URL.Path == "/left-pad" {
+			w.Write([]byte(canary))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+}
+
+func TestMirrorProbe(t *testing.T) {
+	canary := `{"name": "left-pad", "dist-tags": {"latest": "1.3.0"}}`
+	fast := httptest.NewServer(newProbeHandler(0, http.StatusOK, canary))
+	defer fast.Close()
+	slow := httptest.NewServer(newProbeHandler(50*time.Millisecond, http.StatusOK, canary))
+	defer slow.Close()
+	broken := httptest.NewServer(newProbeHandler(0, http.StatusInternalServerError, canary))
+	defer broken.Close()
+	stale := httptest.NewServer(newProbeHandler(0, http.StatusOK, `{"error": "Not found"}`))
+	defer stale.Close()
+	trusted := httptest.NewTLSServer(newProbeHandler(20*time.Millisecond, http.StatusOK, canary))
+	defer trusted.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	probe := NewMirrorProbe(NewProbeOptions().SetTimeout(5*time.Second),
+		Mirror{Name: "slow", URL: slow.URL},
+		Mirror{Name: "down", URL: down.URL},
+		Mirror{Name: "fast", URL: fast.URL},
+		Mirror{Name: "broken", URL: broken.URL},
+		Mirror{Name: "stale", URL: stale.URL},
+		Mirror{Name: "trusted", URL: trusted.URL},
+	)
+	probe.rootCAs = x509.NewCertPool()
+	probe.rootCAs.AddCert(trusted.Certificate())
+	results := probe.Probe(context.Background())
+
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.Mirror.Name)
+	}
+	// 可用的镜像按耗时排序，不可用的按名称排序
+	assert.Equal(t, []string{"fast", "trusted", "slow", "broken", "down", "stale"}, names)
+
+	byName := make(map[string]*ProbeResult)
+	for _, result := range results {
+		byName[result.Mirror.Name] = result
+	}
+	assert.True(t, byName["fast"].Alive)
+	assert.Nil(t, byName["fast"].Error)
+	assert.Equal(t, http.StatusOK, byName["fast"].Status)
+	assert.Equal(t, "1.3.0", byName["fast"].CanaryLatest)
+	assert.Equal(t, 5000, byName["fast"].Information.UpdateSeq)
+	assert.False(t, byName["fast"].HTTPS)
+	assert.True(t, byName["slow"].TotalLatency() >= 100*time.Millisecond)
+
+	assert.True(t, byName["trusted"].HTTPS)
+	assert.True(t, byName["trusted"].TLSValid)
+	assert.False(t, byName["trusted"].TLSExpiry.IsZero())
+
+	// 没有信任 httptest 的证书时 TLS 校验失败
+	results = NewMirrorProbe(nil, Mirror{Name: "untrusted", URL: trusted.URL}).Probe(context.Background())
+	assert.True(t, results[0].HTTPS)
+	assert.False(t, results[0].Alive)
+	assert.False(t, results[0].TLSValid)
+	assert.NotNil(t, results[0].Error)
+
+	assert.Equal(t, http.StatusInternalServerError, byName["broken"].Status)
+	assert.NotNil(t, byName["broken"].Error)
+	assert.Equal(t, http.StatusOK, byName["stale"].CanaryStatus)
+	assert.NotNil(t, byName["stale"].Error)
+	assert.Equal(t, 0, byName["down"].Status)
+	assert.NotNil(t, byName["down"].Error)
+
+	fastest, err := probe.Fastest(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "fast", fastest.Mirror.Name)
+
+	_, err = NewMirrorProbe(nil, Mirror{Name: "down", URL: down.URL}).Fastest(context.Background())
+	assert.ErrorContains(t, err, "no mirror available")
+}
+
+func TestMirrors(t *testing.T) {
+	names := make(map[string]bool)
+	for _, mirror := range Mirrors {
+		assert.False(t, names[mirror.Name], mirror.Name)
+		names[mirror.Name] = true
+		assert.NotEmpty(t, mirror.URL)
+	}
+	assert.Len(t, Mirrors, 8)
+}
@@ -4,6 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/crawler-go-go-go/go-requests"
 	"github.com/scagogogo/npm-crawler/pkg/models"
@@ -13,6 +19,14 @@ import (
 // 可以使用不同的镜像源配置来创建实例，支持代理设置
 type Registry struct {
 	options *Options
+
+	// 签名公钥缓存，由 VerifySignatures 首次使用时获取
+	keysLock sync.Mutex
+	keys     *models.RegistryKeys
+
+	// 快照的搜索索引，离线模式下由 SearchPackages 首次使用时加载
+	indexLock sync.Mutex
+	index     []*models.SearchPackage
 }
 
 // NewRegistry 创建一个新的 Registry 客户端实例
@@ -132,6 +146,9 @@ func (x *Registry) GetRegistryInformation(ctx context.Context) (*models.Registry
 //	fmt.Println("包名:", pkg.Name)
 //	fmt.Println("最新版本:", pkg.DistTags.Latest)
 func (x *Registry) GetPackageInformation(ctx context.Context, packageName string) (*models.Package, error) {
+	if x.options.Snapshot != "" {
+		return x.snapshotPackage(ctx, packageName)
+	}
 	targetUrl := fmt.Sprintf("%s/%s", x.options.RegistryURL, packageName)
 	bytes, err := x.getBytes(ctx, targetUrl)
 	if err != nil {
@@ -140,6 +157,73 @@ func (x *Registry) GetPackageInformation(ctx context.Context, packageName string
 	return unmarshalJson[*models.Package](bytes)
 }
 
+// GetPackageJSON 获取指定 NPM 包的完整元数据的原始 JSON
+//
+// 与 GetPackageInformation 请求的是同一个接口，但不解析响应，保留 models.Package 中没有的字段，
+// 例如 bundleDependencies、libc、_hasShrinkwrap，适合需要原样转发或保存元数据的场景
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - packageName: 要查询的包名称，例如 "react"、"@types/node" 等
+//
+// 返回值:
+//   - []byte: 响应体，包不存在时为 Registry 返回的 {"error": "Not found"}
+//   - error: 如果请求失败则返回错误
+//
+// 使用示例:
+//
+//	registry := NewRegistry()
+//	data, err := registry.GetPackageJSON(context.Background(), "react")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	os.WriteFile("react.json", data, 0644)
+func (x *Registry) GetPackageJSON(ctx context.Context, packageName string) ([]byte, error) {
+	if x.options.Snapshot != "" {
+		return x.snapshotPackageJSON(ctx, packageName)
+	}
+	return x.getBytes(ctx, fmt.Sprintf("%s/%s", x.options.RegistryURL, packageName))
+}
+
+// AbbreviatedMetadataAccept 请求精简版包元数据时使用的 Accept 请求头
+//
+// 精简版元数据（也称为 corgi 文档）只包含安装所需的字段，例如 dist-tags、依赖关系和分发信息，
+// 体积通常只有完整元数据的十分之一，npm 客户端在安装依赖时使用的就是这种格式
+const AbbreviatedMetadataAccept = "application/vnd.npm.install-v1+json; q=1.0, application/json; q=0.8, */*"
+
+// GetAbbreviatedPackageInformation 获取指定 NPM 包的精简版元数据
+//
+// 返回的 Package 中只包含安装相关的字段: name、dist-tags、versions（每个版本只包含依赖关系、
+// dist、engines 等字段）以及 modified 时间，不包含 readme、time 等字段
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - packageName: 要查询的包名称，例如 "react"、"@types/node" 等
+//
+// 返回值:
+//   - *models.Package: 包的精简版元数据
+//   - error: 如果请求失败则返回错误
+//
+// 使用示例:
+//
+//	registry := NewRegistry()
+//	pkg, err := registry.GetAbbreviatedPackageInformation(context.Background(), "express")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	fmt.Println("最新版本:", pkg.DistTags["latest"])
+func (x *Registry) GetAbbreviatedPackageInformation(ctx context.Context, packageName string) (*models.Package, error) {
+	if x.options.Snapshot != "" {
+		return x.snapshotPackage(ctx, packageName)
+	}
+	targetUrl := fmt.Sprintf("%s/%s", x.options.RegistryURL, packageName)
+	bytes, err := x.getBytes(ctx, targetUrl, requestSettingHeader("Accept", AbbreviatedMetadataAccept))
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalJson[*models.Package](bytes)
+}
+
 // SearchPackages 搜索 NPM 包
 //
 // 参数:
@@ -166,6 +250,9 @@ func (x *Registry) SearchPackages(ctx context.Context, query string, limit int)
 	if limit <= 0 {
 		limit = 20
 	}
+	if x.options.Snapshot != "" {
+		return x.snapshotSearch(query, limit)
+	}
 	targetUrl := fmt.Sprintf("%s/-/v1/search?text=%s&size=%d", x.options.RegistryURL, query, limit)
 	bytes, err := x.getBytes(ctx, targetUrl)
 	if err != nil {
@@ -174,6 +261,43 @@ func (x *Registry) SearchPackages(ctx context.Context, query string, limit int)
 	return unmarshalJson[*models.SearchResult](bytes)
 }
 
+// SearchPackagesJSON 使用原始查询参数搜索 NPM 包，返回搜索结果的原始 JSON
+//
+// 所有查询参数原样转发给 /-/v1/search，例如 text、size、from、quality、popularity、maintenance，
+// 响应不经过解析，保留 models.SearchResult 中没有的字段，适合需要原样转发搜索结果的场景。
+// 离线快照模式下只支持 text 和 size 参数
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - query: 查询参数，会被重新编码
+//
+// 返回值:
+//   - []byte: 响应体
+//   - error: 如果请求失败则返回错误
+//
+// 使用示例:
+//
+//	registry := NewRegistry()
+//	data, err := registry.SearchPackagesJSON(context.Background(), url.Values{"text": {"react"}, "from": {"20"}})
+//	if err != nil {
+//		// 处理错误
+//	}
+//	fmt.Println(string(data))
+func (x *Registry) SearchPackagesJSON(ctx context.Context, query url.Values) ([]byte, error) {
+	if x.options.Snapshot != "" {
+		limit, _ := strconv.Atoi(query.Get("size"))
+		if limit <= 0 {
+			limit = 20
+		}
+		result, err := x.snapshotSearch(query.Get("text"), limit)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(result)
+	}
+	return x.getBytes(ctx, fmt.Sprintf("%s/-/v1/search?%s", x.options.RegistryURL, query.Encode()))
+}
+
 // GetPackageVersion 获取指定 NPM 包的特定版本信息
 //
 // 参数:
@@ -196,6 +320,9 @@ func (x *Registry) SearchPackages(ctx context.Context, query string, limit int)
 //	fmt.Println("版本:", version.Version)
 //	fmt.Println("依赖:", version.Dependencies)
 func (x *Registry) GetPackageVersion(ctx context.Context, packageName, version string) (*models.Version, error) {
+	if x.options.Snapshot != "" {
+		return x.snapshotVersion(ctx, packageName, version)
+	}
 	targetUrl := fmt.Sprintf("%s/%s/%s", x.options.RegistryURL, packageName, version)
 	bytes, err := x.getBytes(ctx, targetUrl)
 	if err != nil {
@@ -225,6 +352,9 @@ func (x *Registry) GetPackageVersion(ctx context.Context, packageName, version s
 //	}
 //	fmt.Println("下载次数:", stats.Downloads)
 func (x *Registry) GetDownloadStats(ctx context.Context, packageName, period string) (*models.DownloadStats, error) {
+	if x.options.Snapshot != "" {
+		return x.snapshotDownloadStats(packageName, period)
+	}
 	baseURL := "https://api.npmjs.org/downloads"
 	targetUrl := fmt.Sprintf("%s/point/%s/%s", baseURL, period, packageName)
 	bytes, err := x.getBytes(ctx, targetUrl)
@@ -268,21 +398,117 @@ func unmarshalJson[T any](bytes []byte) (T, error) {
 	return r, nil
 }
 
+// StatusError 表示 Registry 返回了非预期的 HTTP 状态码，可以通过 errors.As 获取状态码
+//
+// 主要字段说明:
+//   - URL: 请求的地址
+//   - StatusCode: 响应的状态码
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+// Error 返回错误描述
+func (x *StatusError) Error() string {
+	return fmt.Sprintf("%s: response status code: %d", x.URL, x.StatusCode)
+}
+
+// bytesResponseHandler 读取状态码为 200 或 404 的响应体，其他状态码返回 *StatusError
+//
+// 404 的响应体是 Registry 返回的 {"error": "Not found"}，由调用方根据解析结果判断包是否存在
+func bytesResponseHandler(targetUrl string) requests.ResponseHandler[[]byte] {
+	return func(response *http.Response) ([]byte, error) {
+		if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotFound {
+			return nil, &StatusError{URL: targetUrl, StatusCode: response.StatusCode}
+		}
+		data, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", targetUrl, err)
+		}
+		return data, nil
+	}
+}
+
 // getBytes 从指定 URL 获取响应数据的字节数组
 //
 // 参数:
 //   - ctx: 上下文，可用于取消请求或设置超时
 //   - targetUrl: 请求的目标 URL
+//   - settings: 额外的请求设置，例如自定义请求头
 //
 // 返回值:
 //   - []byte: 响应数据的字节数组
 //   - error: 如果请求失败则返回错误
 //
 // 注意: 这是一个内部方法，支持代理设置
-func (x *Registry) getBytes(ctx context.Context, targetUrl string) ([]byte, error) {
-	options := requests.NewOptions[any, []byte](targetUrl, requests.BytesResponseHandler())
+func (x *Registry) getBytes(ctx context.Context, targetUrl string, settings ...requests.RequestSetting) ([]byte, error) {
+	if x.options.Snapshot != "" {
+		return nil, errOffline(targetUrl)
+	}
+	options := requests.NewOptions[any, []byte](targetUrl, bytesResponseHandler(targetUrl))
 	if x.options.Proxy != "" {
 		options.AppendRequestSetting(requests.RequestSettingProxy(x.options.Proxy))
 	}
+	if x.shouldAuthorize(targetUrl) {
+		options.AppendRequestSetting(requestSettingHeader("Authorization", "Bearer "+x.options.AuthToken))
+	}
+	for _, setting := range settings {
+		options.AppendRequestSetting(setting)
+	}
 	return requests.SendRequest[any, []byte](ctx, options)
 }
+
+// postJSON 以 JSON 格式向指定 URL 发送 POST 请求并返回响应数据的字节数组
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - targetUrl: 请求的目标 URL
+//   - body: 请求体，会被序列化为 JSON
+//
+// 返回值:
+//   - []byte: 响应数据的字节数组
+//   - error: 如果序列化或请求失败则返回错误
+func (x *Registry) postJSON(ctx context.Context, targetUrl string, body any) ([]byte, error) {
+	if x.options.Snapshot != "" {
+		return nil, errOffline(targetUrl)
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	options := requests.NewOptions[any, []byte](targetUrl, bytesResponseHandler(targetUrl)).
+		WithMethod(http.MethodPost).
+		WithBody(payload).
+		AppendRequestSetting(requestSettingHeader("Content-Type", "application/json"))
+	if x.options.Proxy != "" {
+		options.AppendRequestSetting(requests.RequestSettingProxy(x.options.Proxy))
+	}
+	if x.shouldAuthorize(targetUrl) {
+		options.AppendRequestSetting(requestSettingHeader("Authorization", "Bearer "+x.options.AuthToken))
+	}
+	return requests.SendRequest[any, []byte](ctx, options)
+}
+
+// requestSettingHeader 返回一个设置请求头的请求设置
+func requestSettingHeader(key, value string) requests.RequestSetting {
+	return func(client *http.Client, request *http.Request) error {
+		request.Header.Set(key, value)
+		return nil
+	}
+}
+
+// shouldAuthorize 判断请求是否需要携带访问令牌，只有发往 RegistryURL 所在主机的请求才会携带
+func (x *Registry) shouldAuthorize(targetUrl string) bool {
+	if x.options.AuthToken == "" {
+		return false
+	}
+	registryUrl, err := url.Parse(x.options.RegistryURL)
+	if err != nil {
+		return false
+	}
+	target, err := url.Parse(targetUrl)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(registryUrl.Host, target.Host)
+}
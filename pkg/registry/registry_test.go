@@ -4,8 +4,10 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
+	"github.com/scagogogo/npm-crawler/pkg/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -169,6 +171,39 @@ func TestGetPackageInformation(t *testing.T) {
 	assert.Empty(t, errorPkg.Versions, "服务器错误时应该没有返回版本信息")
 }
 
+func TestGetPackageJSON(t *testing.T) {
+	server := setupTestRegistryServer()
+	defer server.Close()
+	registry := NewRegistry(NewOptions().SetRegistryURL(server.URL))
+
+	// 响应体原样返回，不经过 models.Package
+	data, err := registry.GetPackageJSON(context.Background(), "axios")
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"_rev": "1-abc123"`)
+	pkg, err := unmarshalJson[*models.Package](data)
+	assert.Nil(t, err)
+	assert.Equal(t, "1.0.0", pkg.DistTags["latest"])
+}
+
+func TestSearchPackagesJSON(t *testing.T) {
+	var rawQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/-/v1/search", r.URL.Path)
+		rawQuery = r.URL.RawQuery
+		w.Write([]byte(`{"objects": [], "total": 0, "time": "Mon Jan 01 2024"}`))
+	}))
+	defer server.Close()
+	registry := NewRegistry(NewOptions().SetRegistryURL(server.URL))
+
+	// 所有查询参数都会转发，响应体原样返回
+	data, err := registry.SearchPackagesJSON(context.Background(), url.Values{"text": {"react dom"}, "from": {"20"}, "quality": {"0.5"}})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"objects": [], "total": 0, "time": "Mon Jan 01 2024"}`, string(data))
+	query, err := url.ParseQuery(rawQuery)
+	assert.Nil(t, err)
+	assert.Equal(t, url.Values{"text": {"react dom"}, "from": {"20"}, "quality": {"0.5"}}, query)
+}
+
 func TestUnmarshalJson(t *testing.T) {
 	// 测试正常的 JSON 解析
 	validJson := []byte(`{"name":"test","value":123}`)
@@ -248,3 +283,35 @@ func RegistryTest(t *testing.T, r *Registry) {
 	assert.NotNil(t, packageInformation)
 	assert.Equal(t, "axios", packageInformation.Name)
 }
+
+func TestGetAbbreviatedPackageInformation(t *testing.T) {
+	var accept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		w.Header().Set("Content-Type", "application/vnd.npm.install-v1+json")
+		w.Write([]byte(`{
+			"name": "axios",
+			"modified": "2023-01-01T00:00:00.000Z",
+			"dist-tags": {"latest": "1.0.0"},
+			"versions": {
+				"1.0.0": {
+					"name": "axios",
+					"version": "1.0.0",
+					"dependencies": {"follow-redirects": "^1.15.0"},
+					"peerDependencies": {"debug": "*"},
+					"peerDependenciesMeta": {"debug": {"optional": true}},
+					"dist": {"tarball": "https://registry.npmjs.org/axios/-/axios-1.0.0.tgz"}
+				}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	registry := NewRegistry(NewOptions().SetRegistryURL(server.URL))
+	pkg, err := registry.GetAbbreviatedPackageInformation(context.Background(), "axios")
+	assert.Nil(t, err)
+	assert.Equal(t, AbbreviatedMetadataAccept, accept)
+	assert.Equal(t, "axios", pkg.Name)
+	assert.Equal(t, "^1.15.0", pkg.Versions["1.0.0"].Dependencies["follow-redirects"])
+	assert.True(t, pkg.Versions["1.0.0"].PeerDependenciesMeta["debug"].Optional)
+}
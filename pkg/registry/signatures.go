@@ -0,0 +1,198 @@
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// SignatureStatus 表示一个版本的 Registry 签名校验结果，与 npm audit signatures 的分类一致
+type SignatureStatus string
+
+const (
+	// SignatureUnsigned 版本没有任何 Registry 签名
+	SignatureUnsigned SignatureStatus = "unsigned"
+
+	// SignatureValid 所有签名都校验通过
+	SignatureValid SignatureStatus = "valid"
+
+	// SignatureInvalid 至少有一个签名校验失败，或者签名所用的公钥在发布时已经过期
+	SignatureInvalid SignatureStatus = "invalid"
+
+	// SignatureUnknownKey 至少有一个签名的 keyid 不在 Registry 公布的公钥列表中
+	SignatureUnknownKey SignatureStatus = "unknown-key"
+)
+
+// SignatureResult 表示一个版本的签名校验结果
+//
+// 主要字段说明:
+//   - Name: 包名
+//   - Version: 版本号
+//   - Status: 校验结果
+//   - Keyid: 导致校验失败的签名的 keyid；全部通过时为最后一个签名的 keyid
+//   - Reason: 校验失败的原因，校验通过或未签名时为空
+type SignatureResult struct {
+	Name    string
+	Version string
+	Status  SignatureStatus
+	Keyid   string
+	Reason  string
+}
+
+// GetSigningKeys 获取 Registry 用于签名的公钥列表
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//
+// 返回值:
+//   - *models.RegistryKeys: 公钥列表
+//   - error: 如果请求失败则返回错误
+//
+// 使用示例:
+//
+//	registry := NewRegistry()
+//	keys, err := registry.GetSigningKeys(context.Background())
+//	if err != nil {
+//		// 处理错误
+//	}
+//	for _, key := range keys.Keys {
+//		fmt.Println(key.Keyid)
+//	}
+func (x *Registry) GetSigningKeys(ctx context.Context) (*models.RegistryKeys, error) {
+	targetUrl := fmt.Sprintf("%s/-/npm/v1/keys", x.options.RegistryURL)
+	bytes, err := x.getBytes(ctx, targetUrl)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalJson[*models.RegistryKeys](bytes)
+}
+
+// VerifySignatures 校验版本的 Registry 签名
+//
+// 首次调用时会从 Registry 获取公钥列表并缓存在客户端中，之后的调用复用缓存。
+// 签名内容为 "name@version:integrity"，使用 ECDSA P-256 + SHA-256 校验，
+// 和 npm 一样要求所有签名都校验通过。
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - version: 要校验的版本，需要包含 Dist 信息
+//   - published: 版本的发布时间，通常来自 Package.ReleaseTime；公钥在这个时间之前过期的签名视为无效，
+//     传入零值时带有过期时间的公钥一律视为过期，与 npm 的行为一致
+//
+// 返回值:
+//   - *SignatureResult: 校验结果
+//   - error: 获取公钥列表失败时返回错误
+//
+// 使用示例:
+//
+//	registry := NewRegistry()
+//	pkg, _ := registry.GetPackageInformation(ctx, "react")
+//	version := pkg.Versions["18.0.0"]
+//	published, _ := pkg.ReleaseTime("18.0.0")
+//	result, err := registry.VerifySignatures(ctx, &version, published)
+//	if err != nil {
+//		// 处理错误
+//	}
+//	fmt.Println(result.Status)
+func (x *Registry) VerifySignatures(ctx context.Context, version *models.Version, published time.Time) (*SignatureResult, error) {
+	keys, err := x.signingKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return VerifySignatures(keys, version, published), nil
+}
+
+// VerifySignatures 使用给定的公钥列表校验版本的 Registry 签名，不发起任何网络请求
+//
+// 参数:
+//   - keys: Registry 公钥列表，通常来自 GetSigningKeys
+//   - version: 要校验的版本
+//   - published: 版本的发布时间，语义与 Registry.VerifySignatures 相同
+//
+// 返回值:
+//   - *SignatureResult: 校验结果
+func VerifySignatures(keys *models.RegistryKeys, version *models.Version, published time.Time) *SignatureResult {
+	result := &SignatureResult{
+		Name:    version.Name,
+		Version: version.Version,
+		Status:  SignatureUnsigned,
+	}
+	if version.Dist == nil || len(version.Dist.Signatures) == 0 {
+		return result
+	}
+
+	message := []byte(fmt.Sprintf("%s@%s:%s", version.Name, version.Version, version.Dist.Integrity))
+	for _, signature := range version.Dist.Signatures {
+		if signature == nil {
+			continue
+		}
+		result.Keyid = signature.Keyid
+		var key *models.RegistryKey
+		if keys != nil {
+			key = keys.Key(signature.Keyid)
+		}
+		if key == nil {
+			result.Status = SignatureUnknownKey
+			result.Reason = fmt.Sprintf("no public key found for keyid %s", signature.Keyid)
+			return result
+		}
+		if expires, ok := key.ExpiresAt(); ok && (published.IsZero() || !published.Before(expires)) {
+			result.Status = SignatureInvalid
+			result.Reason = fmt.Sprintf("public key %s expired at %s", key.Keyid, expires.Format(time.RFC3339))
+			return result
+		}
+		if err := verifySignature(key, message, signature.Sig); err != nil {
+			result.Status = SignatureInvalid
+			result.Reason = err.Error()
+			return result
+		}
+		result.Status = SignatureValid
+	}
+	return result
+}
+
+// verifySignature 使用 DER 编码的 ECDSA 公钥校验 Base64 编码的签名
+func verifySignature(key *models.RegistryKey, message []byte, sig string) error {
+	der, err := base64.StdEncoding.DecodeString(key.Key)
+	if err != nil {
+		return fmt.Errorf("decode public key %s: %w", key.Keyid, err)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return fmt.Errorf("parse public key %s: %w", key.Keyid, err)
+	}
+	ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("public key %s is not an ECDSA key", key.Keyid)
+	}
+	signature, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+	digest := sha256.Sum256(message)
+	if !ecdsa.VerifyASN1(ecdsaKey, digest[:], signature) {
+		return fmt.Errorf("signature does not match public key %s", key.Keyid)
+	}
+	return nil
+}
+
+// signingKeys 返回缓存的公钥列表，未缓存时从 Registry 获取
+func (x *Registry) signingKeys(ctx context.Context) (*models.RegistryKeys, error) {
+	x.keysLock.Lock()
+	defer x.keysLock.Unlock()
+	if x.keys != nil {
+		return x.keys, nil
+	}
+	keys, err := x.GetSigningKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	x.keys = keys
+	return keys, nil
+}
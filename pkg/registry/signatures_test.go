@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSigningKey 生成测试用的 ECDSA P-256 密钥对，返回私钥和对应的 Registry 公钥
+func newSigningKey(t *testing.T, keyid string, expires *string) (*ecdsa.PrivateKey, *models.RegistryKey) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	assert.Nil(t, err)
+	return privateKey, &models.RegistryKey{
+		Expires: expires,
+		Keyid:   keyid,
+		Keytype: "ecdsa-sha2-nistp256",
+		Scheme:  "ecdsa-sha2-nistp256",
+		Key:     base64.StdEncoding.EncodeToString(der),
+	}
+}
+
+// signVersion 按照 Registry 的方式为版本签名
+func signVersion(t *testing.T, privateKey *ecdsa.PrivateKey, keyid string, version *models.Version) {
+	digest := sha256.Sum256([]byte(version.Name + "@" + version.Version + ":" + version.Dist.Integrity))
+	sig, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+	assert.Nil(t, err)
+	version.Dist.Signatures = append(version.Dist.Signatures, &models.Signature{
+		Keyid: keyid,
+		Sig:   base64.StdEncoding.EncodeToString(sig),
+	})
+}
+
+func signedTestVersion() *models.Version {
+	return &models.Version{
+		Name:    "pkg",
+		Version: "1.0.0",
+		Dist:    &models.Dist{Integrity: "sha512-abc"},
+	}
+}
+
+func TestVerifySignatures(t *testing.T) {
+	expires := "2025-01-01T00:00:00.000Z"
+	currentKey, current := newSigningKey(t, "SHA256:current", nil)
+	oldKey, old := newSigningKey(t, "SHA256:old", &expires)
+	otherKey, _ := newSigningKey(t, "SHA256:other", nil)
+	keys := &models.RegistryKeys{Keys: []*models.RegistryKey{current, old}}
+	before := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	after := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// 没有签名
+	result := VerifySignatures(keys, signedTestVersion(), before)
+	assert.Equal(t, SignatureUnsigned, result.Status)
+
+	// 有效签名
+	version := signedTestVersion()
+	signVersion(t, currentKey, current.Keyid, version)
+	result = VerifySignatures(keys, version, after)
+	assert.Equal(t, SignatureValid, result.Status)
+	assert.Equal(t, "SHA256:current", result.Keyid)
+	assert.Empty(t, result.Reason)
+
+	// 内容被篡改
+	version.Dist.Integrity = "sha512-tampered"
+	result = VerifySignatures(keys, version, after)
+	assert.Equal(t, SignatureInvalid, result.Status)
+	assert.NotEmpty(t, result.Reason)
+
+	// 用错误的私钥签名
+	version = signedTestVersion()
+	signVersion(t, otherKey, current.Keyid, version)
+	assert.Equal(t, SignatureInvalid, VerifySignatures(keys, version, after).Status)
+
+	// 未知公钥
+	version = signedTestVersion()
+	signVersion(t, otherKey, "SHA256:other", version)
+	result = VerifySignatures(keys, version, after)
+	assert.Equal(t, SignatureUnknownKey, result.Status)
+	assert.Equal(t, "SHA256:other", result.Keyid)
+
+	// 过期公钥：发布时仍有效则通过，发布时已过期或发布时间未知则无效
+	version = signedTestVersion()
+	signVersion(t, oldKey, old.Keyid, version)
+	assert.Equal(t, SignatureValid, VerifySignatures(keys, version, before).Status)
+	assert.Equal(t, SignatureInvalid, VerifySignatures(keys, version, after).Status)
+	assert.Equal(t, SignatureInvalid, VerifySignatures(keys, version, time.Time{}).Status)
+
+	// 多个签名需要全部通过
+	version = signedTestVersion()
+	signVersion(t, currentKey, current.Keyid, version)
+	signVersion(t, otherKey, "SHA256:other", version)
+	assert.Equal(t, SignatureUnknownKey, VerifySignatures(keys, version, after).Status)
+}
+
+func TestRegistryVerifySignatures(t *testing.T) {
+	privateKey, key := newSigningKey(t, "SHA256:current", nil)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/-/npm/v1/keys" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&models.RegistryKeys{Keys: []*models.RegistryKey{key}})
+	}))
+	defer server.Close()
+
+	registry := NewRegistry(NewOptions().SetRegistryURL(server.URL))
+	ctx := context.Background()
+
+	keys, err := registry.GetSigningKeys(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, keys.Keys, 1)
+	assert.Equal(t, "SHA256:current", keys.Keys[0].Keyid)
+
+	version := signedTestVersion()
+	signVersion(t, privateKey, key.Keyid, version)
+	for i := 0; i < 2; i++ {
+		result, err := registry.VerifySignatures(ctx, version, time.Now())
+		assert.Nil(t, err)
+		assert.Equal(t, SignatureValid, result.Status)
+	}
+	// 公钥只会获取一次
+	assert.Equal(t, 2, requests)
+}
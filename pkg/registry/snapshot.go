@@ -0,0 +1,387 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/store"
+)
+
+// ErrNotInSnapshot 表示离线模式下快照中没有请求的数据，或者请求需要访问网络
+var ErrNotInSnapshot = errors.New("not in snapshot")
+
+// 快照目录中 Registry 级别数据的位置，与包目录使用同样的 "-" 前缀，不会与包名冲突
+const (
+	snapshotSearchIndex = "-/search.jsonl"
+	snapshotDownloads   = "-/downloads"
+)
+
+// BuildSnapshotIndex 为快照目录生成 SearchPackages 使用的本地搜索索引，快照内容变化后需要重新生成
+//
+// 快照目录的结构与 store.FileStore 相同，另外包含 Registry 级别的数据:
+//
+//	<dir>/react/index.json                          包元数据
+//	<dir>/react/18.2.0/index.json                   单独保存的版本元数据（可选）
+//	<dir>/react/-/react-18.2.0.tgz                  tarball（可选）
+//	<dir>/-/downloads/last-week/react.json          下载统计，由 WriteSnapshotDownloadStats 写入
+//	<dir>/-/search.jsonl                            搜索索引，由本函数生成
+//
+// 索引中每个包一行，内容取自 dist-tags.latest 指向的版本，格式与搜索接口返回的 package 字段相同
+//
+// 参数:
+//   - ctx: 上下文，可用于取消生成
+//   - dir: 快照目录
+//
+// 返回值:
+//   - error: 读取包元数据或写入索引失败时返回错误
+//
+// 使用示例:
+//
+//	if err := registry.BuildSnapshotIndex(ctx, "/data/npm-snapshot"); err != nil {
+//		// 处理错误
+//	}
+func BuildSnapshotIndex(ctx context.Context, dir string) (err error) {
+	s, err := openSnapshot(dir)
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(dir, filepath.FromSlash(snapshotSearchIndex))
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(filename), ".search-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	err = s.ListPackages(ctx, func(name string) error {
+		pkg, err := s.GetPackage(ctx, name)
+		if err != nil {
+			return err
+		}
+		return encoder.Encode(searchPackageOf(pkg))
+	})
+	if err != nil {
+		return err
+	}
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filename)
+}
+
+// WriteSnapshotDownloadStats 把一个包的下载统计写入快照目录，离线模式下 GetDownloadStats 会读取它
+//
+// 参数:
+//   - dir: 快照目录
+//   - period: 统计周期，与 GetDownloadStats 的参数相同，例如 "last-week"
+//   - stats: 下载统计，包名取自 stats.Package
+//
+// 返回值:
+//   - error: 包名或周期不合法，或者写入失败时返回错误
+func WriteSnapshotDownloadStats(dir, period string, stats *models.DownloadStats) (err error) {
+	filename, err := snapshotDownloadsFile(dir, stats.Package, period)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(filename), ".downloads-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+	if _, err = file.Write(data); err != nil {
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filename)
+}
+
+// snapshotPackage 从快照读取包元数据
+func (x *Registry) snapshotPackage(ctx context.Context, packageName string) (*models.Package, error) {
+	s, err := openSnapshot(x.options.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := s.GetPackage(ctx, packageName)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("package %s: %w", packageName, ErrNotInSnapshot)
+	}
+	return pkg, err
+}
+
+// snapshotPackageJSON 从快照读取包元数据的 JSON
+func (x *Registry) snapshotPackageJSON(ctx context.Context, packageName string) ([]byte, error) {
+	s, err := openSnapshot(x.options.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := s.GetPackageJSON(ctx, packageName)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("package %s: %w", packageName, ErrNotInSnapshot)
+	}
+	return data, err
+}
+
+// snapshotVersion 从快照读取版本元数据，version 也可以是 dist-tag
+func (x *Registry) snapshotVersion(ctx context.Context, packageName, version string) (*models.Version, error) {
+	s, err := openSnapshot(x.options.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := store.LookupVersion(ctx, s, packageName, version)
+	if errors.Is(err, store.ErrNotFound) {
+		if pkg, pkgErr := s.GetPackage(ctx, packageName); pkgErr == nil {
+			if tagged, ok := pkg.DistTags[version]; ok {
+				manifest, err = store.LookupVersion(ctx, s, packageName, tagged)
+			}
+		}
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("version %s@%s: %w", packageName, version, ErrNotInSnapshot)
+	}
+	return manifest, err
+}
+
+// snapshotTarball 从快照读取 tarball 并写入 w，同时校验完整性
+func (x *Registry) snapshotTarball(ctx context.Context, version *models.Version, w io.Writer) error {
+	s, err := openSnapshot(x.options.Snapshot)
+	if err != nil {
+		return err
+	}
+	reader, err := s.OpenTarball(ctx, version.Name, version.Version)
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("tarball of %s@%s: %w", version.Name, version.Version, ErrNotInSnapshot)
+	}
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	checker := newIntegrityChecker(version.Dist)
+	if checker != nil {
+		w = io.MultiWriter(w, checker)
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		return err
+	}
+	if checker != nil {
+		return checker.verify(version.Dist.Tarball)
+	}
+	return nil
+}
+
+// snapshotDownloadStats 从快照读取下载统计
+func (x *Registry) snapshotDownloadStats(packageName, period string) (*models.DownloadStats, error) {
+	filename, err := snapshotDownloadsFile(x.options.Snapshot, packageName, period)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("download stats of %s for %s: %w", packageName, period, ErrNotInSnapshot)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalJson[*models.DownloadStats](data)
+}
+
+// snapshotSearch 使用快照的本地索引搜索
+//
+// 查询按空白拆分为多个关键字，每个关键字都需要出现在包名、关键词或描述中（不区分大小写），
+// 包名完全匹配的得分最高，其次是包名前缀、包名包含、关键词和描述，得分相同时按包名排序
+func (x *Registry) snapshotSearch(query string, limit int) (*models.SearchResult, error) {
+	index, err := x.snapshotIndex()
+	if err != nil {
+		return nil, err
+	}
+	terms := strings.Fields(strings.ToLower(query))
+	var objects []models.SearchObject
+	for _, pkg := range index {
+		score, ok := searchScore(pkg, terms)
+		if !ok {
+			continue
+		}
+		objects = append(objects, models.SearchObject{
+			Package:     *pkg,
+			Score:       models.Score{Final: score},
+			SearchScore: score,
+		})
+	}
+	sort.SliceStable(objects, func(i, j int) bool {
+		if objects[i].SearchScore != objects[j].SearchScore {
+			return objects[i].SearchScore > objects[j].SearchScore
+		}
+		return objects[i].Package.Name < objects[j].Package.Name
+	})
+	result := &models.SearchResult{Total: len(objects)}
+	if len(objects) > limit {
+		objects = objects[:limit]
+	}
+	result.Objects = objects
+	return result, nil
+}
+
+// snapshotIndex 返回快照的搜索索引，第一次调用时从磁盘加载
+func (x *Registry) snapshotIndex() ([]*models.SearchPackage, error) {
+	x.indexLock.Lock()
+	defer x.indexLock.Unlock()
+	if x.index != nil {
+		return x.index, nil
+	}
+	file, err := os.Open(filepath.Join(x.options.Snapshot, filepath.FromSlash(snapshotSearchIndex)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("search index: %w, run BuildSnapshotIndex first", ErrNotInSnapshot)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	index := make([]*models.SearchPackage, 0)
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for {
+		var pkg models.SearchPackage
+		if err := decoder.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("read search index: %w", err)
+		}
+		index = append(index, &pkg)
+	}
+	x.index = index
+	return index, nil
+}
+
+// searchScore 计算包与关键字的匹配得分，所有关键字都匹配时返回 true
+func searchScore(pkg *models.SearchPackage, terms []string) (float64, bool) {
+	if len(terms) == 0 {
+		return 0, false
+	}
+	name := strings.ToLower(pkg.Name)
+	description := strings.ToLower(pkg.Description)
+	var total float64
+	for _, term := range terms {
+		var score float64
+		switch {
+		case name == term:
+			score = 1
+		case strings.HasPrefix(name, term):
+			score = 0.8
+		case strings.Contains(name, term):
+			score = 0.6
+		}
+		for _, keyword := range pkg.Keywords {
+			if strings.EqualFold(keyword, term) && score < 0.5 {
+				score = 0.5
+			}
+		}
+		if score == 0 && strings.Contains(description, term) {
+			score = 0.3
+		}
+		if score == 0 {
+			return 0, false
+		}
+		total += score
+	}
+	return total / float64(len(terms)), true
+}
+
+// searchPackageOf 根据包元数据生成搜索索引中的一行，内容取自最新版本
+func searchPackageOf(pkg *models.Package) *models.SearchPackage {
+	result := &models.SearchPackage{
+		Name:        pkg.Name,
+		Scope:       "unscoped",
+		Description: pkg.Description,
+		Keywords:    pkg.Keywords,
+		Links: models.Links{
+			NPM:        "https://www.npmjs.com/package/" + pkg.Name,
+			Homepage:   pkg.Homepage,
+			Repository: pkg.Repository.URL,
+		},
+	}
+	if scope, _, ok := strings.Cut(pkg.Name, "/"); ok && strings.HasPrefix(scope, "@") {
+		result.Scope = strings.TrimPrefix(scope, "@")
+	}
+	latest := pkg.DistTags["latest"]
+	result.Version = latest
+	result.Date = pkg.Time[latest]
+	if version, ok := pkg.Versions[latest]; ok {
+		if version.Description != "" {
+			result.Description = version.Description
+		}
+		if len(version.Keywords) > 0 {
+			result.Keywords = version.Keywords
+		}
+		if version.Homepage != "" {
+			result.Links.Homepage = version.Homepage
+		}
+		if version.Repository != nil && version.Repository.URL != "" {
+			result.Links.Repository = version.Repository.URL
+		}
+		if version.Bugs != nil {
+			result.Links.Bugs = version.Bugs.URL
+		}
+		result.Author = version.Author
+		result.Publisher = version.NpmUser
+		result.Maintainers = version.Maintainers
+	}
+	return result
+}
+
+// openSnapshot 打开快照目录，目录不存在时返回错误而不是创建
+func openSnapshot(dir string) (*store.FileStore, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open snapshot: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("open snapshot: %s is not a directory", dir)
+	}
+	return store.NewFileStore(dir)
+}
+
+// snapshotDownloadsFile 返回下载统计文件的路径
+func snapshotDownloadsFile(dir, packageName, period string) (string, error) {
+	filename := filepath.Join(period, filepath.FromSlash(packageName)+".json")
+	if packageName == "" || period == "" || strings.ContainsAny(period, "/\\") || !filepath.IsLocal(filename) {
+		return "", fmt.Errorf("invalid download stats key %q for %q", packageName, period)
+	}
+	return filepath.Join(dir, filepath.FromSlash(snapshotDownloads), filename), nil
+}
+
+// errOffline 返回离线模式下访问网络的错误
+func errOffline(targetUrl string) error {
+	return fmt.Errorf("request %s in offline mode: %w", targetUrl, ErrNotInSnapshot)
+}
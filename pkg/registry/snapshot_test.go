@@ -0,0 +1,148 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupSnapshot 创建包含 react、react-dom 和 @types/react 的快照目录
+func setupSnapshot(t *testing.T) string {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := store.NewFileStore(dir)
+	assert.Nil(t, err)
+
+	sum := sha512.Sum512([]byte("react tarball"))
+	assert.Nil(t, s.PutPackage(ctx, &models.Package{
+		Name:     "react",
+		DistTags: map[string]string{"latest": "18.2.0"},
+		Time:     map[string]string{"18.2.0": "2022-06-14T18:58:43.231Z"},
+		Versions: map[string]models.Version{
+			"18.2.0": {
+				Name:        "react",
+				Version:     "18.2.0",
+				Description: "React is a JavaScript library for building user interfaces.",
+				Keywords:    []string{"react"},
+				Dist: &models.Dist{
+					Tarball:   "https://registry.npmjs.org/react/-/react-18.2.0.tgz",
+					Integrity: "sha512-" + base64.StdEncoding.EncodeToString(sum[:]),
+				},
+			},
+		},
+	}))
+	assert.Nil(t, s.PutTarball(ctx, "react", "18.2.0", strings.NewReader("react tarball")))
+	assert.Nil(t, s.PutPackage(ctx, &models.Package{
+		Name:     "react-dom",
+		DistTags: map[string]string{"latest": "18.2.0"},
+		Versions: map[string]models.Version{
+			"18.2.0": {Name: "react-dom", Version: "18.2.0", Description: "React package for working with the DOM."},
+		},
+	}))
+	assert.Nil(t, s.PutPackage(ctx, &models.Package{
+		Name:        "@types/react",
+		Description: "TypeScript definitions for React",
+		DistTags:    map[string]string{"latest": "18.2.0"},
+	}))
+	assert.Nil(t, WriteSnapshotDownloadStats(dir, "last-week", &models.DownloadStats{Package: "react", Downloads: 100}))
+	assert.Nil(t, WriteSnapshotDownloadStats(dir, "last-week", &models.DownloadStats{Package: "@types/react", Downloads: 50}))
+	return dir
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := setupSnapshot(t)
+	r := NewRegistry(NewOptions().SetSnapshot(dir))
+
+	// 没有生成索引时搜索返回错误
+	_, err := r.SearchPackages(ctx, "react", 10)
+	assert.ErrorIs(t, err, ErrNotInSnapshot)
+	assert.Nil(t, BuildSnapshotIndex(ctx, dir))
+
+	pkg, err := r.GetPackageInformation(ctx, "react")
+	assert.Nil(t, err)
+	assert.Equal(t, "18.2.0", pkg.DistTags["latest"])
+	pkg, err = r.GetAbbreviatedPackageInformation(ctx, "@types/react")
+	assert.Nil(t, err)
+	assert.Equal(t, "@types/react", pkg.Name)
+	_, err = r.GetPackageInformation(ctx, "vue")
+	assert.ErrorIs(t, err, ErrNotInSnapshot)
+	data, err := r.GetPackageJSON(ctx, "react")
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"18.2.0"`)
+	_, err = r.GetPackageJSON(ctx, "vue")
+	assert.ErrorIs(t, err, ErrNotInSnapshot)
+
+	version, err := r.GetPackageVersion(ctx, "react", "latest")
+	assert.Nil(t, err)
+	assert.Equal(t, "18.2.0", version.Version)
+	_, err = r.GetPackageVersion(ctx, "react", "17.0.0")
+	assert.ErrorIs(t, err, ErrNotInSnapshot)
+
+	stats, err := r.GetDownloadStats(ctx, "@types/react", "last-week")
+	assert.Nil(t, err)
+	assert.Equal(t, 50, stats.Downloads)
+	_, err = r.GetDownloadStats(ctx, "react", "last-month")
+	assert.ErrorIs(t, err, ErrNotInSnapshot)
+	_, err = r.GetDownloadStats(ctx, "react", "../last-week")
+	assert.NotNil(t, err)
+
+	// tarball 从快照读取并校验完整性
+	var buf bytes.Buffer
+	assert.Nil(t, r.DownloadTarball(ctx, version, &buf))
+	assert.Equal(t, "react tarball", buf.String())
+	version.Dist.Integrity = "sha512-" + base64.StdEncoding.EncodeToString(make([]byte, 64))
+	assert.ErrorIs(t, r.DownloadTarball(ctx, version, &bytes.Buffer{}), ErrIntegrity)
+	dom, err := r.GetPackageVersion(ctx, "react-dom", "18.2.0")
+	assert.Nil(t, err)
+	dom.Dist = &models.Dist{Tarball: "https://registry.npmjs.org/react-dom/-/react-dom-18.2.0.tgz"}
+	assert.ErrorIs(t, r.DownloadTarball(ctx, dom, &bytes.Buffer{}), ErrNotInSnapshot)
+
+	// 其他需要访问网络的方法直接返回错误
+	_, err = r.GetRegistryInformation(ctx)
+	assert.ErrorIs(t, err, ErrNotInSnapshot)
+	_, err = r.GetChanges(ctx, NewChangesOptions())
+	assert.ErrorIs(t, err, ErrNotInSnapshot)
+}
+
+func TestSnapshotSearch(t *testing.T) {
+	ctx := context.Background()
+	dir := setupSnapshot(t)
+	assert.Nil(t, BuildSnapshotIndex(ctx, dir))
+	r := NewRegistry(NewOptions().SetSnapshot(dir))
+
+	result, err := r.SearchPackages(ctx, "React", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.Total)
+	names := make([]string, 0, len(result.Objects))
+	for _, object := range result.Objects {
+		names = append(names, object.Package.Name)
+	}
+	assert.Equal(t, []string{"react", "react-dom", "@types/react"}, names)
+	assert.Equal(t, "types", result.Objects[2].Package.Scope)
+	assert.Equal(t, "unscoped", result.Objects[0].Package.Scope)
+	assert.Equal(t, "2022-06-14T18:58:43.231Z", result.Objects[0].Package.Date)
+
+	// 所有关键字都需要匹配
+	result, err = r.SearchPackages(ctx, "react dom", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Total)
+	assert.Equal(t, "react-dom", result.Objects[0].Package.Name)
+
+	// limit 只限制返回的数量，Total 是全部匹配的数量
+	result, err = r.SearchPackages(ctx, "react", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.Total)
+	assert.Len(t, result.Objects, 1)
+
+	result, err = r.SearchPackages(ctx, "vue", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result.Total)
+}
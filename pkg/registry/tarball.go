@@ -0,0 +1,154 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// npmjsRegistryURL 是 npm 官方仓库的地址，元数据中的 tarball 地址通常指向这里
+const npmjsRegistryURL = "https://registry.npmjs.org"
+
+// DownloadTarball 下载某个版本的 tarball 并写入 w，同时校验完整性
+//
+// 校验规则与 npm 相同:
+//   - 优先使用 dist.integrity（SRI 格式，支持 sha512、sha384、sha256，可以包含多个校验值）
+//   - 没有 integrity 时使用旧版本的 dist.shasum（sha1）
+//   - 两者都没有时不做校验
+//
+// 网络规则:
+//   - 请求使用与元数据相同的代理设置
+//   - 访问令牌只会发送给 RegistryURL 所在的主机，tarball 位于其他主机时不会携带
+//   - 与 npm 的 replace-registry-host 默认行为一致，指向 registry.npmjs.org 的地址会被替换为配置的 RegistryURL
+//   - 离线模式下从快照读取 tarball，快照中没有时返回 ErrNotInSnapshot
+//
+// 注意: 数据是边下载边写入 w 的，校验失败时 w 中已经包含了完整的（错误的）数据，调用方需要自行丢弃
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - version: 包的版本信息，需要包含 dist.tarball
+//   - w: tarball 数据的写入目标
+//
+// 返回值:
+//   - error: 请求失败时返回错误，校验失败时返回 *IntegrityError，可以通过 errors.Is(err, ErrIntegrity) 判断
+//
+// 使用示例:
+//
+//	registry := NewRegistry()
+//	version, _ := registry.GetPackageVersion(ctx, "react", "18.2.0")
+//	var buf bytes.Buffer
+//	if err := registry.DownloadTarball(ctx, version, &buf); err != nil {
+//		if errors.Is(err, ErrIntegrity) {
+//			// tarball 被篡改或损坏
+//		}
+//		// 处理错误
+//	}
+func (x *Registry) DownloadTarball(ctx context.Context, version *models.Version, w io.Writer) error {
+	if version == nil {
+		return errors.New("version is nil")
+	}
+	if version.Dist == nil || version.Dist.Tarball == "" {
+		return fmt.Errorf("%s@%s has no tarball", version.Name, version.Version)
+	}
+	if x.options.Snapshot != "" {
+		return x.snapshotTarball(ctx, version, w)
+	}
+	targetUrl, err := x.tarballURL(version.Dist.Tarball)
+	if err != nil {
+		return err
+	}
+	client, err := x.options.GetHttpClient()
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, targetUrl, nil)
+	if err != nil {
+		return err
+	}
+	if x.shouldAuthorize(targetUrl) {
+		request.Header.Set("Authorization", "Bearer "+x.options.AuthToken)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return &StatusError{URL: targetUrl, StatusCode: response.StatusCode}
+	}
+
+	checker := newIntegrityChecker(version.Dist)
+	if checker != nil {
+		w = io.MultiWriter(w, checker)
+	}
+	if _, err := io.Copy(w, response.Body); err != nil {
+		return fmt.Errorf("download %s: %w", targetUrl, err)
+	}
+	if checker != nil {
+		return checker.verify(targetUrl)
+	}
+	return nil
+}
+
+// DownloadTarballToFile 下载某个版本的 tarball 并保存到文件，规则与 DownloadTarball 相同
+//
+// 数据会先写入同一目录下的临时文件，校验通过后才会重命名为目标文件，
+// 因此下载或校验失败时不会留下不完整或被篡改的文件
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - version: 包的版本信息，需要包含 dist.tarball
+//   - filename: 保存 tarball 的文件路径，所在目录必须已经存在
+//
+// 返回值:
+//   - error: 请求、校验或写入文件失败时返回错误
+//
+// 使用示例:
+//
+//	err := registry.DownloadTarballToFile(ctx, version, "react-18.2.0.tgz")
+func (x *Registry) DownloadTarballToFile(ctx context.Context, version *models.Version, filename string) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(filename), ".download-*.tgz")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+	if err = x.DownloadTarball(ctx, version, file); err != nil {
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filename)
+}
+
+// tarballURL 计算实际请求的 tarball 地址
+//
+// 相对地址基于 RegistryURL 解析，指向 registry.npmjs.org 的地址会被替换为 RegistryURL
+func (x *Registry) tarballURL(tarball string) (string, error) {
+	base := strings.TrimSuffix(x.options.RegistryURL, "/") + "/"
+	if strings.HasPrefix(tarball, npmjsRegistryURL+"/") && strings.TrimSuffix(x.options.RegistryURL, "/") != npmjsRegistryURL {
+		return base + strings.TrimPrefix(tarball, npmjsRegistryURL+"/"), nil
+	}
+	baseUrl, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	target, err := baseUrl.Parse(tarball)
+	if err != nil {
+		return "", err
+	}
+	return target.String(), nil
+}
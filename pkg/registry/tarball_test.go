@@ -0,0 +1,143 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var tarballContent = []byte("fake tarball content")
+
+func sri(algorithm string, data []byte) string {
+	switch algorithm {
+	case "sha256":
+		sum := sha256.Sum256(data)
+		return "sha256-" + base64.StdEncoding.EncodeToString(sum[:])
+	default:
+		sum := sha512.Sum512(data)
+		return "sha512-" + base64.StdEncoding.EncodeToString(sum[:])
+	}
+}
+
+// setupTarballServer 创建提供 tarball 下载的模拟服务器，并记录收到的 Authorization 请求头
+func setupTarballServer(authorization *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*authorization = r.Header.Get("Authorization")
+		if r.URL.Path != "/pkg/-/pkg-1.0.0.tgz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(tarballContent)
+	}))
+}
+
+func versionWithDist(tarball string, dist models.Dist) *models.Version {
+	dist.Tarball = tarball
+	return &models.Version{Name: "pkg", Version: "1.0.0", Dist: &dist}
+}
+
+func TestDownloadTarball(t *testing.T) {
+	var authorization string
+	server := setupTarballServer(&authorization)
+	defer server.Close()
+	registry := NewRegistry(NewOptions().SetRegistryURL(server.URL).SetAuthToken("secret"))
+	tarball := server.URL + "/pkg/-/pkg-1.0.0.tgz"
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	err := registry.DownloadTarball(ctx, versionWithDist(tarball, models.Dist{Integrity: sri("sha512", tarballContent)}), &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, tarballContent, buf.Bytes())
+	assert.Equal(t, "Bearer secret", authorization)
+
+	// 多个校验值时使用最强的算法，任意一个匹配即可
+	integrity := sri("sha256", []byte("other")) + " " + sri("sha512", []byte("other")) + " " + sri("sha512", tarballContent) + "?foo"
+	err = registry.DownloadTarball(ctx, versionWithDist(tarball, models.Dist{Integrity: integrity}), &bytes.Buffer{})
+	assert.Nil(t, err)
+
+	// 只有 sha256 校验值且不匹配
+	err = registry.DownloadTarball(ctx, versionWithDist(tarball, models.Dist{Integrity: sri("sha256", []byte("other"))}), &bytes.Buffer{})
+	assert.True(t, errors.Is(err, ErrIntegrity))
+	var integrityErr *IntegrityError
+	assert.True(t, errors.As(err, &integrityErr))
+	assert.Equal(t, tarball, integrityErr.URL)
+	assert.Equal(t, sri("sha256", tarballContent), integrityErr.Actual)
+
+	// 没有 integrity 时使用 shasum
+	sum := sha1.Sum(tarballContent)
+	err = registry.DownloadTarball(ctx, versionWithDist(tarball, models.Dist{Shasum: hex.EncodeToString(sum[:])}), &bytes.Buffer{})
+	assert.Nil(t, err)
+	err = registry.DownloadTarball(ctx, versionWithDist(tarball, models.Dist{Shasum: "0000"}), &bytes.Buffer{})
+	assert.True(t, errors.Is(err, ErrIntegrity))
+
+	// 相对地址基于 RegistryURL 解析
+	err = registry.DownloadTarball(ctx, versionWithDist("/pkg/-/pkg-1.0.0.tgz", models.Dist{}), &bytes.Buffer{})
+	assert.Nil(t, err)
+
+	err = registry.DownloadTarball(ctx, versionWithDist(server.URL+"/missing.tgz", models.Dist{}), &bytes.Buffer{})
+	assert.False(t, errors.Is(err, ErrIntegrity))
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+
+	err = registry.DownloadTarball(ctx, &models.Version{Name: "pkg", Version: "1.0.0"}, &bytes.Buffer{})
+	assert.NotNil(t, err)
+}
+
+func TestDownloadTarballCredentialRules(t *testing.T) {
+	var registryAuthorization, cdnAuthorization string
+	registryServer := setupTarballServer(&registryAuthorization)
+	defer registryServer.Close()
+	cdnServer := setupTarballServer(&cdnAuthorization)
+	defer cdnServer.Close()
+	registry := NewRegistry(NewOptions().SetRegistryURL(registryServer.URL).SetAuthToken("secret"))
+	ctx := context.Background()
+
+	// 其他主机上的 tarball 不携带访问令牌
+	err := registry.DownloadTarball(ctx, versionWithDist(cdnServer.URL+"/pkg/-/pkg-1.0.0.tgz", models.Dist{}), &bytes.Buffer{})
+	assert.Nil(t, err)
+	assert.Empty(t, cdnAuthorization)
+
+	// 指向官方仓库的地址被替换为配置的仓库
+	err = registry.DownloadTarball(ctx, versionWithDist("https://registry.npmjs.org/pkg/-/pkg-1.0.0.tgz", models.Dist{}), &bytes.Buffer{})
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer secret", registryAuthorization)
+}
+
+func TestDownloadTarballToFile(t *testing.T) {
+	var authorization string
+	server := setupTarballServer(&authorization)
+	defer server.Close()
+	registry := NewRegistry(NewOptions().SetRegistryURL(server.URL))
+	tarball := server.URL + "/pkg/-/pkg-1.0.0.tgz"
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	filename := filepath.Join(dir, "pkg-1.0.0.tgz")
+	err := registry.DownloadTarballToFile(ctx, versionWithDist(tarball, models.Dist{Integrity: sri("sha512", tarballContent)}), filename)
+	assert.Nil(t, err)
+	data, err := os.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, tarballContent, data)
+	assert.Empty(t, authorization)
+
+	// 校验失败时不会留下文件
+	bad := filepath.Join(dir, "bad.tgz")
+	err = registry.DownloadTarballToFile(ctx, versionWithDist(tarball, models.Dist{Integrity: sri("sha512", []byte("other"))}), bad)
+	assert.True(t, errors.Is(err, ErrIntegrity))
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,145 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// ErrPackageNotFound 表示 Registry 中不存在该包
+var ErrPackageNotFound = errors.New("package not found")
+
+// FetchFunc 获取包元数据的函数，Registry 的 GetPackageInformation 和 GetAbbreviatedPackageInformation 都满足该签名
+type FetchFunc func(ctx context.Context, packageName string) (*models.Package, error)
+
+// MetadataCache 是一个并发安全的包元数据缓存
+//
+// 同一个包的并发请求会被合并为一次网络请求，成功获取的元数据和"包不存在"的结果会被缓存，
+// 其他错误（例如网络超时）不会被缓存，下次获取时会重新请求。
+// 同一个缓存可以被多个 Resolver 共享。
+//
+// 合并后的请求不使用任何一个调用方的上下文，而是使用独立的上下文和 SetTimeout 设置的超时时间，
+// 某个调用方被取消时只有它自己返回错误，其他等待同一个包的调用方不受影响。
+//
+// 使用示例:
+//
+//	reg := registry.NewRegistry()
+//	cache := resolver.NewMetadataCache(reg.GetAbbreviatedPackageInformation)
+//	pkg, err := cache.Get(ctx, "express")
+type MetadataCache struct {
+	fetch   FetchFunc
+	timeout time.Duration
+	lock    sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	done chan struct{}
+	pkg  *models.Package
+	err  error
+}
+
+// defaultLoadTimeout 是获取一个包的元数据的默认超时时间
+const defaultLoadTimeout = time.Minute
+
+// NewMetadataCache 创建一个新的元数据缓存
+//
+// 参数:
+//   - fetch: 缓存未命中时用于获取元数据的函数
+//
+// 返回值:
+//   - *MetadataCache: 新创建的缓存
+func NewMetadataCache(fetch FetchFunc) *MetadataCache {
+	return &MetadataCache{
+		fetch:   fetch,
+		timeout: defaultLoadTimeout,
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// SetTimeout 设置获取一个包的元数据的超时时间，默认为 1 分钟，小于等于 0 时不限制
+func (x *MetadataCache) SetTimeout(timeout time.Duration) *MetadataCache {
+	x.timeout = timeout
+	return x
+}
+
+// Get 获取包的元数据，优先从缓存中读取
+//
+// 参数:
+//   - ctx: 上下文，可用于取消请求或设置超时
+//   - packageName: 包名称
+//
+// 返回值:
+//   - *models.Package: 包的元数据
+//   - error: 如果包不存在返回包装了 ErrPackageNotFound 的错误，请求失败时返回对应的错误
+func (x *MetadataCache) Get(ctx context.Context, packageName string) (*models.Package, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	x.lock.Lock()
+	entry, ok := x.entries[packageName]
+	if !ok {
+		entry = &cacheEntry{done: make(chan struct{})}
+		x.entries[packageName] = entry
+		go x.load(packageName, entry)
+	}
+	x.lock.Unlock()
+
+	select {
+	case <-entry.done:
+		return entry.pkg, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Put 直接向缓存中放入包的元数据，例如从本地快照中加载的数据
+func (x *MetadataCache) Put(pkg *models.Package) {
+	entry := &cacheEntry{done: make(chan struct{}), pkg: pkg}
+	close(entry.done)
+	x.lock.Lock()
+	x.entries[pkg.Name] = entry
+	x.lock.Unlock()
+}
+
+// Len 返回缓存中已完成加载的包数量
+func (x *MetadataCache) Len() int {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	n := 0
+	for _, entry := range x.entries {
+		select {
+		case <-entry.done:
+			n++
+		default:
+		}
+	}
+	return n
+}
+
+// load 使用独立的上下文获取元数据，结果由所有等待该包的调用方共享
+func (x *MetadataCache) load(packageName string, entry *cacheEntry) {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if x.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, x.timeout)
+	}
+	defer cancel()
+
+	pkg, err := x.fetch(ctx, packageName)
+	if err == nil && (pkg == nil || (pkg.Name == "" && len(pkg.Versions) == 0)) {
+		// Registry 对不存在的包会返回 {"error":"Not Found"}，解析后是一个空对象
+		err = fmt.Errorf("%w: %s", ErrPackageNotFound, packageName)
+		pkg = nil
+	}
+	entry.pkg, entry.err = pkg, err
+	if err != nil && !errors.Is(err, ErrPackageNotFound) {
+		x.lock.Lock()
+		delete(x.entries, packageName)
+		x.lock.Unlock()
+	}
+	close(entry.done)
+}
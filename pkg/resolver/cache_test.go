@@ -0,0 +1,68 @@
+package resolver
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataCacheCancelledCaller(t *testing.T) {
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	cache := NewMetadataCache(func(ctx context.Context, packageName string) (*models.Package, error) {
+		atomic.AddInt32(&calls, 1)
+		close(started)
+		select {
+		case <-release:
+			return &models.Package{Name: packageName}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+
+	// 第一个调用方发起请求后被取消，第二个调用方等待同一个请求
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := cache.Get(ctx, "react")
+		first <- err
+	}()
+	<-started
+	second := make(chan *models.Package, 1)
+	go func() {
+		pkg, err := cache.Get(context.Background(), "react")
+		assert.Nil(t, err)
+		second <- pkg
+	}()
+
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+	close(release)
+	pkg := <-second
+	if assert.NotNil(t, pkg) {
+		assert.Equal(t, "react", pkg.Name)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 结果已经缓存，已取消的上下文直接返回错误
+	_, err := cache.Get(ctx, "react")
+	assert.ErrorIs(t, err, context.Canceled)
+	pkg, err = cache.Get(context.Background(), "react")
+	assert.Nil(t, err)
+	assert.Equal(t, "react", pkg.Name)
+}
+
+func TestMetadataCacheTimeout(t *testing.T) {
+	cache := NewMetadataCache(func(ctx context.Context, packageName string) (*models.Package, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}).SetTimeout(10 * time.Millisecond)
+	_, err := cache.Get(context.Background(), "slow")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, cache.Len())
+}
@@ -0,0 +1,190 @@
+package resolver
+
+import (
+	"sort"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// DependencyType 表示依赖关系的类型
+type DependencyType string
+
+const (
+	DependencyTypeProd     DependencyType = "prod"     // dependencies
+	DependencyTypeDev      DependencyType = "dev"      // devDependencies
+	DependencyTypeOptional DependencyType = "optional" // optionalDependencies
+	DependencyTypePeer     DependencyType = "peer"     // peerDependencies
+)
+
+// Node 表示依赖图中的一个节点，即某个包的一个具体版本
+//
+// 相同名称和版本的包在图中只会出现一次，多个依赖方会指向同一个节点
+//
+// 主要字段说明:
+//   - Name: 包在 Registry 中的名称
+//   - Version: 解析得到的版本号
+//   - Integrity: 包的完整性校验值，来自 dist.integrity
+//   - Resolved: tarball 的下载地址，来自 dist.tarball
+//   - Manifest: 该版本的完整元数据
+//   - Dependencies: 该节点的出边，按依赖类型和名称排序
+type Node struct {
+	Name         string
+	Version      string
+	Integrity    string
+	Resolved     string
+	Manifest     *models.Version
+	Dependencies []*Edge
+}
+
+// ID 返回节点的唯一标识，格式为 "name@version"
+func (x *Node) ID() string {
+	return nodeID(x.Name, x.Version)
+}
+
+// Edge 表示依赖图中的一条边，即一条依赖声明
+//
+// 主要字段说明:
+//   - From: 声明该依赖的节点，根依赖为 nil
+//   - Name: 依赖名称，别名依赖时为别名
+//   - Spec: 原始的版本声明
+//   - Type: 依赖类型
+//   - To: 解析得到的节点，可选依赖解析失败时为 nil
+//   - Error: 可选依赖解析失败的原因
+type Edge struct {
+	From  *Node
+	Name  string
+	Spec  string
+	Type  DependencyType
+	To    *Node
+	Error error
+}
+
+// Graph 表示完整的传递依赖图
+//
+// 主要字段说明:
+//   - Roots: 根依赖，即调用 Resolve 时传入的声明或根清单中的依赖
+//   - Nodes: 图中所有的节点，键为 "name@version"
+type Graph struct {
+	Roots []*Edge
+	Nodes map[string]*Node
+}
+
+// NewGraph 创建一个空的依赖图
+func NewGraph() *Graph {
+	return &Graph{Nodes: make(map[string]*Node)}
+}
+
+// Node 根据名称和版本查找节点，不存在时返回 nil
+func (x *Graph) Node(name, version string) *Node {
+	return x.Nodes[nodeID(name, version)]
+}
+
+// SortedNodes 返回按名称和版本排序后的所有节点
+func (x *Graph) SortedNodes() []*Node {
+	nodes := make([]*Node, 0, len(x.Nodes))
+	for _, node := range x.Nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Name != nodes[j].Name {
+			return nodes[i].Name < nodes[j].Name
+		}
+		return semver.Compare(nodes[i].Version, nodes[j].Version) < 0
+	})
+	return nodes
+}
+
+// Versions 返回图中每个包名对应的所有版本，版本从小到大排序
+//
+// 返回值:
+//   - map[string][]string: 键为包名，值为该包在图中出现的所有版本
+func (x *Graph) Versions() map[string][]string {
+	versions := make(map[string][]string)
+	for _, node := range x.SortedNodes() {
+		versions[node.Name] = append(versions[node.Name], node.Version)
+	}
+	return versions
+}
+
+// Cycles 返回图中所有的循环依赖
+//
+// 每个循环依赖是一组互相可达的节点（强连通分量），按节点 ID 排序；
+// 依赖自身的节点也会作为只有一个元素的循环返回
+//
+// 返回值:
+//   - [][]*Node: 循环依赖列表，没有循环时返回 nil
+func (x *Graph) Cycles() [][]*Node {
+	t := &tarjan{
+		index:   make(map[*Node]int),
+		lowLink: make(map[*Node]int),
+		onStack: make(map[*Node]bool),
+	}
+	for _, node := range x.SortedNodes() {
+		if _, visited := t.index[node]; !visited {
+			t.visit(node)
+		}
+	}
+	return t.cycles
+}
+
+// tarjan 实现了 Tarjan 强连通分量算法
+type tarjan struct {
+	counter int
+	index   map[*Node]int
+	lowLink map[*Node]int
+	onStack map[*Node]bool
+	stack   []*Node
+	cycles  [][]*Node
+}
+
+func (t *tarjan) visit(node *Node) {
+	t.index[node] = t.counter
+	t.lowLink[node] = t.counter
+	t.counter++
+	t.stack = append(t.stack, node)
+	t.onStack[node] = true
+
+	selfLoop := false
+	for _, edge := range node.Dependencies {
+		next := edge.To
+		if next == nil {
+			continue
+		}
+		if next == node {
+			selfLoop = true
+		}
+		if _, visited := t.index[next]; !visited {
+			t.visit(next)
+			if t.lowLink[next] < t.lowLink[node] {
+				t.lowLink[node] = t.lowLink[next]
+			}
+		} else if t.onStack[next] && t.index[next] < t.lowLink[node] {
+			t.lowLink[node] = t.index[next]
+		}
+	}
+
+	if t.lowLink[node] != t.index[node] {
+		return
+	}
+	var component []*Node
+	for {
+		top := t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+		t.onStack[top] = false
+		component = append(component, top)
+		if top == node {
+			break
+		}
+	}
+	if len(component) > 1 || selfLoop {
+		sort.Slice(component, func(i, j int) bool {
+			return component[i].ID() < component[j].ID()
+		})
+		t.cycles = append(t.cycles, component)
+	}
+}
+
+func nodeID(name, version string) string {
+	return name + "@" + version
+}
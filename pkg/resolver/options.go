@@ -0,0 +1,83 @@
+package resolver
+
+import (
+	"time"
+)
+
+// DefaultConcurrency 默认的最大并发请求数
+const DefaultConcurrency = 8
+
+// Options 表示依赖解析器的配置选项
+//
+// 包含字段:
+//   - Concurrency: 同时向 Registry 发起请求的最大数量
+//   - IncludeOptional: 是否解析 optionalDependencies
+//   - IncludePeer: 是否解析 peerDependencies（npm v7 及以上版本会自动安装同级依赖）
+//   - IncludeDev: 是否解析根清单中的 devDependencies，传递依赖中的开发依赖永远不会被解析
+//   - Before: 如果不为零值，只考虑在该时间之前发布的版本，等价于 npm install --before
+//
+// 使用示例:
+//
+//	options := NewOptions().SetConcurrency(16).SetIncludePeer(false)
+//	r := NewResolver(cache, options)
+type Options struct {
+	Concurrency     int
+	IncludeOptional bool
+	IncludePeer     bool
+	IncludeDev      bool
+	Before          time.Time
+}
+
+// NewOptions 创建并返回与 npm 默认行为一致的配置选项
+//
+// 默认配置:
+//   - Concurrency: 8
+//   - IncludeOptional: true
+//   - IncludePeer: true
+//   - IncludeDev: false
+func NewOptions() *Options {
+	return &Options{
+		Concurrency:     DefaultConcurrency,
+		IncludeOptional: true,
+		IncludePeer:     true,
+	}
+}
+
+// SetConcurrency 设置最大并发请求数，小于等于 0 时使用默认值
+func (o *Options) SetConcurrency(concurrency int) *Options {
+	o.Concurrency = concurrency
+	return o
+}
+
+// SetIncludeOptional 设置是否解析可选依赖
+func (o *Options) SetIncludeOptional(includeOptional bool) *Options {
+	o.IncludeOptional = includeOptional
+	return o
+}
+
+// SetIncludePeer 设置是否解析同级依赖
+func (o *Options) SetIncludePeer(includePeer bool) *Options {
+	o.IncludePeer = includePeer
+	return o
+}
+
+// SetIncludeDev 设置是否解析根清单中的开发依赖
+func (o *Options) SetIncludeDev(includeDev bool) *Options {
+	o.IncludeDev = includeDev
+	return o
+}
+
+// SetBefore 设置只考虑在指定时间之前发布的版本
+//
+// 注意: 精简版元数据中不包含各版本的发布时间，使用该选项时需要通过 GetPackageInformation 获取完整元数据
+func (o *Options) SetBefore(before time.Time) *Options {
+	o.Before = before
+	return o
+}
+
+func (o *Options) concurrency() int {
+	if o.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return o.Concurrency
+}
@@ -0,0 +1,417 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// ErrNoMatchingVersion 表示包中没有满足版本声明的版本
+var ErrNoMatchingVersion = errors.New("no matching version")
+
+// Resolver 依赖解析器，从 Registry 获取元数据并按照 npm 的语义构建完整的传递依赖图
+//
+// 解析规则与 npm 保持一致:
+//   - 版本范围优先选择 dist-tags.latest（如果满足范围），否则选择满足范围的最大未弃用版本
+//   - 相同名称和版本的包会被合并为同一个节点，循环依赖不会导致无限递归
+//   - 可选依赖解析失败时不会中断整体解析，失败原因记录在对应边的 Error 字段中
+//   - 可选的同级依赖（peerDependenciesMeta 中 optional 为 true）不会被解析
+type Resolver struct {
+	cache   *MetadataCache
+	options *Options
+}
+
+// NewResolver 创建一个新的依赖解析器
+//
+// 参数:
+//   - cache: 元数据缓存，可以在多个解析器之间共享
+//   - options: 可选的配置选项，如未提供则使用 NewOptions() 的默认配置
+//
+// 返回值:
+//   - *Resolver: 新创建的依赖解析器
+//
+// 使用示例:
+//
+//	reg := registry.NewRegistry()
+//	cache := resolver.NewMetadataCache(reg.GetAbbreviatedPackageInformation)
+//	r := resolver.NewResolver(cache)
+//	graph, err := r.Resolve(ctx, "express@^4")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	for _, node := range graph.SortedNodes() {
+//		fmt.Println(node.ID(), node.Integrity)
+//	}
+func NewResolver(cache *MetadataCache, options ...*Options) *Resolver {
+	if len(options) == 0 {
+		options = append(options, NewOptions())
+	}
+	return &Resolver{
+		cache:   cache,
+		options: options[0],
+	}
+}
+
+// GetOptions 获取当前解析器的配置选项
+func (x *Resolver) GetOptions() *Options {
+	return x.options
+}
+
+// Resolve 解析一组根依赖声明的完整传递依赖图
+//
+// 参数:
+//   - ctx: 上下文，可用于取消解析
+//   - specs: 根依赖声明，格式与 npm install 的参数相同，例如 "express@^4"、"@types/node"
+//
+// 返回值:
+//   - *Graph: 解析得到的依赖图
+//   - error: 任意非可选依赖解析失败时返回错误
+func (x *Resolver) Resolve(ctx context.Context, specs ...string) (*Graph, error) {
+	edges := make([]*Edge, 0, len(specs))
+	parsed := make([]*Spec, 0, len(specs))
+	for _, raw := range specs {
+		spec, err := ParseSpec(raw)
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, &Edge{Name: spec.Name, Spec: spec.Raw, Type: DependencyTypeProd})
+		parsed = append(parsed, spec)
+	}
+	return x.resolve(ctx, edges, parsed)
+}
+
+// ResolveManifest 解析一个根清单（即项目的 package.json）的完整传递依赖图
+//
+// 参数:
+//   - ctx: 上下文，可用于取消解析
+//   - manifest: 根清单，其中的 dependencies、optionalDependencies、peerDependencies
+//     以及在 IncludeDev 为 true 时的 devDependencies 会作为根依赖
+//
+// 返回值:
+//   - *Graph: 解析得到的依赖图
+//   - error: 任意非可选依赖解析失败时返回错误
+func (x *Resolver) ResolveManifest(ctx context.Context, manifest *models.Version) (*Graph, error) {
+	var edges []*Edge
+	var specs []*Spec
+	for _, dep := range x.dependencies(manifest, true) {
+		spec, err := ParseDependency(dep.Name, dep.Spec)
+		if err != nil {
+			if dep.Type == DependencyTypeOptional {
+				continue
+			}
+			return nil, err
+		}
+		edges = append(edges, &Edge{Name: dep.Name, Spec: dep.Spec, Type: dep.Type})
+		specs = append(specs, spec)
+	}
+	return x.resolve(ctx, edges, specs)
+}
+
+// PickManifest 按照 npm 的规则从包元数据中选择满足声明的版本
+//
+// 参数:
+//   - pkg: 包元数据
+//   - selector: 版本范围或分发标签，例如 "^1.2.0"、"latest"
+//
+// 返回值:
+//   - *models.Version: 选中的版本
+//   - error: 没有满足条件的版本时返回包装了 ErrNoMatchingVersion 的错误
+func PickManifest(pkg *models.Package, selector string) (*models.Version, error) {
+	spec, err := ParseDependency(pkg.Name, selector)
+	if err != nil {
+		return nil, err
+	}
+	version := pickVersion(pkg, spec)
+	if version == "" {
+		return nil, fmt.Errorf("%w for %s@%s", ErrNoMatchingVersion, pkg.Name, selector)
+	}
+	manifest := pkg.Versions[version]
+	return &manifest, nil
+}
+
+func pickVersion(pkg *models.Package, spec *Spec) string {
+	if spec.Type == SpecTypeTag {
+		version := pkg.DistTags[spec.Selector]
+		if _, ok := pkg.Versions[version]; ok {
+			return version
+		}
+		return ""
+	}
+
+	r, err := semver.ParseRange(spec.Selector)
+	if err != nil {
+		return ""
+	}
+	if latest := pkg.DistTags["latest"]; latest != "" {
+		if _, ok := pkg.Versions[latest]; ok && r.Contains(latest) {
+			return latest
+		}
+	}
+
+	var active, deprecated []string
+	for version, manifest := range pkg.Versions {
+		if !r.Contains(version) {
+			continue
+		}
+		if manifest.Deprecated != "" {
+			deprecated = append(deprecated, version)
+		} else {
+			active = append(active, version)
+		}
+	}
+	if best := semver.Max(active, true); best != "" {
+		return best
+	}
+	return semver.Max(deprecated, true)
+}
+
+// Dependency 表示清单中需要解析的一条依赖声明
+//
+// 主要字段说明:
+//   - Name: 依赖名称，别名依赖时为别名
+//   - Spec: 原始的版本声明
+//   - Type: 依赖类型
+type Dependency struct {
+	Name string
+	Spec string
+	Type DependencyType
+}
+
+// Dependencies 按照 npm 的规则选出清单中需要解析的依赖，按依赖类型和名称排序，同一个名称只保留一次
+//
+// 依赖类型的顺序为 optional、prod、dev、peer。同时出现在 dependencies 中的可选依赖以可选依赖为准，
+// 不包含可选依赖时这些依赖会被完全跳过；peerDependenciesMeta 中标记为可选的同级依赖不会被解析
+//
+// 参数:
+//   - manifest: 版本清单
+//   - includeOptional: 是否包含 optionalDependencies
+//   - includeDev: 是否包含 devDependencies
+//   - includePeer: 是否包含 peerDependencies
+//
+// 返回值:
+//   - []Dependency: 需要解析的依赖
+//
+// 使用示例:
+//
+//	for _, dep := range resolver.Dependencies(manifest, true, false, true) {
+//		fmt.Println(dep.Type, dep.Name, dep.Spec)
+//	}
+func Dependencies(manifest *models.Version, includeOptional, includeDev, includePeer bool) []Dependency {
+	var result []Dependency
+	seen := make(map[string]bool)
+	add := func(deps map[string]string, depType DependencyType) {
+		names := make([]string, 0, len(deps))
+		for name := range deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			result = append(result, Dependency{Name: name, Spec: deps[name], Type: depType})
+		}
+	}
+
+	// optionalDependencies 中的依赖同样会出现在 dependencies 中，以可选依赖为准
+	if includeOptional {
+		add(manifest.OptionalDependencies, DependencyTypeOptional)
+	} else {
+		for name := range manifest.OptionalDependencies {
+			seen[name] = true
+		}
+	}
+	add(manifest.Dependencies, DependencyTypeProd)
+	if includeDev {
+		add(manifest.DevDependencies, DependencyTypeDev)
+	}
+	if includePeer {
+		peers := make(map[string]string)
+		for name, spec := range manifest.PeerDependencies {
+			if meta, ok := manifest.PeerDependenciesMeta[name]; ok && meta.Optional {
+				continue
+			}
+			peers[name] = spec
+		}
+		add(peers, DependencyTypePeer)
+	}
+	return result
+}
+
+// dependencies 返回清单中需要解析的依赖，devDependencies 只对根清单解析
+func (x *Resolver) dependencies(manifest *models.Version, isRoot bool) []Dependency {
+	return Dependencies(manifest, x.options.IncludeOptional, isRoot && x.options.IncludeDev, x.options.IncludePeer)
+}
+
+// resolution 保存一次解析过程中的共享状态
+type resolution struct {
+	resolver  *Resolver
+	ctx       context.Context
+	cancel    context.CancelFunc
+	semaphore chan struct{}
+	wait      sync.WaitGroup
+	lock      sync.Mutex
+	graph     *Graph
+	err       error
+}
+
+func (x *Resolver) resolve(ctx context.Context, edges []*Edge, specs []*Spec) (*Graph, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	r := &resolution{
+		resolver:  x,
+		ctx:       ctx,
+		cancel:    cancel,
+		semaphore: make(chan struct{}, x.options.concurrency()),
+		graph:     NewGraph(),
+	}
+	r.graph.Roots = edges
+	r.resolveEdges(nil, edges, specs)
+	r.wait.Wait()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.graph, nil
+}
+
+// expand 解析节点的所有依赖
+func (r *resolution) expand(node *Node) {
+	defer r.wait.Done()
+
+	var edges []*Edge
+	var specs []*Spec
+	for _, dep := range r.resolver.dependencies(node.Manifest, false) {
+		edge := &Edge{From: node, Name: dep.Name, Spec: dep.Spec, Type: dep.Type}
+		spec, err := ParseDependency(dep.Name, dep.Spec)
+		if err != nil {
+			if dep.Type != DependencyTypeOptional {
+				r.fail(fmt.Errorf("%s (required by %s): %w", dep.Name, node.ID(), err))
+				return
+			}
+			// 无法解析的可选依赖保留在原来的位置，spec 为 nil 表示不需要获取
+			edge.Error = err
+		}
+		edges = append(edges, edge)
+		specs = append(specs, spec)
+	}
+	r.resolveEdges(node, edges, specs)
+}
+
+// resolveEdges 并发获取所有边的元数据，然后按顺序将边连接到节点上
+//
+// specs[i] 为 nil 的边是声明无法解析的可选依赖，直接连接到节点上
+func (r *resolution) resolveEdges(from *Node, edges []*Edge, specs []*Spec) {
+	manifests := make([]*models.Version, len(edges))
+	errs := make([]error, len(edges))
+
+	var wait sync.WaitGroup
+	for i := range edges {
+		if specs[i] == nil {
+			continue
+		}
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			manifests[i], errs[i] = r.fetch(specs[i])
+		}(i)
+	}
+	wait.Wait()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for i, edge := range edges {
+		switch {
+		case specs[i] == nil:
+			// Error 已经在解析声明时设置
+		case errs[i] != nil:
+			if edge.Type != DependencyTypeOptional || r.ctx.Err() != nil {
+				required := "root"
+				if from != nil {
+					required = from.ID()
+				}
+				r.failLocked(fmt.Errorf("%s@%s (required by %s): %w", edge.Name, edge.Spec, required, errs[i]))
+				return
+			}
+			edge.Error = errs[i]
+		default:
+			edge.To = r.nodeLocked(manifests[i])
+		}
+		if from != nil {
+			from.Dependencies = append(from.Dependencies, edge)
+		}
+	}
+}
+
+// nodeLocked 获取或创建节点，新创建的节点会被调度解析，调用方必须持有锁
+func (r *resolution) nodeLocked(manifest *models.Version) *Node {
+	id := nodeID(manifest.Name, manifest.Version)
+	if node, ok := r.graph.Nodes[id]; ok {
+		return node
+	}
+	node := &Node{
+		Name:     manifest.Name,
+		Version:  manifest.Version,
+		Manifest: manifest,
+	}
+	if manifest.Dist != nil {
+		node.Integrity = manifest.Dist.Integrity
+		node.Resolved = manifest.Dist.Tarball
+	}
+	r.graph.Nodes[id] = node
+	r.wait.Add(1)
+	go r.expand(node)
+	return node
+}
+
+// fetch 获取依赖的元数据并选出满足声明的版本
+func (r *resolution) fetch(spec *Spec) (*models.Version, error) {
+	select {
+	case r.semaphore <- struct{}{}:
+	case <-r.ctx.Done():
+		return nil, r.ctx.Err()
+	}
+	pkg, err := r.resolver.cache.Get(r.ctx, spec.PackageName)
+	<-r.semaphore
+	if err != nil {
+		return nil, err
+	}
+	if !r.resolver.options.Before.IsZero() {
+		pkg = pkg.AsOf(r.resolver.options.Before)
+	}
+	version := pickVersion(pkg, spec)
+	if version == "" {
+		return nil, fmt.Errorf("%w for %s@%s", ErrNoMatchingVersion, spec.PackageName, spec.Selector)
+	}
+	manifest := pkg.Versions[version]
+	if manifest.Name == "" {
+		manifest.Name = pkg.Name
+	}
+	if manifest.Version == "" {
+		manifest.Version = version
+	}
+	return &manifest, nil
+}
+
+func (r *resolution) fail(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.failLocked(err)
+}
+
+func (r *resolution) failLocked(err error) {
+	if r.err == nil {
+		r.err = err
+		r.cancel()
+	}
+}
@@ -0,0 +1,379 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRegistry 是一个内存中的 Registry，用于在没有网络的情况下测试解析器
+type fakeRegistry struct {
+	packages map[string]*models.Package
+	calls    map[string]int
+	inFlight int32
+	maxSeen  int32
+	lock     sync.Mutex
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		packages: make(map[string]*models.Package),
+		calls:    make(map[string]int),
+	}
+}
+
+// add 添加一个版本，deps 中的键为依赖名，值为版本范围
+func (x *fakeRegistry) add(name, version string, deps map[string]string, mutate ...func(v *models.Version)) {
+	pkg, ok := x.packages[name]
+	if !ok {
+		pkg = &models.Package{
+			Name:     name,
+			DistTags: map[string]string{},
+			Versions: map[string]models.Version{},
+			Time:     map[string]string{},
+		}
+		x.packages[name] = pkg
+	}
+	v := models.Version{
+		Name:         name,
+		Version:      version,
+		Dependencies: deps,
+		Dist: &models.Dist{
+			Integrity: "sha512-" + name + version,
+			Tarball:   fmt.Sprintf("https://registry.example.com/%s/-/%s-%s.tgz", name, name, version),
+		},
+	}
+	for _, m := range mutate {
+		m(&v)
+	}
+	pkg.Versions[version] = v
+	pkg.DistTags["latest"] = version
+}
+
+func (x *fakeRegistry) fetch(ctx context.Context, name string) (*models.Package, error) {
+	n := atomic.AddInt32(&x.inFlight, 1)
+	defer atomic.AddInt32(&x.inFlight, -1)
+	for {
+		seen := atomic.LoadInt32(&x.maxSeen)
+		if n <= seen || atomic.CompareAndSwapInt32(&x.maxSeen, seen, n) {
+			break
+		}
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	x.calls[name]++
+	if pkg, ok := x.packages[name]; ok {
+		return pkg, nil
+	}
+	// 与真实 Registry 一样，不存在的包返回空对象
+	return &models.Package{}, nil
+}
+
+func TestResolve(t *testing.T) {
+	reg := newFakeRegistry()
+	reg.add("app-lib", "1.0.0", map[string]string{"a": "^1.0.0", "b": "~2.1.0"})
+	reg.add("a", "1.0.0", nil)
+	reg.add("a", "1.5.0", map[string]string{"c": "*"})
+	reg.add("a", "2.0.0", nil)
+	reg.packages["a"].DistTags["latest"] = "2.0.0"
+	reg.add("b", "2.1.3", map[string]string{"c": "^3.0.0", "aliased": "npm:a@1.0.0"})
+	reg.add("b", "2.2.0", nil)
+	reg.add("c", "3.1.0", nil)
+
+	r := NewResolver(NewMetadataCache(reg.fetch))
+	graph, err := r.Resolve(context.Background(), "app-lib@^1")
+	assert.Nil(t, err)
+	assert.NotNil(t, graph)
+
+	assert.Len(t, graph.Roots, 1)
+	root := graph.Roots[0].To
+	assert.Equal(t, "app-lib@1.0.0", root.ID())
+	assert.Equal(t, "sha512-app-lib1.0.0", root.Integrity)
+	assert.Equal(t, "https://registry.example.com/app-lib/-/app-lib-1.0.0.tgz", root.Resolved)
+
+	// latest 不满足范围时选择满足范围的最大版本
+	assert.NotNil(t, graph.Node("a", "1.5.0"))
+	assert.NotNil(t, graph.Node("b", "2.1.3"))
+	// 别名依赖指向真实的包
+	assert.NotNil(t, graph.Node("a", "1.0.0"))
+	assert.Nil(t, graph.Node("a", "2.0.0"))
+	// c 被两个节点依赖，但只出现一次
+	assert.NotNil(t, graph.Node("c", "3.1.0"))
+	assert.Len(t, graph.Nodes, 5)
+	assert.Equal(t, map[string][]string{
+		"a":       {"1.0.0", "1.5.0"},
+		"app-lib": {"1.0.0"},
+		"b":       {"2.1.3"},
+		"c":       {"3.1.0"},
+	}, graph.Versions())
+
+	// 边按名称排序
+	assert.Len(t, root.Dependencies, 2)
+	assert.Equal(t, "a", root.Dependencies[0].Name)
+	assert.Equal(t, "b", root.Dependencies[1].Name)
+	assert.Equal(t, root, root.Dependencies[0].From)
+
+	b := graph.Node("b", "2.1.3")
+	assert.Equal(t, "aliased", b.Dependencies[0].Name)
+	assert.Equal(t, "npm:a@1.0.0", b.Dependencies[0].Spec)
+	assert.Equal(t, "a@1.0.0", b.Dependencies[0].To.ID())
+
+	// 每个包只请求一次
+	for name, calls := range reg.calls {
+		assert.Equal(t, 1, calls, name)
+	}
+	assert.Empty(t, graph.Cycles())
+}
+
+func TestResolveCycles(t *testing.T) {
+	reg := newFakeRegistry()
+	reg.add("x", "1.0.0", map[string]string{"y": "^1"})
+	reg.add("y", "1.0.0", map[string]string{"z": "^1"})
+	reg.add("z", "1.0.0", map[string]string{"x": "^1", "self": "^1"})
+	reg.add("self", "1.0.0", map[string]string{"self": "^1"})
+
+	graph, err := NewResolver(NewMetadataCache(reg.fetch)).Resolve(context.Background(), "x")
+	assert.Nil(t, err)
+	assert.Len(t, graph.Nodes, 4)
+
+	cycles := graph.Cycles()
+	assert.Len(t, cycles, 2)
+	var ids [][]string
+	for _, cycle := range cycles {
+		var cycleIDs []string
+		for _, node := range cycle {
+			cycleIDs = append(cycleIDs, node.ID())
+		}
+		ids = append(ids, cycleIDs)
+	}
+	assert.Contains(t, ids, []string{"self@1.0.0"})
+	assert.Contains(t, ids, []string{"x@1.0.0", "y@1.0.0", "z@1.0.0"})
+}
+
+func TestResolveOptionalAndPeer(t *testing.T) {
+	reg := newFakeRegistry()
+	reg.add("plugin", "1.0.0", map[string]string{"fsevents": "^2"}, func(v *models.Version) {
+		v.OptionalDependencies = map[string]string{"fsevents": "^2", "gitdep": "github:user/repo"}
+		v.PeerDependencies = map[string]string{"host": "^1", "maybe": "^1"}
+		v.PeerDependenciesMeta = map[string]models.PeerDependencyMeta{"maybe": {Optional: true}}
+	})
+	reg.add("host", "1.2.0", nil)
+
+	graph, err := NewResolver(NewMetadataCache(reg.fetch)).Resolve(context.Background(), "plugin")
+	assert.Nil(t, err)
+
+	plugin := graph.Node("plugin", "1.0.0")
+	assert.NotNil(t, plugin)
+	byName := make(map[string]*Edge)
+	for _, edge := range plugin.Dependencies {
+		byName[edge.Name] = edge
+	}
+	assert.Len(t, byName, 3)
+	assert.Equal(t, DependencyTypeOptional, byName["fsevents"].Type)
+	assert.True(t, errors.Is(byName["fsevents"].Error, ErrPackageNotFound))
+	assert.Nil(t, byName["fsevents"].To)
+	assert.True(t, errors.Is(byName["gitdep"].Error, ErrUnsupportedSpec))
+	assert.Equal(t, DependencyTypePeer, byName["host"].Type)
+	assert.Equal(t, "host@1.2.0", byName["host"].To.ID())
+	assert.NotContains(t, byName, "maybe")
+
+	// 无法解析声明的可选依赖同样按依赖类型和名称排序
+	var names []string
+	for _, edge := range plugin.Dependencies {
+		names = append(names, edge.Name)
+	}
+	assert.Equal(t, []string{"fsevents", "gitdep", "host"}, names)
+
+	// 关闭同级依赖和可选依赖
+	options := NewOptions().SetIncludePeer(false).SetIncludeOptional(false)
+	graph, err = NewResolver(NewMetadataCache(reg.fetch), options).Resolve(context.Background(), "plugin")
+	assert.Nil(t, err)
+	assert.Len(t, graph.Nodes, 1)
+	assert.Empty(t, graph.Node("plugin", "1.0.0").Dependencies)
+}
+
+func TestDependencies(t *testing.T) {
+	manifest := &models.Version{
+		Dependencies:         map[string]string{"b": "^1", "fsevents": "^2", "a": "^1"},
+		OptionalDependencies: map[string]string{"fsevents": "^2"},
+		DevDependencies:      map[string]string{"a": "^2", "jest": "^29"},
+		PeerDependencies:     map[string]string{"host": "^1", "maybe": "^1"},
+		PeerDependenciesMeta: map[string]models.PeerDependencyMeta{"maybe": {Optional: true}},
+	}
+	assert.Equal(t, []Dependency{
+		{Name: "fsevents", Spec: "^2", Type: DependencyTypeOptional},
+		{Name: "a", Spec: "^1", Type: DependencyTypeProd},
+		{Name: "b", Spec: "^1", Type: DependencyTypeProd},
+		{Name: "jest", Spec: "^29", Type: DependencyTypeDev},
+		{Name: "host", Spec: "^1", Type: DependencyTypePeer},
+	}, Dependencies(manifest, true, true, true))
+
+	// 不包含可选依赖时，同时出现在 dependencies 中的可选依赖也被跳过
+	assert.Equal(t, []Dependency{
+		{Name: "a", Spec: "^1", Type: DependencyTypeProd},
+		{Name: "b", Spec: "^1", Type: DependencyTypeProd},
+	}, Dependencies(manifest, false, false, false))
+}
+
+func TestResolveErrors(t *testing.T) {
+	reg := newFakeRegistry()
+	reg.add("broken", "1.0.0", map[string]string{"missing": "^1"})
+	reg.add("old", "1.0.0", nil)
+
+	r := NewResolver(NewMetadataCache(reg.fetch))
+	_, err := r.Resolve(context.Background(), "broken")
+	assert.True(t, errors.Is(err, ErrPackageNotFound))
+	assert.Contains(t, err.Error(), "required by broken@1.0.0")
+
+	_, err = r.Resolve(context.Background(), "old@^2")
+	assert.True(t, errors.Is(err, ErrNoMatchingVersion))
+
+	_, err = r.Resolve(context.Background(), "git@github:user/repo")
+	assert.True(t, errors.Is(err, ErrUnsupportedSpec))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewResolver(NewMetadataCache(reg.fetch)).Resolve(ctx, "old")
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestResolveManifest(t *testing.T) {
+	reg := newFakeRegistry()
+	reg.add("dep", "1.0.0", nil)
+	reg.add("devdep", "1.0.0", nil)
+
+	manifest := &models.Version{
+		Name:            "my-app",
+		Version:         "0.0.0",
+		Dependencies:    map[string]string{"dep": "^1"},
+		DevDependencies: map[string]string{"devdep": "^1"},
+	}
+
+	graph, err := NewResolver(NewMetadataCache(reg.fetch)).ResolveManifest(context.Background(), manifest)
+	assert.Nil(t, err)
+	assert.Len(t, graph.Roots, 1)
+
+	graph, err = NewResolver(NewMetadataCache(reg.fetch), NewOptions().SetIncludeDev(true)).ResolveManifest(context.Background(), manifest)
+	assert.Nil(t, err)
+	assert.Len(t, graph.Roots, 2)
+	assert.Equal(t, DependencyTypeDev, graph.Roots[1].Type)
+	assert.Equal(t, "devdep@1.0.0", graph.Roots[1].To.ID())
+}
+
+func TestResolveBefore(t *testing.T) {
+	reg := newFakeRegistry()
+	reg.add("lib", "1.0.0", nil)
+	reg.add("lib", "1.1.0", nil)
+	reg.packages["lib"].Time = map[string]string{
+		"1.0.0": "2020-01-01T00:00:00Z",
+		"1.1.0": "2022-01-01T00:00:00Z",
+	}
+
+	options := NewOptions().SetBefore(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	graph, err := NewResolver(NewMetadataCache(reg.fetch), options).Resolve(context.Background(), "lib@^1")
+	assert.Nil(t, err)
+	assert.Equal(t, "lib@1.0.0", graph.Roots[0].To.ID())
+}
+
+func TestResolveConcurrencyLimit(t *testing.T) {
+	reg := newFakeRegistry()
+	deps := map[string]string{}
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("leaf-%d", i)
+		reg.add(name, "1.0.0", nil)
+		deps[name] = "^1"
+	}
+	reg.add("wide", "1.0.0", deps)
+
+	graph, err := NewResolver(NewMetadataCache(reg.fetch), NewOptions().SetConcurrency(3)).Resolve(context.Background(), "wide")
+	assert.Nil(t, err)
+	assert.Len(t, graph.Nodes, 21)
+	assert.LessOrEqual(t, atomic.LoadInt32(&reg.maxSeen), int32(3))
+}
+
+func TestPickManifest(t *testing.T) {
+	pkg := &models.Package{
+		Name:     "picky",
+		DistTags: map[string]string{"latest": "1.2.0", "next": "2.0.0-rc.1"},
+		Versions: map[string]models.Version{
+			"1.2.0":      {Version: "1.2.0"},
+			"1.3.0":      {Version: "1.3.0", Deprecated: "broken release"},
+			"1.4.0-rc.1": {Version: "1.4.0-rc.1"},
+			"2.0.0-rc.1": {Version: "2.0.0-rc.1"},
+		},
+	}
+
+	v, err := PickManifest(pkg, "^1.0.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2.0", v.Version, "latest 满足范围时优先选择 latest")
+
+	v, err = PickManifest(pkg, ">=1.2.1 <2")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.3.0", v.Version, "只有弃用版本满足时选择弃用版本")
+
+	v, err = PickManifest(pkg, "next")
+	assert.Nil(t, err)
+	assert.Equal(t, "2.0.0-rc.1", v.Version)
+
+	_, err = PickManifest(pkg, "beta")
+	assert.True(t, errors.Is(err, ErrNoMatchingVersion))
+}
+
+func TestMetadataCache(t *testing.T) {
+	var calls int32
+	failures := int32(1)
+	cache := NewMetadataCache(func(ctx context.Context, name string) (*models.Package, error) {
+		atomic.AddInt32(&calls, 1)
+		if name == "flaky" && atomic.AddInt32(&failures, -1) >= 0 {
+			return nil, errors.New("connection reset")
+		}
+		if name == "missing" {
+			return &models.Package{}, nil
+		}
+		time.Sleep(5 * time.Millisecond)
+		return &models.Package{Name: name}, nil
+	})
+
+	// 并发请求同一个包只会发起一次请求
+	var wait sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			pkg, err := cache.Get(context.Background(), "shared")
+			assert.Nil(t, err)
+			assert.Equal(t, "shared", pkg.Name)
+		}()
+	}
+	wait.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 不存在的包会被缓存
+	_, err := cache.Get(context.Background(), "missing")
+	assert.True(t, errors.Is(err, ErrPackageNotFound))
+	_, err = cache.Get(context.Background(), "missing")
+	assert.True(t, errors.Is(err, ErrPackageNotFound))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// 网络错误不会被缓存
+	_, err = cache.Get(context.Background(), "flaky")
+	assert.NotNil(t, err)
+	pkg, err := cache.Get(context.Background(), "flaky")
+	assert.Nil(t, err)
+	assert.Equal(t, "flaky", pkg.Name)
+
+	cache.Put(&models.Package{Name: "preloaded"})
+	pkg, err = cache.Get(context.Background(), "preloaded")
+	assert.Nil(t, err)
+	assert.Equal(t, "preloaded", pkg.Name)
+	assert.Equal(t, 4, cache.Len())
+}
@@ -0,0 +1,162 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// ErrUnsupportedSpec 表示依赖声明不是来自 Registry 的版本，例如 git 仓库、本地路径或 tarball URL
+var ErrUnsupportedSpec = errors.New("unsupported dependency spec")
+
+// SpecType 表示依赖声明的类型
+type SpecType string
+
+const (
+	// SpecTypeRange 版本范围，例如 "^1.2.3"、"1.x"、"*"
+	SpecTypeRange SpecType = "range"
+
+	// SpecTypeTag 分发标签，例如 "latest"、"next"
+	SpecTypeTag SpecType = "tag"
+)
+
+// Spec 表示一个解析后的依赖声明，例如 "express@^4" 或 package.json 中的 "foo": "npm:bar@^1"
+//
+// 主要字段说明:
+//   - Name: 依赖在 node_modules 中使用的名称（别名依赖时为别名）
+//   - PackageName: 在 Registry 中真实的包名
+//   - Raw: 原始的版本声明，例如 "^4"、"npm:bar@^1"
+//   - Type: 声明类型，版本范围或分发标签
+//   - Selector: 去掉别名前缀之后的版本范围或标签名
+type Spec struct {
+	Name        string
+	PackageName string
+	Raw         string
+	Type        SpecType
+	Selector    string
+}
+
+// IsAlias 判断是否是别名依赖，例如 "foo": "npm:bar@^1"
+func (x *Spec) IsAlias() bool {
+	return x.Name != x.PackageName
+}
+
+// String 返回 "name@raw" 格式的字符串
+func (x *Spec) String() string {
+	return x.Name + "@" + x.Raw
+}
+
+// ParseSpec 解析命令行风格的包声明，例如 "express"、"express@^4"、"@types/node@18"、"react@next"
+//
+// 参数:
+//   - spec: 包声明，没有版本部分时等价于 "name@latest"
+//
+// 返回值:
+//   - *Spec: 解析后的依赖声明
+//   - error: 如果声明不合法或不是 Registry 依赖则返回错误
+//
+// 使用示例:
+//
+//	spec, err := resolver.ParseSpec("@types/node@^18")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	fmt.Println(spec.Name, spec.Selector) // @types/node ^18
+func ParseSpec(spec string) (*Spec, error) {
+	spec = strings.TrimSpace(spec)
+	name, selector := splitNameAndSelector(spec)
+	if !validPackageName(name) {
+		return nil, fmt.Errorf("invalid package spec %q", spec)
+	}
+	if selector == "" {
+		selector = "latest"
+	}
+	return ParseDependency(name, selector)
+}
+
+// ParseDependency 解析 package.json 中的一条依赖声明
+//
+// 参数:
+//   - name: 依赖名称，即 dependencies 中的键
+//   - value: 依赖的版本声明，即 dependencies 中的值，例如 "^1.0.0"、"latest"、"npm:bar@^1"
+//
+// 返回值:
+//   - *Spec: 解析后的依赖声明
+//   - error: 如果是 git、文件路径、URL 等非 Registry 依赖，返回包装了 ErrUnsupportedSpec 的错误
+func ParseDependency(name, value string) (*Spec, error) {
+	raw := value
+	value = strings.TrimSpace(value)
+	result := &Spec{Name: name, PackageName: name, Raw: raw}
+
+	if strings.HasPrefix(value, "npm:") {
+		aliasName, aliasSelector := splitNameAndSelector(strings.TrimPrefix(value, "npm:"))
+		if !validPackageName(aliasName) {
+			return nil, fmt.Errorf("invalid alias spec %q for %s", raw, name)
+		}
+		if aliasSelector == "" {
+			aliasSelector = "latest"
+		}
+		result.PackageName = aliasName
+		value = aliasSelector
+	}
+
+	if isUnsupportedSelector(value) {
+		return nil, fmt.Errorf("%w: %s@%s", ErrUnsupportedSpec, name, raw)
+	}
+
+	result.Selector = value
+	if value == "" || semver.ValidRange(value) {
+		result.Type = SpecTypeRange
+		if value == "" {
+			result.Selector = "*"
+		}
+		return result, nil
+	}
+	if strings.ContainsAny(value, " <>=~^|") {
+		return nil, fmt.Errorf("invalid version range %q for %s", raw, name)
+	}
+	result.Type = SpecTypeTag
+	return result, nil
+}
+
+// splitNameAndSelector 将 "name@selector" 拆分为包名和版本选择器，正确处理作用域包 "@scope/name@selector"
+func splitNameAndSelector(spec string) (string, string) {
+	start := 0
+	if strings.HasPrefix(spec, "@") {
+		start = 1
+	}
+	i := strings.Index(spec[start:], "@")
+	if i < 0 {
+		return spec, ""
+	}
+	i += start
+	return spec[:i], strings.TrimSpace(spec[i+1:])
+}
+
+// isUnsupportedSelector 判断版本声明是否指向 Registry 之外的来源
+func isUnsupportedSelector(value string) bool {
+	for _, prefix := range []string{"git+", "git:", "git@", "github:", "gitlab:", "bitbucket:", "gist:", "http:", "https:", "file:", "link:", "workspace:", "portal:", "patch:"} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	if strings.HasPrefix(value, ".") || strings.HasPrefix(value, "/") || strings.HasPrefix(value, "~/") {
+		return true
+	}
+	// GitHub 简写形式，例如 "user/repo" 或 "user/repo#branch"
+	return strings.Contains(value, "/") && !strings.ContainsAny(value, " <>=")
+}
+
+// validPackageName 对包名做基本的合法性检查，作用域包必须是 "@scope/name" 的形式
+func validPackageName(name string) bool {
+	if name == "" {
+		return false
+	}
+	if !strings.HasPrefix(name, "@") {
+		return !strings.ContainsAny(name, " @")
+	}
+	scope, rest, ok := strings.Cut(name[1:], "/")
+	return ok && scope != "" && rest != "" && !strings.ContainsAny(rest, "/@ ")
+}
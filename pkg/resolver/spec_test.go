@@ -0,0 +1,84 @@
+package resolver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSpec(t *testing.T) {
+	spec, err := ParseSpec("express@^4")
+	assert.Nil(t, err)
+	assert.Equal(t, "express", spec.Name)
+	assert.Equal(t, "express", spec.PackageName)
+	assert.Equal(t, SpecTypeRange, spec.Type)
+	assert.Equal(t, "^4", spec.Selector)
+	assert.False(t, spec.IsAlias())
+	assert.Equal(t, "express@^4", spec.String())
+
+	// 没有版本部分时使用 latest 标签
+	spec, err = ParseSpec("lodash")
+	assert.Nil(t, err)
+	assert.Equal(t, SpecTypeTag, spec.Type)
+	assert.Equal(t, "latest", spec.Selector)
+
+	// 作用域包
+	spec, err = ParseSpec("@types/node@18")
+	assert.Nil(t, err)
+	assert.Equal(t, "@types/node", spec.Name)
+	assert.Equal(t, "18", spec.Selector)
+
+	spec, err = ParseSpec("@babel/core")
+	assert.Nil(t, err)
+	assert.Equal(t, "@babel/core", spec.Name)
+	assert.Equal(t, "latest", spec.Selector)
+
+	spec, err = ParseSpec("react@next")
+	assert.Nil(t, err)
+	assert.Equal(t, SpecTypeTag, spec.Type)
+
+	_, err = ParseSpec("@")
+	assert.NotNil(t, err)
+}
+
+func TestParseDependency(t *testing.T) {
+	// 别名依赖
+	spec, err := ParseDependency("string-width-cjs", "npm:string-width@^4.2.0")
+	assert.Nil(t, err)
+	assert.True(t, spec.IsAlias())
+	assert.Equal(t, "string-width-cjs", spec.Name)
+	assert.Equal(t, "string-width", spec.PackageName)
+	assert.Equal(t, "^4.2.0", spec.Selector)
+	assert.Equal(t, "npm:string-width@^4.2.0", spec.Raw)
+
+	spec, err = ParseDependency("scoped-alias", "npm:@scope/pkg")
+	assert.Nil(t, err)
+	assert.Equal(t, "@scope/pkg", spec.PackageName)
+	assert.Equal(t, "latest", spec.Selector)
+
+	// 空字符串等价于 "*"
+	spec, err = ParseDependency("foo", "")
+	assert.Nil(t, err)
+	assert.Equal(t, SpecTypeRange, spec.Type)
+	assert.Equal(t, "*", spec.Selector)
+
+	// 非 Registry 依赖
+	for _, value := range []string{
+		"git+https://github.com/user/repo.git",
+		"github:user/repo",
+		"user/repo#main",
+		"https://example.com/foo.tgz",
+		"file:../foo",
+		"./local",
+		"workspace:*",
+	} {
+		_, err := ParseDependency("foo", value)
+		assert.True(t, errors.Is(err, ErrUnsupportedSpec), value)
+	}
+
+	// 不合法的版本范围
+	_, err = ParseDependency("foo", ">= abc")
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrUnsupportedSpec))
+}
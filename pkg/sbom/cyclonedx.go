@@ -0,0 +1,254 @@
+package sbom
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/purl"
+)
+
+// CycloneDXSpecVersion 是导出的 CycloneDX 规范版本
+const CycloneDXSpecVersion = "1.5"
+
+type cdxBOM struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     *cdxMetadata    `json:"metadata"`
+	Components   []*cdxComponent `json:"components"`
+	Dependencies []*cdxDepend    `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp"`
+	Tools     *cdxTools     `json:"tools,omitempty"`
+	Component *cdxComponent `json:"component,omitempty"`
+}
+
+type cdxTools struct {
+	Components []*cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type               string                  `json:"type"`
+	BOMRef             string                  `json:"bom-ref,omitempty"`
+	Supplier           *cdxOrganization        `json:"supplier,omitempty"`
+	Group              string                  `json:"group,omitempty"`
+	Name               string                  `json:"name"`
+	Version            string                  `json:"version,omitempty"`
+	Description        string                  `json:"description,omitempty"`
+	Hashes             []*cdxHash              `json:"hashes,omitempty"`
+	Licenses           []*cdxLicenseChoice     `json:"licenses,omitempty"`
+	PURL               string                  `json:"purl,omitempty"`
+	ExternalReferences []*cdxExternalReference `json:"externalReferences,omitempty"`
+}
+
+type cdxOrganization struct {
+	Name    string        `json:"name,omitempty"`
+	Contact []*cdxContact `json:"contact,omitempty"`
+}
+
+type cdxContact struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+type cdxHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+type cdxLicenseChoice struct {
+	License    *cdxLicense `json:"license,omitempty"`
+	Expression string      `json:"expression,omitempty"`
+}
+
+type cdxLicense struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type cdxExternalReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cdxDepend struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// cdxHashAlgorithms 将摘要算法转换为 CycloneDX 的算法名称
+var cdxHashAlgorithms = map[string]string{
+	"sha1":   "SHA-1",
+	"sha256": "SHA-256",
+	"sha384": "SHA-384",
+	"sha512": "SHA-512",
+}
+
+// WriteCycloneDX 以 CycloneDX 1.5 JSON 格式输出 SBOM
+//
+// 组件的 bom-ref 为其 purl，依赖关系写入 dependencies，项目本身作为 metadata.component
+//
+// 参数:
+//   - w: 输出目标
+//   - options: 可选的配置选项，如未提供则使用 NewOptions() 的默认配置
+//
+// 返回值:
+//   - error: 如果写入失败则返回错误
+//
+// 使用示例:
+//
+//	doc := sbom.FromProject(project)
+//	file, _ := os.Create("bom.cdx.json")
+//	defer file.Close()
+//	err := doc.WriteCycloneDX(file)
+func (x *Document) WriteCycloneDX(w io.Writer, options ...*Options) error {
+	if len(options) == 0 {
+		options = append(options, NewOptions())
+	}
+	opts := options[0]
+	timestamp := opts.timestamp()
+
+	serialNumber := opts.SerialNumber
+	if serialNumber == "" {
+		serialNumber = "urn:uuid:" + x.uuid(timestamp)
+	}
+	bom := &cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  CycloneDXSpecVersion,
+		SerialNumber: serialNumber,
+		Version:      1,
+		Metadata: &cdxMetadata{
+			Timestamp: timestamp.Format(time.RFC3339),
+		},
+		Components:   []*cdxComponent{},
+		Dependencies: []*cdxDepend{},
+	}
+	if opts.ToolName != "" {
+		bom.Metadata.Tools = &cdxTools{Components: []*cdxComponent{{
+			Type:    "application",
+			Name:    opts.ToolName,
+			Version: opts.ToolVersion,
+		}}}
+	}
+
+	rootRef := x.rootRef()
+	group, name := splitScope(x.rootName())
+	bom.Metadata.Component = &cdxComponent{
+		Type:    "application",
+		BOMRef:  rootRef,
+		Group:   group,
+		Name:    name,
+		Version: x.Version,
+	}
+	if x.Name != "" && x.Version != "" {
+		bom.Metadata.Component.PURL = rootRef
+	}
+	bom.Dependencies = append(bom.Dependencies, &cdxDepend{Ref: rootRef, DependsOn: nonNil(x.Dependencies)})
+
+	for _, component := range x.Components {
+		group, name := splitScope(component.Name)
+		c := &cdxComponent{
+			Type:        "library",
+			BOMRef:      component.PURL,
+			Supplier:    cdxSupplier(component),
+			Group:       group,
+			Name:        name,
+			Version:     component.Version,
+			Description: component.Description,
+			PURL:        component.PURL,
+		}
+		for _, hash := range component.Hashes {
+			c.Hashes = append(c.Hashes, &cdxHash{Algorithm: cdxHashAlgorithms[hash.Algorithm], Content: hash.Value})
+		}
+		if license := cdxLicenses(component.License); license != nil {
+			c.Licenses = []*cdxLicenseChoice{license}
+		}
+		if component.DownloadURL != "" {
+			c.ExternalReferences = []*cdxExternalReference{{Type: "distribution", URL: component.DownloadURL}}
+		}
+		bom.Components = append(bom.Components, c)
+		bom.Dependencies = append(bom.Dependencies, &cdxDepend{Ref: component.PURL, DependsOn: nonNil(component.Dependencies)})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bom)
+}
+
+// cdxLicenses 将许可证转换为 CycloneDX 的许可证
+//
+// SPDX 许可证列表中的单个许可证使用 id，复合表达式使用 expression，无法识别的许可证使用原始字符串作为名称
+func cdxLicenses(s string) *cdxLicenseChoice {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	e := normalizeLicense(s)
+	switch {
+	case e == nil:
+		return &cdxLicenseChoice{License: &cdxLicense{Name: s}}
+	case e.IsCompound() || e.OrLater:
+		return &cdxLicenseChoice{Expression: e.String()}
+	default:
+		return &cdxLicenseChoice{License: &cdxLicense{ID: e.License}}
+	}
+}
+
+// cdxSupplier 使用维护者作为组件的供应商
+func cdxSupplier(component *Component) *cdxOrganization {
+	var supplier *cdxOrganization
+	for _, maintainer := range component.Maintainers {
+		if maintainer == nil || (maintainer.Name == "" && maintainer.Email == "") {
+			continue
+		}
+		if supplier == nil {
+			supplier = &cdxOrganization{Name: maintainer.Name}
+		}
+		supplier.Contact = append(supplier.Contact, &cdxContact{Name: maintainer.Name, Email: maintainer.Email})
+	}
+	return supplier
+}
+
+// rootName 返回项目名称，未知时使用占位名称
+func (x *Document) rootName() string {
+	if x.Name == "" {
+		return "npm-project"
+	}
+	return x.Name
+}
+
+// rootRef 返回项目本身的引用，项目名称和版本已知时使用 purl
+func (x *Document) rootRef() string {
+	if x.Name != "" && x.Version != "" {
+		return purl.NPM(x.Name, x.Version)
+	}
+	return "root:" + x.rootName()
+}
+
+// uuid 根据文档内容和生成时间计算一个确定性的 UUID（版本 5 格式），相同输入得到相同输出
+func (x *Document) uuid(timestamp time.Time) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s@%s\n%s\n", x.Name, x.Version, timestamp.Format(time.RFC3339))
+	for _, component := range x.Components {
+		fmt.Fprintln(h, component.PURL)
+	}
+	sum := h.Sum(nil)
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// nonNil 将 nil 转换为空切片，使 JSON 输出为 [] 而不是 null
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
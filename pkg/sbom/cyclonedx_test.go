@@ -0,0 +1,108 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/scagogogo/npm-crawler/pkg/lockfile"
+	"github.com/stretchr/testify/assert"
+)
+
+var fixedTime = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// cycloneDXSchema 是 testdata 中的 CycloneDX 1.5 官方 JSON Schema，以及它引用的 SPDX 许可证列表和 JSF 签名 Schema
+var cycloneDXSchema = compileSchema("http://cyclonedx.org/schema/bom-1.5.schema.json", map[string]string{
+	"http://cyclonedx.org/schema/bom-1.5.schema.json":  "testdata/bom-1.5.schema.json",
+	"http://cyclonedx.org/schema/spdx.schema.json":     "testdata/spdx.schema.json",
+	"http://cyclonedx.org/schema/jsf-0.82.schema.json": "testdata/jsf-0.82.schema.json",
+})
+
+// compileSchema 从 testdata 中加载 Schema，resources 为 Schema 的 $id 到文件路径的映射，不访问网络
+func compileSchema(url string, resources map[string]string) *jsonschema.Schema {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+	for id, file := range resources {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		if err := compiler.AddResource(id, bytes.NewReader(data)); err != nil {
+			panic(err)
+		}
+	}
+	return compiler.MustCompile(url)
+}
+
+// validateCycloneDX 使用 CycloneDX 1.5 官方 Schema 校验完整输出，并检查 Schema 无法表达的引用约束
+func validateCycloneDX(t *testing.T, data []byte) map[string]interface{} {
+	var bom map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &bom))
+	assert.Nil(t, cycloneDXSchema.Validate(bom))
+	assert.Equal(t, "1.5", bom["specVersion"])
+
+	refs := make(map[string]bool)
+	addRef := func(component map[string]interface{}) {
+		if ref, ok := component["bom-ref"].(string); ok {
+			assert.False(t, refs[ref], "duplicate bom-ref %s", ref)
+			refs[ref] = true
+		}
+	}
+	addRef(bom["metadata"].(map[string]interface{})["component"].(map[string]interface{}))
+	for _, c := range bom["components"].([]interface{}) {
+		addRef(c.(map[string]interface{}))
+	}
+
+	seen := make(map[string]bool)
+	for _, d := range bom["dependencies"].([]interface{}) {
+		dependency := d.(map[string]interface{})
+		ref := dependency["ref"].(string)
+		assert.True(t, refs[ref], "dependency ref %s is not a bom-ref", ref)
+		assert.False(t, seen[ref], "duplicate dependency ref %s", ref)
+		seen[ref] = true
+		for _, on := range dependency["dependsOn"].([]interface{}) {
+			assert.True(t, refs[on.(string)], "dependsOn %s is not a bom-ref", on)
+		}
+	}
+	return bom
+}
+
+func TestWriteCycloneDX(t *testing.T) {
+	doc := FromGraph("my-app", "1.0.0", testGraph())
+	var buf bytes.Buffer
+	assert.Nil(t, doc.WriteCycloneDX(&buf, NewOptions().SetTimestamp(fixedTime).SetToolVersion("1.0.0")))
+	bom := validateCycloneDX(t, buf.Bytes())
+
+	metadata := bom["metadata"].(map[string]interface{})
+	assert.Equal(t, "2024-06-01T12:00:00Z", metadata["timestamp"])
+	assert.Equal(t, "pkg:npm/my-app@1.0.0", metadata["component"].(map[string]interface{})["bom-ref"])
+
+	components := bom["components"].([]interface{})
+	assert.Len(t, components, 3)
+	cli := components[0].(map[string]interface{})
+	assert.Equal(t, "@sigstore", cli["group"])
+	assert.Equal(t, "cli", cli["name"])
+	assert.Equal(t, "pkg:npm/%40sigstore/cli@0.1.0", cli["purl"])
+	assert.Equal(t, "bdehamer", cli["supplier"].(map[string]interface{})["name"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"license": map[string]interface{}{"id": "Apache-2.0"}}}, cli["licenses"])
+	custom := components[1].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"license": map[string]interface{}{"name": "SEE LICENSE IN LICENSE.md"}}}, custom["licenses"])
+	tslib := components[2].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"expression": "MIT OR Apache-2.0"}}, tslib["licenses"])
+
+	// 相同的输入和时间得到相同的输出
+	var again bytes.Buffer
+	assert.Nil(t, doc.WriteCycloneDX(&again, NewOptions().SetTimestamp(fixedTime).SetToolVersion("1.0.0")))
+	assert.Equal(t, buf.String(), again.String())
+}
+
+func TestWriteCycloneDXWithoutRoot(t *testing.T) {
+	doc := FromProject(&lockfile.Project{})
+	var buf bytes.Buffer
+	assert.Nil(t, doc.WriteCycloneDX(&buf))
+	bom := validateCycloneDX(t, buf.Bytes())
+	assert.Empty(t, bom["components"])
+}
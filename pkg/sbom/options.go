@@ -0,0 +1,82 @@
+package sbom
+
+import (
+	"time"
+)
+
+// DefaultToolName 是写入 SBOM 的生成工具名称
+const DefaultToolName = "npm-crawler"
+
+// DefaultNamespace 是 SPDX documentNamespace 的默认前缀
+const DefaultNamespace = "https://spdx.org/spdxdocs"
+
+// Options 表示 SBOM 导出的配置选项
+//
+// 包含字段:
+//   - Timestamp: 文档的生成时间，为零值时使用当前时间，固定该值可以得到可复现的输出
+//   - ToolName: 生成工具名称
+//   - ToolVersion: 生成工具版本
+//   - Namespace: SPDX documentNamespace 的前缀，最终的命名空间为 "<Namespace>/<name>-<uuid>"
+//   - SerialNumber: CycloneDX 的 serialNumber，格式为 "urn:uuid:..."，为空时根据文档内容生成
+//
+// 使用示例:
+//
+//	options := NewOptions().SetNamespace("https://sbom.example.com").SetToolVersion("1.2.0")
+//	err := doc.WriteSPDX(os.Stdout, options)
+type Options struct {
+	Timestamp    time.Time
+	ToolName     string
+	ToolVersion  string
+	Namespace    string
+	SerialNumber string
+}
+
+// NewOptions 创建并返回默认的配置选项
+//
+// 默认配置:
+//   - ToolName: "npm-crawler"
+//   - Namespace: "https://spdx.org/spdxdocs"
+func NewOptions() *Options {
+	return &Options{
+		ToolName:  DefaultToolName,
+		Namespace: DefaultNamespace,
+	}
+}
+
+// SetTimestamp 设置文档的生成时间
+func (o *Options) SetTimestamp(timestamp time.Time) *Options {
+	o.Timestamp = timestamp
+	return o
+}
+
+// SetToolName 设置生成工具名称
+func (o *Options) SetToolName(name string) *Options {
+	o.ToolName = name
+	return o
+}
+
+// SetToolVersion 设置生成工具版本
+func (o *Options) SetToolVersion(version string) *Options {
+	o.ToolVersion = version
+	return o
+}
+
+// SetNamespace 设置 SPDX documentNamespace 的前缀
+func (o *Options) SetNamespace(namespace string) *Options {
+	o.Namespace = namespace
+	return o
+}
+
+// SetSerialNumber 设置 CycloneDX 的 serialNumber
+func (o *Options) SetSerialNumber(serialNumber string) *Options {
+	o.SerialNumber = serialNumber
+	return o
+}
+
+// timestamp 返回文档的生成时间，精确到秒并使用 UTC
+func (o *Options) timestamp() time.Time {
+	if o.Timestamp.IsZero() {
+		return time.Now().UTC().Truncate(time.Second)
+	}
+	return o.Timestamp.UTC().Truncate(time.Second)
+}
@@ -0,0 +1,271 @@
+package sbom
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/scagogogo/npm-crawler/pkg/license"
+	"github.com/scagogogo/npm-crawler/pkg/lockfile"
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/purl"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+)
+
+// Hash 表示组件的一个摘要
+//
+// 主要字段说明:
+//   - Algorithm: 摘要算法，取值为 "sha1"、"sha256"、"sha384" 或 "sha512"
+//   - Value: 十六进制编码的摘要
+type Hash struct {
+	Algorithm string
+	Value     string
+}
+
+// Component 表示 SBOM 中的一个组件，即一个包的具体版本
+//
+// 主要字段说明:
+//   - Name: 包名称，例如 "@scope/name"
+//   - Version: 版本号
+//   - PURL: 包的 purl，例如 "pkg:npm/%40scope/name@1.0.0"
+//   - Hashes: 来自 dist.integrity 和 dist.shasum 的摘要
+//   - License: 声明的许可证，通常是 SPDX 许可证表达式，没有记录时为空
+//   - Description: 包描述
+//   - DownloadURL: tarball 的下载地址
+//   - Maintainers: 维护者，作为组件的供应商
+//   - Dependencies: 依赖的组件的 purl，已排序
+type Component struct {
+	Name         string
+	Version      string
+	PURL         string
+	Hashes       []Hash
+	License      string
+	Description  string
+	DownloadURL  string
+	Maintainers  []*models.User
+	Dependencies []string
+}
+
+// Document 表示与输出格式无关的 SBOM 文档，可以导出为 CycloneDX 或 SPDX
+//
+// 主要字段说明:
+//   - Name: 被描述的项目名称
+//   - Version: 被描述的项目版本
+//   - Dependencies: 项目直接依赖的组件的 purl，已排序
+//   - Components: 所有组件，按 purl 排序
+type Document struct {
+	Name         string
+	Version      string
+	Dependencies []string
+	Components   []*Component
+}
+
+// Component 根据 purl 查找组件，不存在时返回 nil
+func (x *Document) Component(purl string) *Component {
+	for _, component := range x.Components {
+		if component.PURL == purl {
+			return component
+		}
+	}
+	return nil
+}
+
+// FromGraph 根据依赖图生成 SBOM 文档
+//
+// 参数:
+//   - name: 被描述的项目名称
+//   - version: 被描述的项目版本
+//   - graph: 解析得到的依赖图，节点的 Manifest 用于填充许可证、描述和维护者
+//
+// 返回值:
+//   - *Document: SBOM 文档
+//
+// 使用示例:
+//
+//	graph, _ := resolver.NewResolver(cache).ResolveManifest(ctx, manifest)
+//	doc := sbom.FromGraph(manifest.Name, manifest.Version, graph)
+//	err := doc.WriteCycloneDX(os.Stdout)
+func FromGraph(name, version string, graph *resolver.Graph) *Document {
+	doc := &Document{Name: name, Version: version}
+	for _, node := range graph.SortedNodes() {
+		component := newComponent(node.Name, node.Version, node.Integrity, node.Resolved, node.Manifest)
+		for _, edge := range node.Dependencies {
+			if edge.To != nil {
+				component.Dependencies = append(component.Dependencies, purl.NPM(edge.To.Name, edge.To.Version))
+			}
+		}
+		doc.Components = append(doc.Components, component)
+	}
+	for _, edge := range graph.Roots {
+		if edge.To != nil {
+			doc.Dependencies = append(doc.Dependencies, purl.NPM(edge.To.Name, edge.To.Version))
+		}
+	}
+	doc.normalize()
+	return doc
+}
+
+// FromProject 根据锁文件生成 SBOM 文档
+//
+// 锁文件中通常不包含许可证和维护者，需要先调用 Project.Enrich 从 Registry 获取元数据
+//
+// 参数:
+//   - project: 解析后的锁文件，项目名称和版本来自 Project.Name 和 Project.Version
+//
+// 返回值:
+//   - *Document: SBOM 文档
+func FromProject(project *lockfile.Project) *Document {
+	doc := &Document{Name: project.Name, Version: project.Version}
+	resolve := func(dep *lockfile.Dependency) string {
+		if pkg := project.Resolve(dep); pkg != nil {
+			return purl.NPM(pkg.Name, pkg.Version)
+		}
+		return ""
+	}
+
+	for _, pkg := range project.Packages {
+		component := newComponent(pkg.Name, pkg.Version, pkg.Integrity, pkg.Resolved, pkg.Manifest)
+		for _, dep := range pkg.Dependencies {
+			if purl := resolve(dep); purl != "" {
+				component.Dependencies = append(component.Dependencies, purl)
+			}
+		}
+		doc.Components = append(doc.Components, component)
+	}
+	for _, dep := range project.Dependencies {
+		if purl := resolve(dep); purl != "" {
+			doc.Dependencies = append(doc.Dependencies, purl)
+		}
+	}
+	doc.normalize()
+	return doc
+}
+
+// newComponent 根据包的基本信息和元数据创建组件
+func newComponent(name, version, integrity, resolved string, manifest *models.Version) *Component {
+	component := &Component{
+		Name:        name,
+		Version:     version,
+		PURL:        purl.NPM(name, version),
+		DownloadURL: resolved,
+	}
+	if manifest != nil {
+		component.License = manifest.License
+		component.Description = manifest.Description
+		component.Maintainers = manifest.Maintainers
+		if manifest.Dist != nil {
+			if integrity == "" {
+				integrity = manifest.Dist.Integrity
+			}
+			if component.DownloadURL == "" {
+				component.DownloadURL = manifest.Dist.Tarball
+			}
+			component.Hashes = parseHashes(integrity, manifest.Dist.Shasum)
+			return component
+		}
+	}
+	component.Hashes = parseHashes(integrity, "")
+	return component
+}
+
+// parseHashes 将 SRI 格式的 integrity 和十六进制的 shasum 转换为摘要列表，按算法排序并去重
+func parseHashes(integrity, shasum string) []Hash {
+	seen := make(map[string]bool)
+	var hashes []Hash
+	add := func(algorithm, value string) {
+		key := algorithm + "-" + value
+		if !seen[key] {
+			seen[key] = true
+			hashes = append(hashes, Hash{Algorithm: algorithm, Value: value})
+		}
+	}
+	for _, token := range strings.Fields(integrity) {
+		algorithm, digest, ok := strings.Cut(token, "-")
+		if !ok {
+			continue
+		}
+		switch algorithm {
+		case "sha1", "sha256", "sha384", "sha512":
+		default:
+			continue
+		}
+		digest, _, _ = strings.Cut(digest, "?")
+		raw, err := base64.StdEncoding.DecodeString(digest)
+		if err != nil {
+			continue
+		}
+		add(algorithm, hex.EncodeToString(raw))
+	}
+	if len(shasum) == sha1.Size*2 {
+		if _, err := hex.DecodeString(shasum); err == nil {
+			add("sha1", strings.ToLower(shasum))
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return hashes[i].Algorithm < hashes[j].Algorithm
+	})
+	return hashes
+}
+
+// normalize 对组件和依赖排序去重，保证输出稳定
+func (x *Document) normalize() {
+	sort.Slice(x.Components, func(i, j int) bool {
+		return x.Components[i].PURL < x.Components[j].PURL
+	})
+	x.Dependencies = uniqueSorted(x.Dependencies)
+	for _, component := range x.Components {
+		component.Dependencies = uniqueSorted(component.Dependencies)
+	}
+}
+
+func uniqueSorted(list []string) []string {
+	sort.Strings(list)
+	result := list[:0]
+	for i, item := range list {
+		if i == 0 || item != list[i-1] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// splitScope 将 "@scope/name" 拆分为作用域和名称，非作用域包的作用域为空
+func splitScope(name string) (string, string) {
+	if strings.HasPrefix(name, "@") {
+		if scope, rest, ok := strings.Cut(name, "/"); ok {
+			return scope, rest
+		}
+	}
+	return "", name
+}
+
+// normalizeLicense 使用 license.ParseNormalized 将许可证字符串规范化为 SPDX 许可证表达式
+//
+// 返回值:
+//   - *license.Expression: 规范化后的表达式；为空、无法解析，或者包含不在 SPDX 许可证列表中的标识符时返回 nil，
+//     例如 "SEE LICENSE IN LICENSE.md"、"UNLICENSED" 和 "LicenseRef-Proprietary"
+func normalizeLicense(s string) *license.Expression {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	e, err := license.ParseNormalized(s)
+	if err != nil || !listedLicense(e) {
+		return nil
+	}
+	return e
+}
+
+// listedLicense 判断表达式中的许可证和例外是否都在 SPDX 许可证列表中
+func listedLicense(e *license.Expression) bool {
+	if e.Operator != "" {
+		for _, operand := range e.Operands {
+			if !listedLicense(operand) {
+				return false
+			}
+		}
+		return true
+	}
+	return license.IsLicense(e.License) && (e.Exception == "" || license.IsException(e.Exception))
+}
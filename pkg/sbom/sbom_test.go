@@ -0,0 +1,130 @@
+package sbom
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/lockfile"
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+var testDigest = sha512.Sum512([]byte("tarball"))
+
+func testIntegrity() string {
+	return "sha512-" + base64.StdEncoding.EncodeToString(testDigest[:])
+}
+
+// testGraph 创建一个包含作用域包、复合许可证和非 SPDX 许可证的依赖图
+func testGraph() *resolver.Graph {
+	graph := resolver.NewGraph()
+	cli := &resolver.Node{
+		Name:      "@sigstore/cli",
+		Version:   "0.1.0",
+		Integrity: testIntegrity(),
+		Resolved:  "https://registry.npmjs.org/@sigstore/cli/-/cli-0.1.0.tgz",
+		Manifest: &models.Version{
+			Name:        "@sigstore/cli",
+			Version:     "0.1.0",
+			License:     "Apache-2.0",
+			Description: "Sigstore CLI",
+			Maintainers: []*models.User{{Name: "bdehamer", Email: "bdehamer@github.com"}},
+			Dist:        &models.Dist{Shasum: "0123456789abcdef0123456789abcdef01234567"},
+		},
+	}
+	tslib := &resolver.Node{
+		Name:     "tslib",
+		Version:  "2.6.2",
+		Manifest: &models.Version{Name: "tslib", Version: "2.6.2", License: "(MIT OR Apache-2.0)"},
+	}
+	custom := &resolver.Node{
+		Name:     "custom",
+		Version:  "1.0.0+build.1",
+		Manifest: &models.Version{Name: "custom", Version: "1.0.0+build.1", License: "SEE LICENSE IN LICENSE.md"},
+	}
+	cli.Dependencies = []*resolver.Edge{
+		{From: cli, Name: "tslib", Type: resolver.DependencyTypeProd, To: tslib},
+		{From: cli, Name: "missing", Type: resolver.DependencyTypeOptional},
+	}
+	for _, node := range []*resolver.Node{cli, tslib, custom} {
+		graph.Nodes[node.ID()] = node
+	}
+	graph.Roots = []*resolver.Edge{
+		{Name: "@sigstore/cli", Type: resolver.DependencyTypeProd, To: cli},
+		{Name: "custom", Type: resolver.DependencyTypeDev, To: custom},
+	}
+	return graph
+}
+
+func TestFromGraph(t *testing.T) {
+	doc := FromGraph("my-app", "1.0.0", testGraph())
+	assert.Equal(t, "my-app", doc.Name)
+	assert.Equal(t, []string{"pkg:npm/%40sigstore/cli@0.1.0", "pkg:npm/custom@1.0.0%2Bbuild.1"}, doc.Dependencies)
+	assert.Len(t, doc.Components, 3)
+
+	cli := doc.Component("pkg:npm/%40sigstore/cli@0.1.0")
+	assert.NotNil(t, cli)
+	assert.Equal(t, "Apache-2.0", cli.License)
+	assert.Equal(t, []string{"pkg:npm/tslib@2.6.2"}, cli.Dependencies)
+	assert.Equal(t, []Hash{
+		{Algorithm: "sha1", Value: "0123456789abcdef0123456789abcdef01234567"},
+		{Algorithm: "sha512", Value: hex.EncodeToString(testDigest[:])},
+	}, cli.Hashes)
+	assert.Equal(t, "bdehamer", cli.Maintainers[0].Name)
+}
+
+func TestFromProject(t *testing.T) {
+	project := &lockfile.Project{
+		Name:    "my-app",
+		Version: "1.0.0",
+		Dependencies: []*lockfile.Dependency{
+			{Name: "lodash", Spec: "^4.17.0", Version: "4.17.21"},
+			{Name: "my-lodash", Spec: "npm:lodash@^3", Version: "3.10.1"},
+		},
+		Packages: []*lockfile.Package{
+			{Name: "lodash", Version: "3.10.1", Integrity: testIntegrity()},
+			{Name: "lodash", Version: "4.17.21", Resolved: "https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz"},
+			{Name: "wrapper", Version: "1.0.0", Dependencies: []*lockfile.Dependency{
+				{Name: "lodash", Spec: "4.17.21", Version: "4.17.21"},
+				{Name: "peer", Spec: "^1.0.0"},
+			}},
+		},
+	}
+	doc := FromProject(project)
+	assert.Equal(t, []string{"pkg:npm/lodash@3.10.1", "pkg:npm/lodash@4.17.21"}, doc.Dependencies)
+	assert.Equal(t, []string{"pkg:npm/lodash@4.17.21"}, doc.Component("pkg:npm/wrapper@1.0.0").Dependencies)
+	assert.Equal(t, "https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz", doc.Component("pkg:npm/lodash@4.17.21").DownloadURL)
+	assert.Len(t, doc.Component("pkg:npm/lodash@3.10.1").Hashes, 1)
+}
+
+func TestNormalizeLicense(t *testing.T) {
+	cases := map[string]string{
+		"MIT":                            "MIT",
+		"mit":                            "MIT",
+		"Apache 2.0":                     "Apache-2.0",
+		"GPL-2.0+":                       "GPL-2.0-or-later",
+		"(MIT OR Apache-2.0)":            "MIT OR Apache-2.0",
+		"Apache-2.0 WITH LLVM-exception": "Apache-2.0 WITH LLVM-exception",
+		"MIT AND (BSD-2-Clause OR ISC)":  "MIT AND (BSD-2-Clause OR ISC)",
+		"UNLICENSED":                     "",
+		"LicenseRef-Proprietary":         "",
+		"MIT OR Custom-1.0":              "",
+		"MIT WITH Custom-exception":      "",
+		"SEE LICENSE IN LICENSE.md":      "",
+		"MIT OR":                         "",
+		"":                               "",
+	}
+	for license, expected := range cases {
+		e := normalizeLicense(license)
+		if expected == "" {
+			assert.Nil(t, e, license)
+			continue
+		}
+		if assert.NotNil(t, e, license) {
+			assert.Equal(t, expected, e.String(), license)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SPDXVersion 是导出的 SPDX 规范版本
+const SPDXVersion = "SPDX-2.3"
+
+// noAssertion 表示 SPDX 中没有可用信息的字段
+const noAssertion = "NOASSERTION"
+
+type spdxDocument struct {
+	SPDXVersion       string              `json:"spdxVersion"`
+	DataLicense       string              `json:"dataLicense"`
+	SPDXID            string              `json:"SPDXID"`
+	Name              string              `json:"name"`
+	DocumentNamespace string              `json:"documentNamespace"`
+	CreationInfo      *spdxCreationInfo   `json:"creationInfo"`
+	Packages          []*spdxPackage      `json:"packages"`
+	Relationships     []*spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                  string             `json:"name"`
+	SPDXID                string             `json:"SPDXID"`
+	VersionInfo           string             `json:"versionInfo,omitempty"`
+	Supplier              string             `json:"supplier,omitempty"`
+	DownloadLocation      string             `json:"downloadLocation"`
+	FilesAnalyzed         bool               `json:"filesAnalyzed"`
+	Checksums             []*spdxChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded      string             `json:"licenseConcluded"`
+	LicenseDeclared       string             `json:"licenseDeclared"`
+	CopyrightText         string             `json:"copyrightText"`
+	Description           string             `json:"description,omitempty"`
+	ExternalRefs          []*spdxExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string             `json:"primaryPackagePurpose,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// spdxIDRegexp 匹配 SPDXID 中不允许出现的字符
+var spdxIDRegexp = regexp.MustCompile(`[^A-Za-z0-9.\-]+`)
+
+// WriteSPDX 以 SPDX 2.3 JSON 格式输出 SBOM
+//
+// 项目本身作为根包，文档 DESCRIBES 根包，包之间的依赖使用 DEPENDS_ON 关系表示
+//
+// 参数:
+//   - w: 输出目标
+//   - options: 可选的配置选项，如未提供则使用 NewOptions() 的默认配置
+//
+// 返回值:
+//   - error: 如果写入失败则返回错误
+//
+// 使用示例:
+//
+//	doc := sbom.FromGraph("my-app", "1.0.0", graph)
+//	err := doc.WriteSPDX(os.Stdout, sbom.NewOptions().SetNamespace("https://sbom.example.com"))
+func (x *Document) WriteSPDX(w io.Writer, options ...*Options) error {
+	if len(options) == 0 {
+		options = append(options, NewOptions())
+	}
+	opts := options[0]
+	timestamp := opts.timestamp()
+
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	creators := []string{"Tool: " + DefaultToolName}
+	if opts.ToolName != "" {
+		creators[0] = "Tool: " + opts.ToolName
+		if opts.ToolVersion != "" {
+			creators[0] += "-" + opts.ToolVersion
+		}
+	}
+	doc := &spdxDocument{
+		SPDXVersion:       SPDXVersion,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              x.rootName(),
+		DocumentNamespace: fmt.Sprintf("%s/%s-%s", strings.TrimSuffix(namespace, "/"), spdxIDRegexp.ReplaceAllString(x.rootName(), "-"), x.uuid(timestamp)),
+		CreationInfo: &spdxCreationInfo{
+			Created:  timestamp.Format(time.RFC3339),
+			Creators: creators,
+		},
+		Packages:      []*spdxPackage{},
+		Relationships: []*spdxRelationship{},
+	}
+
+	const rootID = "SPDXRef-RootPackage"
+	root := &spdxPackage{
+		Name:                  x.rootName(),
+		SPDXID:                rootID,
+		VersionInfo:           x.Version,
+		DownloadLocation:      noAssertion,
+		LicenseConcluded:      noAssertion,
+		LicenseDeclared:       noAssertion,
+		CopyrightText:         noAssertion,
+		PrimaryPackagePurpose: "APPLICATION",
+	}
+	if x.Name != "" && x.Version != "" {
+		root.ExternalRefs = []*spdxExternalRef{purlRef(x.rootRef())}
+	}
+	doc.Packages = append(doc.Packages, root)
+	doc.Relationships = append(doc.Relationships, &spdxRelationship{
+		SPDXElementID:      doc.SPDXID,
+		RelationshipType:   "DESCRIBES",
+		RelatedSPDXElement: rootID,
+	})
+
+	ids := make(map[string]string, len(x.Components))
+	used := map[string]bool{rootID: true}
+	for _, component := range x.Components {
+		base := "SPDXRef-Package-" + strings.Trim(spdxIDRegexp.ReplaceAllString(component.Name+"-"+component.Version, "-"), "-")
+		id := base
+		for i := 2; used[id]; i++ {
+			id = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[id] = true
+		ids[component.PURL] = id
+	}
+
+	for _, component := range x.Components {
+		p := &spdxPackage{
+			Name:                  component.Name,
+			SPDXID:                ids[component.PURL],
+			VersionInfo:           component.Version,
+			Supplier:              spdxSupplier(component),
+			DownloadLocation:      noAssertion,
+			LicenseConcluded:      noAssertion,
+			LicenseDeclared:       noAssertion,
+			CopyrightText:         noAssertion,
+			Description:           component.Description,
+			ExternalRefs:          []*spdxExternalRef{purlRef(component.PURL)},
+			PrimaryPackagePurpose: "LIBRARY",
+		}
+		if component.DownloadURL != "" {
+			p.DownloadLocation = component.DownloadURL
+		}
+		if e := normalizeLicense(component.License); e != nil {
+			p.LicenseDeclared = e.String()
+		}
+		for _, hash := range component.Hashes {
+			p.Checksums = append(p.Checksums, &spdxChecksum{
+				Algorithm:     strings.ToUpper(hash.Algorithm),
+				ChecksumValue: hash.Value,
+			})
+		}
+		doc.Packages = append(doc.Packages, p)
+	}
+
+	addDependencies := func(from string, dependencies []string) {
+		for _, purl := range dependencies {
+			if to, ok := ids[purl]; ok {
+				doc.Relationships = append(doc.Relationships, &spdxRelationship{
+					SPDXElementID:      from,
+					RelationshipType:   "DEPENDS_ON",
+					RelatedSPDXElement: to,
+				})
+			}
+		}
+	}
+	addDependencies(rootID, x.Dependencies)
+	for _, component := range x.Components {
+		addDependencies(ids[component.PURL], component.Dependencies)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// purlRef 返回 purl 类型的外部引用
+func purlRef(purl string) *spdxExternalRef {
+	return &spdxExternalRef{
+		ReferenceCategory: "PACKAGE-MANAGER",
+		ReferenceType:     "purl",
+		ReferenceLocator:  purl,
+	}
+}
+
+// spdxSupplier 使用第一个维护者作为包的供应商，格式为 "Person: name (email)"
+func spdxSupplier(component *Component) string {
+	for _, maintainer := range component.Maintainers {
+		if maintainer == nil || maintainer.Name == "" {
+			continue
+		}
+		if maintainer.Email != "" {
+			return fmt.Sprintf("Person: %s (%s)", maintainer.Name, maintainer.Email)
+		}
+		return "Person: " + maintainer.Name
+	}
+	return noAssertion
+}
@@ -0,0 +1,145 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/license"
+	"github.com/stretchr/testify/assert"
+)
+
+// 以下约束取自 SPDX 2.3 JSON Schema（spdx-schema.json）以及规范中对字段格式的要求
+var (
+	spdxIDPattern      = regexp.MustCompile(`^SPDXRef-[A-Za-z0-9.\-]+$`)
+	spdxCreatorPattern = regexp.MustCompile(`^(Person|Organization|Tool):\s*(.+)$`)
+	spdxSupplierRegexp = regexp.MustCompile(`^(NOASSERTION|(Person|Organization):\s.+)$`)
+	spdxChecksumAlgs   = map[string]bool{"SHA1": true, "SHA224": true, "SHA256": true, "SHA384": true, "SHA512": true, "SHA3-256": true, "SHA3-384": true, "SHA3-512": true, "BLAKE2b-256": true, "BLAKE2b-384": true, "BLAKE2b-512": true, "BLAKE3": true, "MD2": true, "MD4": true, "MD5": true, "MD6": true, "ADLER32": true}
+	spdxPurposes       = map[string]bool{"APPLICATION": true, "FRAMEWORK": true, "LIBRARY": true, "CONTAINER": true, "OPERATING-SYSTEM": true, "DEVICE": true, "FIRMWARE": true, "SOURCE": true, "ARCHIVE": true, "FILE": true, "INSTALL": true, "OTHER": true}
+)
+
+// validateSPDX 按照 SPDX 2.3 Schema 的必填字段、枚举和格式约束校验输出
+func validateSPDX(t *testing.T, data []byte) map[string]interface{} {
+	var doc map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "SPDX-2.3", doc["spdxVersion"])
+	assert.Equal(t, "CC0-1.0", doc["dataLicense"])
+	assert.Equal(t, "SPDXRef-DOCUMENT", doc["SPDXID"])
+	assert.NotEmpty(t, doc["name"])
+
+	namespace, err := url.Parse(doc["documentNamespace"].(string))
+	assert.Nil(t, err)
+	assert.True(t, namespace.IsAbs())
+	assert.Empty(t, namespace.Fragment)
+
+	creationInfo := doc["creationInfo"].(map[string]interface{})
+	_, err = time.Parse(time.RFC3339, creationInfo["created"].(string))
+	assert.Nil(t, err)
+	creators := creationInfo["creators"].([]interface{})
+	assert.NotEmpty(t, creators)
+	for _, creator := range creators {
+		assert.Regexp(t, spdxCreatorPattern, creator)
+	}
+
+	ids := map[string]bool{"SPDXRef-DOCUMENT": true}
+	for _, p := range doc["packages"].([]interface{}) {
+		pkg := p.(map[string]interface{})
+		id := pkg["SPDXID"].(string)
+		assert.Regexp(t, spdxIDPattern, id)
+		assert.False(t, ids[id], "duplicate SPDXID %s", id)
+		ids[id] = true
+		assert.NotEmpty(t, pkg["name"])
+		assert.NotEmpty(t, pkg["downloadLocation"])
+		assert.NotEmpty(t, pkg["licenseConcluded"])
+		if declared := pkg["licenseDeclared"].(string); declared != "NOASSERTION" {
+			// 文档中没有定义 LicenseRef-，许可证表达式只能使用 SPDX 许可证列表中的标识符
+			e, err := license.Parse(declared)
+			if assert.Nil(t, err, declared) {
+				for _, id := range e.Licenses() {
+					assert.True(t, license.IsLicense(id), id)
+				}
+			}
+		}
+		assert.NotEmpty(t, pkg["copyrightText"])
+		if supplier, ok := pkg["supplier"]; ok {
+			assert.Regexp(t, spdxSupplierRegexp, supplier)
+		}
+		if purpose, ok := pkg["primaryPackagePurpose"]; ok {
+			assert.True(t, spdxPurposes[purpose.(string)], purpose)
+		}
+		checksums, _ := pkg["checksums"].([]interface{})
+		for _, c := range checksums {
+			checksum := c.(map[string]interface{})
+			assert.True(t, spdxChecksumAlgs[checksum["algorithm"].(string)], checksum["algorithm"])
+			assert.NotEmpty(t, checksum["checksumValue"])
+		}
+		refs, _ := pkg["externalRefs"].([]interface{})
+		for _, r := range refs {
+			ref := r.(map[string]interface{})
+			assert.Equal(t, "PACKAGE-MANAGER", ref["referenceCategory"])
+			assert.Equal(t, "purl", ref["referenceType"])
+			assert.True(t, strings.HasPrefix(ref["referenceLocator"].(string), "pkg:npm/"))
+		}
+	}
+
+	for _, r := range doc["relationships"].([]interface{}) {
+		relationship := r.(map[string]interface{})
+		assert.True(t, ids[relationship["spdxElementId"].(string)], relationship["spdxElementId"])
+		assert.True(t, ids[relationship["relatedSpdxElement"].(string)], relationship["relatedSpdxElement"])
+		assert.Contains(t, []string{"DESCRIBES", "DEPENDS_ON"}, relationship["relationshipType"])
+	}
+	return doc
+}
+
+func TestWriteSPDX(t *testing.T) {
+	doc := FromGraph("@acme/my-app", "1.0.0", testGraph())
+	var buf bytes.Buffer
+	options := NewOptions().SetTimestamp(fixedTime).SetNamespace("https://sbom.example.com/")
+	assert.Nil(t, doc.WriteSPDX(&buf, options))
+	spdx := validateSPDX(t, buf.Bytes())
+
+	assert.True(t, strings.HasPrefix(spdx["documentNamespace"].(string), "https://sbom.example.com/-acme-my-app-"))
+	packages := spdx["packages"].([]interface{})
+	assert.Len(t, packages, 4)
+	cli := packages[1].(map[string]interface{})
+	assert.Equal(t, "@sigstore/cli", cli["name"])
+	assert.Equal(t, "SPDXRef-Package-sigstore-cli-0.1.0", cli["SPDXID"])
+	assert.Equal(t, "Apache-2.0", cli["licenseDeclared"])
+	assert.Equal(t, "Person: bdehamer (bdehamer@github.com)", cli["supplier"])
+	assert.Equal(t, "https://registry.npmjs.org/@sigstore/cli/-/cli-0.1.0.tgz", cli["downloadLocation"])
+	custom := packages[2].(map[string]interface{})
+	assert.Equal(t, "NOASSERTION", custom["licenseDeclared"])
+	assert.Equal(t, "NOASSERTION", custom["supplier"])
+
+	var relationships []string
+	for _, r := range spdx["relationships"].([]interface{}) {
+		relationship := r.(map[string]interface{})
+		relationships = append(relationships, relationship["spdxElementId"].(string)+" "+
+			relationship["relationshipType"].(string)+" "+relationship["relatedSpdxElement"].(string))
+	}
+	assert.Equal(t, []string{
+		"SPDXRef-DOCUMENT DESCRIBES SPDXRef-RootPackage",
+		"SPDXRef-RootPackage DEPENDS_ON SPDXRef-Package-sigstore-cli-0.1.0",
+		"SPDXRef-RootPackage DEPENDS_ON SPDXRef-Package-custom-1.0.0-build.1",
+		"SPDXRef-Package-sigstore-cli-0.1.0 DEPENDS_ON SPDXRef-Package-tslib-2.6.2",
+	}, relationships)
+}
+
+func TestWriteSPDXDuplicateIDs(t *testing.T) {
+	doc := &Document{Components: []*Component{
+		{Name: "a.b", Version: "1.0.0", PURL: "pkg:npm/a.b@1.0.0", License: "UNLICENSED"},
+		{Name: "a_b", Version: "1.0.0", PURL: "pkg:npm/a_b@1.0.0", License: "mit"},
+	}}
+	var buf bytes.Buffer
+	assert.Nil(t, doc.WriteSPDX(&buf))
+	spdx := validateSPDX(t, buf.Bytes())
+	assert.Equal(t, "npm-project", spdx["name"])
+	packages := spdx["packages"].([]interface{})
+	assert.Len(t, packages, 3)
+	assert.Equal(t, "NOASSERTION", packages[1].(map[string]interface{})["licenseDeclared"])
+	assert.Equal(t, "MIT", packages[2].(map[string]interface{})["licenseDeclared"])
+}
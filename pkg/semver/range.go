@@ -0,0 +1,425 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Range 表示一个 npm 风格的版本范围，例如 "^1.2.3"、">=1.0.0 <2.0.0 || 3.x"
+//
+// 支持的语法与 npm 的 node-semver 保持一致:
+//   - 比较运算符: "<"、"<="、">"、">="、"="
+//   - X-Range: "*"、"x"、"1.x"、"1.2.*"、"1"、"1.2"
+//   - 波浪号范围: "~1.2.3"、"~1.2"、"~1"
+//   - 插入号范围: "^1.2.3"、"^0.2.3"、"^0.0.3"
+//   - 连字符范围: "1.2.3 - 2.3.4"
+//   - 多个范围使用 "||" 连接
+//
+// 预发布版本只有在同一个比较器集合中存在相同 [major, minor, patch] 的预发布比较器时才会匹配，
+// 例如 ">=1.2.3-alpha.3" 匹配 "1.2.3-alpha.7"，但不匹配 "3.4.5-alpha.9"
+type Range struct {
+	raw  string
+	sets [][]comparator
+}
+
+// comparator 表示一个基本的比较条件，op 为空表示匹配任何版本
+type comparator struct {
+	op      string
+	version *Version
+}
+
+var (
+	operatorSpaceRegexp = regexp.MustCompile(`(<=|>=|<|>|=|~>|~|\^)\s+`)
+	hyphenRegexp        = regexp.MustCompile(`^\s*(\S+)\s+-\s+(\S+)\s*$`)
+	comparatorRegexp    = regexp.MustCompile(`^(<=|>=|<|>|=|~>|~|\^)?v?=?\s*(.*)$`)
+)
+
+// ParseRange 解析 npm 风格的版本范围
+//
+// 参数:
+//   - r: 版本范围字符串，空字符串等价于 "*"
+//
+// 返回值:
+//   - *Range: 解析后的版本范围
+//   - error: 如果范围语法不合法则返回错误
+//
+// 使用示例:
+//
+//	r, err := semver.ParseRange("^4.17.0")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	fmt.Println(r.Contains("4.18.2")) // true
+func ParseRange(r string) (*Range, error) {
+	result := &Range{raw: r}
+	for _, part := range strings.Split(r, "||") {
+		set, err := parseComparatorSet(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q: %w", r, err)
+		}
+		result.sets = append(result.sets, set)
+	}
+	return result, nil
+}
+
+// ValidRange 判断字符串是否是合法的版本范围
+func ValidRange(r string) bool {
+	_, err := ParseRange(r)
+	return err == nil
+}
+
+// String 返回原始的范围字符串
+func (r *Range) String() string {
+	return r.raw
+}
+
+// Satisfies 判断版本是否满足该范围
+func (r *Range) Satisfies(v *Version) bool {
+	for _, set := range r.sets {
+		if setSatisfies(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// SatisfiesIncludingPrerelease 判断版本是否满足该范围，预发布版本与正式版本同等对待
+//
+// 等价于 node-semver 的 includePrerelease 选项，npm audit 使用这种方式匹配漏洞范围，
+// 例如 "<4.17.21" 也会匹配 "4.17.21-beta.1"
+func (r *Range) SatisfiesIncludingPrerelease(v *Version) bool {
+	for _, set := range r.sets {
+		matched := true
+		for _, c := range set {
+			if !c.test(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains 判断版本号字符串是否满足该范围，无法解析的版本号总是返回 false
+func (r *Range) Contains(version string) bool {
+	v, err := Parse(version)
+	if err != nil {
+		return false
+	}
+	return r.Satisfies(v)
+}
+
+// MaxSatisfying 返回版本列表中满足该范围的最大版本
+//
+// 返回值:
+//   - string: 满足范围的最大版本号，如果没有满足的版本则返回空字符串
+func (r *Range) MaxSatisfying(versions []string) string {
+	var best *Version
+	var bestRaw string
+	for _, raw := range versions {
+		v, err := Parse(raw)
+		if err != nil || !r.Satisfies(v) {
+			continue
+		}
+		if best == nil || v.Compare(best) > 0 {
+			best, bestRaw = v, raw
+		}
+	}
+	return bestRaw
+}
+
+// Satisfies 判断版本号是否满足版本范围，任意一方无法解析时返回 false
+//
+// 使用示例:
+//
+//	semver.Satisfies("1.2.3", "^1.0.0") // true
+func Satisfies(version, r string) bool {
+	parsed, err := ParseRange(r)
+	if err != nil {
+		return false
+	}
+	return parsed.Contains(version)
+}
+
+// MaxSatisfying 返回版本列表中满足范围的最大版本，范围不合法时返回空字符串
+func MaxSatisfying(versions []string, r string) string {
+	parsed, err := ParseRange(r)
+	if err != nil {
+		return ""
+	}
+	return parsed.MaxSatisfying(versions)
+}
+
+func setSatisfies(set []comparator, v *Version) bool {
+	for _, c := range set {
+		if !c.test(v) {
+			return false
+		}
+	}
+	if !v.IsPrerelease() {
+		return true
+	}
+	// 预发布版本只有在同一个集合中有相同版本号的预发布比较器时才允许匹配
+	for _, c := range set {
+		if c.version == nil || !c.version.IsPrerelease() {
+			continue
+		}
+		if c.version.Major == v.Major && c.version.Minor == v.Minor && c.version.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparator) test(v *Version) bool {
+	if c.op == "" {
+		return true
+	}
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+func parseComparatorSet(s string) ([]comparator, error) {
+	s = strings.TrimSpace(s)
+	if m := hyphenRegexp.FindStringSubmatch(s); m != nil {
+		return parseHyphen(m[1], m[2])
+	}
+	s = operatorSpaceRegexp.ReplaceAllString(s, "$1")
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return []comparator{{}}, nil
+	}
+	var set []comparator
+	for _, field := range fields {
+		cs, err := parseComparator(field)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, cs...)
+	}
+	return set, nil
+}
+
+// partial 表示一个可能不完整的版本号，例如 "1"、"1.2"、"1.x"
+type partial struct {
+	major, minor, patch    uint64
+	hasMinor, hasPatch     bool
+	prerelease, build      []string
+	isAnyMajor, isAnyMinor bool
+}
+
+func parsePartial(s string) (*partial, error) {
+	s = strings.TrimLeft(s, "v=")
+	if s == "" || s == "*" || s == "x" || s == "X" {
+		return &partial{isAnyMajor: true}, nil
+	}
+	p := &partial{}
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		p.build = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		p.prerelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+		for _, id := range p.prerelease {
+			if !isIdentifier(id) {
+				return nil, fmt.Errorf("invalid prerelease %q", s)
+			}
+		}
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("invalid version %q", s)
+	}
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			switch i {
+			case 0:
+				p.isAnyMajor = true
+			case 1:
+				p.isAnyMinor = true
+			}
+			p.prerelease = nil
+			return p, nil
+		}
+		if !isNumeric(part) {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		switch i {
+		case 0:
+			p.major = n
+		case 1:
+			p.minor, p.hasMinor = n, true
+		case 2:
+			p.patch, p.hasPatch = n, true
+		}
+	}
+	if !p.hasMinor {
+		p.isAnyMinor = true
+	}
+	if !p.hasPatch {
+		p.prerelease = nil
+	}
+	return p, nil
+}
+
+// complete 判断是否是完整的 major.minor.patch 版本号
+func (p *partial) complete() bool {
+	return !p.isAnyMajor && p.hasMinor && p.hasPatch
+}
+
+// lower 返回部分版本号对应的最小完整版本
+func (p *partial) lower() *Version {
+	return &Version{Major: p.major, Minor: p.minor, Patch: p.patch, Prerelease: p.prerelease}
+}
+
+func parseComparator(s string) ([]comparator, error) {
+	m := comparatorRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid comparator %q", s)
+	}
+	op, rest := m[1], m[2]
+	p, err := parsePartial(rest)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "~", "~>":
+		return tildeRange(p), nil
+	case "^":
+		return caretRange(p), nil
+	case "", "=":
+		return xRange(p), nil
+	}
+	return primitiveRange(op, p), nil
+}
+
+func tildeRange(p *partial) []comparator {
+	if p.isAnyMajor {
+		return []comparator{{}}
+	}
+	lower := p.lower()
+	var upper *Version
+	if p.isAnyMinor {
+		upper = &Version{Major: p.major + 1, Prerelease: []string{"0"}}
+	} else {
+		upper = &Version{Major: p.major, Minor: p.minor + 1, Prerelease: []string{"0"}}
+	}
+	return []comparator{{">=", lower}, {"<", upper}}
+}
+
+func caretRange(p *partial) []comparator {
+	if p.isAnyMajor {
+		return []comparator{{}}
+	}
+	lower := p.lower()
+	var upper *Version
+	switch {
+	case p.isAnyMinor:
+		upper = &Version{Major: p.major + 1, Prerelease: []string{"0"}}
+	case p.major > 0:
+		upper = &Version{Major: p.major + 1, Prerelease: []string{"0"}}
+	case !p.hasPatch || p.minor > 0:
+		upper = &Version{Minor: p.minor + 1, Prerelease: []string{"0"}}
+	default:
+		upper = &Version{Patch: p.patch + 1, Prerelease: []string{"0"}}
+	}
+	return []comparator{{">=", lower}, {"<", upper}}
+}
+
+func xRange(p *partial) []comparator {
+	if p.isAnyMajor {
+		return []comparator{{}}
+	}
+	if p.complete() {
+		return []comparator{{"=", p.lower()}}
+	}
+	lower := p.lower()
+	var upper *Version
+	if p.isAnyMinor {
+		upper = &Version{Major: p.major + 1, Prerelease: []string{"0"}}
+	} else {
+		upper = &Version{Major: p.major, Minor: p.minor + 1, Prerelease: []string{"0"}}
+	}
+	return []comparator{{">=", lower}, {"<", upper}}
+}
+
+func primitiveRange(op string, p *partial) []comparator {
+	if p.isAnyMajor {
+		if op == "<" || op == ">" {
+			// "<*" 和 ">*" 不可能被满足
+			return []comparator{{"<", &Version{Prerelease: []string{"0"}}}}
+		}
+		return []comparator{{}}
+	}
+	if p.complete() {
+		return []comparator{{op, p.lower()}}
+	}
+	switch op {
+	case ">":
+		// ">1" => ">=2.0.0"，">1.2" => ">=1.3.0"
+		if p.isAnyMinor {
+			return []comparator{{">=", &Version{Major: p.major + 1}}}
+		}
+		return []comparator{{">=", &Version{Major: p.major, Minor: p.minor + 1}}}
+	case "<=":
+		// "<=1" => "<2.0.0-0"，"<=1.2" => "<1.3.0-0"
+		if p.isAnyMinor {
+			return []comparator{{"<", &Version{Major: p.major + 1, Prerelease: []string{"0"}}}}
+		}
+		return []comparator{{"<", &Version{Major: p.major, Minor: p.minor + 1, Prerelease: []string{"0"}}}}
+	case "<":
+		// "<1.2" => "<1.2.0-0"
+		return []comparator{{"<", &Version{Major: p.major, Minor: p.minor, Prerelease: []string{"0"}}}}
+	}
+	// ">=1.2" => ">=1.2.0"
+	return []comparator{{">=", p.lower()}}
+}
+
+func parseHyphen(from, to string) ([]comparator, error) {
+	lowerPart, err := parsePartial(from)
+	if err != nil {
+		return nil, err
+	}
+	upperPart, err := parsePartial(to)
+	if err != nil {
+		return nil, err
+	}
+	var set []comparator
+	if !lowerPart.isAnyMajor {
+		set = append(set, comparator{">=", lowerPart.lower()})
+	}
+	switch {
+	case upperPart.isAnyMajor:
+	case upperPart.complete():
+		set = append(set, comparator{"<=", upperPart.lower()})
+	case upperPart.isAnyMinor:
+		set = append(set, comparator{"<", &Version{Major: upperPart.major + 1, Prerelease: []string{"0"}}})
+	default:
+		set = append(set, comparator{"<", &Version{Major: upperPart.major, Minor: upperPart.minor + 1, Prerelease: []string{"0"}}})
+	}
+	if len(set) == 0 {
+		set = append(set, comparator{})
+	}
+	return set, nil
+}
@@ -0,0 +1,108 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeSatisfies(t *testing.T) {
+	// 以下用例取自 node-semver 的测试
+	cases := []struct {
+		r       string
+		version string
+		want    bool
+	}{
+		{"", "1.0.0", true},
+		{"*", "1.2.3", true},
+		{"x", "0.0.1", true},
+		{"*", "1.0.0-beta", false},
+		{"1.0.0", "1.0.0", true},
+		{"=1.0.0", "1.0.1", false},
+		{">=1.0.0", "1.0.0", true},
+		{">1.0.0", "1.0.0", false},
+		{"<2.0.0", "1.9999.9999", true},
+		{"<=2.0.0", "2.0.0", true},
+		{">= 1.0.0", "1.0.1", true},
+		{"1.2.3 - 2.3.4", "2.3.4", true},
+		{"1.2.3 - 2.3.4", "2.3.5", false},
+		{"1.2 - 2.3", "2.3.9", true},
+		{"1.2 - 2.3", "2.4.0", false},
+		{"1.2.3 - 2", "2.9.9", true},
+		{"1.2.x", "1.2.9", true},
+		{"1.2.x", "1.3.0", false},
+		{"1.x", "1.9.0", true},
+		{"1", "1.9.0", true},
+		{"1", "2.0.0", false},
+		{"1.2", "1.2.99", true},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1.2", "1.2.0", true},
+		{"~1", "1.9.9", true},
+		{"~> 1.2", "1.2.5", true},
+		{"~0.2.3", "0.2.5", true},
+		{"^1.2.3", "1.9.9", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^1.2.3", "2.0.0-alpha", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"^0.0.x", "0.0.9", true},
+		{"^0.0", "0.0.9", true},
+		{"^0.x", "0.9.0", true},
+		{"^1.x", "1.5.0", true},
+		{"^1.2.3-beta.2", "1.2.3-beta.4", true},
+		{"^1.2.3-beta.2", "1.2.4-beta.2", false},
+		{"^1.2.3-beta.2", "1.2.4", true},
+		{">1", "2.0.0", true},
+		{">1", "1.9.9", false},
+		{">1.2", "1.3.0", true},
+		{"<1.2", "1.1.9", true},
+		{"<1.2", "1.2.0-alpha", false},
+		{"<=1.2", "1.2.9", true},
+		{">=1.0.0 <2.0.0 || 3.x", "3.4.0", true},
+		{">=1.0.0 <2.0.0 || 3.x", "2.4.0", false},
+		{">=1.2.3-alpha.3", "1.2.3-alpha.7", true},
+		{">=1.2.3-alpha.3", "3.4.5-alpha.9", false},
+		{"v1.2.3", "1.2.3", true},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, Satisfies(c.version, c.r), "%q satisfies %q", c.version, c.r)
+	}
+}
+
+func TestParseRangeInvalid(t *testing.T) {
+	for _, r := range []string{"latest", "1.2.3.4", "^abc", ">=1.0.0 <foo"} {
+		_, err := ParseRange(r)
+		assert.NotNil(t, err, r)
+		assert.False(t, ValidRange(r), r)
+	}
+	assert.False(t, Satisfies("1.0.0", "latest"))
+}
+
+func TestSatisfiesIncludingPrerelease(t *testing.T) {
+	r, err := ParseRange("<4.17.21 || >=5.0.0 <5.0.2")
+	assert.Nil(t, err)
+	assert.True(t, r.SatisfiesIncludingPrerelease(MustParse("4.17.21-beta.1")))
+	assert.False(t, r.Satisfies(MustParse("4.17.21-beta.1")))
+	assert.True(t, r.SatisfiesIncludingPrerelease(MustParse("5.0.1")))
+	assert.False(t, r.SatisfiesIncludingPrerelease(MustParse("4.17.21")))
+	assert.True(t, r.SatisfiesIncludingPrerelease(MustParse("5.0.2-rc.1")))
+	assert.False(t, r.SatisfiesIncludingPrerelease(MustParse("5.0.0-rc.1")))
+}
+
+func TestMaxSatisfying(t *testing.T) {
+	versions := []string{"1.0.0", "1.2.0", "1.10.0", "2.0.0-rc.1", "2.0.0", "not-a-version"}
+	assert.Equal(t, "1.10.0", MaxSatisfying(versions, "^1.0.0"))
+	assert.Equal(t, "1.2.0", MaxSatisfying(versions, "~1.2.0"))
+	assert.Equal(t, "2.0.0", MaxSatisfying(versions, "*"))
+	assert.Equal(t, "", MaxSatisfying(versions, "^3"))
+	assert.Equal(t, "", MaxSatisfying(versions, "latest"))
+
+	r, err := ParseRange("^2.0.0-rc.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "^2.0.0-rc.0", r.String())
+	assert.True(t, r.Contains("2.0.0-rc.1"))
+	assert.False(t, r.Contains("not-a-version"))
+}
@@ -0,0 +1,44 @@
+package semver
+
+import (
+	"sort"
+)
+
+// Sort 按照语义化版本从小到大对版本号字符串排序，无法解析的版本号排在最前面
+//
+// 使用示例:
+//
+//	versions := []string{"1.10.0", "1.2.0", "1.2.0-beta.1"}
+//	semver.Sort(versions)
+//	// versions: ["1.2.0-beta.1", "1.2.0", "1.10.0"]
+func Sort(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return Compare(versions[i], versions[j]) < 0
+	})
+}
+
+// Max 返回版本号列表中最大的合法版本
+//
+// 参数:
+//   - versions: 版本号字符串列表
+//   - includePrerelease: 是否将预发布版本纳入比较
+//
+// 返回值:
+//   - string: 最大的版本号，如果没有符合条件的版本则返回空字符串
+func Max(versions []string, includePrerelease bool) string {
+	var best *Version
+	var bestRaw string
+	for _, raw := range versions {
+		v, err := Parse(raw)
+		if err != nil {
+			continue
+		}
+		if v.IsPrerelease() && !includePrerelease {
+			continue
+		}
+		if best == nil || v.Compare(best) > 0 {
+			best, bestRaw = v, raw
+		}
+	}
+	return bestRaw
+}
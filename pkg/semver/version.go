@@ -0,0 +1,248 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version 表示一个符合 SemVer 2.0 规范的版本号
+//
+// NPM 中所有发布的版本都必须是合法的语义化版本，例如 "1.2.3"、"2.0.0-beta.1"、"1.0.0+build.5"
+//
+// 主要字段说明:
+//   - Major: 主版本号
+//   - Minor: 次版本号
+//   - Patch: 修订号
+//   - Prerelease: 预发布标识列表，例如 "beta.1" 会被拆分为 ["beta", "1"]
+//   - Build: 构建元数据列表，比较版本大小时会被忽略
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      []string
+}
+
+// Parse 解析版本号字符串
+//
+// 与 npm 的 semver 一样，允许版本号前面带有 "v" 或 "=" 前缀以及首尾空白
+//
+// 参数:
+//   - version: 版本号字符串，例如 "1.2.3"、"v2.0.0-rc.1"
+//
+// 返回值:
+//   - *Version: 解析后的版本号
+//   - error: 如果不是合法的语义化版本则返回错误
+//
+// 使用示例:
+//
+//	v, err := semver.Parse("1.2.3-beta.1")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	fmt.Println(v.Major, v.Minor, v.Patch)
+func Parse(version string) (*Version, error) {
+	s := strings.TrimSpace(version)
+	s = strings.TrimLeft(s, "=v")
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("invalid version: %q", version)
+	}
+
+	v := &Version{}
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		build := s[i+1:]
+		s = s[:i]
+		if build == "" {
+			return nil, fmt.Errorf("invalid version: %q", version)
+		}
+		v.Build = strings.Split(build, ".")
+		for _, id := range v.Build {
+			if !isIdentifier(id) {
+				return nil, fmt.Errorf("invalid build metadata in version: %q", version)
+			}
+		}
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		pre := s[i+1:]
+		s = s[:i]
+		if pre == "" {
+			return nil, fmt.Errorf("invalid version: %q", version)
+		}
+		v.Prerelease = strings.Split(pre, ".")
+		for _, id := range v.Prerelease {
+			if !isIdentifier(id) || (isNumeric(id) && len(id) > 1 && id[0] == '0') {
+				return nil, fmt.Errorf("invalid prerelease in version: %q", version)
+			}
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid version: %q", version)
+	}
+	numbers := make([]uint64, 3)
+	for i, part := range parts {
+		if !isNumeric(part) || (len(part) > 1 && part[0] == '0') {
+			return nil, fmt.Errorf("invalid version: %q", version)
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version: %q", version)
+		}
+		numbers[i] = n
+	}
+	v.Major, v.Minor, v.Patch = numbers[0], numbers[1], numbers[2]
+	return v, nil
+}
+
+// MustParse 与 Parse 相同，但解析失败时会 panic，适用于常量版本号
+func MustParse(version string) *Version {
+	v, err := Parse(version)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Valid 判断字符串是否是合法的语义化版本
+func Valid(version string) bool {
+	_, err := Parse(version)
+	return err == nil
+}
+
+// String 返回规范化后的版本号字符串（不包含 "v" 前缀）
+func (v *Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+// IsPrerelease 判断是否是预发布版本，例如 "1.0.0-beta.1"
+func (v *Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Compare 比较两个版本号的大小
+//
+// 比较规则遵循 SemVer 2.0 规范，构建元数据不参与比较
+//
+// 返回值:
+//   - int: v < other 返回 -1，相等返回 0，v > other 返回 1
+func (v *Version) Compare(other *Version) int {
+	if c := compareUint(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+// LessThan 判断 v 是否小于 other
+func (v *Version) LessThan(other *Version) bool {
+	return v.Compare(other) < 0
+}
+
+// Equal 判断 v 是否等于 other（忽略构建元数据）
+func (v *Version) Equal(other *Version) bool {
+	return v.Compare(other) == 0
+}
+
+// Compare 比较两个版本号字符串的大小，无法解析的版本号被视为比任何合法版本都小
+//
+// 使用示例:
+//
+//	semver.Compare("1.2.3", "1.10.0") // -1
+func Compare(a, b string) int {
+	va, errA := Parse(a)
+	vb, errB := Parse(b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return va.Compare(vb)
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparePrerelease 按照 SemVer 规范比较预发布标识，没有预发布标识的版本更大
+func comparePrerelease(a, b []string) int {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	if len(a) == 0 {
+		return 1
+	}
+	if len(b) == 0 {
+		return -1
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(a)), uint64(len(b)))
+}
+
+func compareIdentifier(a, b string) int {
+	aNum, bNum := isNumeric(a), isNumeric(b)
+	switch {
+	case aNum && bNum:
+		if len(a) != len(b) {
+			return compareUint(uint64(len(a)), uint64(len(b)))
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
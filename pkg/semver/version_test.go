@@ -0,0 +1,69 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	v, err := Parse("1.2.3")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), v.Major)
+	assert.Equal(t, uint64(2), v.Minor)
+	assert.Equal(t, uint64(3), v.Patch)
+	assert.False(t, v.IsPrerelease())
+
+	// 前缀和空白
+	v, err = Parse(" v2.0.0-beta.1+build.5 ")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"beta", "1"}, v.Prerelease)
+	assert.Equal(t, []string{"build", "5"}, v.Build)
+	assert.True(t, v.IsPrerelease())
+	assert.Equal(t, "2.0.0-beta.1+build.5", v.String())
+
+	// 非法版本
+	for _, s := range []string{"", "1.2", "1.2.3.4", "01.2.3", "1.2.x", "1.2.3-", "1.2.3-01", "latest"} {
+		_, err := Parse(s)
+		assert.NotNil(t, err, s)
+		assert.False(t, Valid(s), s)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// SemVer 规范中给出的顺序
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.2.0",
+		"1.10.0",
+		"2.0.0",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		assert.Equal(t, -1, Compare(ordered[i], ordered[i+1]), "%s < %s", ordered[i], ordered[i+1])
+		assert.Equal(t, 1, Compare(ordered[i+1], ordered[i]), "%s > %s", ordered[i+1], ordered[i])
+	}
+
+	// 构建元数据不参与比较
+	assert.Equal(t, 0, Compare("1.0.0+a", "1.0.0+b"))
+	// 非法版本排在前面
+	assert.Equal(t, -1, Compare("not-a-version", "0.0.1"))
+}
+
+func TestSortAndMax(t *testing.T) {
+	versions := []string{"1.10.0", "1.2.0", "2.0.0-beta.1", "1.2.0-beta.1"}
+	Sort(versions)
+	assert.Equal(t, []string{"1.2.0-beta.1", "1.2.0", "1.10.0", "2.0.0-beta.1"}, versions)
+
+	assert.Equal(t, "1.10.0", Max(versions, false))
+	assert.Equal(t, "2.0.0-beta.1", Max(versions, true))
+	assert.Equal(t, "", Max([]string{"1.0.0-rc.1"}, false))
+	assert.Equal(t, "", Max(nil, true))
+}
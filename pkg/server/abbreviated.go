@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"path"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// abbreviatedFields 是精简版包元数据（corgi 文档）中每个版本保留的字段，与 registry.npmjs.org 返回的一致
+var abbreviatedFields = []string{
+	"name", "version", "deprecated",
+	"dependencies", "optionalDependencies", "devDependencies", "peerDependencies", "peerDependenciesMeta",
+	"bundleDependencies", "acceptDependencies",
+	"bin", "directories", "engines", "os", "cpu", "libc", "funding",
+	"_hasShrinkwrap", "hasInstallScript", "dist",
+}
+
+// abbreviatedPackage 是精简版包元数据（corgi 文档），只包含安装依赖需要的字段
+type abbreviatedPackage struct {
+	Name     string                                `json:"name"`
+	Modified string                                `json:"modified,omitempty"`
+	DistTags json.RawMessage                       `json:"dist-tags"`
+	Versions map[string]map[string]json.RawMessage `json:"versions"`
+}
+
+// abbreviate 把完整的包元数据转换为精简版元数据，每个版本只保留 abbreviatedFields 中的字段，字段的值保持原样
+func abbreviate(name string, pkg *models.Package, doc *rawPackument) *abbreviatedPackage {
+	abbreviated := &abbreviatedPackage{
+		Name:     name,
+		Modified: pkg.Time["modified"],
+		DistTags: doc.fields["dist-tags"],
+		Versions: make(map[string]map[string]json.RawMessage, len(doc.versions)),
+	}
+	if abbreviated.DistTags == nil {
+		abbreviated.DistTags = json.RawMessage("{}")
+	}
+	for version, manifest := range doc.versions {
+		fields := make(map[string]json.RawMessage)
+		for _, field := range abbreviatedFields {
+			if value, ok := manifest[field]; ok {
+				fields[field] = value
+			}
+		}
+		var command string
+		if json.Unmarshal(fields["bin"], &command) == nil {
+			// 字符串形式的 bin 以去掉作用域的包名作为命令名
+			fields["bin"], _ = json.Marshal(map[string]string{path.Base(name): command})
+		}
+		abbreviated.Versions[version] = fields
+	}
+	return abbreviated
+}
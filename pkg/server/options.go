@@ -0,0 +1,54 @@
+package server
+
+import (
+	"time"
+)
+
+// Options 表示缓存服务器的配置选项
+//
+// 包含字段:
+//   - PublicURL: 客户端访问服务器使用的地址，例如 "https://npm.example.com"，
+//     元数据中的 dist.tarball 会被改写为指向这个地址，为空时根据请求的 Host 推断
+//   - MaxAge: 缓存的包元数据在多长时间内直接使用而不向上游确认，默认为 5 分钟，为 0 时每次都向上游确认
+//   - Timeout: 向上游请求元数据和 tarball 的超时时间，默认为 1 分钟
+//
+// 使用示例:
+//
+//	options := NewOptions().SetPublicURL("https://npm.example.com").SetMaxAge(time.Minute)
+//	server := NewServer(registry.NewRegistry(), s, options)
+type Options struct {
+	PublicURL string
+	MaxAge    time.Duration
+	Timeout   time.Duration
+}
+
+// NewOptions 创建并返回默认的配置选项
+//
+// 默认配置:
+//   - PublicURL: 根据请求推断
+//   - MaxAge: 5 分钟
+//   - Timeout: 1 分钟
+func NewOptions() *Options {
+	return &Options{
+		MaxAge:  5 * time.Minute,
+		Timeout: time.Minute,
+	}
+}
+
+// SetPublicURL 设置客户端访问服务器使用的地址
+func (o *Options) SetPublicURL(publicURL string) *Options {
+	o.PublicURL = publicURL
+	return o
+}
+
+// SetMaxAge 设置缓存的包元数据直接使用的时长
+func (o *Options) SetMaxAge(maxAge time.Duration) *Options {
+	o.MaxAge = maxAge
+	return o
+}
+
+// SetTimeout 设置向上游请求的超时时间
+func (o *Options) SetTimeout(timeout time.Duration) *Options {
+	o.Timeout = timeout
+	return o
+}
@@ -0,0 +1,463 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/scagogogo/npm-crawler/pkg/store"
+)
+
+// 响应头 X-Cache 的取值，表示响应的来源
+const (
+	// CacheHit 直接使用缓存，没有访问上游
+	CacheHit = "HIT"
+
+	// CacheMiss 从上游获取并写入缓存
+	CacheMiss = "MISS"
+
+	// CacheStale 上游不可用，使用过期的缓存
+	CacheStale = "STALE"
+)
+
+// errNotFound 表示上游不存在请求的包或版本
+var errNotFound = errors.New("not found")
+
+// upstreamError 表示访问上游失败，响应 502
+type upstreamError struct {
+	err error
+}
+
+// Error 返回错误信息
+func (x *upstreamError) Error() string {
+	return x.err.Error()
+}
+
+// Unwrap 返回原始错误
+func (x *upstreamError) Unwrap() error {
+	return x.err
+}
+
+// Server 是 npm Registry 的拉取式缓存服务器（pull-through cache），实现了 Registry 读接口，
+// npm、yarn、pnpm 等客户端可以直接把它配置为 registry 使用
+//
+// 支持的接口:
+//   - GET /<name>: 包元数据，请求头 Accept 为 application/vnd.npm.install-v1+json 时返回精简版元数据
+//   - GET /<name>/<version>: 版本元数据，version 也可以是 dist-tag，例如 latest
+//   - GET /<name>/-/<name>-<version>.tgz: tarball
+//   - GET /-/v1/search: 搜索，直接转发给上游，只支持 text 和 size 参数
+//   - GET /-/ping: 健康检查
+//
+// 作用域包既可以使用 /@scope/name，也可以使用 npm 客户端发送的 /@scope%2fname。
+//
+// 缓存规则:
+//   - 缓存中没有的包元数据从上游获取后原样写入 Store，在 Options.MaxAge 内直接使用缓存，
+//     是否过期按 Store 中记录的写入时间判断，服务器重启后仍然有效
+//   - 超过 MaxAge 后向上游重新获取，上游不可用（网络错误或 5xx）时使用过期的缓存
+//   - tarball 下载并通过完整性校验后写入 Store，之后一直使用缓存
+//   - 返回的元数据与上游相同，只有 dist.tarball 被改写为指向服务器本身，Store 中保存的是上游的原始地址
+//
+// 响应头 X-Cache 表示响应的来源，取值为 CacheHit、CacheMiss 或 CacheStale。
+// 同一个包或 tarball 的并发请求只会向上游请求一次。
+//
+// 使用示例:
+//
+//	s, _ := store.NewFileStore("./storage")
+//	server := NewServer(registry.NewNpmMirrorRegistry(), s, nil)
+//	http.ListenAndServe(":4873", server)
+//
+//	// 客户端
+//	// npm config set registry http://localhost:4873/
+type Server struct {
+	upstream *registry.Registry
+	store    store.Store
+	options  *Options
+
+	lock     sync.Mutex
+	inflight map[string]*call
+}
+
+var _ http.Handler = &Server{}
+
+// packument 表示缓存的包元数据
+//
+// 主要字段说明:
+//   - pkg: 解析后的包元数据，用于查找版本和 dist-tag
+//   - data: 上游返回的原始 JSON，响应中的元数据由它生成，不会丢失 models.Package 中没有的字段
+type packument struct {
+	pkg  *models.Package
+	data []byte
+}
+
+// parsePackument 解析包元数据的 JSON
+func parsePackument(data []byte) (*packument, error) {
+	var pkg models.Package
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+	return &packument{pkg: &pkg, data: data}, nil
+}
+
+// call 表示一个正在进行的上游请求
+type call struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// NewServer 创建一个缓存服务器
+//
+// 参数:
+//   - upstream: 上游 Registry，可以是官方仓库或 mirror.go 中的任意镜像
+//   - s: 保存缓存的存储
+//   - options: 配置选项，为 nil 时使用默认选项
+//
+// 返回值:
+//   - *Server: 新创建的服务器，实现了 http.Handler
+func NewServer(upstream *registry.Registry, s store.Store, options *Options) *Server {
+	if options == nil {
+		options = NewOptions()
+	}
+	return &Server{
+		upstream: upstream,
+		store:    s,
+		options:  options,
+		inflight: make(map[string]*call),
+	}
+}
+
+// ServeHTTP 处理 Registry 读接口的请求
+func (x *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	p := strings.TrimPrefix(r.URL.Path, "/")
+	switch {
+	case p == "-/ping":
+		writeJSON(w, http.StatusOK, "application/json", struct{}{})
+		return
+	case p == "-/v1/search":
+		x.serveSearch(w, r)
+		return
+	case p == "" || strings.HasPrefix(p, "-/"):
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	segments := strings.Split(p, "/")
+	name, rest := segments[0], segments[1:]
+	if strings.HasPrefix(name, "@") {
+		if len(rest) == 0 {
+			writeError(w, http.StatusNotFound, "Not found")
+			return
+		}
+		name, rest = name+"/"+rest[0], rest[1:]
+	}
+	switch {
+	case len(rest) == 0:
+		x.servePackage(w, r, name)
+	case len(rest) == 1:
+		x.serveVersion(w, r, name, rest[0])
+	case len(rest) == 2 && rest[0] == "-":
+		x.serveTarball(w, r, name, rest[1])
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// servePackage 返回包元数据
+func (x *Server) servePackage(w http.ResponseWriter, r *http.Request, name string) {
+	p, cache, err := x.packument(r.Context(), name)
+	if err != nil {
+		x.writeFetchError(w, err)
+		return
+	}
+	doc, err := rewriteTarballs(p.data, name, x.baseURL(r))
+	if err != nil {
+		x.writeFetchError(w, err)
+		return
+	}
+	w.Header().Set("X-Cache", cache)
+	w.Header().Add("Vary", "Accept")
+	if strings.Contains(r.Header.Get("Accept"), "application/vnd.npm.install-v1+json") {
+		writeJSON(w, http.StatusOK, "application/vnd.npm.install-v1+json", abbreviate(name, p.pkg, doc))
+		return
+	}
+	writeJSON(w, http.StatusOK, "application/json", doc)
+}
+
+// serveVersion 返回版本元数据，version 可以是版本号或 dist-tag
+func (x *Server) serveVersion(w http.ResponseWriter, r *http.Request, name, version string) {
+	p, cache, err := x.packument(r.Context(), name)
+	if err != nil {
+		x.writeFetchError(w, err)
+		return
+	}
+	if tagged, ok := p.pkg.DistTags[version]; ok {
+		version = tagged
+	}
+	if _, ok := p.pkg.Versions[version]; !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("version not found: %s", version))
+		return
+	}
+	doc, err := rewriteTarballs(p.data, name, x.baseURL(r))
+	if err != nil {
+		x.writeFetchError(w, err)
+		return
+	}
+	w.Header().Set("X-Cache", cache)
+	writeJSON(w, http.StatusOK, "application/json", doc.versions[version])
+}
+
+// serveTarball 返回 tarball，缓存中没有时从上游下载
+func (x *Server) serveTarball(w http.ResponseWriter, r *http.Request, name, filename string) {
+	prefix := path.Base(name) + "-"
+	if !strings.HasPrefix(filename, prefix) || !strings.HasSuffix(filename, ".tgz") || len(filename) <= len(prefix)+len(".tgz") {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	version := strings.TrimSuffix(strings.TrimPrefix(filename, prefix), ".tgz")
+
+	reader, err := x.store.OpenTarball(r.Context(), name, version)
+	if err == nil {
+		defer reader.Close()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("X-Cache", CacheHit)
+		_, _ = io.Copy(w, reader)
+		return
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		x.writeFetchError(w, err)
+		return
+	}
+
+	data, err := x.do("tarball:"+name+"@"+version, func(ctx context.Context) (any, error) {
+		return x.fetchTarball(ctx, name, version)
+	})
+	if err != nil {
+		x.writeFetchError(w, err)
+		return
+	}
+	tarball := data.([]byte)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(tarball)))
+	w.Header().Set("X-Cache", CacheMiss)
+	_, _ = w.Write(tarball)
+}
+
+// serveSearch 把搜索请求的所有查询参数转发给上游，并原样返回上游的响应
+func (x *Server) serveSearch(w http.ResponseWriter, r *http.Request) {
+	data, err := x.upstream.SearchPackagesJSON(r.Context(), r.URL.Query())
+	if err != nil {
+		x.writeFetchError(w, &upstreamError{err})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// packument 返回包元数据以及它的来源
+//
+// 缓存的写入时间在 MaxAge 内时直接返回，否则向上游获取，上游不可用时返回过期的缓存
+func (x *Server) packument(ctx context.Context, name string) (*packument, string, error) {
+	var cached *packument
+	data, stored, err := x.store.GetPackageJSON(ctx, name)
+	switch {
+	case err == nil:
+		if cached, err = parsePackument(data); err != nil {
+			return nil, "", err
+		}
+	case !errors.Is(err, store.ErrNotFound):
+		return nil, "", err
+	}
+	if cached != nil && time.Since(stored) < x.options.MaxAge {
+		return cached, CacheHit, nil
+	}
+	value, err := x.do("package:"+name, func(ctx context.Context) (any, error) {
+		return x.fetchPackage(ctx, name)
+	})
+	if err == nil {
+		return value.(*packument), CacheMiss, nil
+	}
+	var upstreamErr *upstreamError
+	if cached != nil && errors.As(err, &upstreamErr) {
+		return cached, CacheStale, nil
+	}
+	return nil, "", err
+}
+
+// fetchPackage 从上游获取包元数据，原样写入缓存
+func (x *Server) fetchPackage(ctx context.Context, name string) (*packument, error) {
+	data, err := x.upstream.GetPackageJSON(ctx, name)
+	if err != nil {
+		return nil, &upstreamError{fmt.Errorf("fetch %s from upstream: %w", name, err)}
+	}
+	p, err := parsePackument(data)
+	if err != nil {
+		return nil, &upstreamError{fmt.Errorf("parse %s from upstream: %w", name, err)}
+	}
+	// 上游对不存在的包返回 404 和 {"error": "Not found"}，解析出来的 Package 没有名称
+	if p.pkg.Name == "" && p.pkg.ID == "" {
+		return nil, fmt.Errorf("%s: %w", name, errNotFound)
+	}
+	if err := x.store.PutPackageJSON(ctx, name, data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// fetchTarball 从上游下载 tarball 并写入缓存，tarball 的地址取自缓存的包元数据
+func (x *Server) fetchTarball(ctx context.Context, name, version string) ([]byte, error) {
+	p, _, err := x.packument(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	manifest, ok := p.pkg.Versions[version]
+	if !ok {
+		return nil, fmt.Errorf("%s@%s: %w", name, version, errNotFound)
+	}
+	var buf bytes.Buffer
+	if err := x.upstream.DownloadTarball(ctx, &manifest, &buf); err != nil {
+		return nil, &upstreamError{err}
+	}
+	if err := x.store.PutTarball(ctx, name, version, bytes.NewReader(buf.Bytes())); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// do 执行 key 对应的上游请求，同一个 key 的并发调用共享一次请求的结果
+//
+// 请求使用独立的上下文和 Options.Timeout，客户端断开连接不会中断正在写入缓存的请求
+func (x *Server) do(key string, fn func(ctx context.Context) (any, error)) (any, error) {
+	x.lock.Lock()
+	if c, ok := x.inflight[key]; ok {
+		x.lock.Unlock()
+		<-c.done
+		return c.value, c.err
+	}
+	c := &call{done: make(chan struct{})}
+	x.inflight[key] = c
+	x.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), x.options.Timeout)
+	c.value, c.err = fn(ctx)
+	cancel()
+
+	x.lock.Lock()
+	delete(x.inflight, key)
+	x.lock.Unlock()
+	close(c.done)
+	return c.value, c.err
+}
+
+// baseURL 返回 tarball 地址使用的服务器地址
+func (x *Server) baseURL(r *http.Request) string {
+	if x.options.PublicURL != "" {
+		return strings.TrimSuffix(x.options.PublicURL, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+// writeFetchError 把获取数据的错误转换为响应
+func (x *Server) writeFetchError(w http.ResponseWriter, err error) {
+	var upstreamErr *upstreamError
+	switch {
+	case errors.Is(err, errNotFound):
+		writeError(w, http.StatusNotFound, "Not found")
+	case errors.Is(err, store.ErrInvalidKey):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.As(err, &upstreamErr):
+		writeError(w, http.StatusBadGateway, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// rawPackument 是按字段拆分的包元数据 JSON，序列化时保留所有字段
+type rawPackument struct {
+	fields   map[string]json.RawMessage
+	versions map[string]map[string]json.RawMessage
+}
+
+// MarshalJSON 把改写后的版本写回 versions 字段
+func (x *rawPackument) MarshalJSON() ([]byte, error) {
+	if x.versions != nil {
+		versions, err := json.Marshal(x.versions)
+		if err != nil {
+			return nil, err
+		}
+		x.fields["versions"] = versions
+	}
+	return json.Marshal(x.fields)
+}
+
+// rewriteTarballs 解析包元数据的 JSON，把每个版本的 dist.tarball 改写为指向 baseURL，其它字段保持不变
+func rewriteTarballs(data []byte, name, baseURL string) (*rawPackument, error) {
+	doc := &rawPackument{}
+	if err := json.Unmarshal(data, &doc.fields); err != nil {
+		return nil, err
+	}
+	if raw, ok := doc.fields["versions"]; ok {
+		if err := json.Unmarshal(raw, &doc.versions); err != nil {
+			return nil, err
+		}
+	}
+	for version, manifest := range doc.versions {
+		var dist map[string]json.RawMessage
+		if err := json.Unmarshal(manifest["dist"], &dist); err != nil || dist == nil {
+			continue
+		}
+		tarball, err := json.Marshal(baseURL + "/" + store.TarballPath(name, version))
+		if err != nil {
+			return nil, err
+		}
+		dist["tarball"] = tarball
+		if manifest["dist"], err = json.Marshal(dist); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// writeJSON 写入 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, contentType string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// writeError 写入与 npm Registry 格式相同的错误响应
+func writeError(w http.ResponseWriter, status int, message string) {
+	data, _ := json.Marshal(map[string]string{"error": message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/registry"
+	"github.com/scagogogo/npm-crawler/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupUpstream 创建提供 react 和 @types/node 两个包的模拟上游，requests 统计请求次数
+func setupUpstream(requests *int32) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		switch {
+		case name == "-/v1/search":
+			query := r.URL.Query()
+			fmt.Fprintf(w, `{"objects": [{"package": {"name": %q}, "flags": {"insecure": 0}}], "total": 1, "from": %q}`, query.Get("text"), query.Get("from"))
+			return
+		case strings.HasSuffix(name, ".tgz"):
+			w.Write([]byte("tarball of " + name))
+			return
+		case name != "react" && name != "@types/node":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "Not found"}`))
+			return
+		}
+		base := name[strings.LastIndex(name, "/")+1:]
+		tarball := fmt.Sprintf("%s/-/%s-1.0.0.tgz", name, base)
+		sum := sha1.Sum([]byte("tarball of " + tarball))
+		fmt.Fprintf(w, `{
+			"_id": %[1]q,
+			"_rev": "1-a",
+			"name": %[1]q,
+			"dist-tags": {"latest": "1.0.0"},
+			"time": {"modified": "2024-01-01T00:00:00.000Z"},
+			"readme": "long readme",
+			"versions": {
+				"1.0.0": {
+					"name": %[1]q,
+					"version": "1.0.0",
+					"bin": "cli.js",
+					"dependencies": {"loose-envify": "^1.1.0"},
+					"bundleDependencies": ["loose-envify"],
+					"acceptDependencies": {"loose-envify": "^2.0.0"},
+					"libc": ["glibc"],
+					"_hasShrinkwrap": false,
+					"dist": {"tarball": "%[2]s/%[3]s", "shasum": %[4]q, "fileCount": 3}
+				}
+			}
+		}`, name, server.URL, tarball, hex.EncodeToString(sum[:]))
+	}))
+	return server
+}
+
+// get 请求缓存服务器，返回状态码、X-Cache 响应头和响应体
+func get(t *testing.T, handler http.Handler, target string, headers ...string) (int, string, string) {
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		request.Header.Set(headers[i], headers[i+1])
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	body, err := io.ReadAll(recorder.Body)
+	assert.Nil(t, err)
+	return recorder.Code, recorder.Header().Get("X-Cache"), string(body)
+}
+
+func TestServer(t *testing.T) {
+	var requests int32
+	upstream := setupUpstream(&requests)
+	defer upstream.Close()
+	s, err := store.NewFileStore(t.TempDir())
+	assert.Nil(t, err)
+	server := NewServer(registry.NewRegistry(registry.NewOptions().SetRegistryURL(upstream.URL)), s, NewOptions().SetPublicURL("http://cache.example.com/"))
+
+	// 第一次请求从上游获取，之后使用缓存
+	code, cache, body := get(t, server, "/react")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, CacheMiss, cache)
+	assert.Contains(t, body, `"tarball":"http://cache.example.com/react/-/react-1.0.0.tgz"`)
+	assert.Contains(t, body, "long readme")
+	var full map[string]any
+	assert.Nil(t, json.Unmarshal([]byte(body), &full))
+	manifest := full["versions"].(map[string]any)["1.0.0"].(map[string]any)
+	assert.Equal(t, []any{"loose-envify"}, manifest["bundleDependencies"])
+	assert.Equal(t, map[string]any{"loose-envify": "^2.0.0"}, manifest["acceptDependencies"])
+	assert.Equal(t, []any{"glibc"}, manifest["libc"])
+	assert.Equal(t, false, manifest["_hasShrinkwrap"])
+	assert.Equal(t, float64(3), manifest["dist"].(map[string]any)["fileCount"])
+	code, cache, _ = get(t, server, "/react")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, CacheHit, cache)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// 缓存中保存的是上游的原始地址
+	pkg, err := s.GetPackage(context.Background(), "react")
+	assert.Nil(t, err)
+	assert.Equal(t, upstream.URL+"/react/-/react-1.0.0.tgz", pkg.Versions["1.0.0"].Dist.Tarball)
+
+	// 精简版元数据
+	code, _, body = get(t, server, "/react", "Accept", registry.AbbreviatedMetadataAccept)
+	assert.Equal(t, http.StatusOK, code)
+	var abbreviated map[string]any
+	assert.Nil(t, json.Unmarshal([]byte(body), &abbreviated))
+	assert.Equal(t, "2024-01-01T00:00:00.000Z", abbreviated["modified"])
+	assert.NotContains(t, body, "long readme")
+	assert.Contains(t, body, `"bin":{"react":"cli.js"}`)
+	assert.Contains(t, body, `"libc":["glibc"]`)
+	assert.Contains(t, body, `"_hasShrinkwrap":false`)
+
+	// 版本元数据，支持 dist-tag
+	code, _, body = get(t, server, "/react/latest")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `"version":"1.0.0"`)
+	assert.Contains(t, body, `"tarball":"http://cache.example.com/react/-/react-1.0.0.tgz"`)
+	assert.Contains(t, body, `"bundleDependencies":["loose-envify"]`)
+	code, _, _ = get(t, server, "/react/2.0.0")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// tarball 下载后写入缓存
+	code, cache, body = get(t, server, "/react/-/react-1.0.0.tgz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, CacheMiss, cache)
+	assert.Equal(t, "tarball of react/-/react-1.0.0.tgz", body)
+	code, cache, body = get(t, server, "/react/-/react-1.0.0.tgz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, CacheHit, cache)
+	assert.Equal(t, "tarball of react/-/react-1.0.0.tgz", body)
+	code, _, _ = get(t, server, "/react/-/react-2.0.0.tgz")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// 作用域包，npm 客户端会把 "/" 编码为 %2f
+	code, _, body = get(t, server, "/@types%2fnode")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `"tarball":"http://cache.example.com/@types/node/-/node-1.0.0.tgz"`)
+	code, _, body = get(t, server, "/@types/node/-/node-1.0.0.tgz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "tarball of @types/node/-/node-1.0.0.tgz", body)
+
+	// 上游不存在的包和非法的路径
+	code, _, body = get(t, server, "/missing")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.JSONEq(t, `{"error": "Not found"}`, body)
+	code, _, _ = get(t, server, "/react/-/other-1.0.0.tgz")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _, _ = get(t, server, "/..")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 搜索的所有查询参数都转发给上游，响应原样返回
+	code, _, body = get(t, server, "/-/v1/search?text=react+dom&size=5&from=20&quality=0.5")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"objects": [{"package": {"name": "react dom"}, "flags": {"insecure": 0}}], "total": 1, "from": "20"}`, body)
+
+	code, _, _ = get(t, server, "/-/ping")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestServer_UpstreamDown(t *testing.T) {
+	var requests int32
+	upstream := setupUpstream(&requests)
+	s, err := store.NewFileStore(t.TempDir())
+	assert.Nil(t, err)
+	server := NewServer(registry.NewRegistry(registry.NewOptions().SetRegistryURL(upstream.URL)), s, NewOptions().SetMaxAge(0).SetTimeout(5*time.Second))
+
+	code, cache, _ := get(t, server, "/react")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, CacheMiss, cache)
+	code, _, _ = get(t, server, "/react/-/react-1.0.0.tgz")
+	assert.Equal(t, http.StatusOK, code)
+
+	// MaxAge 为 0 时每次都向上游确认
+	_, cache, _ = get(t, server, "/react")
+	assert.Equal(t, CacheMiss, cache)
+
+	// 上游不可用时使用过期的缓存，缓存中没有的包返回 502
+	upstream.Close()
+	code, cache, body := get(t, server, "/react")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, CacheStale, cache)
+	assert.Contains(t, body, `"tarball":"http://example.com/react/-/react-1.0.0.tgz"`)
+	code, cache, _ = get(t, server, "/react/-/react-1.0.0.tgz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, CacheHit, cache)
+	code, _, _ = get(t, server, "/@types/node")
+	assert.Equal(t, http.StatusBadGateway, code)
+}
+
+func TestServer_Restart(t *testing.T) {
+	var requests int32
+	upstream := setupUpstream(&requests)
+	defer upstream.Close()
+	s, err := store.NewFileStore(t.TempDir())
+	assert.Nil(t, err)
+	r := registry.NewRegistry(registry.NewOptions().SetRegistryURL(upstream.URL))
+
+	_, cache, _ := get(t, NewServer(r, s, nil), "/react")
+	assert.Equal(t, CacheMiss, cache)
+
+	// 写入时间保存在 Store 中，重启后的服务器仍然认为缓存是新鲜的
+	_, cache, body := get(t, NewServer(r, s, nil), "/react")
+	assert.Equal(t, CacheHit, cache)
+	assert.Contains(t, body, `"libc":["glibc"]`)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// 超过 MaxAge 后重新获取
+	_, cache, _ = get(t, NewServer(r, s, NewOptions().SetMaxAge(0)), "/react")
+	assert.Equal(t, CacheMiss, cache)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,503 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// 归档记录的操作类型
+const (
+	archivePackage = "package"
+	archiveVersion = "version"
+	archiveTarball = "tarball"
+	archiveDelete  = "delete"
+)
+
+// archiveRecord 是归档文件中的一行记录
+//
+// 删除记录的 Version 为空时表示删除整个包，否则只删除该版本；包元数据记录的 Time 为写入时间
+type archiveRecord struct {
+	Op      string          `json:"op"`
+	Name    string          `json:"name"`
+	Version string          `json:"version,omitempty"`
+	Rev     string          `json:"rev,omitempty"`
+	Time    *time.Time      `json:"time,omitempty"`
+	Doc     json.RawMessage `json:"doc,omitempty"`
+	Data    []byte          `json:"data,omitempty"`
+}
+
+// ArchiveStore 是只追加的 JSON Lines 归档存储，每次写入或删除都在文件末尾追加一行记录
+//
+// 文件名以 ".gz" 结尾时每条记录单独压缩为一个 gzip 成员，整个文件仍然是合法的 gzip 文件，
+// 可以直接用 zcat 查看。打开时会扫描整个文件在内存中建立每个键最新记录的偏移量索引，
+// 读取时按偏移量直接定位。文件末尾不完整的记录（例如写入时进程崩溃）会在打开时被截断。
+//
+// 适合作为抓取结果的归档: 顺序写入，便于传输和用标准工具处理。
+// 被覆盖和删除的记录仍然占用空间，文件只会增长
+type ArchiveStore struct {
+	lock   sync.RWMutex
+	file   *os.File
+	gzip   bool
+	size   int64
+	closed bool
+
+	packages  map[string]int64
+	revisions map[string]string
+	versions  map[string]map[string]int64
+	tarballs  map[string]map[string]int64
+}
+
+var _ Store = &ArchiveStore{}
+
+// OpenArchiveStore 打开保存在 filename 的归档，文件不存在时创建
+//
+// 使用示例:
+//
+//	s, err := store.OpenArchiveStore("packages.jsonl.gz")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	defer s.Close()
+func OpenArchiveStore(filename string) (*ArchiveStore, error) {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	x := &ArchiveStore{
+		file:      file,
+		gzip:      strings.HasSuffix(filename, ".gz"),
+		packages:  make(map[string]int64),
+		revisions: make(map[string]string),
+		versions:  make(map[string]map[string]int64),
+		tarballs:  make(map[string]map[string]int64),
+	}
+	if err := x.replay(); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("replay archive %s: %w", filename, err)
+	}
+	return x, nil
+}
+
+// PutPackage 追加一条包元数据记录
+func (x *ArchiveStore) PutPackage(ctx context.Context, pkg *models.Package) error {
+	name := packageName(pkg)
+	if err := checkName(name); err != nil {
+		return err
+	}
+	doc, err := json.Marshal(pkg)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return x.append(&archiveRecord{Op: archivePackage, Name: name, Rev: pkg.Rev, Time: &now, Doc: doc})
+}
+
+// GetPackage 读取最新的包元数据记录
+func (x *ArchiveStore) GetPackage(ctx context.Context, name string) (*models.Package, error) {
+	record, err := x.packageRecord(name)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalPackage(record.Doc)
+}
+
+// PutPackageJSON 追加一条原样保存 JSON 的包元数据记录
+func (x *ArchiveStore) PutPackageJSON(ctx context.Context, name string, data []byte) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	rev, err := packageRevision(data)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return x.append(&archiveRecord{Op: archivePackage, Name: name, Rev: rev, Time: &now, Doc: data})
+}
+
+// GetPackageJSON 读取最新的包元数据记录的 JSON 以及写入时间，没有记录写入时间的旧记录返回零值
+func (x *ArchiveStore) GetPackageJSON(ctx context.Context, name string) ([]byte, time.Time, error) {
+	record, err := x.packageRecord(name)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var stored time.Time
+	if record.Time != nil {
+		stored = *record.Time
+	}
+	return record.Doc, stored, nil
+}
+
+// packageRecord 读取最新的包元数据记录
+func (x *ArchiveStore) packageRecord(name string) (*archiveRecord, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	x.lock.RLock()
+	offset, ok := x.packages[name]
+	x.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	return x.read(offset)
+}
+
+// Revision 返回最新的包元数据的修订版本，直接从内存索引中读取
+func (x *ArchiveStore) Revision(ctx context.Context, name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	rev, ok := x.revisions[name]
+	if !ok {
+		return "", fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	return rev, nil
+}
+
+// DeletePackage 追加一条删除整个包的记录
+func (x *ArchiveStore) DeletePackage(ctx context.Context, name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	return x.append(&archiveRecord{Op: archiveDelete, Name: name})
+}
+
+// ListPackages 按包名顺序遍历保存了包元数据的包
+func (x *ArchiveStore) ListPackages(ctx context.Context, fn func(name string) error) error {
+	x.lock.RLock()
+	names := make([]string, 0, len(x.packages))
+	for name := range x.packages {
+		names = append(names, name)
+	}
+	x.lock.RUnlock()
+	sort.Strings(names)
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PutVersion 追加一条版本元数据记录
+func (x *ArchiveStore) PutVersion(ctx context.Context, version *models.Version) error {
+	if err := checkName(version.Name); err != nil {
+		return err
+	}
+	if err := checkVersion(version.Version); err != nil {
+		return err
+	}
+	doc, err := json.Marshal(version)
+	if err != nil {
+		return err
+	}
+	return x.append(&archiveRecord{Op: archiveVersion, Name: version.Name, Version: version.Version, Doc: doc})
+}
+
+// GetVersion 读取最新的版本元数据记录
+func (x *ArchiveStore) GetVersion(ctx context.Context, name, version string) (*models.Version, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	x.lock.RLock()
+	offset, ok := x.versions[name][version]
+	x.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%s@%s: %w", name, version, ErrNotFound)
+	}
+	record, err := x.read(offset)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalVersion(record.Doc)
+}
+
+// ListVersions 返回保存了元数据的版本号
+func (x *ArchiveStore) ListVersions(ctx context.Context, name string) ([]string, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	x.lock.RLock()
+	var versions []string
+	for version := range x.versions[name] {
+		versions = append(versions, version)
+	}
+	x.lock.RUnlock()
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// DeleteVersion 追加一条删除版本的记录
+func (x *ArchiveStore) DeleteVersion(ctx context.Context, name, version string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if err := checkVersion(version); err != nil {
+		return err
+	}
+	return x.append(&archiveRecord{Op: archiveDelete, Name: name, Version: version})
+}
+
+// PutTarball 追加一条 tarball 记录，数据会完整读入内存后以 base64 编码写入
+func (x *ArchiveStore) PutTarball(ctx context.Context, name, version string, r io.Reader) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if err := checkVersion(version); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return x.append(&archiveRecord{Op: archiveTarball, Name: name, Version: version, Data: data})
+}
+
+// OpenTarball 读取最新的 tarball 记录
+func (x *ArchiveStore) OpenTarball(ctx context.Context, name, version string) (io.ReadCloser, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	x.lock.RLock()
+	offset, ok := x.tarballs[name][version]
+	x.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("tarball of %s@%s: %w", name, version, ErrNotFound)
+	}
+	record, err := x.read(offset)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(record.Data)), nil
+}
+
+// Close 把数据刷到磁盘并关闭文件
+func (x *ArchiveStore) Close() error {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	if x.closed {
+		return nil
+	}
+	x.closed = true
+	syncErr := x.file.Sync()
+	return errors.Join(syncErr, x.file.Close())
+}
+
+// append 在文件末尾写入一条记录并更新索引
+//
+// 写入失败时不移动文件末尾的位置，下一条记录会覆盖写了一半的数据
+func (x *ArchiveStore) append(record *archiveRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if x.gzip {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	if x.closed {
+		return os.ErrClosed
+	}
+	if _, err := x.file.WriteAt(data, x.size); err != nil {
+		return err
+	}
+	x.apply(record, x.size)
+	x.size += int64(len(data))
+	return nil
+}
+
+// apply 把一条位于 offset 的记录应用到索引
+func (x *ArchiveStore) apply(record *archiveRecord, offset int64) {
+	switch record.Op {
+	case archivePackage:
+		x.packages[record.Name] = offset
+		x.revisions[record.Name] = record.Rev
+	case archiveVersion:
+		setOffset(x.versions, record.Name, record.Version, offset)
+	case archiveTarball:
+		setOffset(x.tarballs, record.Name, record.Version, offset)
+	case archiveDelete:
+		if record.Version == "" {
+			delete(x.packages, record.Name)
+			delete(x.revisions, record.Name)
+			delete(x.versions, record.Name)
+			delete(x.tarballs, record.Name)
+		} else {
+			deleteOffset(x.versions, record.Name, record.Version)
+			deleteOffset(x.tarballs, record.Name, record.Version)
+		}
+	}
+}
+
+// read 读取位于 offset 的记录
+func (x *ArchiveStore) read(offset int64) (*archiveRecord, error) {
+	var r io.Reader = io.NewSectionReader(x.file, offset, 1<<62)
+	if x.gzip {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		gz.Multistream(false)
+		r = gz
+	}
+	line, err := bufio.NewReader(r).ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read archive record at %d: %w", offset, err)
+	}
+	var record archiveRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, fmt.Errorf("read archive record at %d: %w", offset, err)
+	}
+	return &record, nil
+}
+
+// replay 扫描整个文件重建索引，截断文件末尾不完整的记录
+func (x *ArchiveStore) replay() error {
+	var end int64
+	var err error
+	if x.gzip {
+		end, err = x.replayGzip()
+	} else {
+		end, err = x.replayLines()
+	}
+	if err != nil {
+		return err
+	}
+	info, err := x.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > end {
+		if err := x.file.Truncate(end); err != nil {
+			return err
+		}
+	}
+	x.size = end
+	return nil
+}
+
+// replayLines 扫描未压缩的归档，返回最后一条完整记录结束的位置
+func (x *ArchiveStore) replayLines() (int64, error) {
+	reader := bufio.NewReader(io.NewSectionReader(x.file, 0, 1<<62))
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// 没有换行符的最后一行是写了一半的记录
+			return offset, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		var record archiveRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return 0, fmt.Errorf("record at %d: %w", offset, err)
+		}
+		x.apply(&record, offset)
+		offset += int64(len(line))
+	}
+}
+
+// replayGzip 扫描压缩的归档，每个 gzip 成员是一条记录，返回最后一条完整记录结束的位置
+func (x *ArchiveStore) replayGzip() (int64, error) {
+	counter := &countingReader{reader: bufio.NewReader(io.NewSectionReader(x.file, 0, 1<<62))}
+	var offset int64
+	for {
+		gz, err := gzip.NewReader(counter)
+		if err == io.EOF {
+			return offset, nil
+		}
+		if err != nil {
+			// 不完整的 gzip 头
+			return offset, nil
+		}
+		gz.Multistream(false)
+		data, err := io.ReadAll(gz)
+		if err != nil {
+			// 不完整的数据或者校验和错误，视为写了一半的记录
+			return offset, nil
+		}
+		var record archiveRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return 0, fmt.Errorf("record at %d: %w", offset, err)
+		}
+		x.apply(&record, offset)
+		offset = counter.count
+	}
+}
+
+// countingReader 统计已经读取的字节数
+//
+// 实现了 io.ByteReader，gzip 解压时不会额外预读，读完一个 gzip 成员后 count 正好是下一个成员的起始位置
+type countingReader struct {
+	reader *bufio.Reader
+	count  int64
+}
+
+// Read 读取数据并计数
+func (x *countingReader) Read(p []byte) (int, error) {
+	n, err := x.reader.Read(p)
+	x.count += int64(n)
+	return n, err
+}
+
+// ReadByte 读取一个字节并计数
+func (x *countingReader) ReadByte() (byte, error) {
+	b, err := x.reader.ReadByte()
+	if err == nil {
+		x.count++
+	}
+	return b, err
+}
+
+// setOffset 设置二级索引中的偏移量
+func setOffset(index map[string]map[string]int64, name, version string, offset int64) {
+	versions, ok := index[name]
+	if !ok {
+		versions = make(map[string]int64)
+		index[name] = versions
+	}
+	versions[version] = offset
+}
+
+// deleteOffset 删除二级索引中的偏移量
+func deleteOffset(index map[string]map[string]int64, name, version string) {
+	delete(index[name], version)
+	if len(index[name]) == 0 {
+		delete(index, name)
+	}
+}
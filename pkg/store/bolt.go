@@ -0,0 +1,312 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltStore 中使用的 bucket，版本和 tarball 的键为 "<包名>\x00<版本号>"
+var (
+	boltPackages  = []byte("packages")
+	boltRevisions = []byte("revisions")
+	boltStored    = []byte("stored")
+	boltVersions  = []byte("versions")
+	boltTarballs  = []byte("tarballs")
+)
+
+// BoltStore 是基于 bbolt 嵌入式键值数据库的存储，所有数据保存在单个文件中
+//
+// 适合保存数百万个包的元数据: 按键有序存储，ListPackages 不需要遍历目录，
+// 修订版本单独保存，Revision 不需要解析完整的包元数据
+type BoltStore struct {
+	db *bolt.DB
+}
+
+var _ Store = &BoltStore{}
+
+// OpenBoltStore 打开保存在 filename 的 BoltStore，文件不存在时创建
+//
+// 同一个文件同时只能被一个进程打开，另一个进程已经打开时等待 1 秒后返回错误
+//
+// 使用示例:
+//
+//	s, err := store.OpenBoltStore("packages.db")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	defer s.Close()
+func OpenBoltStore(filename string) (*BoltStore, error) {
+	db, err := bolt.Open(filename, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltPackages, boltRevisions, boltStored, boltVersions, boltTarballs} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// PutPackage 保存包元数据
+func (x *BoltStore) PutPackage(ctx context.Context, pkg *models.Package) error {
+	name := packageName(pkg)
+	if err := checkName(name); err != nil {
+		return err
+	}
+	data, err := json.Marshal(pkg)
+	if err != nil {
+		return err
+	}
+	return x.putPackage(name, data, pkg.Rev)
+}
+
+// GetPackage 读取包元数据
+func (x *BoltStore) GetPackage(ctx context.Context, name string) (*models.Package, error) {
+	data, err := x.get(boltPackages, []byte(name), name)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalPackage(data)
+}
+
+// PutPackageJSON 原样保存包元数据的 JSON
+func (x *BoltStore) PutPackageJSON(ctx context.Context, name string, data []byte) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	rev, err := packageRevision(data)
+	if err != nil {
+		return err
+	}
+	return x.putPackage(name, data, rev)
+}
+
+// GetPackageJSON 读取包元数据的 JSON 以及写入时间，没有记录写入时间的旧数据返回零值
+func (x *BoltStore) GetPackageJSON(ctx context.Context, name string) ([]byte, time.Time, error) {
+	data, err := x.get(boltPackages, []byte(name), name)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var stored time.Time
+	if value, err := x.get(boltStored, []byte(name), name); err == nil {
+		_ = stored.UnmarshalText(value)
+	}
+	return data, stored, nil
+}
+
+// putPackage 在一个事务中保存包元数据、修订版本和写入时间
+func (x *BoltStore) putPackage(name string, data []byte, rev string) error {
+	stored, err := time.Now().MarshalText()
+	if err != nil {
+		return err
+	}
+	return x.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(boltPackages).Put([]byte(name), data); err != nil {
+			return err
+		}
+		if err := tx.Bucket(boltStored).Put([]byte(name), stored); err != nil {
+			return err
+		}
+		return tx.Bucket(boltRevisions).Put([]byte(name), []byte(rev))
+	})
+}
+
+// Revision 返回包元数据的修订版本
+func (x *BoltStore) Revision(ctx context.Context, name string) (string, error) {
+	data, err := x.get(boltRevisions, []byte(name), name)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// DeletePackage 删除包元数据以及该包所有的版本元数据和 tarball
+func (x *BoltStore) DeletePackage(ctx context.Context, name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	return x.db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{boltPackages, boltRevisions, boltStored} {
+			if err := tx.Bucket(bucket).Delete([]byte(name)); err != nil {
+				return err
+			}
+		}
+		prefix := versionKey(name, "")
+		for _, bucket := range [][]byte{boltVersions, boltTarballs} {
+			var keys [][]byte
+			cursor := tx.Bucket(bucket).Cursor()
+			for k, _ := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cursor.Next() {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			for _, k := range keys {
+				if err := tx.Bucket(bucket).Delete(k); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// ListPackages 按包名顺序遍历保存了包元数据的包
+//
+// 包名是在一个只读事务中全部读出后再依次调用 fn 的，因此 fn 中可以读写存储
+func (x *BoltStore) ListPackages(ctx context.Context, fn func(name string) error) error {
+	var names []string
+	err := x.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltRevisions).ForEach(func(k, v []byte) error {
+			names = append(names, string(k))
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PutVersion 保存版本元数据
+func (x *BoltStore) PutVersion(ctx context.Context, version *models.Version) error {
+	if err := checkName(version.Name); err != nil {
+		return err
+	}
+	if err := checkVersion(version.Version); err != nil {
+		return err
+	}
+	data, err := json.Marshal(version)
+	if err != nil {
+		return err
+	}
+	return x.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltVersions).Put(versionKey(version.Name, version.Version), data)
+	})
+}
+
+// GetVersion 读取版本元数据
+func (x *BoltStore) GetVersion(ctx context.Context, name, version string) (*models.Version, error) {
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	data, err := x.get(boltVersions, versionKey(name, version), name+"@"+version)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalVersion(data)
+}
+
+// ListVersions 返回保存了元数据的版本号
+func (x *BoltStore) ListVersions(ctx context.Context, name string) ([]string, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	var versions []string
+	prefix := versionKey(name, "")
+	err := x.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltVersions).Cursor()
+		for k, _ := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cursor.Next() {
+			versions = append(versions, string(k[len(prefix):]))
+		}
+		return nil
+	})
+	return versions, err
+}
+
+// DeleteVersion 删除版本元数据和 tarball
+func (x *BoltStore) DeleteVersion(ctx context.Context, name, version string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if err := checkVersion(version); err != nil {
+		return err
+	}
+	return x.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(boltVersions).Delete(versionKey(name, version)); err != nil {
+			return err
+		}
+		return tx.Bucket(boltTarballs).Delete(versionKey(name, version))
+	})
+}
+
+// PutTarball 保存 tarball，数据会完整读入内存后写入数据库
+func (x *BoltStore) PutTarball(ctx context.Context, name, version string, r io.Reader) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if err := checkVersion(version); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return x.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltTarballs).Put(versionKey(name, version), data)
+	})
+}
+
+// OpenTarball 读取 tarball
+func (x *BoltStore) OpenTarball(ctx context.Context, name, version string) (io.ReadCloser, error) {
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	data, err := x.get(boltTarballs, versionKey(name, version), "tarball of "+name+"@"+version)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Close 关闭数据库
+func (x *BoltStore) Close() error {
+	return x.db.Close()
+}
+
+// get 读取 bucket 中的键并复制一份，不存在时返回包装了 ErrNotFound 的错误
+func (x *BoltStore) get(bucket, key []byte, description string) ([]byte, error) {
+	name, _, _ := bytes.Cut(key, []byte{0})
+	if err := checkName(string(name)); err != nil {
+		return nil, err
+	}
+	var data []byte
+	err := x.db.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(bucket).Get(key); value != nil {
+			data = append([]byte(nil), value...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%s: %w", description, ErrNotFound)
+	}
+	return data, nil
+}
+
+// versionKey 返回版本和 tarball 使用的键
+func versionKey(name, version string) []byte {
+	return []byte(name + "\x00" + version)
+}
@@ -0,0 +1,374 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// 文件存储中包目录下的文件名
+const (
+	fsPackageFile = "index.json"
+	fsTarballDir  = "-"
+)
+
+// FileStore 是把数据保存为普通文件的存储，目录结构与 Registry 的 URL 路径一致
+//
+// 目录结构:
+//
+//	<root>/react/index.json                      包元数据，对应 /react
+//	<root>/react/18.2.0/index.json               版本元数据，对应 /react/18.2.0
+//	<root>/react/-/react-18.2.0.tgz              tarball，对应 /react/-/react-18.2.0.tgz
+//	<root>/@types/node/index.json                作用域包
+//	<root>/@types/node/-/node-20.1.0.tgz
+//
+// 写入时先写临时文件再重命名，进程崩溃不会留下不完整的文件
+type FileStore struct {
+	root string
+}
+
+var _ Store = &FileStore{}
+
+// NewFileStore 创建保存到 root 目录的 FileStore，目录不存在时会自动创建
+//
+// 使用示例:
+//
+//	s, err := store.NewFileStore("/data/npm")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	defer s.Close()
+func NewFileStore(root string) (*FileStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{root: root}, nil
+}
+
+// PutPackage 保存包元数据
+func (x *FileStore) PutPackage(ctx context.Context, pkg *models.Package) error {
+	name := packageName(pkg)
+	if err := checkName(name); err != nil {
+		return err
+	}
+	data, err := json.Marshal(pkg)
+	if err != nil {
+		return err
+	}
+	return writeFile(x.packageFile(name), data)
+}
+
+// PutPackageJSON 原样保存包元数据的 JSON
+func (x *FileStore) PutPackageJSON(ctx context.Context, name string, data []byte) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if _, err := packageRevision(data); err != nil {
+		return err
+	}
+	return writeFile(x.packageFile(name), data)
+}
+
+// GetPackageJSON 读取包元数据的 JSON，写入时间为文件的修改时间
+func (x *FileStore) GetPackageJSON(ctx context.Context, name string) ([]byte, time.Time, error) {
+	if err := checkName(name); err != nil {
+		return nil, time.Time{}, err
+	}
+	file, err := os.Open(x.packageFile(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, time.Time{}, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return data, info.ModTime(), nil
+}
+
+// GetPackage 读取包元数据
+func (x *FileStore) GetPackage(ctx context.Context, name string) (*models.Package, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	data, err := readFile(name, x.packageFile(name))
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalPackage(data)
+}
+
+// Revision 返回包元数据的修订版本
+func (x *FileStore) Revision(ctx context.Context, name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	data, err := readFile(name, x.packageFile(name))
+	if err != nil {
+		return "", err
+	}
+	return packageRevision(data)
+}
+
+// DeletePackage 删除包的整个目录，作用域包会保留其他同作用域的包
+func (x *FileStore) DeletePackage(ctx context.Context, name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	dir := x.packageDir(name)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if strings.HasPrefix(name, "@") {
+		// 作用域目录为空时一并删除，忽略非空时的错误
+		_ = os.Remove(filepath.Dir(dir))
+	}
+	return nil
+}
+
+// ListPackages 按包名顺序遍历保存了包元数据的包
+func (x *FileStore) ListPackages(ctx context.Context, fn func(name string) error) error {
+	entries, err := readDirNames(x.root)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		names := []string{entry}
+		if strings.HasPrefix(entry, "@") {
+			scoped, err := readDirNames(filepath.Join(x.root, entry))
+			if err != nil {
+				return err
+			}
+			names = names[:0]
+			for _, name := range scoped {
+				names = append(names, entry+"/"+name)
+			}
+		}
+		for _, name := range names {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if _, err := os.Stat(x.packageFile(name)); err != nil {
+				continue
+			}
+			if err := fn(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PutVersion 保存版本元数据
+func (x *FileStore) PutVersion(ctx context.Context, version *models.Version) error {
+	if err := checkName(version.Name); err != nil {
+		return err
+	}
+	if err := checkVersion(version.Version); err != nil {
+		return err
+	}
+	data, err := json.Marshal(version)
+	if err != nil {
+		return err
+	}
+	return writeFile(x.versionFile(version.Name, version.Version), data)
+}
+
+// GetVersion 读取版本元数据
+func (x *FileStore) GetVersion(ctx context.Context, name, version string) (*models.Version, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	data, err := readFile(name+"@"+version, x.versionFile(name, version))
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalVersion(data)
+}
+
+// ListVersions 返回保存了元数据的版本号
+func (x *FileStore) ListVersions(ctx context.Context, name string) ([]string, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	entries, err := readDirNames(x.packageDir(name))
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, entry := range entries {
+		if entry == fsTarballDir {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(x.packageDir(name), entry, fsPackageFile)); err == nil {
+			versions = append(versions, entry)
+		}
+	}
+	return versions, nil
+}
+
+// DeleteVersion 删除版本元数据和 tarball
+func (x *FileStore) DeleteVersion(ctx context.Context, name, version string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if err := checkVersion(version); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Dir(x.versionFile(name, version))); err != nil {
+		return err
+	}
+	if err := os.Remove(x.tarballFile(name, version)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// PutTarball 保存 tarball
+func (x *FileStore) PutTarball(ctx context.Context, name, version string, r io.Reader) (err error) {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if err := checkVersion(version); err != nil {
+		return err
+	}
+	filename := x.tarballFile(name, version)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(filename), ".tarball-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+	if _, err = io.Copy(file, r); err != nil {
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filename)
+}
+
+// OpenTarball 打开 tarball 文件
+func (x *FileStore) OpenTarball(ctx context.Context, name, version string) (io.ReadCloser, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(x.tarballFile(name, version))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("tarball of %s@%s: %w", name, version, ErrNotFound)
+	}
+	return file, err
+}
+
+// Close 文件存储没有需要释放的资源
+func (x *FileStore) Close() error {
+	return nil
+}
+
+// TarballPath 返回 tarball 相对于 Registry 根路径的 URL 路径，与 registry.npmjs.org 的格式一致，
+// 例如 "react/-/react-18.2.0.tgz"、"@types/node/-/node-20.1.0.tgz"
+func TarballPath(name, version string) string {
+	return name + "/" + fsTarballDir + "/" + path.Base(name) + "-" + version + ".tgz"
+}
+
+// packageDir 返回包的目录
+func (x *FileStore) packageDir(name string) string {
+	return filepath.Join(x.root, filepath.FromSlash(name))
+}
+
+// packageFile 返回包元数据文件的路径
+func (x *FileStore) packageFile(name string) string {
+	return filepath.Join(x.packageDir(name), fsPackageFile)
+}
+
+// versionFile 返回版本元数据文件的路径
+func (x *FileStore) versionFile(name, version string) string {
+	return filepath.Join(x.packageDir(name), version, fsPackageFile)
+}
+
+// tarballFile 返回 tarball 文件的路径
+func (x *FileStore) tarballFile(name, version string) string {
+	return filepath.Join(x.root, filepath.FromSlash(TarballPath(name, version)))
+}
+
+// readFile 读取文件，不存在时返回包装了 ErrNotFound 的错误
+func readFile(key, filename string) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	return data, err
+}
+
+// writeFile 原子地写入文件，目录不存在时自动创建
+func writeFile(filename string, data []byte) (err error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(filename), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+	if _, err = file.Write(data); err != nil {
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filename)
+}
+
+// readDirNames 返回目录下按名称排序的子目录，忽略以 "." 开头的临时文件，目录不存在时返回空列表
+func readDirNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+// ErrNotFound 表示存储中没有请求的包、版本或 tarball
+var ErrNotFound = errors.New("not found in store")
+
+// ErrInvalidKey 表示包名或版本号不能安全地用作存储的键，例如包含 ".." 或 "/" 的路径
+var ErrInvalidKey = errors.New("invalid key")
+
+// Store 是抓取数据的存储接口，爬虫、元数据缓存和本地 Registry 服务使用同一个接口
+//
+// 存储的内容分为三类:
+//   - 包元数据（packument）: 完整的 models.Package 或上游返回的原始 JSON，按包名存储，
+//     同时记录 _rev 作为修订版本，以及写入时间
+//   - 版本元数据: 单独存储的 models.Version，对应 Registry 的 /<name>/<version> 接口
+//   - tarball: 某个版本的压缩包数据
+//
+// 实现需要保证并发安全。删除包时会同时删除它的版本元数据和 tarball。
+// 包名可以是作用域包 "@scope/name"，非法的包名或版本号（例如包含 ".." 的路径）会返回 ErrInvalidKey
+type Store interface {
+
+	// PutPackage 保存包元数据，覆盖已有的数据
+	PutPackage(ctx context.Context, pkg *models.Package) error
+
+	// GetPackage 读取包元数据，不存在时返回 ErrNotFound
+	GetPackage(ctx context.Context, name string) (*models.Package, error)
+
+	// PutPackageJSON 原样保存包元数据的 JSON，例如上游返回的 packument，保留 models.Package 中没有的字段，
+	// 覆盖已有的数据，修订版本取自 JSON 中的 _rev，data 不是 JSON 对象时返回错误
+	PutPackageJSON(ctx context.Context, name string, data []byte) error
+
+	// GetPackageJSON 读取包元数据的 JSON 以及写入时间，不存在时返回 ErrNotFound
+	GetPackageJSON(ctx context.Context, name string) ([]byte, time.Time, error)
+
+	// Revision 返回保存的包元数据的修订版本（Package.Rev），不存在时返回 ErrNotFound
+	Revision(ctx context.Context, name string) (string, error)
+
+	// DeletePackage 删除包元数据以及该包所有的版本元数据和 tarball，不存在时不返回错误
+	DeletePackage(ctx context.Context, name string) error
+
+	// ListPackages 按包名顺序对每个保存了包元数据的包调用 fn，fn 返回错误时停止遍历并返回该错误
+	ListPackages(ctx context.Context, fn func(name string) error) error
+
+	// PutVersion 保存单个版本的元数据，包名和版本号取自 version.Name 和 version.Version
+	PutVersion(ctx context.Context, version *models.Version) error
+
+	// GetVersion 读取单独保存的版本元数据，不存在时返回 ErrNotFound，不会查找包元数据中的版本
+	GetVersion(ctx context.Context, name, version string) (*models.Version, error)
+
+	// ListVersions 返回单独保存了元数据的版本号，按字典序排序
+	ListVersions(ctx context.Context, name string) ([]string, error)
+
+	// DeleteVersion 删除单个版本的元数据和 tarball，不存在时不返回错误
+	DeleteVersion(ctx context.Context, name, version string) error
+
+	// PutTarball 保存某个版本的 tarball
+	PutTarball(ctx context.Context, name, version string, r io.Reader) error
+
+	// OpenTarball 打开某个版本的 tarball，不存在时返回 ErrNotFound，读取完毕后需要关闭
+	OpenTarball(ctx context.Context, name, version string) (io.ReadCloser, error)
+
+	// Close 关闭存储
+	Close() error
+}
+
+// LookupVersion 读取某个版本的元数据，先查找单独保存的版本元数据，没有时再从包元数据中查找
+//
+// 参数:
+//   - ctx: 上下文
+//   - s: 存储
+//   - name: 包名
+//   - version: 版本号
+//
+// 返回值:
+//   - *models.Version: 版本元数据
+//   - error: 两处都没有时返回 ErrNotFound
+func LookupVersion(ctx context.Context, s Store, name, version string) (*models.Version, error) {
+	v, err := s.GetVersion(ctx, name, version)
+	if !errors.Is(err, ErrNotFound) {
+		return v, err
+	}
+	pkg, err := s.GetPackage(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	manifest, ok := pkg.Versions[version]
+	if !ok {
+		return nil, fmt.Errorf("%s@%s: %w", name, version, ErrNotFound)
+	}
+	return &manifest, nil
+}
+
+// ReadThrough 返回一个优先从存储读取包元数据、不存在时调用 fetch 获取并写入存储的函数
+//
+// 返回的函数与 resolver.FetchFunc 的签名相同，可以直接用于 resolver.NewMetadataCache
+//
+// 使用示例:
+//
+//	s, _ := store.OpenBoltStore("packages.db")
+//	cache := resolver.NewMetadataCache(store.ReadThrough(s, registry.NewRegistry().GetPackageInformation))
+func ReadThrough(s Store, fetch func(ctx context.Context, name string) (*models.Package, error)) func(ctx context.Context, name string) (*models.Package, error) {
+	return func(ctx context.Context, name string) (*models.Package, error) {
+		pkg, err := s.GetPackage(ctx, name)
+		if !errors.Is(err, ErrNotFound) {
+			return pkg, err
+		}
+		pkg, err = fetch(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.PutPackage(ctx, pkg); err != nil {
+			return nil, err
+		}
+		return pkg, nil
+	}
+}
+
+// checkName 检查包名是否可以安全地用作存储的键和文件路径
+func checkName(name string) error {
+	segments := strings.Split(name, "/")
+	valid := (len(segments) == 1 && !strings.HasPrefix(name, "@")) || (len(segments) == 2 && strings.HasPrefix(segments[0], "@") && len(segments[0]) > 1)
+	for _, segment := range segments {
+		if !valid || !checkSegment(segment) {
+			return fmt.Errorf("%w: package name %q", ErrInvalidKey, name)
+		}
+	}
+	return nil
+}
+
+// checkVersion 检查版本号是否可以安全地用作存储的键和文件路径
+func checkVersion(version string) error {
+	if !checkSegment(version) || version == fsTarballDir || version == fsPackageFile {
+		return fmt.Errorf("%w: version %q", ErrInvalidKey, version)
+	}
+	return nil
+}
+
+// checkSegment 检查路径中的一段
+func checkSegment(segment string) bool {
+	return segment != "" && segment != "." && segment != ".." && !strings.ContainsAny(segment, "/\\\x00\n")
+}
+
+// packageName 返回包元数据的包名，优先使用 name 字段
+func packageName(pkg *models.Package) string {
+	if pkg.Name != "" {
+		return pkg.Name
+	}
+	return pkg.ID
+}
+
+// packageRevision 返回包元数据 JSON 中的修订版本，data 不是 JSON 对象时返回错误
+func packageRevision(data []byte) (string, error) {
+	var doc struct {
+		Rev string `json:"_rev"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", err
+	}
+	return doc.Rev, nil
+}
+
+// unmarshalPackage 解析包元数据
+func unmarshalPackage(data []byte) (*models.Package, error) {
+	var pkg models.Package
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// unmarshalVersion 解析版本元数据
+func unmarshalVersion(data []byte) (*models.Version, error) {
+	var version models.Version
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
@@ -0,0 +1,271 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// testStore 是所有 Store 实现共用的一致性测试
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	_, err := s.GetPackage(ctx, "react")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.Revision(ctx, "react")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.GetVersion(ctx, "react", "18.2.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.OpenTarball(ctx, "react", "18.2.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 包元数据和修订版本
+	assert.Nil(t, s.PutPackage(ctx, &models.Package{
+		Name:     "react",
+		Rev:      "1-a",
+		DistTags: map[string]string{"latest": "18.2.0"},
+		Versions: map[string]models.Version{"18.2.0": {Name: "react", Version: "18.2.0"}},
+	}))
+	assert.Nil(t, s.PutPackage(ctx, &models.Package{Name: "react", Rev: "2-b", DistTags: map[string]string{"latest": "18.3.0"}}))
+	pkg, err := s.GetPackage(ctx, "react")
+	assert.Nil(t, err)
+	assert.Equal(t, "18.3.0", pkg.DistTags["latest"])
+	rev, err := s.Revision(ctx, "react")
+	assert.Nil(t, err)
+	assert.Equal(t, "2-b", rev)
+
+	// 原样保存的 JSON 保留 models.Package 中没有的字段
+	_, _, err = s.GetPackageJSON(ctx, "lodash")
+	assert.ErrorIs(t, err, ErrNotFound)
+	before := time.Now().Add(-time.Second)
+	raw := `{"_rev":"3-c","name":"lodash","dist-tags":{"latest":"4.17.21"},"versions":{"4.17.21":{"name":"lodash","version":"4.17.21","_hasShrinkwrap":false,"libc":["glibc"]}}}`
+	assert.Nil(t, s.PutPackageJSON(ctx, "lodash", []byte(raw)))
+	data, stored, err := s.GetPackageJSON(ctx, "lodash")
+	assert.Nil(t, err)
+	assert.JSONEq(t, raw, string(data))
+	assert.True(t, stored.After(before), stored)
+	pkg, err = s.GetPackage(ctx, "lodash")
+	assert.Nil(t, err)
+	assert.Equal(t, "4.17.21", pkg.DistTags["latest"])
+	rev, err = s.Revision(ctx, "lodash")
+	assert.Nil(t, err)
+	assert.Equal(t, "3-c", rev)
+	_, stored, err = s.GetPackageJSON(ctx, "react")
+	assert.Nil(t, err)
+	assert.True(t, stored.After(before), stored)
+	assert.NotNil(t, s.PutPackageJSON(ctx, "lodash", []byte("not json")))
+	assert.ErrorIs(t, s.PutPackageJSON(ctx, "../lodash", []byte(raw)), ErrInvalidKey)
+	assert.Nil(t, s.DeletePackage(ctx, "lodash"))
+	_, _, err = s.GetPackageJSON(ctx, "lodash")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.Nil(t, s.PutPackage(ctx, &models.Package{Name: "@types/node", Rev: "1-c"}))
+	assert.Nil(t, s.PutPackage(ctx, &models.Package{Name: "@types/react", Rev: "1-d"}))
+	assert.Nil(t, s.PutPackage(ctx, &models.Package{Name: "vue", Rev: "1-e"}))
+	assert.Equal(t, []string{"@types/node", "@types/react", "react", "vue"}, listPackages(t, s))
+
+	// 版本元数据和 tarball
+	assert.Nil(t, s.PutVersion(ctx, &models.Version{Name: "react", Version: "18.3.0", Description: "React"}))
+	assert.Nil(t, s.PutVersion(ctx, &models.Version{Name: "react", Version: "18.2.0"}))
+	assert.Nil(t, s.PutVersion(ctx, &models.Version{Name: "@types/node", Version: "20.1.0"}))
+	version, err := s.GetVersion(ctx, "react", "18.3.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "React", version.Description)
+	versions, err := s.ListVersions(ctx, "react")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"18.2.0", "18.3.0"}, versions)
+
+	assert.Nil(t, s.PutTarball(ctx, "react", "18.3.0", strings.NewReader("react tarball")))
+	assert.Nil(t, s.PutTarball(ctx, "@types/node", "20.1.0", strings.NewReader("node tarball")))
+	assert.Equal(t, "react tarball", readTarball(t, s, "react", "18.3.0"))
+	assert.Equal(t, "node tarball", readTarball(t, s, "@types/node", "20.1.0"))
+
+	// 删除版本会同时删除 tarball
+	assert.Nil(t, s.DeleteVersion(ctx, "react", "18.3.0"))
+	assert.Nil(t, s.DeleteVersion(ctx, "react", "0.0.0"))
+	_, err = s.GetVersion(ctx, "react", "18.3.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.OpenTarball(ctx, "react", "18.3.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+	versions, err = s.ListVersions(ctx, "react")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"18.2.0"}, versions)
+
+	// 删除包会同时删除版本元数据和 tarball，不影响同作用域的其他包
+	assert.Nil(t, s.DeletePackage(ctx, "@types/node"))
+	assert.Nil(t, s.DeletePackage(ctx, "missing"))
+	_, err = s.GetPackage(ctx, "@types/node")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.GetVersion(ctx, "@types/node", "20.1.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.OpenTarball(ctx, "@types/node", "20.1.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, []string{"@types/react", "react", "vue"}, listPackages(t, s))
+
+	// 遍历时 fn 返回的错误会原样返回
+	stop := errors.New("stop")
+	assert.ErrorIs(t, s.ListPackages(ctx, func(name string) error { return stop }), stop)
+
+	// 非法的包名和版本号
+	for _, name := range []string{"", "..", "../etc", "a/b", "@scope", "@/a", "@scope/../a", "a\\b"} {
+		assert.ErrorIs(t, s.PutPackage(ctx, &models.Package{Name: name}), ErrInvalidKey, name)
+		_, err := s.GetPackage(ctx, name)
+		assert.ErrorIs(t, err, ErrInvalidKey, name)
+	}
+	for _, version := range []string{"", "..", "-", "index.json", "1.0.0/../x"} {
+		assert.ErrorIs(t, s.PutVersion(ctx, &models.Version{Name: "react", Version: version}), ErrInvalidKey, version)
+		assert.ErrorIs(t, s.PutTarball(ctx, "react", version, strings.NewReader("")), ErrInvalidKey, version)
+	}
+}
+
+// listPackages 返回存储中的所有包名
+func listPackages(t *testing.T, s Store) []string {
+	var names []string
+	assert.Nil(t, s.ListPackages(context.Background(), func(name string) error {
+		names = append(names, name)
+		return nil
+	}))
+	return names
+}
+
+// readTarball 读取 tarball 的内容
+func readTarball(t *testing.T, s Store, name, version string) string {
+	r, err := s.OpenTarball(context.Background(), name, version)
+	if !assert.Nil(t, err) {
+		return ""
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	return string(data)
+}
+
+func TestFileStore(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewFileStore(root)
+	assert.Nil(t, err)
+	testStore(t, s)
+	assert.Nil(t, s.Close())
+
+	// 目录结构与 Registry 的 URL 路径一致
+	assert.FileExists(t, filepath.Join(root, "react", "index.json"))
+	assert.FileExists(t, filepath.Join(root, "react", "18.2.0", "index.json"))
+	assert.FileExists(t, filepath.Join(root, "@types", "react", "index.json"))
+	assert.NoDirExists(t, filepath.Join(root, "@types", "node"))
+}
+
+func TestBoltStore(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "packages.db")
+	s, err := OpenBoltStore(filename)
+	assert.Nil(t, err)
+	testStore(t, s)
+	assert.Nil(t, s.Close())
+
+	s, err = OpenBoltStore(filename)
+	assert.Nil(t, err)
+	defer s.Close()
+	assert.Equal(t, []string{"@types/react", "react", "vue"}, listPackages(t, s))
+}
+
+func TestArchiveStore(t *testing.T) {
+	for _, name := range []string{"packages.jsonl", "packages.jsonl.gz"} {
+		t.Run(name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), name)
+			s, err := OpenArchiveStore(filename)
+			assert.Nil(t, err)
+			testStore(t, s)
+			assert.Nil(t, s.Close())
+
+			// 重新打开后从文件重建索引
+			s, err = OpenArchiveStore(filename)
+			assert.Nil(t, err)
+			defer s.Close()
+			assert.Equal(t, []string{"@types/react", "react", "vue"}, listPackages(t, s))
+			rev, err := s.Revision(context.Background(), "react")
+			assert.Nil(t, err)
+			assert.Equal(t, "2-b", rev)
+			versions, err := s.ListVersions(context.Background(), "react")
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"18.2.0"}, versions)
+		})
+	}
+}
+
+func TestArchiveStore_TruncatePartialRecord(t *testing.T) {
+	for _, name := range []string{"packages.jsonl", "packages.jsonl.gz"} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			filename := filepath.Join(t.TempDir(), name)
+			s, err := OpenArchiveStore(filename)
+			assert.Nil(t, err)
+			assert.Nil(t, s.PutPackage(ctx, &models.Package{Name: "react", Rev: "1-a"}))
+			complete := s.size
+			assert.Nil(t, s.PutPackage(ctx, &models.Package{Name: "vue", Rev: "1-b"}))
+			// 模拟写入第二条记录时进程崩溃
+			assert.Nil(t, s.file.Truncate(s.size-5))
+			assert.Nil(t, s.Close())
+
+			s, err = OpenArchiveStore(filename)
+			assert.Nil(t, err)
+			defer s.Close()
+			assert.Equal(t, complete, s.size)
+			assert.Equal(t, []string{"react"}, listPackages(t, s))
+
+			// 截断后可以继续追加
+			assert.Nil(t, s.PutPackage(ctx, &models.Package{Name: "vue", Rev: "1-c"}))
+			pkg, err := s.GetPackage(ctx, "vue")
+			assert.Nil(t, err)
+			assert.Equal(t, "1-c", pkg.Rev)
+		})
+	}
+}
+
+func TestLookupVersion(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewFileStore(t.TempDir())
+	assert.Nil(t, err)
+	assert.Nil(t, s.PutPackage(ctx, &models.Package{
+		Name:     "react",
+		Versions: map[string]models.Version{"18.2.0": {Name: "react", Version: "18.2.0", Description: "from packument"}},
+	}))
+	assert.Nil(t, s.PutVersion(ctx, &models.Version{Name: "react", Version: "18.3.0", Description: "from version"}))
+
+	version, err := LookupVersion(ctx, s, "react", "18.3.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "from version", version.Description)
+	version, err = LookupVersion(ctx, s, "react", "18.2.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "from packument", version.Description)
+	_, err = LookupVersion(ctx, s, "react", "1.0.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = LookupVersion(ctx, s, "vue", "1.0.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestReadThrough(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewFileStore(t.TempDir())
+	assert.Nil(t, err)
+	calls := 0
+	fetch := ReadThrough(s, func(ctx context.Context, name string) (*models.Package, error) {
+		calls++
+		return &models.Package{Name: name, Rev: "1-a"}, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		pkg, err := fetch(ctx, "react")
+		assert.Nil(t, err)
+		assert.Equal(t, "react", pkg.Name)
+	}
+	assert.Equal(t, 1, calls)
+	rev, err := s.Revision(ctx, "react")
+	assert.Nil(t, err)
+	assert.Equal(t, "1-a", rev)
+}
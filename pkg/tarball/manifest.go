@@ -0,0 +1,140 @@
+package tarball
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// personPattern 匹配 "Name <email> (url)" 形式的人员字符串，三部分都是可选的
+var personPattern = regexp.MustCompile(`^([^<(]*?)\s*(?:<([^>]*)>)?\s*(?:\(([^)]*)\))?\s*$`)
+
+// normalizeManifest 按照 read-package-json 和 normalize-package-data 的规则规范化 package.json 中的宽松写法，
+// 使其可以解析为 models.Version:
+//   - author 和 maintainers 中的 "Name <email> (url)" 字符串转换为对象
+//   - 字符串形式的 repository 转换为 {"type": "git", "url": ...}
+//   - 字符串形式的 bugs 按内容转换为 {"url": ...} 或 {"email": ...}
+//   - 字符串形式的 keywords 按逗号和空白拆分，字符串形式的 os 和 cpu 转换为数组
+//
+// 无法转换的字段会被删除，而不是让整个清单解析失败
+func normalizeManifest(data []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	normalizeField(fields, "author", normalizePerson)
+	normalizeField(fields, "maintainers", func(value interface{}) (interface{}, bool) {
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		people := make([]interface{}, 0, len(list))
+		for _, item := range list {
+			if person, ok := normalizePerson(item); ok {
+				people = append(people, person)
+			}
+		}
+		return people, true
+	})
+	normalizeField(fields, "repository", func(value interface{}) (interface{}, bool) {
+		switch value := value.(type) {
+		case string:
+			return map[string]interface{}{"type": "git", "url": value}, true
+		case map[string]interface{}:
+			return value, true
+		}
+		return nil, false
+	})
+	normalizeField(fields, "bugs", func(value interface{}) (interface{}, bool) {
+		switch value := value.(type) {
+		case string:
+			if strings.Contains(value, "@") && !strings.Contains(value, "://") {
+				return map[string]interface{}{"email": value}, true
+			}
+			return map[string]interface{}{"url": value}, true
+		case map[string]interface{}:
+			return value, true
+		}
+		return nil, false
+	})
+	normalizeField(fields, "keywords", func(value interface{}) (interface{}, bool) {
+		if keywords, ok := value.(string); ok {
+			return strings.FieldsFunc(keywords, func(r rune) bool {
+				return r == ',' || r == ' ' || r == '\t' || r == '\n'
+			}), true
+		}
+		return normalizeStrings(value)
+	})
+	normalizeField(fields, "os", normalizeStrings)
+	normalizeField(fields, "cpu", normalizeStrings)
+	return json.Marshal(fields)
+}
+
+// normalizeField 使用 normalize 转换 fields 中的 key 字段，返回 false 时删除该字段
+func normalizeField(fields map[string]json.RawMessage, key string, normalize func(value interface{}) (interface{}, bool)) {
+	raw, ok := fields[key]
+	if !ok {
+		return
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil || value == nil {
+		delete(fields, key)
+		return
+	}
+	normalized, ok := normalize(value)
+	if !ok {
+		delete(fields, key)
+		return
+	}
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		delete(fields, key)
+		return
+	}
+	fields[key] = data
+}
+
+// normalizePerson 把 "Name <email> (url)" 字符串转换为对象，对象中只保留字符串类型的 name、email 和 url
+func normalizePerson(value interface{}) (interface{}, bool) {
+	switch value := value.(type) {
+	case string:
+		match := personPattern.FindStringSubmatch(strings.TrimSpace(value))
+		if match == nil {
+			return map[string]interface{}{"name": strings.TrimSpace(value)}, true
+		}
+		person := map[string]interface{}{"name": match[1]}
+		if match[2] != "" {
+			person["email"] = match[2]
+		}
+		if match[3] != "" {
+			person["url"] = match[3]
+		}
+		return person, true
+	case map[string]interface{}:
+		person := make(map[string]interface{})
+		for _, key := range []string{"name", "email", "url"} {
+			if s, ok := value[key].(string); ok {
+				person[key] = s
+			}
+		}
+		return person, true
+	}
+	return nil, false
+}
+
+// normalizeStrings 把字符串转换为只有一个元素的数组，数组中只保留字符串元素
+func normalizeStrings(value interface{}) (interface{}, bool) {
+	switch value := value.(type) {
+	case string:
+		return []string{value}, true
+	case []interface{}:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result, true
+	}
+	return nil, false
+}
@@ -0,0 +1,63 @@
+package tarball
+
+const (
+	// DefaultMaxFileSize 默认的单个文件解压后的最大大小，64 MiB
+	DefaultMaxFileSize int64 = 64 << 20
+
+	// DefaultMaxTotalSize 默认的所有文件解压后的最大总大小，512 MiB
+	DefaultMaxTotalSize int64 = 512 << 20
+
+	// DefaultMaxEntries 默认的最大条目数量
+	DefaultMaxEntries = 100000
+)
+
+// Options 表示读取 tarball 时的安全限制
+//
+// 包含字段:
+//   - MaxFileSize: 单个文件解压后的最大字节数
+//   - MaxTotalSize: 所有文件解压后的最大总字节数，用于防御解压炸弹
+//   - MaxEntries: 最大条目数量（包括目录和符号链接）
+//
+// 任意一项小于等于 0 时表示不限制
+//
+// 使用示例:
+//
+//	options := NewOptions().SetMaxTotalSize(100 << 20)
+//	t, err := tarball.Read(r, options)
+type Options struct {
+	MaxFileSize  int64
+	MaxTotalSize int64
+	MaxEntries   int
+}
+
+// NewOptions 创建并返回默认的配置选项
+//
+// 默认配置:
+//   - MaxFileSize: 64 MiB
+//   - MaxTotalSize: 512 MiB
+//   - MaxEntries: 100000
+func NewOptions() *Options {
+	return &Options{
+		MaxFileSize:  DefaultMaxFileSize,
+		MaxTotalSize: DefaultMaxTotalSize,
+		MaxEntries:   DefaultMaxEntries,
+	}
+}
+
+// SetMaxFileSize 设置单个文件解压后的最大字节数
+func (o *Options) SetMaxFileSize(maxFileSize int64) *Options {
+	o.MaxFileSize = maxFileSize
+	return o
+}
+
+// SetMaxTotalSize 设置所有文件解压后的最大总字节数
+func (o *Options) SetMaxTotalSize(maxTotalSize int64) *Options {
+	o.MaxTotalSize = maxTotalSize
+	return o
+}
+
+// SetMaxEntries 设置最大条目数量
+func (o *Options) SetMaxEntries(maxEntries int) *Options {
+	o.MaxEntries = maxEntries
+	return o
+}
@@ -0,0 +1,320 @@
+package tarball
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+)
+
+var (
+	// ErrLimitExceeded 表示 tarball 超过了 Options 中设置的限制，可能是解压炸弹
+	ErrLimitExceeded = errors.New("tarball limit exceeded")
+
+	// ErrUnsafePath 表示条目的路径或链接目标指向包目录之外，例如 "../../etc/passwd"
+	ErrUnsafePath = errors.New("unsafe path in tarball")
+)
+
+// EntryType 表示条目的类型
+type EntryType string
+
+const (
+	EntryTypeFile     EntryType = "file"
+	EntryTypeDir      EntryType = "dir"
+	EntryTypeSymlink  EntryType = "symlink"
+	EntryTypeHardlink EntryType = "hardlink"
+)
+
+// maxLinkDepth 是读取文件时最多跟随的链接层数
+const maxLinkDepth = 16
+
+// Entry 表示 tarball 中的一个条目
+//
+// 主要字段说明:
+//   - Path: 去掉第一级目录（通常是 "package/"）之后的路径，例如 "lib/index.js"
+//   - Type: 条目类型
+//   - Size: 文件大小（字节），目录和链接为 0
+//   - Mode: 文件权限和类型
+//   - LinkTarget: 符号链接或硬链接的目标，已经转换为相对于包根目录的路径
+//   - ModTime: 修改时间，npm 打包时通常固定为 1985-10-26
+type Entry struct {
+	Path       string
+	Type       EntryType
+	Size       int64
+	Mode       fs.FileMode
+	LinkTarget string
+	ModTime    time.Time
+}
+
+// Tarball 表示一个完全读入内存的 npm 包 tarball
+type Tarball struct {
+	entries map[string]*Entry
+	files   map[string][]byte
+	size    int64
+}
+
+// Read 从 r 中读取 npm 包的 tarball，支持 gzip 压缩和未压缩的 tar 格式
+//
+// 读取规则与 npm 解压时相同:
+//   - 去掉每个条目路径的第一级目录，通常是 "package/"，少数包使用其他名称
+//   - 同一路径出现多次时以最后一次为准
+//   - 字符设备、FIFO 等特殊条目会被忽略
+//
+// 安全限制:
+//   - 路径或链接目标指向包目录之外时返回包装了 ErrUnsafePath 的错误
+//   - 超过 Options 中设置的大小或数量限制时返回包装了 ErrLimitExceeded 的错误
+//
+// 参数:
+//   - r: tarball 数据流，例如 HTTP 响应体
+//   - options: 可选的配置选项，如未提供则使用 NewOptions() 的默认配置
+//
+// 返回值:
+//   - *Tarball: 读取得到的 tarball
+//   - error: 如果数据格式错误或违反安全限制则返回错误
+//
+// 使用示例:
+//
+//	var buf bytes.Buffer
+//	if err := reg.DownloadTarball(ctx, version, &buf); err != nil {
+//		// 处理错误
+//	}
+//	t, err := tarball.Read(&buf)
+//	if err != nil {
+//		// 处理错误
+//	}
+//	for _, entry := range t.Entries() {
+//		fmt.Println(entry.Path, entry.Size, entry.Mode)
+//	}
+//	manifest, _ := t.Manifest()
+//	fmt.Println(manifest.Name, manifest.Version)
+func Read(r io.Reader, options ...*Options) (*Tarball, error) {
+	if len(options) == 0 {
+		options = append(options, NewOptions())
+	}
+	limits := options[0]
+
+	buffered := bufio.NewReader(r)
+	var reader io.Reader = buffered
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	result := &Tarball{
+		entries: make(map[string]*Entry),
+		files:   make(map[string][]byte),
+	}
+	tr := tar.NewReader(reader)
+	count := 0
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		count++
+		if limits.MaxEntries > 0 && count > limits.MaxEntries {
+			return nil, fmt.Errorf("%w: more than %d entries", ErrLimitExceeded, limits.MaxEntries)
+		}
+
+		name, err := stripPath(header.Name)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			continue
+		}
+		entry := &Entry{
+			Path:    name,
+			Mode:    header.FileInfo().Mode(),
+			ModTime: header.ModTime,
+		}
+		// 相同路径的条目会替换之前的条目，之前文件的大小不再计入总大小
+		previous := int64(len(result.files[name]))
+		switch header.Typeflag {
+		case tar.TypeReg:
+			entry.Type = EntryTypeFile
+			entry.Size = header.Size
+			if limits.MaxFileSize > 0 && header.Size > limits.MaxFileSize {
+				return nil, fmt.Errorf("%w: %s is %d bytes", ErrLimitExceeded, name, header.Size)
+			}
+			if limits.MaxTotalSize > 0 && result.size-previous+header.Size > limits.MaxTotalSize {
+				return nil, fmt.Errorf("%w: total size exceeds %d bytes", ErrLimitExceeded, limits.MaxTotalSize)
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			result.files[name] = data
+			result.size += int64(len(data)) - previous
+		case tar.TypeDir:
+			entry.Type = EntryTypeDir
+		case tar.TypeSymlink:
+			entry.Type = EntryTypeSymlink
+			target := header.Linkname
+			if !path.IsAbs(target) {
+				target = path.Join(path.Dir(name), target)
+			}
+			if entry.LinkTarget, err = cleanPath(target); err != nil {
+				return nil, fmt.Errorf("%w: symlink %s -> %s", ErrUnsafePath, name, header.Linkname)
+			}
+		case tar.TypeLink:
+			entry.Type = EntryTypeHardlink
+			if entry.LinkTarget, err = stripPath(header.Linkname); err != nil || entry.LinkTarget == "" {
+				return nil, fmt.Errorf("%w: hardlink %s -> %s", ErrUnsafePath, name, header.Linkname)
+			}
+		default:
+			continue
+		}
+		if entry.Type != EntryTypeFile {
+			result.size -= previous
+			delete(result.files, name)
+		}
+		result.entries[name] = entry
+	}
+	return result, nil
+}
+
+// Entries 返回所有条目，按路径排序
+func (x *Tarball) Entries() []*Entry {
+	entries := make([]*Entry, 0, len(x.entries))
+	for _, entry := range x.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+// Entry 根据路径查找条目，不存在时返回 nil
+func (x *Tarball) Entry(name string) *Entry {
+	return x.entries[strings.TrimPrefix(path.Clean("/"+name), "/")]
+}
+
+// Size 返回所有文件解压后的总大小（字节）
+func (x *Tarball) Size() int64 {
+	return x.size
+}
+
+// ReadFile 读取文件内容，路径中的符号链接和硬链接会被跟随，但不会跟随到包目录之外
+//
+// 参数:
+//   - name: 相对于包根目录的路径，例如 "package.json"、"lib/index.js"
+//
+// 返回值:
+//   - []byte: 文件内容，调用方不应修改
+//   - error: 文件不存在或不是普通文件时返回包装了 fs.ErrNotExist 的错误
+func (x *Tarball) ReadFile(name string) ([]byte, error) {
+	resolved, err := x.resolve(strings.TrimPrefix(path.Clean("/"+name), "/"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	entry, ok := x.entries[resolved]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	if entry.Type != EntryTypeFile {
+		return nil, fmt.Errorf("%s is a %s: %w", name, entry.Type, fs.ErrNotExist)
+	}
+	return x.files[resolved], nil
+}
+
+// resolve 逐级替换路径中的链接，返回最终指向的路径
+func (x *Tarball) resolve(name string) (string, error) {
+	for depth := 0; depth <= maxLinkDepth; depth++ {
+		parts := strings.Split(name, "/")
+		replaced := false
+		for i := range parts {
+			entry, ok := x.entries[strings.Join(parts[:i+1], "/")]
+			if !ok || (entry.Type != EntryTypeSymlink && entry.Type != EntryTypeHardlink) {
+				continue
+			}
+			name = strings.TrimPrefix(path.Join(append([]string{entry.LinkTarget}, parts[i+1:]...)...), "/")
+			replaced = true
+			break
+		}
+		if !replaced {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("too many levels of links: %w", fs.ErrNotExist)
+}
+
+// PackageJSON 返回包根目录下 package.json 的原始内容
+func (x *Tarball) PackageJSON() ([]byte, error) {
+	return x.ReadFile("package.json")
+}
+
+// Manifest 解析包根目录下的 package.json
+//
+// 与 npm 读取 package.json 的方式相同，字符串形式的 author、repository、bugs 等字段会先被规范化为对象，
+// 类型不正确的字段会被忽略
+//
+// 返回值:
+//   - *models.Version: tarball 中实际打包的清单，可能与 Registry 中记录的元数据不同
+//   - error: package.json 不存在或不是 JSON 对象时返回错误
+func (x *Tarball) Manifest() (*models.Version, error) {
+	data, err := x.PackageJSON()
+	if err != nil {
+		return nil, err
+	}
+	data, err = normalizeManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse package.json: %w", err)
+	}
+	var manifest models.Version
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parse package.json: %w", err)
+	}
+	return &manifest, nil
+}
+
+// stripPath 去掉路径的第一级目录并检查路径是否安全，只有一级的路径返回空字符串
+func stripPath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = strings.TrimPrefix(name, "./")
+	if path.IsAbs(name) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	_, rest, ok := strings.Cut(strings.TrimLeft(name, "/"), "/")
+	if !ok {
+		return "", nil
+	}
+	clean, err := cleanPath(rest)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	return clean, nil
+}
+
+// cleanPath 清理相对于包根目录的路径，路径指向包目录之外时返回错误
+func cleanPath(name string) (string, error) {
+	if path.IsAbs(name) {
+		return "", ErrUnsafePath
+	}
+	clean := path.Clean(name)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", ErrUnsafePath
+	}
+	if clean == "." {
+		return "", nil
+	}
+	return clean, nil
+}
@@ -0,0 +1,229 @@
+package tarball
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testEntry 用于在测试中构建 tarball
+type testEntry struct {
+	name     string
+	typeflag byte
+	body     string
+	linkname string
+	mode     int64
+}
+
+func buildTarball(t *testing.T, compress bool, entries ...testEntry) *bytes.Buffer {
+	var buf bytes.Buffer
+	var tw *tar.Writer
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	} else {
+		tw = tar.NewWriter(&buf)
+	}
+	for _, entry := range entries {
+		mode := entry.mode
+		if mode == 0 {
+			mode = 0644
+		}
+		header := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Mode:     mode,
+			Size:     int64(len(entry.body)),
+			Linkname: entry.linkname,
+		}
+		if entry.typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		assert.Nil(t, tw.WriteHeader(header))
+		if entry.typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(entry.body))
+			assert.Nil(t, err)
+		}
+	}
+	assert.Nil(t, tw.Close())
+	if gz != nil {
+		assert.Nil(t, gz.Close())
+	}
+	return &buf
+}
+
+func TestRead(t *testing.T) {
+	buf := buildTarball(t, true,
+		testEntry{name: "package/package.json", typeflag: tar.TypeReg, body: `{"name": "demo", "version": "1.0.0", "bin": "./cli.js"}`},
+		testEntry{name: "package/lib/", typeflag: tar.TypeDir, mode: 0755},
+		testEntry{name: "package/lib/index.js", typeflag: tar.TypeReg, body: "module.exports = 1"},
+		testEntry{name: "package/cli.js", typeflag: tar.TypeReg, body: "#!/usr/bin/env node", mode: 0755},
+		testEntry{name: "package/main.js", typeflag: tar.TypeSymlink, linkname: "lib/index.js"},
+		testEntry{name: "package/copy.js", typeflag: tar.TypeLink, linkname: "package/lib/index.js"},
+		testEntry{name: "package/lib/dir-link", typeflag: tar.TypeSymlink, linkname: "."},
+		testEntry{name: "package/loop", typeflag: tar.TypeSymlink, linkname: "loop"},
+		// 只有一级目录的条目会被忽略
+		testEntry{name: "README", typeflag: tar.TypeReg, body: "ignored"},
+	)
+
+	tb, err := Read(buf)
+	assert.Nil(t, err)
+
+	var paths []string
+	for _, entry := range tb.Entries() {
+		paths = append(paths, entry.Path)
+	}
+	assert.Equal(t, []string{"cli.js", "copy.js", "lib", "lib/dir-link", "lib/index.js", "loop", "main.js", "package.json"}, paths)
+	assert.Equal(t, int64(len(`{"name": "demo", "version": "1.0.0", "bin": "./cli.js"}`)+len("module.exports = 1")+len("#!/usr/bin/env node")), tb.Size())
+
+	cli := tb.Entry("cli.js")
+	assert.Equal(t, EntryTypeFile, cli.Type)
+	assert.Equal(t, fs.FileMode(0755), cli.Mode.Perm())
+	assert.Equal(t, int64(19), cli.Size)
+	assert.Equal(t, EntryTypeDir, tb.Entry("/lib/").Type)
+	assert.Equal(t, "lib/index.js", tb.Entry("main.js").LinkTarget)
+	assert.Equal(t, "lib", tb.Entry("lib/dir-link").LinkTarget)
+
+	data, err := tb.ReadFile("main.js")
+	assert.Nil(t, err)
+	assert.Equal(t, "module.exports = 1", string(data))
+	data, err = tb.ReadFile("copy.js")
+	assert.Nil(t, err)
+	assert.Equal(t, "module.exports = 1", string(data))
+	data, err = tb.ReadFile("lib/dir-link/index.js")
+	assert.Nil(t, err)
+	assert.Equal(t, "module.exports = 1", string(data))
+	_, err = tb.ReadFile("lib")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	_, err = tb.ReadFile("loop")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	_, err = tb.ReadFile("missing.js")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	manifest, err := tb.Manifest()
+	assert.Nil(t, err)
+	assert.Equal(t, "demo", manifest.Name)
+	assert.Equal(t, map[string]string{"demo": "cli.js"}, manifest.Bin.Normalize(manifest.Name))
+}
+
+func TestReadUncompressedAndCustomPrefix(t *testing.T) {
+	buf := buildTarball(t, false,
+		testEntry{name: "node/package.json", typeflag: tar.TypeReg, body: `{"name": "x"}`},
+		testEntry{name: "node/package.json", typeflag: tar.TypeReg, body: `{"name": "y"}`},
+	)
+	tb, err := Read(buf)
+	assert.Nil(t, err)
+	assert.Len(t, tb.Entries(), 1)
+	manifest, err := tb.Manifest()
+	assert.Nil(t, err)
+	assert.Equal(t, "y", manifest.Name)
+
+	tb, err = Read(buildTarball(t, true, testEntry{name: "package/index.js", typeflag: tar.TypeReg}))
+	assert.Nil(t, err)
+	_, err = tb.Manifest()
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
+
+func TestReadUnsafePaths(t *testing.T) {
+	for _, entry := range []testEntry{
+		{name: "package/../../etc/passwd", typeflag: tar.TypeReg, body: "x"},
+		{name: "/etc/passwd", typeflag: tar.TypeReg, body: "x"},
+		{name: "package/link", typeflag: tar.TypeSymlink, linkname: "../../etc/passwd"},
+		{name: "package/lib/link", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"},
+		{name: "package/hard", typeflag: tar.TypeLink, linkname: "package/../../etc/passwd"},
+	} {
+		_, err := Read(buildTarball(t, true, entry))
+		assert.True(t, errors.Is(err, ErrUnsafePath), entry.name)
+	}
+}
+
+func TestReadLimits(t *testing.T) {
+	buf := func() *bytes.Buffer {
+		return buildTarball(t, true,
+			testEntry{name: "package/a.txt", typeflag: tar.TypeReg, body: string(make([]byte, 1000))},
+			testEntry{name: "package/b.txt", typeflag: tar.TypeReg, body: string(make([]byte, 1000))},
+		)
+	}
+
+	_, err := Read(buf(), NewOptions().SetMaxFileSize(999))
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+	_, err = Read(buf(), NewOptions().SetMaxTotalSize(1500))
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+	_, err = Read(buf(), NewOptions().SetMaxEntries(1))
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+
+	// 小于等于 0 表示不限制
+	tb, err := Read(buf(), NewOptions().SetMaxFileSize(0).SetMaxTotalSize(0).SetMaxEntries(0))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2000), tb.Size())
+
+	_, err = Read(bytes.NewReader([]byte{0x1f, 0x8b, 0, 0}))
+	assert.NotNil(t, err)
+}
+
+func TestReadDuplicatePaths(t *testing.T) {
+	// 相同路径的条目以最后一个为准，被替换的文件不计入总大小
+	tb, err := Read(buildTarball(t, true,
+		testEntry{name: "package/index.js", typeflag: tar.TypeReg, body: "0123456789"},
+		testEntry{name: "package/index.js", typeflag: tar.TypeReg, body: "ok"},
+		testEntry{name: "package/lib", typeflag: tar.TypeReg, body: "replaced by a directory"},
+		testEntry{name: "package/lib", typeflag: tar.TypeDir},
+	), NewOptions().SetMaxTotalSize(30))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, tb.Entries(), 2)
+	assert.Equal(t, int64(2), tb.Size())
+	data, err := tb.ReadFile("index.js")
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", string(data))
+	assert.Equal(t, EntryTypeDir, tb.Entry("lib").Type)
+}
+
+func TestManifestLooseFields(t *testing.T) {
+	buf := buildTarball(t, true, testEntry{name: "package/package.json", typeflag: tar.TypeReg, body: `{
+		"name": "demo", "version": "1.0.0",
+		"author": "Jane Doe <jane@example.com> (https://jane.dev)",
+		"maintainers": ["Bob <bob@example.com>", {"name": "Alice", "email": 1}, 42],
+		"repository": "github:jane/demo",
+		"bugs": "issues@example.com",
+		"keywords": "cli, demo tool",
+		"os": "darwin",
+		"cpu": 64
+	}`})
+	tb, err := Read(buf)
+	assert.Nil(t, err)
+
+	manifest, err := tb.Manifest()
+	assert.Nil(t, err)
+	assert.Equal(t, "Jane Doe", manifest.Author.Name)
+	assert.Equal(t, "jane@example.com", manifest.Author.Email)
+	assert.Equal(t, "https://jane.dev", manifest.Author.URL)
+	assert.Len(t, manifest.Maintainers, 2)
+	assert.Equal(t, "Bob", manifest.Maintainers[0].Name)
+	assert.Equal(t, "bob@example.com", manifest.Maintainers[0].Email)
+	assert.Equal(t, "Alice", manifest.Maintainers[1].Name)
+	assert.Equal(t, "git", manifest.Repository.Type)
+	assert.Equal(t, "github:jane/demo", manifest.Repository.URL)
+	assert.Equal(t, "issues@example.com", manifest.Bugs.Email)
+	assert.Equal(t, []string{"cli", "demo", "tool"}, manifest.Keywords)
+	assert.Equal(t, []string{"darwin"}, manifest.Os)
+	assert.Nil(t, manifest.Cpu)
+
+	// 只有名字的作者和 URL 形式的 bugs
+	buf = buildTarball(t, true, testEntry{name: "package/package.json", typeflag: tar.TypeReg,
+		body: `{"name": "demo", "author": "Jane", "bugs": "https://github.com/jane/demo/issues"}`})
+	tb, err = Read(buf)
+	assert.Nil(t, err)
+	manifest, err = tb.Manifest()
+	assert.Nil(t, err)
+	assert.Equal(t, "Jane", manifest.Author.Name)
+	assert.Equal(t, "", manifest.Author.Email)
+	assert.Equal(t, "https://github.com/jane/demo/issues", manifest.Bugs.URL)
+}
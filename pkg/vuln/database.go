@@ -0,0 +1,302 @@
+package vuln
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Database 本地的 OSV 漏洞数据库，按包名建立索引，可以在离线环境中匹配漏洞
+//
+// 数据来源通常是 OSV 发布的 npm 生态全量导出（https://osv-vulnerabilities.storage.googleapis.com/npm/all.zip），
+// 也可以是解压后的目录。数据库支持增量更新：重复加载时只有 modified 更新的记录才会替换已有记录，
+// 因此可以先加载全量数据，之后只加载新下载的单个漏洞文件
+//
+// Database 可以被多个 goroutine 并发使用
+type Database struct {
+	lock      sync.RWMutex
+	byID      map[string]*Vulnerability
+	byPackage map[string][]*Vulnerability
+}
+
+// NewDatabase 创建一个空的漏洞数据库
+func NewDatabase() *Database {
+	return &Database{
+		byID:      make(map[string]*Vulnerability),
+		byPackage: make(map[string][]*Vulnerability),
+	}
+}
+
+// Open 创建漏洞数据库并从 zip 文件或目录中加载数据
+//
+// 参数:
+//   - path: OSV 导出的 zip 文件路径或者包含 JSON 文件的目录
+//
+// 返回值:
+//   - *Database: 加载完成的数据库
+//   - error: 如果读取或解析失败则返回错误
+//
+// 使用示例:
+//
+//	db, err := vuln.Open("osv/npm/all.zip")
+//	if err != nil {
+//		// 处理错误
+//	}
+//	for _, finding := range db.Match("lodash", "4.17.20") {
+//		fmt.Println(finding.Vulnerability.ID, finding.Fixed)
+//	}
+func Open(path string) (*Database, error) {
+	db := NewDatabase()
+	if _, err := db.Load(path); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Load 从 zip 文件或目录中加载数据，合并到已有的数据库中
+//
+// 参数:
+//   - path: OSV 导出的 zip 文件路径或者包含 JSON 文件的目录，目录会被递归遍历
+//
+// 返回值:
+//   - int: 新增或被更新的记录数量
+//   - error: 如果读取或解析失败则返回错误，发生错误之前加载的记录会保留
+func (x *Database) Load(path string) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if info.IsDir() {
+		return x.LoadDir(path)
+	}
+	return x.LoadZip(path)
+}
+
+// LoadZip 从 OSV 导出的 zip 文件中加载数据，合并到已有的数据库中
+//
+// 参数:
+//   - filename: zip 文件路径，其中每个 .json 文件是一条漏洞记录
+//
+// 返回值:
+//   - int: 新增或被更新的记录数量
+//   - error: 如果读取或解析失败则返回错误
+func (x *Database) LoadZip(filename string) (int, error) {
+	reader, err := zip.OpenReader(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	updated := 0
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || !strings.HasSuffix(file.Name, ".json") {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return updated, err
+		}
+		changed, err := x.Read(r)
+		r.Close()
+		if err != nil {
+			return updated, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		if changed {
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// LoadDir 从目录中加载所有 .json 漏洞记录，合并到已有的数据库中
+//
+// 参数:
+//   - dir: 目录路径，会被递归遍历
+//
+// 返回值:
+//   - int: 新增或被更新的记录数量
+//   - error: 如果读取或解析失败则返回错误
+func (x *Database) LoadDir(dir string) (int, error) {
+	updated := 0
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		changed, err := x.Read(file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if changed {
+			updated++
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// Read 读取一条 JSON 格式的漏洞记录并加入数据库
+//
+// 参数:
+//   - r: 漏洞记录的 JSON 内容
+//
+// 返回值:
+//   - bool: 记录是否被新增或更新
+//   - error: 如果解析失败则返回错误
+func (x *Database) Read(r io.Reader) (bool, error) {
+	var vulnerability Vulnerability
+	if err := json.NewDecoder(r).Decode(&vulnerability); err != nil {
+		return false, err
+	}
+	return x.Add(&vulnerability)
+}
+
+// Add 将漏洞记录加入数据库
+//
+// 已存在相同 ID 的记录时，只有新记录的 modified 更晚才会替换旧记录。
+// 只有影响 npm 生态的记录会被建立包名索引
+//
+// 参数:
+//   - vulnerability: 漏洞记录
+//
+// 返回值:
+//   - bool: 记录是否被新增或更新
+//   - error: 记录缺少 ID 时返回错误
+func (x *Database) Add(vulnerability *Vulnerability) (bool, error) {
+	if vulnerability.ID == "" {
+		return false, errors.New("vulnerability without id")
+	}
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	if old, ok := x.byID[vulnerability.ID]; ok {
+		if !vulnerability.Modified.After(old.Modified) {
+			return false, nil
+		}
+		x.unindexLocked(old)
+	}
+	x.byID[vulnerability.ID] = vulnerability
+	for _, name := range affectedPackages(vulnerability) {
+		x.byPackage[name] = append(x.byPackage[name], vulnerability)
+	}
+	return true, nil
+}
+
+// unindexLocked 从包名索引中移除记录，调用方需要持有写锁
+func (x *Database) unindexLocked(vulnerability *Vulnerability) {
+	for _, name := range affectedPackages(vulnerability) {
+		list := x.byPackage[name]
+		for i, v := range list {
+			if v == vulnerability {
+				list = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+		if len(list) == 0 {
+			delete(x.byPackage, name)
+		} else {
+			x.byPackage[name] = list
+		}
+	}
+}
+
+// affectedPackages 返回记录中受影响的 npm 包名，已去重
+func affectedPackages(vulnerability *Vulnerability) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, affected := range vulnerability.Affected {
+		if affected == nil || affected.Package == nil || affected.Package.Ecosystem != EcosystemNpm {
+			continue
+		}
+		if name := affected.Package.Name; !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Get 根据 ID 查找漏洞记录，不存在时返回 nil
+func (x *Database) Get(id string) *Vulnerability {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	return x.byID[id]
+}
+
+// Len 返回数据库中的记录数量，包括已撤回的记录
+func (x *Database) Len() int {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	return len(x.byID)
+}
+
+// Vulnerabilities 返回影响指定包的所有记录，包括已撤回的记录，按 ID 排序
+func (x *Database) Vulnerabilities(name string) []*Vulnerability {
+	x.lock.RLock()
+	list := append([]*Vulnerability(nil), x.byPackage[name]...)
+	x.lock.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// LastModified 返回数据库中最新的 modified 时间，可以据此判断需要增量下载哪些记录
+//
+// 返回值:
+//   - time.Time: 最新的修改时间，数据库为空时返回零值
+func (x *Database) LastModified() time.Time {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	var last time.Time
+	for _, vulnerability := range x.byID {
+		if vulnerability.Modified.After(last) {
+			last = vulnerability.Modified
+		}
+	}
+	return last
+}
+
+// Save 将数据库中的所有记录写入目录，每条记录一个 "<ID>.json" 文件
+//
+// 保存的目录可以再通过 Open 或 LoadDir 加载，便于维护一个持续增量更新的本地数据库
+//
+// 参数:
+//   - dir: 目标目录，不存在时会被创建
+//
+// 返回值:
+//   - error: 如果写入失败则返回错误
+func (x *Database) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	for id, vulnerability := range x.byID {
+		if strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+			return fmt.Errorf("invalid vulnerability id %q", id)
+		}
+		data, err := json.MarshalIndent(vulnerability, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, id+".json"), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
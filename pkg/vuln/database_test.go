@@ -0,0 +1,156 @@
+package vuln
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const lodashOSV = `{
+  "id": "GHSA-35jh-r3h4-6jhm",
+  "modified": "2023-01-01T00:00:00Z",
+  "published": "2021-05-06T16:05:51Z",
+  "aliases": ["CVE-2021-23337"],
+  "summary": "Command Injection in lodash",
+  "severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H"}],
+  "affected": [
+    {
+      "package": {"ecosystem": "npm", "name": "lodash", "purl": "pkg:npm/lodash"},
+      "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]
+    },
+    {
+      "package": {"ecosystem": "npm", "name": "lodash-es"},
+      "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]
+    }
+  ],
+  "database_specific": {"severity": "HIGH", "cwe_ids": ["CWE-77", "CWE-94"], "github_reviewed": true}
+}`
+
+const lodashOSVUpdated = `{
+  "id": "GHSA-35jh-r3h4-6jhm",
+  "modified": "2024-01-01T00:00:00Z",
+  "affected": [
+    {
+      "package": {"ecosystem": "npm", "name": "lodash"},
+      "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "4.0.0"}, {"fixed": "4.17.21"}]}]
+    }
+  ],
+  "database_specific": {"severity": "CRITICAL"}
+}`
+
+const minimistOSV = `{
+  "id": "GHSA-xvch-5gv4-984h",
+  "modified": "2023-06-01T00:00:00Z",
+  "affected": [
+    {
+      "package": {"ecosystem": "npm", "name": "minimist"},
+      "ranges": [
+        {"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "0.2.4"}]},
+        {"type": "ECOSYSTEM", "events": [{"introduced": "1.0.0"}, {"fixed": "1.2.6"}]}
+      ]
+    }
+  ],
+  "database_specific": {"severity": "CRITICAL"}
+}`
+
+const pypiOSV = `{
+  "id": "PYSEC-2021-1",
+  "modified": "2023-06-01T00:00:00Z",
+  "affected": [{"package": {"ecosystem": "PyPI", "name": "lodash"}, "versions": ["1.0.0"]}]
+}`
+
+// writeZip 将漏洞记录写入 zip 文件，键为文件名
+func writeZip(t *testing.T, filename string, files map[string]string) {
+	file, err := os.Create(filename)
+	assert.Nil(t, err)
+	defer file.Close()
+	w := zip.NewWriter(file)
+	for name, content := range files {
+		f, err := w.Create(name)
+		assert.Nil(t, err)
+		f.Write([]byte(content))
+	}
+	assert.Nil(t, w.Close())
+}
+
+func TestOpenZip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "all.zip")
+	writeZip(t, filename, map[string]string{
+		"GHSA-35jh-r3h4-6jhm.json": lodashOSV,
+		"GHSA-xvch-5gv4-984h.json": minimistOSV,
+		"PYSEC-2021-1.json":        pypiOSV,
+		"README.txt":               "not a vulnerability",
+	})
+
+	db, err := Open(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, db.Len())
+	assert.Len(t, db.Vulnerabilities("lodash"), 1)
+	assert.Len(t, db.Vulnerabilities("lodash-es"), 1)
+	assert.Len(t, db.Vulnerabilities("minimist"), 1)
+	assert.Equal(t, time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), db.LastModified())
+
+	vulnerability := db.Get("GHSA-35jh-r3h4-6jhm")
+	assert.Equal(t, []string{"CVE-2021-23337"}, vulnerability.Aliases)
+	assert.Equal(t, []string{"CWE-77", "CWE-94"}, vulnerability.DatabaseSpecific.CWEIDs)
+
+	// 再次加载相同的数据不会产生更新
+	updated, err := db.Load(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, updated)
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing.zip"))
+	assert.NotNil(t, err)
+}
+
+func TestIncrementalUpdate(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "npm"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "npm", "GHSA-35jh-r3h4-6jhm.json"), []byte(lodashOSV), 0o644))
+	db, err := Open(dir)
+	assert.Nil(t, err)
+	assert.Len(t, db.Vulnerabilities("lodash-es"), 1)
+
+	// 增量目录中包含一条更新的记录和一条新记录
+	update := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(update, "GHSA-35jh-r3h4-6jhm.json"), []byte(lodashOSVUpdated), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(update, "GHSA-xvch-5gv4-984h.json"), []byte(minimistOSV), 0o644))
+	updated, err := db.Load(update)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, updated)
+	assert.Equal(t, 2, db.Len())
+	assert.Equal(t, "CRITICAL", db.Get("GHSA-35jh-r3h4-6jhm").DatabaseSpecific.Severity)
+	assert.Empty(t, db.Vulnerabilities("lodash-es"))
+
+	// 旧记录不会覆盖新记录
+	changed, err := db.Add(&Vulnerability{ID: "GHSA-35jh-r3h4-6jhm", Modified: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)})
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	_, err = db.Add(&Vulnerability{})
+	assert.NotNil(t, err)
+}
+
+func TestSave(t *testing.T) {
+	db := NewDatabase()
+	for _, content := range []string{lodashOSV, minimistOSV} {
+		_, err := db.Read(stringReader(content))
+		assert.Nil(t, err)
+	}
+	dir := filepath.Join(t.TempDir(), "db")
+	assert.Nil(t, db.Save(dir))
+
+	loaded, err := Open(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, loaded.Len())
+	assert.Equal(t, db.LastModified(), loaded.LastModified())
+	assert.Len(t, loaded.Match("lodash", "4.17.20"), 1)
+
+	_, err = db.Add(&Vulnerability{ID: "../escape", Modified: time.Now()})
+	assert.Nil(t, err)
+	assert.NotNil(t, db.Save(dir))
+}
@@ -0,0 +1,104 @@
+package vuln
+
+import (
+	"sort"
+
+	"github.com/scagogogo/npm-crawler/pkg/lockfile"
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// Finding 表示一个受漏洞影响的版本
+//
+// 主要字段说明:
+//   - Name: 包名称
+//   - Version: 受影响的版本
+//   - Vulnerability: 漏洞记录
+//   - Fixed: 高于当前版本的修复版本，从小到大排序，没有修复版本时为空
+type Finding struct {
+	Name          string         `json:"name"`
+	Version       string         `json:"version"`
+	Vulnerability *Vulnerability `json:"vulnerability"`
+	Fixed         []string       `json:"fixed,omitempty"`
+}
+
+// Match 查找影响指定版本的漏洞，已撤回的漏洞会被忽略
+//
+// 参数:
+//   - name: 包名称
+//   - version: 版本号
+//
+// 返回值:
+//   - []*Finding: 命中的漏洞，按漏洞 ID 排序
+func (x *Database) Match(name, version string) []*Finding {
+	var findings []*Finding
+	for _, vulnerability := range x.Vulnerabilities(name) {
+		if vulnerability.IsWithdrawn() {
+			continue
+		}
+		var finding *Finding
+		for _, affected := range vulnerability.Affected {
+			if affected == nil || affected.Package == nil || affected.Package.Ecosystem != EcosystemNpm ||
+				affected.Package.Name != name || !affected.Affects(version) {
+				continue
+			}
+			if finding == nil {
+				finding = &Finding{Name: name, Version: version, Vulnerability: vulnerability}
+			}
+			for _, fixed := range affected.FixedVersions() {
+				if semver.Compare(fixed, version) > 0 && !contains(finding.Fixed, fixed) {
+					finding.Fixed = append(finding.Fixed, fixed)
+				}
+			}
+		}
+		if finding != nil {
+			semver.Sort(finding.Fixed)
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// MatchVersion 查找影响版本元数据对应版本的漏洞
+//
+// 参数:
+//   - version: 版本元数据，例如 Registry.GetPackageVersion 的返回值
+//
+// 返回值:
+//   - []*Finding: 命中的漏洞，按漏洞 ID 排序
+func (x *Database) MatchVersion(version *models.Version) []*Finding {
+	return x.Match(version.Name, version.Version)
+}
+
+// MatchProject 查找影响锁文件中锁定的包的漏洞
+//
+// 参数:
+//   - project: 解析后的锁文件
+//
+// 返回值:
+//   - []*Finding: 命中的漏洞，按包名、版本和漏洞 ID 排序
+func (x *Database) MatchProject(project *lockfile.Project) []*Finding {
+	var findings []*Finding
+	for _, pkg := range project.Packages {
+		if pkg.Version == "" {
+			continue
+		}
+		findings = append(findings, x.Match(pkg.Name, pkg.Version)...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Name != findings[j].Name {
+			return findings[i].Name < findings[j].Name
+		}
+		return semver.Compare(findings[i].Version, findings[j].Version) < 0
+	})
+	return findings
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package vuln
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/lockfile"
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func stringReader(s string) io.Reader {
+	return strings.NewReader(s)
+}
+
+func newTestDatabase(t *testing.T) *Database {
+	db := NewDatabase()
+	for _, content := range []string{lodashOSV, minimistOSV, pypiOSV} {
+		_, err := db.Read(stringReader(content))
+		assert.Nil(t, err)
+	}
+	return db
+}
+
+func TestMatch(t *testing.T) {
+	db := newTestDatabase(t)
+
+	findings := db.Match("lodash", "4.17.20")
+	assert.Len(t, findings, 1)
+	assert.Equal(t, "GHSA-35jh-r3h4-6jhm", findings[0].Vulnerability.ID)
+	assert.Equal(t, []string{"4.17.21"}, findings[0].Fixed)
+	assert.Equal(t, models.SeverityHigh, findings[0].Vulnerability.SeverityLevel())
+
+	assert.Empty(t, db.Match("lodash", "4.17.21"))
+	assert.Empty(t, db.Match("left-pad", "1.0.0"))
+	// PyPI 中的同名包不影响 npm 包
+	findings = db.Match("lodash", "1.0.0")
+	assert.Len(t, findings, 1)
+	assert.Equal(t, "GHSA-35jh-r3h4-6jhm", findings[0].Vulnerability.ID)
+
+	findings = db.MatchVersion(&models.Version{Name: "minimist", Version: "0.0.8"})
+	assert.Len(t, findings, 1)
+	assert.Equal(t, []string{"0.2.4", "1.2.6"}, findings[0].Fixed)
+	findings = db.Match("minimist", "1.2.5")
+	assert.Equal(t, []string{"1.2.6"}, findings[0].Fixed)
+	assert.Empty(t, db.Match("minimist", "0.2.4"))
+}
+
+func TestMatchWithdrawn(t *testing.T) {
+	db := newTestDatabase(t)
+	_, err := db.Read(stringReader(`{
+  "id": "GHSA-35jh-r3h4-6jhm",
+  "modified": "2025-01-01T00:00:00Z",
+  "withdrawn": "2025-01-01T00:00:00Z",
+  "affected": [{"package": {"ecosystem": "npm", "name": "lodash"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}]}]}]
+}`))
+	assert.Nil(t, err)
+	assert.Empty(t, db.Match("lodash", "4.17.20"))
+	assert.Len(t, db.Vulnerabilities("lodash"), 1)
+}
+
+func TestMatchProject(t *testing.T) {
+	db := newTestDatabase(t)
+	project := &lockfile.Project{Packages: []*lockfile.Package{
+		{Name: "minimist", Version: "1.2.5"},
+		{Name: "lodash", Version: "4.17.21"},
+		{Name: "lodash", Version: "4.17.11"},
+		{Name: "linked", Version: ""},
+	}}
+	findings := db.MatchProject(project)
+	assert.Len(t, findings, 2)
+	assert.Equal(t, "lodash", findings[0].Name)
+	assert.Equal(t, "4.17.11", findings[0].Version)
+	assert.Equal(t, "minimist", findings[1].Name)
+}
@@ -0,0 +1,213 @@
+package vuln
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/scagogogo/npm-crawler/pkg/semver"
+)
+
+// EcosystemNpm 是 OSV 中 npm 生态的名称
+const EcosystemNpm = "npm"
+
+// OSV 范围类型，npm 生态中 SEMVER 和 ECOSYSTEM 都按照语义化版本比较
+const (
+	RangeTypeSemver    = "SEMVER"
+	RangeTypeEcosystem = "ECOSYSTEM"
+	RangeTypeGit       = "GIT"
+)
+
+// Vulnerability 表示 OSV 格式的一条漏洞记录
+//
+// 格式定义见 https://ossf.github.io/osv-schema/，这里只包含匹配 npm 包时用到的字段
+//
+// 主要字段说明:
+//   - ID: 漏洞 ID，例如 "GHSA-35jh-r3h4-6jhm" 或 "MAL-2022-1"
+//   - Modified: 最后修改时间，用于增量更新
+//   - Withdrawn: 撤回时间，撤回的漏洞不会参与匹配
+//   - Aliases: 别名，例如 CVE 编号
+//   - Affected: 受影响的包及版本范围
+//   - DatabaseSpecific: 数据源特有的信息，GitHub Advisory Database 中包含 severity 和 cwe_ids
+type Vulnerability struct {
+	ID               string            `json:"id"`
+	Modified         time.Time         `json:"modified"`
+	Published        time.Time         `json:"published,omitempty"`
+	Withdrawn        *time.Time        `json:"withdrawn,omitempty"`
+	Aliases          []string          `json:"aliases,omitempty"`
+	Summary          string            `json:"summary,omitempty"`
+	Details          string            `json:"details,omitempty"`
+	Severity         []*SeverityScore  `json:"severity,omitempty"`
+	Affected         []*Affected       `json:"affected,omitempty"`
+	References       []*Reference      `json:"references,omitempty"`
+	DatabaseSpecific *DatabaseSpecific `json:"database_specific,omitempty"`
+}
+
+// SeverityScore 表示 OSV 中的严重程度评分，例如 {"type": "CVSS_V3", "score": "CVSS:3.1/..."}
+type SeverityScore struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// Affected 表示一个受影响的包
+//
+// 主要字段说明:
+//   - Package: 受影响的包
+//   - Ranges: 受影响的版本范围
+//   - Versions: 明确列出的受影响版本
+type Affected struct {
+	Package  *AffectedPackage `json:"package"`
+	Ranges   []*Range         `json:"ranges,omitempty"`
+	Versions []string         `json:"versions,omitempty"`
+}
+
+// AffectedPackage 表示受影响的包的名称和所属生态
+type AffectedPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	Purl      string `json:"purl,omitempty"`
+}
+
+// Range 表示 OSV 的版本范围，由一组按版本排序的事件组成
+type Range struct {
+	Type   string   `json:"type"`
+	Events []*Event `json:"events"`
+}
+
+// Event 表示版本范围中的一个事件，每个事件只设置一个字段
+//
+// 主要字段说明:
+//   - Introduced: 从该版本开始受影响，"0" 表示所有版本
+//   - Fixed: 从该版本开始修复
+//   - LastAffected: 最后一个受影响的版本
+//   - Limit: 范围上限，只用于 GIT 范围
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// Reference 表示漏洞的参考链接
+type Reference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// DatabaseSpecific 表示 GitHub Advisory Database 在 OSV 记录中附加的信息
+type DatabaseSpecific struct {
+	Severity       string   `json:"severity,omitempty"` // 严重程度，例如 "HIGH"
+	CWEIDs         []string `json:"cwe_ids,omitempty"`  // CWE 编号
+	GithubReviewed bool     `json:"github_reviewed,omitempty"`
+}
+
+// IsWithdrawn 判断漏洞是否已经被撤回
+func (x *Vulnerability) IsWithdrawn() bool {
+	return x.Withdrawn != nil && !x.Withdrawn.IsZero()
+}
+
+// SeverityLevel 返回漏洞的严重程度
+//
+// 返回值:
+//   - models.Severity: 来自 database_specific.severity，没有记录时返回空字符串
+func (x *Vulnerability) SeverityLevel() models.Severity {
+	if x.DatabaseSpecific == nil {
+		return ""
+	}
+	return models.Severity(strings.ToLower(x.DatabaseSpecific.Severity))
+}
+
+// Affects 判断版本是否在受影响范围内
+//
+// 参数:
+//   - version: 版本号，无法解析时只与 Versions 列表做精确匹配
+//
+// 返回值:
+//   - bool: 命中 Versions 列表或者任意一个 SEMVER、ECOSYSTEM 范围时返回 true
+func (x *Affected) Affects(version string) bool {
+	for _, v := range x.Versions {
+		if v == version {
+			return true
+		}
+	}
+	parsed, err := semver.Parse(version)
+	if err != nil {
+		return false
+	}
+	for _, r := range x.Ranges {
+		if (r.Type == RangeTypeSemver || r.Type == RangeTypeEcosystem) && r.contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// FixedVersions 返回所有 SEMVER、ECOSYSTEM 范围中记录的修复版本，从小到大排序
+func (x *Affected) FixedVersions() []string {
+	var fixed []string
+	seen := make(map[string]bool)
+	for _, r := range x.Ranges {
+		if r.Type != RangeTypeSemver && r.Type != RangeTypeEcosystem {
+			continue
+		}
+		for _, event := range r.Events {
+			if event.Fixed != "" && !seen[event.Fixed] {
+				seen[event.Fixed] = true
+				fixed = append(fixed, event.Fixed)
+			}
+		}
+	}
+	semver.Sort(fixed)
+	return fixed
+}
+
+// contains 按照 OSV 规范的算法判断版本是否落在范围内：
+// 事件按版本排序后依次处理，introduced 进入受影响状态，fixed 和 last_affected 离开受影响状态
+func (x *Range) contains(version *semver.Version) bool {
+	type point struct {
+		version *semver.Version
+		event   *Event
+	}
+	var points []point
+	for _, event := range x.Events {
+		raw := event.Introduced + event.Fixed + event.LastAffected
+		if event.Limit != "" || raw == "" {
+			continue
+		}
+		if event.Introduced == "0" {
+			points = append(points, point{event: event})
+			continue
+		}
+		v, err := semver.Parse(raw)
+		if err != nil {
+			continue
+		}
+		points = append(points, point{version: v, event: event})
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		if points[i].version == nil || points[j].version == nil {
+			return points[i].version == nil && points[j].version != nil
+		}
+		return points[i].version.Compare(points[j].version) < 0
+	})
+
+	affected := false
+	for _, p := range points {
+		switch {
+		case p.event.Introduced != "":
+			if p.version == nil || version.Compare(p.version) >= 0 {
+				affected = true
+			}
+		case p.event.Fixed != "":
+			if version.Compare(p.version) >= 0 {
+				affected = false
+			}
+		case p.event.LastAffected != "":
+			if version.Compare(p.version) > 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
@@ -0,0 +1,64 @@
+package vuln
+
+import (
+	"testing"
+
+	"github.com/scagogogo/npm-crawler/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAffectedAffects(t *testing.T) {
+	affected := &Affected{
+		Package: &AffectedPackage{Ecosystem: EcosystemNpm, Name: "pkg"},
+		Ranges: []*Range{
+			{Type: RangeTypeSemver, Events: []*Event{
+				{Introduced: "0"}, {Fixed: "1.2.3"},
+				{Introduced: "2.0.0"}, {Fixed: "2.0.5"},
+			}},
+			{Type: RangeTypeEcosystem, Events: []*Event{
+				{Introduced: "3.0.0"}, {LastAffected: "3.1.0"},
+			}},
+			{Type: RangeTypeGit, Events: []*Event{
+				{Introduced: "0"}, {Limit: "abcdef"},
+			}},
+		},
+		Versions: []string{"9.9.9"},
+	}
+	cases := map[string]bool{
+		"0.0.1":        true,
+		"1.2.2":        true,
+		"1.2.3-beta.1": true,
+		"1.2.3":        false,
+		"1.9.0":        false,
+		"2.0.0":        true,
+		"2.0.4":        true,
+		"2.0.5":        false,
+		"3.0.0":        true,
+		"3.1.0":        true,
+		"3.1.1":        false,
+		"9.9.9":        true,
+		"not-semver":   false,
+	}
+	for version, want := range cases {
+		assert.Equal(t, want, affected.Affects(version), version)
+	}
+	assert.Equal(t, []string{"1.2.3", "2.0.5"}, affected.FixedVersions())
+}
+
+func TestRangeUnsortedEvents(t *testing.T) {
+	r := &Range{Type: RangeTypeSemver, Events: []*Event{
+		{Fixed: "2.0.0"}, {Introduced: "1.5.0"}, {Introduced: "0"}, {Fixed: "1.0.0"},
+	}}
+	affected := &Affected{Ranges: []*Range{r}}
+	assert.True(t, affected.Affects("0.5.0"))
+	assert.False(t, affected.Affects("1.2.0"))
+	assert.True(t, affected.Affects("1.5.0"))
+	assert.False(t, affected.Affects("2.0.0"))
+}
+
+func TestVulnerabilitySeverity(t *testing.T) {
+	v := &Vulnerability{DatabaseSpecific: &DatabaseSpecific{Severity: "MODERATE"}}
+	assert.Equal(t, models.SeverityModerate, v.SeverityLevel())
+	assert.Equal(t, models.Severity(""), (&Vulnerability{}).SeverityLevel())
+	assert.False(t, v.IsWithdrawn())
+}